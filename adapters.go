@@ -0,0 +1,170 @@
+package sqlf
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BoolInt is a codec that stores bool values as the integers 0 and 1,
+// for databases that do not have a boolean type, such as Oracle, and
+// MySQL tables that use a numeric column for flags. It is registered
+// with the name "boolint":
+//
+//	type User struct {
+//		ID     int64
+//		Active bool `sql:"codec:boolint"`
+//	}
+//
+// The field can be of any type whose underlying type is bool. When
+// scanning, integer values other than 0 and 1 are an error.
+var BoolInt Codec = boolIntCodec{}
+
+type boolIntCodec struct{}
+
+func (boolIntCodec) Encode(value interface{}) (driver.Value, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Bool {
+		return nil, fmt.Errorf("expected bool, got %T", value)
+	}
+	if v.Bool() {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func (boolIntCodec) Decode(dbValue interface{}) (interface{}, error) {
+	var n int64
+	switch v := dbValue.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return v, nil
+	case int64:
+		n = v
+	case []byte:
+		return parseBoolInt(string(v))
+	case string:
+		return parseBoolInt(v)
+	default:
+		return nil, fmt.Errorf("cannot convert %T to bool", dbValue)
+	}
+	if n != 0 && n != 1 {
+		return nil, fmt.Errorf("invalid bool value %d", n)
+	}
+	return n == 1, nil
+}
+
+// parseBoolInt parses the text form of a boolean column value,
+// which some drivers return for numeric columns.
+func parseBoolInt(s string) (interface{}, error) {
+	switch strings.TrimSpace(s) {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bool value %q", s)
+	}
+	return b, nil
+}
+
+// EnumCodec returns a codec for a string-backed enumerated type, which
+// checks that each value written to, and read from, the database is one of
+// the values listed. The zero value (a blank string) is always permitted,
+// as is NULL. Register the codec with a name, and attach it to columns
+// using the "codec" field tag:
+//
+//	type Status string
+//
+//	func init() {
+//		sqlf.RegisterCodec("status", sqlf.EnumCodec("active", "suspended", "closed"))
+//	}
+//
+//	type Account struct {
+//		ID     int64
+//		Status Status `sql:"codec:status"`
+//	}
+//
+// Alternatively, the values can be declared in the field tag using
+// "enum", and the built-in codec named "enum" applies them on bind and
+// scan, as well as in Validate:
+//
+//	Status Status `sql:"enum:active,suspended,closed;codec:enum"`
+func EnumCodec(values ...string) Codec {
+	return enumCodec{values: append([]string(nil), values...)}
+}
+
+type enumCodec struct {
+	values []string
+}
+
+func (c enumCodec) check(s string) error {
+	if s == "" {
+		return nil
+	}
+	for _, v := range c.values {
+		if s == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("value %q is not one of %s", s, strings.Join(c.values, ","))
+}
+
+func (c enumCodec) Encode(value interface{}) (driver.Value, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("expected string, got %T", value)
+	}
+	s := v.String()
+	if err := c.check(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c enumCodec) Decode(dbValue interface{}) (interface{}, error) {
+	var s string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return nil, fmt.Errorf("cannot convert %T to string", dbValue)
+	}
+	if err := c.check(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fieldCodec returns the codec named in the field tag. The name "enum"
+// refers to a codec for the values of the "enum" tag.
+func fieldCodec(field reflect.StructField, name string, c constraints) Codec {
+	if name == "enum" {
+		if len(c.enum) == 0 {
+			panic(fmt.Sprintf("sqlf.Table: field %s: codec enum requires the enum tag", field.Name))
+		}
+		return EnumCodec(c.enum...)
+	}
+	return lookupCodec(field, name)
+}
@@ -0,0 +1,112 @@
+package sqlf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type accountStatus string
+
+func TestBoolInt(t *testing.T) {
+	assert := assert.New(t)
+
+	type Row struct {
+		ID     int `sql:"primary_key;auto_increment"`
+		Name   string
+		Active bool `sql:"codec:boolint"`
+	}
+
+	db := createDatabase(t, "")
+	_, err := db.Exec("create table flags(id integer primary key autoincrement, name text, active integer)")
+	assert.NoError(err)
+	tbl := Table("flags", Row{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	args, err := ins.Args(Row{Name: "a", Active: true})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"a", int64(1)}, args)
+	assert.NoError(ins.Exec(db, &Row{Name: "a", Active: true}))
+	assert.NoError(ins.Exec(db, &Row{Name: "b"}))
+
+	var stored []int64
+	assert.NoError(db.Select(&stored, "select active from flags order by id"))
+	assert.Equal([]int64{1, 0}, stored)
+
+	var rows []Row
+	sel := Queryf("select %s from %s order by id", tbl.Select.Columns, tbl.Select.TableName)
+	assert.NoError(sel.Select(db, &rows))
+	if assert.Equal(2, len(rows)) {
+		assert.True(rows[0].Active)
+		assert.False(rows[1].Active)
+	}
+
+	_, err = db.Exec("update flags set active = 2")
+	assert.NoError(err)
+	assert.Error(sel.Select(db, &rows))
+
+	for _, tt := range []struct {
+		db   interface{}
+		want interface{}
+	}{
+		{int64(0), false},
+		{int64(1), true},
+		{[]byte("1"), true},
+		{"false", false},
+		{true, true},
+		{nil, nil},
+	} {
+		v, err := BoolInt.Decode(tt.db)
+		assert.NoError(err)
+		assert.Equal(tt.want, v)
+	}
+}
+
+func TestEnumCodec(t *testing.T) {
+	assert := assert.New(t)
+	RegisterCodec("account_status", EnumCodec("active", "closed"))
+
+	type Row struct {
+		ID     int           `sql:"primary_key;auto_increment"`
+		Status accountStatus `sql:"codec:account_status"`
+		Kind   string        `sql:"enum:basic,premium;codec:enum"`
+	}
+
+	db := createDatabase(t, "")
+	_, err := db.Exec("create table accounts(id integer primary key autoincrement, status text, kind text)")
+	assert.NoError(err)
+	tbl := Table("accounts", Row{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &Row{Status: "active", Kind: "basic"}))
+
+	err = ins.Exec(db, &Row{Status: "deleted", Kind: "basic"})
+	if assert.Error(err) {
+		assert.Contains(err.Error(), `cannot encode status: value "deleted" is not one of active,closed`)
+	}
+	err = ins.Exec(db, &Row{Status: "closed", Kind: "gold"})
+	if assert.Error(err) {
+		assert.Contains(err.Error(), `cannot encode kind: value "gold" is not one of basic,premium`)
+	}
+
+	sel := Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName)
+	var rows []Row
+	assert.NoError(sel.Select(db, &rows))
+	if assert.Equal(1, len(rows)) {
+		assert.Equal(accountStatus("active"), rows[0].Status)
+		assert.Equal("basic", rows[0].Kind)
+	}
+
+	_, err = db.Exec("update accounts set status = 'unknown'")
+	assert.NoError(err)
+	err = sel.Select(db, &rows)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), `cannot decode status: value "unknown" is not one of active,closed`)
+	}
+
+	assert.Panics(func() {
+		type Bad struct {
+			ID     int
+			Status string `sql:"codec:enum"`
+		}
+		Table("bad", Bad{})
+	})
+}
@@ -0,0 +1,125 @@
+package sqlf
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// WithArgCheck causes a command to check its args before it is executed.
+// Each arg is converted to a value that can be passed to a database
+// driver, calling its Value method if it implements driver.Valuer. If any
+// args cannot be converted, the command is not executed, and the error
+// returned is an *Error whose Err is an ArgErrors, with the column and
+// field of each arg that failed:
+//
+//	sqlf: insertrow: arg 7 (column dob, field User.DOB): unsupported type main.Date, a struct
+//
+// This is much easier to diagnose than the error returned by most
+// drivers, which only identifies the position of the arg. The check uses
+// the conversions of database/sql, so it is not suitable for commands
+// with args of types that are supported by a particular driver, such as
+// the array types of the pgx driver. Named args (sql.Named) and output
+// parameters (sql.Out) are not checked.
+func WithArgCheck() Option {
+	return func(o *options) {
+		o.argCheck = true
+	}
+}
+
+// ArgError describes an arg that cannot be passed to the database driver.
+type ArgError struct {
+	Position int    // Position of the arg, starting at 1
+	Column   string // Name of the column, "-" if not known
+	Field    string // Row type and field name, eg "User.DOB", "-" if not known
+	Err      error  // Error converting the arg
+}
+
+// Error implements the error interface.
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("arg %d (column %s, field %s): %v", e.Position, e.Column, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+// ArgErrors is the error returned by a command built using WithArgCheck
+// when one or more of its args cannot be passed to the database driver.
+// There is one entry for each arg.
+type ArgErrors []*ArgError
+
+// Error implements the error interface.
+func (errs ArgErrors) Error() string {
+	var buf bytes.Buffer
+	for i, err := range errs {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// argLabelsFor returns the label of each arg supplied when executing a
+// command with the inputs, which are []positioner or []*columnInfo, in
+// placeholder order. The args inserted when the command is executed,
+// such as the tenant, are not supplied, so they are skipped.
+func argLabelsFor(inputs interface{}) []argLabel {
+	var labels []argLabel
+	switch inputs := inputs.(type) {
+	case []*columnInfo:
+		for _, ci := range inputs {
+			labels = append(labels, columnLabel(ci))
+		}
+	case []positioner:
+		for _, input := range inputs {
+			if _, ok := fixedInput(input); ok || isTenantInput(input) {
+				continue
+			}
+			labels = append(labels, inputLabel(input))
+		}
+	}
+	return labels
+}
+
+// setArgLabels records the labels of the args for a command built using
+// WithArgCheck, see argLabelsFor.
+func (o *options) setArgLabels(inputs interface{}) {
+	if o.argCheck {
+		o.argLabels = argLabelsFor(inputs)
+	}
+}
+
+// checkArgs returns an ArgErrors if the command was built using
+// WithArgCheck and any of the args cannot be converted to driver values.
+func (o options) checkArgs(args []interface{}) error {
+	if !o.argCheck {
+		return nil
+	}
+	var errs ArgErrors
+	for i, arg := range args {
+		switch arg.(type) {
+		case sql.NamedArg, sql.Out:
+			continue
+		}
+		if _, err := driver.DefaultParameterConverter.ConvertValue(arg); err != nil {
+			label := argLabel{column: "-", field: "-"}
+			if i < len(o.argLabels) {
+				label = o.argLabels[i]
+			}
+			errs = append(errs, &ArgError{
+				Position: i + 1,
+				Column:   label.column,
+				Field:    label.field,
+				Err:      err,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
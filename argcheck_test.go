@@ -0,0 +1,71 @@
+package sqlf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithArgCheck(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table shapes(id integer primary key, name text, origin text)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Shape struct {
+		ID     int64
+		Name   string
+		Origin complex128
+	}
+	shapes := sqlf.Table("shapes", Shape{}).WithDialect(sqlf.DialectSQLite)
+
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		shapes.Insert.TableName, shapes.Insert.Columns, shapes.Insert.Values, sqlf.WithArgCheck())
+	err = insert.Exec(db, &Shape{ID: 1, Name: "square"})
+	var sqlfErr *sqlf.Error
+	assert.True(errors.As(err, &sqlfErr))
+	var argErrs sqlf.ArgErrors
+	if assert.True(errors.As(err, &argErrs)) && assert.Len(argErrs, 1) {
+		assert.Equal(3, argErrs[0].Position)
+		assert.Equal("origin", argErrs[0].Column)
+		assert.Equal("Shape.Origin", argErrs[0].Field)
+	}
+	assert.Contains(err.Error(), "arg 3 (column origin, field Shape.Origin)")
+
+	// the row was not inserted
+	var count int
+	assert.NoError(sqlf.Queryf("select count(*) from shapes").Get(db, &count))
+	assert.Equal(0, count)
+
+	// args that are not bound to a column
+	query := sqlf.Queryf("select %s from %s where %s and name = ?",
+		shapes.Select.Columns, shapes.Select.TableName, shapes.Update.WhereColumns, sqlf.WithArgCheck())
+	var rows []Shape
+	err = query.Select(db, &rows, 1, complex(1, 2))
+	argErrs = nil
+	if assert.True(errors.As(err, &argErrs)) && assert.Len(argErrs, 1) {
+		assert.Equal(2, argErrs[0].Position)
+		assert.Equal("-", argErrs[0].Column)
+	}
+	err = query.Select(db, &rows, complex(1, 2), []int{1})
+	argErrs = nil
+	if assert.True(errors.As(err, &argErrs)) && assert.Len(argErrs, 2) {
+		assert.Equal("id", argErrs[0].Column)
+		assert.Equal("Shape.ID", argErrs[0].Field)
+	}
+	assert.NoError(query.Select(db, &rows, 1, "square"))
+
+	exec := sqlf.Execf("delete from shapes where id = ?", sqlf.WithArgCheck())
+	_, err = exec.Exec(db, complex(1, 2))
+	assert.True(errors.As(err, &argErrs))
+	_, err = exec.Exec(db, 1)
+	assert.NoError(err)
+}
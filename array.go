@@ -0,0 +1,217 @@
+package sqlf
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// isArrayType reports whether fields of type t are stored in array
+// columns. This applies to all slice types except []byte, and any
+// slice type that implements driver.Valuer or sql.Scanner, as these
+// types know how to store themselves.
+func isArrayType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	return !t.Implements(valuerType) && !reflect.PtrTo(t).Implements(sqlScanType)
+}
+
+// arrayValue is a driver.Valuer for a slice field. For PostgreSQL the
+// value is an array literal (eg "{1,2,3}"), which the server converts to
+// the array type of the column. For other databases, which do not have
+// array types, the value is a JSON array.
+type arrayValue struct {
+	dialect Dialect
+	value   interface{}
+}
+
+func (a arrayValue) Value() (driver.Value, error) {
+	v := reflect.ValueOf(a.value)
+	if v.IsNil() {
+		return nil, nil
+	}
+	if DialectName(a.dialect) != "postgres" {
+		b, err := json.Marshal(a.value)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				break
+			}
+			elem = elem.Elem()
+		}
+		switch elem.Kind() {
+		case reflect.Ptr:
+			buf.WriteString("NULL")
+		case reflect.String:
+			buf.WriteByte('"')
+			s := strings.Replace(elem.String(), `\`, `\\`, -1)
+			buf.WriteString(strings.Replace(s, `"`, `\"`, -1))
+			buf.WriteByte('"')
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			buf.WriteString(strconv.FormatInt(elem.Int(), 10))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			buf.WriteString(strconv.FormatUint(elem.Uint(), 10))
+		case reflect.Float32, reflect.Float64:
+			buf.WriteString(strconv.FormatFloat(elem.Float(), 'g', -1, 64))
+		case reflect.Bool:
+			buf.WriteString(strconv.FormatBool(elem.Bool()))
+		default:
+			return nil, fmt.Errorf("unsupported array element type %s", elem.Type())
+		}
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+// arrayScanner implements sql.Scanner for scanning an array
+// column into a slice field.
+type arrayScanner struct {
+	column *columnInfo
+	field  reflect.Value
+}
+
+func (as *arrayScanner) Scan(src interface{}) error {
+	var text string
+	switch s := src.(type) {
+	case nil:
+		as.field.Set(reflect.Zero(as.field.Type()))
+		return nil
+	case string:
+		text = s
+	case []byte:
+		text = string(s)
+	default:
+		return fmt.Errorf("cannot scan %s: unexpected array value of type %T", as.column.columnName, src)
+	}
+
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		err = parseArray(text, as.field)
+	} else {
+		err = json.Unmarshal([]byte(text), as.field.Addr().Interface())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot scan %s: %v", as.column.columnName, err)
+	}
+	return nil
+}
+
+// parseArray parses a one-dimensional PostgreSQL array literal
+// (eg `{1,2,NULL}` or `{"a b",c}`) into the slice.
+func parseArray(text string, slice reflect.Value) error {
+	text = strings.TrimSpace(text)
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return fmt.Errorf("invalid array literal %q", text)
+	}
+	text = text[1 : len(text)-1]
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	elemType := slice.Type().Elem()
+
+	for pos := 0; pos < len(text); {
+		var elem string
+		var quoted bool
+		if text[pos] == '"' {
+			quoted = true
+			var buf bytes.Buffer
+			pos++
+			for pos < len(text) && text[pos] != '"' {
+				if text[pos] == '\\' && pos+1 < len(text) {
+					pos++
+				}
+				buf.WriteByte(text[pos])
+				pos++
+			}
+			if pos >= len(text) {
+				return errors.New("unterminated quoted array element")
+			}
+			pos++ // closing quote
+			elem = buf.String()
+		} else {
+			end := strings.IndexByte(text[pos:], ',')
+			if end < 0 {
+				end = len(text) - pos
+			}
+			elem = strings.TrimSpace(text[pos : pos+end])
+			pos += end
+		}
+		if pos < len(text) {
+			if text[pos] != ',' {
+				return fmt.Errorf("unexpected %q in array literal", text[pos])
+			}
+			pos++
+		}
+
+		v := reflect.New(elemType).Elem()
+		if quoted || !strings.EqualFold(elem, "NULL") {
+			if err := setArrayElem(v, elem); err != nil {
+				return err
+			}
+		}
+		result = reflect.Append(result, v)
+	}
+	slice.Set(result)
+	return nil
+}
+
+// setArrayElem sets v, which is an array element, from its text
+// representation in an array literal.
+func setArrayElem(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "t", "true":
+			v.SetBool(true)
+		case "f", "false":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+	default:
+		return fmt.Errorf("unsupported array element type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sqlf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Article struct {
+	ID      int64 `sql:"primary_key;auto_increment"`
+	Title   string
+	Tags    []string
+	Ratings []int
+	Data    []byte
+}
+
+func TestArrayColumnsJSON(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	defer db.Close()
+	if _, err := db.Exec(`create table articles(id integer primary key autoincrement, title text, tags text, ratings text, data blob)`); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl := Table("articles", Article{}).WithDialect(DialectSQLite)
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	article := &Article{Title: "Arrays", Tags: []string{"go", "sql \"quoted\""}, Ratings: []int{4, 5}, Data: []byte("x")}
+
+	args, err := ins.Args(article)
+	assert.NoError(err)
+	s, err := ins.Interpolate(article)
+	assert.NoError(err)
+	assert.Equal("insert into `articles`(`title`,`tags`,`ratings`,`data`) values('Arrays','[\"go\",\"sql \\\"quoted\\\"\"]','[4,5]',X'78')", s)
+	assert.Len(args, 4)
+
+	assert.NoError(ins.Exec(db, article))
+	assert.NoError(ins.Exec(db, &Article{Title: "Nil"}))
+
+	sel := Queryf("select %s from %s order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy)
+	var articles []Article
+	assert.NoError(sel.Select(db, &articles))
+	if assert.Len(articles, 2) {
+		assert.Equal(*article, articles[0])
+		assert.Nil(articles[1].Tags)
+		assert.Nil(articles[1].Ratings)
+	}
+}
+
+func TestArrayColumnsPostgres(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Table("articles", Article{}).WithDialect(DialectPG)
+	upd := UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	s, err := upd.Interpolate(&Article{ID: 1, Tags: []string{"a b", `c"d`, `e\f`}, Ratings: []int{}})
+	assert.NoError(err)
+	assert.Equal(`update "articles" set "title"='',"tags"='{"a b","c\"d","e\\f"}',"ratings"='{}',"data"='\x' where "id"=1`, s)
+}
+
+func TestParseArray(t *testing.T) {
+	tests := []struct {
+		text string
+		want interface{}
+	}{
+		{`{}`, []string{}},
+		{`{a,b}`, []string{"a", "b"}},
+		{`{"a b","c\"d","e\\f",NULL,"NULL"}`, []string{"a b", `c"d`, `e\f`, "", "NULL"}},
+		{`{1, 2,3}`, []int{1, 2, 3}},
+		{`{1.5,-2}`, []float64{1.5, -2}},
+		{`{t,f,true}`, []bool{true, false, true}},
+		{`{1,NULL}`, []*int{intPtr(1), nil}},
+	}
+	for _, tt := range tests {
+		v := reflect.New(reflect.TypeOf(tt.want)).Elem()
+		if err := parseArray(tt.text, v); err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		assert.Equal(t, tt.want, v.Interface(), tt.text)
+	}
+
+	for _, text := range []string{`a,b`, `{"a}`, `{1,x}`, `{"a"b}`} {
+		v := reflect.New(reflect.TypeOf([]int{})).Elem()
+		assert.Error(t, parseArray(text, v), text)
+	}
+}
+
+func TestArrayScanner(t *testing.T) {
+	assert := assert.New(t)
+	var tags []string
+	as := &arrayScanner{column: &columnInfo{columnName: "tags"}, field: reflect.ValueOf(&tags).Elem()}
+	assert.NoError(as.Scan([]byte(`{x,"y z"}`)))
+	assert.Equal([]string{"x", "y z"}, tags)
+	assert.NoError(as.Scan(`["a"]`))
+	assert.Equal([]string{"a"}, tags)
+	assert.NoError(as.Scan(nil))
+	assert.Nil(tags)
+	assert.EqualError(as.Scan(int64(1)), "cannot scan tags: unexpected array value of type int64")
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,115 @@
+package sqlf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrNoAuditUser is returned by the default audit function when a row
+// with an audit column is inserted or updated using a handle whose
+// context does not contain a user. See SetAuditFunc.
+var ErrNoAuditUser = errors.New("sqlf: audit column requires a user")
+
+// AuditFunc returns the value of the audit columns of a row that is
+// inserted or updated using a handle with the context ctx. See SetAuditFunc.
+type AuditFunc func(ctx context.Context) (interface{}, error)
+
+// auditKind identifies the audit columns, which are specified using the
+// "createdby" and "updatedby" field tags.
+type auditKind int
+
+const (
+	auditNone auditKind = iota
+	auditCreatedBy
+	auditUpdatedBy
+)
+
+var audit = struct {
+	mutex sync.RWMutex
+	fn    AuditFunc
+}{}
+
+// SetAuditFunc sets the function that provides the values of audit columns,
+// which are the columns whose fields are tagged "createdby" or "updatedby":
+//
+//	type Document struct {
+//		ID        int64
+//		Title     string
+//		CreatedBy string `sql:"createdby"`
+//		UpdatedBy string `sql:"updatedby"`
+//	}
+//
+// When a command built using InsertRowf or UpdateRowf is executed, the
+// value of each audit column that is an input of the command is the value
+// returned by the audit function for the context of the handle (see
+// ForContext), regardless of the value of the field in the row. A
+// "createdby" column is excluded from Update.SetColumns, so it is set when
+// the row is inserted and is not changed by updates or upserts. An
+// "updatedby" column is set by both inserts and updates.
+//
+// The default audit function returns the user stored in the context using
+// ContextWithUser, or ErrNoAuditUser if there is none. Setting a nil
+// function restores the default. The function is typically set once
+// during program initialization.
+func SetAuditFunc(fn AuditFunc) {
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+	audit.fn = fn
+}
+
+func auditFunc() AuditFunc {
+	audit.mutex.RLock()
+	defer audit.mutex.RUnlock()
+	if audit.fn == nil {
+		return defaultAuditFunc
+	}
+	return audit.fn
+}
+
+func defaultAuditFunc(ctx context.Context) (interface{}, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, ErrNoAuditUser
+	}
+	return user, nil
+}
+
+// parseAudit returns the audit kind of the field. It panics if the field
+// is tagged with both "createdby" and "updatedby", in the same way that
+// Table panics for an invalid row type.
+func parseAudit(field reflect.StructField, tagSettings map[string]string) auditKind {
+	_, createdBy := tagSettings["CREATEDBY"]
+	_, updatedBy := tagSettings["UPDATEDBY"]
+	switch {
+	case createdBy && updatedBy:
+		panic(fmt.Sprintf("sqlf.Table: field %s: createdby and updatedby cannot both be specified", field.Name))
+	case createdBy:
+		return auditCreatedBy
+	case updatedBy:
+		return auditUpdatedBy
+	}
+	return auditNone
+}
+
+// auditValue returns the value of the audit column for the context,
+// which is obtained at most once for each row.
+type auditValue struct {
+	ctx   context.Context
+	value interface{}
+	err   error
+	done  bool
+}
+
+func (av *auditValue) get(ci *columnInfo) (interface{}, error) {
+	if !av.done {
+		av.value, av.err = auditFunc()(av.ctx)
+		av.done = true
+	}
+	if av.err != nil {
+		return nil, fmt.Errorf("cannot set %s: %w", ci.columnName, av.err)
+	}
+	return av.value, nil
+}
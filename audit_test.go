@@ -0,0 +1,78 @@
+package sqlf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditColumns(t *testing.T) {
+	assert := assert.New(t)
+
+	type Document struct {
+		ID        int64 `sql:"primary key"`
+		Title     string
+		CreatedBy string `sql:"createdby"`
+		UpdatedBy string `sql:"updatedby"`
+	}
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("create table documents(id integer primary key, title text, created_by text, updated_by text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl := sqlf.Table("documents", Document{}).WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	ups := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithUpsert())
+	sel := sqlf.Queryf("select %s from %s order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy)
+
+	assert.Equal("update `documents` set `title`=?,`updated_by`=? where `id`=?", upd.Command())
+
+	alice := sqlf.ForContext(sqlf.ContextWithUser(context.Background(), "alice"), db)
+	bob := sqlf.ForContext(sqlf.ContextWithUser(context.Background(), "bob"), db)
+
+	// field values are ignored
+	assert.NoError(ins.Exec(alice, &Document{ID: 1, Title: "one", CreatedBy: "mallory"}))
+	assert.NoError(ins.Exec(alice, &Document{ID: 2, Title: "two"}))
+	_, err = upd.Exec(bob, &Document{ID: 1, Title: "uno"})
+	assert.NoError(err)
+	assert.NoError(ups.Exec(bob, &Document{ID: 2, Title: "dos"}))
+
+	var docs []Document
+	assert.NoError(sel.Select(db, &docs))
+	assert.Equal([]Document{
+		{ID: 1, Title: "uno", CreatedBy: "alice", UpdatedBy: "bob"},
+		{ID: 2, Title: "dos", CreatedBy: "alice", UpdatedBy: "bob"},
+	}, docs)
+
+	// no user
+	err = ins.Exec(db, &Document{ID: 3})
+	assert.True(errors.Is(err, sqlf.ErrNoAuditUser), "%v", err)
+
+	// configurable function
+	sqlf.SetAuditFunc(func(ctx context.Context) (interface{}, error) {
+		return "system", nil
+	})
+	defer sqlf.SetAuditFunc(nil)
+	assert.NoError(ins.Exec(db, &Document{ID: 3, Title: "three"}))
+	var doc Document
+	assert.NoError(sqlf.Queryf("select %s from %s where id = 3", tbl.Select.Columns, tbl.Select.TableName).Get(db, &doc))
+	assert.Equal("system", doc.CreatedBy)
+
+	assert.Panics(func() {
+		type Bad struct {
+			ID int64
+			By string `sql:"createdby;updatedby"`
+		}
+		sqlf.Table("bad", Bad{})
+	})
+}
@@ -0,0 +1,236 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjeffery/sqlf/scan"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxMultiStatements is the maximum number of statements combined
+// into a single request when using the WithMultiStatements option.
+const maxMultiStatements = 100
+
+// WithProgress sets a function that is called as a bulk operation makes
+// progress, with the number of argument sets or rows that have been
+// executed (done) and the total number. It applies to ExecCommand.ExecBatch,
+// UpdateRows, ExecChunks, InsertRowChunks and UpdateRowChunks, and is
+// useful for reporting the progress of long-running imports. The function
+// is called from the goroutine executing the operation, which waits for it
+// to return.
+//
+// Bulk operations can be interrupted by canceling the context of the
+// database handle (see ForContext). The context is checked before each
+// argument set or row is executed, and if it is done, the operation stops
+// and returns the context's error, along with the partial result described
+// for the operation.
+func WithProgress(fn func(done, total int)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// reportProgress calls the progress function, if there is one.
+func (o options) reportProgress(done, total int) {
+	if o.progress != nil {
+		o.progress(done, total)
+	}
+}
+
+// WithMultiStatements causes ExecCommand.ExecBatch to send the statements
+// for many argument sets to the database server in a single request, which
+// reduces the number of round trips. The statements are separated by
+// semicolons, and the arguments of all of the statements are passed with
+// the request, with numbered placeholders (eg "$1") renumbered to suit.
+// The number of statements in a request is limited so that the request
+// has fewer parameters than the database server accepts (see MaxParams).
+// The database driver must permit multiple statements in a request, for
+// example using the MySQL driver's "multiStatements=true" setting.
+func WithMultiStatements() Option {
+	return func(o *options) {
+		o.multiStatements = true
+	}
+}
+
+// preparer is implemented by *sqlx.DB and *sqlx.Tx.
+type preparer interface {
+	Preparex(query string) (*sqlx.Stmt, error)
+}
+
+func (cmd execCommand) ExecBatch(db sqlx.Execer, argSets [][]interface{}) (rowCount int64, err error) {
+	if cmd.err != nil {
+		return 0, cmd.err
+	}
+	if len(argSets) == 0 {
+		return 0, nil
+	}
+	var ctx context.Context
+	parent, ok := handleContext(db)
+	if ok {
+		ctx = parent
+	}
+	start := time.Now()
+	defer func() {
+		cmd.opts.report(parent, OpBatch, cmd.command, start, rowCount, err)
+	}()
+	cmd.opts.debugBindings(OpBatch, cmd.command, cmd.inputs, argSets[0])
+
+	// bind the tenant once for all of the arg sets, so that
+	// the underlying handle can be used to begin a transaction
+	bound := make([][]interface{}, len(argSets))
+	var scoped interface{}
+	for i, args := range argSets {
+		if err = cmd.opts.checkArgs(args); err != nil {
+			return 0, cmd.opts.wrapError(OpBatch, cmd.command, args, err)
+		}
+		if args, err = cmd.opts.bindArgs(args); err != nil {
+			return 0, err
+		}
+		if scoped, bound[i], err = cmd.opts.bindTenant(db, args); err != nil {
+			return 0, err
+		}
+	}
+	db, argSets = scoped.(sqlx.Execer), bound
+	cmd.opts.binding = nil
+	cmd.opts.tenant = nil
+	cmd.opts.argCheck = false
+
+	if b, ok := db.(txBeginner); ok {
+		tx, err := b.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		n, err := cmd.execBatch(tx, ctx, argSets)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	return cmd.execBatch(db, ctx, argSets)
+}
+
+// execBatch executes the command for each of the argument sets, and
+// returns the total number of rows affected, or -1 if not known. If ctx
+// is not nil, the commands are executed using it, and no more commands
+// are executed once it is done. If an error occurs, the rows affected
+// by the commands already executed are returned with the error.
+func (cmd execCommand) execBatch(db sqlx.Execer, ctx context.Context, argSets [][]interface{}) (int64, error) {
+	var parent = context.Background()
+	if ctx != nil {
+		parent = ctx
+		if ext, ok := db.(sqlx.Ext); ok {
+			db = ForContext(ctx, ext)
+		}
+	}
+
+	var total int64
+	add := func(n int64) {
+		if n < 0 || total < 0 {
+			total = -1
+		} else {
+			total += n
+		}
+	}
+
+	if cmd.opts.multiStatements {
+		query := cmd.queryFor(db)
+		size := maxMultiStatements
+		if n := len(argSets[0]); n > 0 {
+			if max := maxParamsFor(db, commandDialect(cmd.dialect)) / n; max < size {
+				size = max
+			}
+			if size < 1 {
+				size = 1
+			}
+		}
+		for start := 0; start < len(argSets); start += size {
+			end := start + size
+			if end > len(argSets) {
+				end = len(argSets)
+			}
+			stmts := make([]string, 0, end-start)
+			var args []interface{}
+			for i := start; i < end; i++ {
+				stmts = append(stmts, renumber(query, len(args)))
+				args = append(args, argSets[i]...)
+			}
+			if err := parent.Err(); err != nil {
+				return total, err
+			}
+			result, err := cmd.opts.exec(db, strings.Join(stmts, ";\n"), args)
+			if err != nil {
+				return total, cmd.opts.wrapError(OpBatch, cmd.command, argSets[start], err)
+			}
+			add(rowsAffected(result))
+			cmd.opts.reportProgress(end, len(argSets))
+		}
+		return total, nil
+	}
+
+	query := cmd.queryFor(db)
+	var stmt *sqlx.Stmt
+	if p, ok := unwrapHandle(db).(preparer); ok {
+		var err error
+		if stmt, err = p.Preparex(query); err != nil {
+			return 0, cmd.opts.wrapError(OpBatch, cmd.command, argSets[0], err)
+		}
+		defer stmt.Close()
+	}
+	for i, args := range argSets {
+		if err := parent.Err(); err != nil {
+			return total, err
+		}
+		var result sql.Result
+		var err error
+		if stmt != nil {
+			ctx, cancel := cmd.opts.context(parent)
+			result, err = stmt.ExecContext(ctx, args...)
+			cancel()
+		} else {
+			result, err = cmd.opts.exec(db, query, args)
+		}
+		if err != nil {
+			return total, cmd.opts.wrapError(OpBatch, cmd.command, args, fmt.Errorf("arg set %d: %w", i, err))
+		}
+		add(rowsAffected(result))
+		cmd.opts.reportProgress(i+1, len(argSets))
+	}
+	return total, nil
+}
+
+// renumber returns the query with its numbered placeholders (eg "$1"
+// or "@p1") increased by offset, so that the query can follow other
+// statements in a multi-statement request. Positional placeholders
+// are unchanged.
+func renumber(query string, offset int) string {
+	if offset == 0 {
+		return query
+	}
+	var buf strings.Builder
+	var prev string
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		text := lit
+		if (tok == scan.PLACEHOLDER || tok == scan.IDENT && prev == "@" && strings.HasPrefix(lit, "p")) && len(lit) > 1 {
+			if n, err := strconv.Atoi(lit[1:]); err == nil {
+				text = lit[:1] + strconv.Itoa(n+offset)
+			}
+		}
+		buf.WriteString(text)
+		prev = lit
+	}
+	return buf.String()
+}
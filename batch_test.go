@@ -0,0 +1,117 @@
+package sqlf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecBatch(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", "file:batch?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.Execf("insert into %s(id, given_name) values(%s, %s)",
+		tbl.Insert.TableName, tbl.Insert.Placeholder(), tbl.Insert.Placeholder())
+
+	n, err := ins.ExecBatch(db, [][]interface{}{{1, "one"}, {2, "two"}, {3, "three"}})
+	assert.NoError(err)
+	assert.Equal(int64(3), n)
+
+	count := func() int {
+		var n int
+		assert.NoError(db.Get(&n, "select count(*) from table1"))
+		return n
+	}
+	assert.Equal(3, count())
+
+	// a failure rolls back the whole batch
+	_, err = ins.ExecBatch(db, [][]interface{}{{4, "four"}, {1, "duplicate"}})
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "arg set 1")
+	}
+	assert.Equal(3, count())
+
+	// using a transaction
+	tx, err := db.Beginx()
+	assert.NoError(err)
+	n, err = ins.ExecBatch(tx, [][]interface{}{{4, "four"}})
+	assert.NoError(err)
+	assert.Equal(int64(1), n)
+	assert.NoError(tx.Commit())
+	assert.Equal(4, count())
+
+	// multiple statements in a request
+	multi := sqlf.Execf("insert into %s(id, given_name) values(%s, %s)",
+		tbl.Insert.TableName, tbl.Insert.Placeholder(), tbl.Insert.Placeholder(),
+		sqlf.WithMultiStatements())
+	var argSets [][]interface{}
+	for i := 5; i <= 250; i++ {
+		argSets = append(argSets, []interface{}{i, "name"})
+	}
+	_, err = multi.ExecBatch(db, argSets)
+	assert.NoError(err)
+	assert.Equal(250, count())
+
+	n, err = ins.ExecBatch(db, nil)
+	assert.NoError(err)
+	assert.Equal(int64(0), n)
+}
+
+func TestExecBatchProgress(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", "file:batchprogress?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var progress [][2]int
+	ins := sqlf.Execf("insert into %s(id, given_name) values(%s, %s)",
+		tbl.Insert.TableName, tbl.Insert.Placeholder(), tbl.Insert.Placeholder(),
+		sqlf.WithProgress(func(done, total int) {
+			progress = append(progress, [2]int{done, total})
+			if done == 2 {
+				cancel()
+			}
+		}))
+	argSets := [][]interface{}{{1, "one"}, {2, "two"}, {3, "three"}}
+
+	// canceling the context stops the batch, and the transaction is rolled back
+	_, err = ins.ExecBatch(sqlf.ForContext(ctx, db), argSets)
+	assert.True(errors.Is(err, context.Canceled), "%v", err)
+	assert.Equal([][2]int{{1, 3}, {2, 3}}, progress)
+	var count int
+	assert.NoError(db.Get(&count, "select count(*) from table1"))
+	assert.Equal(0, count)
+
+	// without a transaction, the partial result is returned
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	progress = nil
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := ins.ExecBatch(sqlf.ForContext(ctx, tx), argSets)
+	assert.True(errors.Is(err, context.Canceled), "%v", err)
+	assert.Equal(int64(2), n)
+	assert.NoError(tx.Commit())
+	assert.NoError(db.Get(&count, "select count(*) from table1"))
+	assert.Equal(2, count)
+}
@@ -0,0 +1,295 @@
+package sqlf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// BatchResult is the result of a bulk operation that continues after
+// a failure, which is returned by ExecChunks, InsertRowChunks and
+// UpdateRowChunks. Inputs (argument sets or rows) are identified by
+// their index in the slice passed to the operation.
+//
+// If the database handle can begin a transaction (eg *sqlx.DB), the inputs
+// are executed in transactions of at most chunkSize inputs each, or in a
+// single transaction if chunkSize is zero. When an input fails, its chunk
+// is rolled back, the error is recorded, and the operation continues with
+// the next chunk, so that an import job can report the inputs that failed
+// and retry the inputs of the chunks that were rolled back. If the handle
+// is a transaction (eg *sqlx.Tx), the inputs are executed in one chunk
+// using it, which stops at the first failure, and it is up to the caller
+// to commit or roll back the transaction.
+type BatchResult struct {
+	Chunks []ChunkResult // one for each chunk, in order of execution
+	Keys   map[int]int64 // generated key of each committed inserted row, see InsertRowChunks
+	Errors map[int]error // error of each input that failed
+	Total  int           // number of inputs
+}
+
+// ChunkResult is the result of executing a chunk of the inputs
+// of a bulk operation in a transaction, see BatchResult.
+type ChunkResult struct {
+	Indexes      []int // indexes of the inputs in the chunk, in order of execution
+	RowsAffected int64 // total rows affected, or -1 if not known
+	Err          error // error that stopped the chunk, nil if it was committed
+}
+
+// RowsAffected returns the total number of rows affected by the
+// chunks that were committed, or -1 if it is not known.
+func (r *BatchResult) RowsAffected() int64 {
+	var total int64
+	for _, c := range r.Chunks {
+		if c.Err != nil {
+			continue
+		}
+		if c.RowsAffected < 0 {
+			return -1
+		}
+		total += c.RowsAffected
+	}
+	return total
+}
+
+// Committed returns the number of inputs in the chunks that were committed.
+func (r *BatchResult) Committed() int {
+	var n int
+	for _, c := range r.Chunks {
+		if c.Err == nil {
+			n += len(c.Indexes)
+		}
+	}
+	return n
+}
+
+// Err returns an error that summarizes the chunks that failed, which
+// wraps the error of the first of them, or nil if none failed.
+func (r *BatchResult) Err() error {
+	var first error
+	var failed int
+	for _, c := range r.Chunks {
+		if c.Err != nil {
+			if first == nil {
+				first = c.Err
+			}
+			failed++
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return fmt.Errorf("%d of %d chunks failed, %d of %d inputs committed: %w",
+		failed, len(r.Chunks), r.Committed(), r.Total, first)
+}
+
+// ExecChunks executes the command once for each of the argument sets,
+// in chunks, and returns the result of each chunk and the error of each
+// argument set that failed, see BatchResult. Unlike ExecCommand.ExecBatch,
+// a failure does not stop the remaining chunks from being executed.
+//
+// BatchResult.Errors records only the argument set that stopped each chunk
+// that failed. The argument sets that follow it in the chunk are never
+// attempted, and those that precede it are rolled back, so neither have an
+// entry in Errors. To retry a failed chunk, correct the argument sets in
+// Errors and execute all of the argument sets in its ChunkResult.Indexes
+// again. A chunk whose transaction could not be begun or committed has an
+// Err, but no entry in Errors.
+//
+// The error returned is nil if every chunk was committed, and otherwise
+// is BatchResult.Err, or the error that prevented the operation from
+// starting or continuing, such as the context of db being canceled. The
+// result is returned along with the error in every case except the last.
+func ExecChunks(db sqlx.Execer, cmd ExecCommand, argSets [][]interface{}, chunkSize int) (*BatchResult, error) {
+	ec, ok := cmd.(execCommand)
+	if !ok {
+		return nil, fmt.Errorf("ExecChunks: expected command built using Execf, got %T", cmd)
+	}
+	if ec.err != nil {
+		return nil, ec.err
+	}
+	return execChunks(db, "ExecChunks", sequence(len(argSets)), chunkSize, ec.opts, func(db sqlx.Execer, i int) (int64, error) {
+		result, err := ec.Exec(db, argSets[i]...)
+		if err != nil {
+			return 0, fmt.Errorf("arg set %d: %w", i, err)
+		}
+		return rowsAffected(result), nil
+	}, nil)
+}
+
+// InsertRowChunks executes an insert command built using InsertRowf for
+// each of the rows, which is a slice of the table's row type, or of pointers
+// to it, in chunks, and returns the result of each chunk, see BatchResult.
+// For a table with an auto-increment column, the auto-increment field of
+// each row is set as it is inserted, as for InsertRowCommand.Exec, and the
+// keys of the rows in the chunks that were committed are also reported in
+// BatchResult.Keys. (The fields of the rows in a chunk that was rolled back
+// can also have been set.) The error returned is as for ExecChunks.
+func InsertRowChunks(db sqlx.Execer, cmd InsertRowCommand, rows interface{}, chunkSize int) (*BatchResult, error) {
+	irc, ok := cmd.(insertRowCommand)
+	if !ok {
+		return nil, fmt.Errorf("InsertRowChunks: expected command built using InsertRowf, got %T", cmd)
+	}
+	if irc.table == nil {
+		return nil, errors.New("InsertRowChunks: command does not refer to a table")
+	}
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("InsertRowChunks: expected slice but got %T", rows)
+	}
+
+	// the key is generated if the auto-increment column is not inserted
+	var autoInc *columnInfo
+	for _, ci := range irc.table.columns {
+		if ci.autoIncrement {
+			autoInc = ci
+			break
+		}
+	}
+	for _, ci := range irc.inputs {
+		if ci == autoInc {
+			autoInc = nil
+		}
+	}
+	row := func(i int) reflect.Value {
+		row := v.Index(i)
+		if row.Kind() != reflect.Ptr {
+			row = row.Addr()
+		}
+		return row
+	}
+	var key func(i int) (int64, bool)
+	if autoInc != nil {
+		key = func(i int) (int64, bool) {
+			field := reflectx.FieldByIndexesReadOnly(row(i).Elem(), autoInc.fields)
+			switch field.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return field.Int(), true
+			}
+			return 0, false
+		}
+	}
+	return execChunks(db, "InsertRowChunks", sequence(v.Len()), chunkSize, irc.opts, func(db sqlx.Execer, i int) (int64, error) {
+		r := row(i)
+		if r.IsNil() {
+			return 0, fmt.Errorf("row %d is a nil pointer", i)
+		}
+		result, err := irc.ExecResult(db, r.Interface())
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %w", i, err)
+		}
+		return rowsAffected(result), nil
+	}, key)
+}
+
+// UpdateRowChunks executes an update (or delete) command built using
+// UpdateRowf for each of the rows, in chunks, and returns the result of
+// each chunk, see BatchResult. The rows are updated in order of their
+// primary key, as for UpdateRows. The error returned is as for ExecChunks.
+func UpdateRowChunks(db sqlx.Execer, cmd UpdateRowCommand, rows interface{}, chunkSize int) (*BatchResult, error) {
+	urc, ok := cmd.(updateRowCommand)
+	if !ok {
+		return nil, fmt.Errorf("UpdateRowChunks: expected command built using UpdateRowf, got %T", cmd)
+	}
+	if urc.table == nil {
+		return nil, errors.New("UpdateRowChunks: command does not refer to a table")
+	}
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("UpdateRowChunks: expected slice but got %T", rows)
+	}
+	order, err := primaryKeyOrder(urc.table, v)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateRowChunks: %v", err)
+	}
+	return execChunks(db, "UpdateRowChunks", order, chunkSize, urc.opts, func(db sqlx.Execer, i int) (int64, error) {
+		n, err := urc.Exec(db, v.Index(i).Interface())
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %w", i, err)
+		}
+		return int64(n), nil
+	}, nil)
+}
+
+// execChunks calls exec for each of the inputs with the indexes in order,
+// in chunks, see BatchResult. If key is not nil, it returns the generated
+// key of an input after its chunk is committed. The name is the name of
+// the calling function, for error messages.
+func execChunks(db sqlx.Execer, name string, order []int, chunkSize int, opts options, exec func(db sqlx.Execer, i int) (int64, error), key func(i int) (int64, bool)) (*BatchResult, error) {
+	if chunkSize < 0 {
+		return nil, fmt.Errorf("%s: invalid chunk size %d", name, chunkSize)
+	}
+	result := &BatchResult{
+		Errors: make(map[int]error),
+		Total:  len(order),
+	}
+	if key != nil {
+		result.Keys = make(map[int]int64)
+	}
+	ctx := contextOf(db)
+	b, ok := unwrapHandle(db).(txBeginner)
+	if !ok || chunkSize == 0 {
+		chunkSize = len(order)
+	}
+
+	// run executes the inputs of a chunk, stopping at the first failure
+	run := func(db sqlx.Execer, chunk *ChunkResult, done int) {
+		for j, i := range chunk.Indexes {
+			n, err := exec(db, i)
+			if err != nil {
+				result.Errors[i] = err
+				chunk.Err = err
+				return
+			}
+			if n < 0 || chunk.RowsAffected < 0 {
+				chunk.RowsAffected = -1
+			} else {
+				chunk.RowsAffected += n
+			}
+			opts.reportProgress(done+j+1, len(order))
+		}
+	}
+
+	for start := 0; start < len(order); start += chunkSize {
+		end := start + chunkSize
+		if end > len(order) {
+			end = len(order)
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		chunk := ChunkResult{Indexes: order[start:end]}
+		if !ok {
+			run(db, &chunk, start)
+		} else if tx, err := b.Beginx(); err != nil {
+			chunk.Err = err
+		} else {
+			run(rewrapHandle(db, tx), &chunk, start)
+			if chunk.Err != nil {
+				tx.Rollback()
+			} else if err := tx.Commit(); err != nil {
+				chunk.Err = err
+			}
+		}
+		if chunk.Err == nil && key != nil {
+			for _, i := range chunk.Indexes {
+				if k, ok := key(i); ok {
+					result.Keys[i] = k
+				}
+			}
+		}
+		result.Chunks = append(result.Chunks, chunk)
+	}
+	return result, result.Err()
+}
+
+// sequence returns the indexes 0 to n-1.
+func sequence(n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
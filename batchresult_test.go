@@ -0,0 +1,100 @@
+package sqlf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchResult(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", "file:batchresult?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table imports(id integer primary key autoincrement, code text not null unique, qty integer)`); err != nil {
+		t.Fatal(err)
+	}
+	count := func() int {
+		var n int
+		assert.NoError(db.Get(&n, "select count(*) from imports"))
+		return n
+	}
+
+	type Import struct {
+		ID   int64 `sql:"primary_key;auto_increment"`
+		Code string
+		Qty  int
+	}
+	tbl := sqlf.Table("imports", Import{}).WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+
+	// the chunk with the duplicate code is rolled back, and the others are committed
+	rows := []Import{{Code: "a"}, {Code: "b"}, {Code: "c"}, {Code: "a"}, {Code: "e"}}
+	var progress []int
+	result, err := sqlf.InsertRowChunks(db, ins, rows, 2)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "1 of 3 chunks failed, 3 of 5 inputs committed: row 3:")
+	}
+	if assert.NotNil(result) {
+		assert.Len(result.Chunks, 3)
+		assert.Equal([]int{2, 3}, result.Chunks[1].Indexes)
+		assert.Error(result.Chunks[1].Err)
+		assert.Equal(int64(2), result.Chunks[0].RowsAffected)
+		assert.Equal(int64(3), result.RowsAffected())
+		assert.Equal(3, result.Committed())
+		assert.Len(result.Errors, 1)
+		assert.Contains(result.Errors[3].Error(), "UNIQUE")
+		assert.Equal(map[int]int64{0: rows[0].ID, 1: rows[1].ID, 4: rows[4].ID}, result.Keys)
+		assert.NotZero(rows[4].ID)
+		assert.True(errors.Is(err, result.Chunks[1].Err))
+	}
+	assert.Equal(3, count())
+
+	// the rows of the failed chunk can be retried
+	retry := []Import{rows[2]}
+	retry[0].ID = 0
+	result, err = sqlf.InsertRowChunks(db, ins, retry, 2)
+	assert.NoError(err)
+	assert.Equal(int64(1), result.RowsAffected())
+	assert.Equal(4, count())
+
+	// updates and commands
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns.Include("Qty"), tbl.Update.WhereColumns,
+		sqlf.WithProgress(func(done, total int) { progress = append(progress, done) }))
+	result, err = sqlf.UpdateRowChunks(db, upd, []*Import{{ID: rows[4].ID, Qty: 5}, {ID: rows[0].ID, Qty: 1}}, 0)
+	assert.NoError(err)
+	assert.Len(result.Chunks, 1)
+	assert.Equal([]int{1, 0}, result.Chunks[0].Indexes)
+	assert.Equal(int64(2), result.RowsAffected())
+	assert.Equal([]int{1, 2}, progress)
+
+	del := sqlf.Execf("delete from imports where code = ? and qty > 0")
+	result, err = sqlf.ExecChunks(db, del, [][]interface{}{{"a"}, {"b"}, {"e"}}, 1)
+	assert.NoError(err)
+	assert.Len(result.Chunks, 3)
+	assert.Equal(int64(0), result.Chunks[1].RowsAffected)
+	assert.Equal(int64(2), result.RowsAffected())
+	assert.Equal(2, count())
+
+	// using a transaction, the inputs stop at the first failure
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = sqlf.ExecChunks(tx, sqlf.Execf("insert into imports(code) values(?)"), [][]interface{}{{"x"}, {"b"}, {"y"}}, 1)
+	assert.Error(err)
+	assert.Len(result.Chunks, 1)
+	assert.Equal(int64(1), result.Chunks[0].RowsAffected)
+	assert.Contains(result.Errors[1].Error(), "arg set 1")
+	assert.NoError(tx.Rollback())
+
+	_, err = sqlf.InsertRowChunks(db, ins, rows[0], 2)
+	assert.Error(err)
+	_, err = sqlf.ExecChunks(db, del, nil, -1)
+	assert.Error(err)
+}
@@ -0,0 +1,177 @@
+package sqlf
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BoundQuery is a query command bound to a database handle, so that its
+// methods do not need the handle. It is returned by QueryCommand.Bind.
+// Passing bound commands to the code that uses them keeps the choice of
+// connection pool or transaction out of that code:
+//
+//	type OrderStore struct {
+//		ListOrders sqlf.BoundQuery
+//		AddOrder   sqlf.BoundInsertRow
+//	}
+//
+//	func NewOrderStore(tx *sqlx.Tx) *OrderStore {
+//		return &OrderStore{
+//			ListOrders: listOrders.Bind(tx),
+//			AddOrder:   addOrder.Bind(tx),
+//		}
+//	}
+//
+// The methods are the same as the methods of QueryCommand with the same
+// names, and the handle can be a ContextDB or a TenantScope.
+type BoundQuery interface {
+	// Command returns the SQL select statement with placeholders for arguments.
+	Command() string
+
+	// Query executes the query with the arguments given.
+	Query(args ...interface{}) (*QueryRows, error)
+
+	// QueryRow executes the query, which is expected to return at most one row.
+	QueryRow(args ...interface{}) *Row
+
+	// Select executes the query and scans each row into dest, which must be a slice.
+	Select(dest interface{}, args ...interface{}) error
+
+	// Get executes the query, which is expected to return
+	// exactly one row, and scans the row into dest.
+	Get(dest interface{}, args ...interface{}) error
+}
+
+// BoundExec is a command bound to a database handle, so that its methods
+// do not need the handle. It is returned by ExecCommand.Bind. See BoundQuery.
+type BoundExec interface {
+	// Command returns the SQL statement with placeholders for arguments.
+	Command() string
+
+	// Exec executes the SQL statement with the arguments given.
+	Exec(args ...interface{}) (sql.Result, error)
+
+	// ExecStruct executes the SQL statement with the arguments obtained from arg.
+	ExecStruct(arg interface{}) (sql.Result, error)
+}
+
+// BoundInsertRow is an insert row command bound to a database handle, so
+// that its methods do not need the handle. It is returned by
+// InsertRowCommand.Bind. See BoundQuery.
+type BoundInsertRow interface {
+	// Command returns the SQL insert statement with placeholders for arguments.
+	Command() string
+
+	// Exec inserts the row.
+	Exec(row interface{}) error
+
+	// ExecResult inserts the row, and returns the result of the statement.
+	ExecResult(row interface{}) (sql.Result, error)
+
+	// ExecInserted inserts the row, and reports whether it was inserted.
+	ExecInserted(row interface{}) (inserted bool, err error)
+}
+
+// BoundUpdateRow is an update row command bound to a database handle, so
+// that its methods do not need the handle. It is returned by
+// UpdateRowCommand.Bind. See BoundQuery.
+type BoundUpdateRow interface {
+	// Command returns the SQL update/delete statement with placeholders for arguments.
+	Command() string
+
+	// Exec updates or deletes the row, and returns the number of rows affected.
+	Exec(row interface{}) (rowCount int, err error)
+}
+
+func (cmd *queryCommand) Bind(db sqlx.Queryer) BoundQuery {
+	return boundQuery{cmd: cmd, db: db}
+}
+
+func (cmd execCommand) Bind(db sqlx.Execer) BoundExec {
+	return boundExec{cmd: cmd, db: db}
+}
+
+func (cmd insertRowCommand) Bind(db sqlx.Execer) BoundInsertRow {
+	return boundInsertRow{cmd: cmd, db: db}
+}
+
+func (cmd updateRowCommand) Bind(db sqlx.Execer) BoundUpdateRow {
+	return boundUpdateRow{cmd: cmd, db: db}
+}
+
+type boundQuery struct {
+	cmd QueryCommand
+	db  sqlx.Queryer
+}
+
+func (b boundQuery) Command() string {
+	return b.cmd.Command()
+}
+
+func (b boundQuery) Query(args ...interface{}) (*QueryRows, error) {
+	return b.cmd.Query(b.db, args...)
+}
+
+func (b boundQuery) QueryRow(args ...interface{}) *Row {
+	return b.cmd.QueryRow(b.db, args...)
+}
+
+func (b boundQuery) Select(dest interface{}, args ...interface{}) error {
+	return b.cmd.Select(b.db, dest, args...)
+}
+
+func (b boundQuery) Get(dest interface{}, args ...interface{}) error {
+	return b.cmd.Get(b.db, dest, args...)
+}
+
+type boundExec struct {
+	cmd ExecCommand
+	db  sqlx.Execer
+}
+
+func (b boundExec) Command() string {
+	return b.cmd.Command()
+}
+
+func (b boundExec) Exec(args ...interface{}) (sql.Result, error) {
+	return b.cmd.Exec(b.db, args...)
+}
+
+func (b boundExec) ExecStruct(arg interface{}) (sql.Result, error) {
+	return b.cmd.ExecStruct(b.db, arg)
+}
+
+type boundInsertRow struct {
+	cmd InsertRowCommand
+	db  sqlx.Execer
+}
+
+func (b boundInsertRow) Command() string {
+	return b.cmd.Command()
+}
+
+func (b boundInsertRow) Exec(row interface{}) error {
+	return b.cmd.Exec(b.db, row)
+}
+
+func (b boundInsertRow) ExecResult(row interface{}) (sql.Result, error) {
+	return b.cmd.ExecResult(b.db, row)
+}
+
+func (b boundInsertRow) ExecInserted(row interface{}) (bool, error) {
+	return b.cmd.ExecInserted(b.db, row)
+}
+
+type boundUpdateRow struct {
+	cmd UpdateRowCommand
+	db  sqlx.Execer
+}
+
+func (b boundUpdateRow) Command() string {
+	return b.cmd.Command()
+}
+
+func (b boundUpdateRow) Exec(row interface{}) (int, error) {
+	return b.cmd.Exec(b.db, row)
+}
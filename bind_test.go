@@ -0,0 +1,78 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBind(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`create table notes(id integer primary key autoincrement, text text)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Note struct {
+		ID   int64 `sql:"primary_key;auto_increment"`
+		Text string
+	}
+	notes := sqlf.Table("notes", Note{}).WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		notes.Insert.TableName, notes.Insert.Columns, notes.Insert.Values)
+	update := sqlf.UpdateRowf("update %s set %s where %s",
+		notes.Update.TableName, notes.Update.SetColumns, notes.Update.WhereColumns)
+	list := sqlf.Queryf("select %s from %s order by %s",
+		notes.Select.Columns, notes.Select.TableName, notes.Select.OrderBy)
+	deleteAll := sqlf.Execf("delete from %s", notes.Delete.TableName)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// the code using the bound commands does not know about the transaction
+	store := struct {
+		Insert    sqlf.BoundInsertRow
+		Update    sqlf.BoundUpdateRow
+		List      sqlf.BoundQuery
+		DeleteAll sqlf.BoundExec
+	}{
+		Insert:    insert.Bind(tx),
+		Update:    update.Bind(tx),
+		List:      list.Bind(tx),
+		DeleteAll: deleteAll.Bind(tx),
+	}
+	assert.Equal(insert.Command(), store.Insert.Command())
+
+	note := Note{Text: "first"}
+	assert.NoError(store.Insert.Exec(&note))
+	assert.Equal(int64(1), note.ID)
+	inserted, err := store.Insert.ExecInserted(&Note{Text: "second"})
+	assert.NoError(err)
+	assert.True(inserted)
+	note.Text = "changed"
+	n, err := store.Update.Exec(&note)
+	assert.NoError(err)
+	assert.Equal(1, n)
+
+	var rows []Note
+	assert.NoError(store.List.Select(&rows))
+	assert.Equal([]Note{{ID: 1, Text: "changed"}, {ID: 2, Text: "second"}}, rows)
+	var count int
+	assert.NoError(sqlf.Queryf("select count(*) from notes").Bind(tx).Get(&count))
+	assert.Equal(2, count)
+
+	result, err := store.DeleteAll.Exec()
+	assert.NoError(err)
+	affected, _ := result.RowsAffected()
+	assert.Equal(int64(2), affected)
+}
@@ -0,0 +1,91 @@
+package sqlf
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// annotateAll is non-zero if callers are annotated for all commands.
+var annotateAll int32
+
+// AnnotateCallers turns caller annotation on or off for all commands built
+// after it is called. See WithCaller for a description of caller annotation.
+// It is typically called once during program initialization, before any
+// commands are built.
+func AnnotateCallers(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&annotateAll, v)
+}
+
+// WithCaller annotates a command with the location of the code that built
+// it, in the form "dir/file.go:line". The location is appended to the SQL
+// statement as a comment, so that it appears in the database server's slow
+// query log and activity views:
+//
+//	select id, name from users where email = ? /* store/users.go:42 */
+//
+// It is also included in the Error returned when the command fails, in the
+// CommandEvent reported to the metrics sink, and in debug output. Commands
+// built using Scriptf are not annotated.
+//
+// The location is determined once, when the command is built, so there is
+// no cost each time the command is executed. Use AnnotateCallers to
+// annotate all commands.
+func WithCaller() Option {
+	return func(o *options) {
+		o.withCaller = true
+	}
+}
+
+// sqlfPackage is the import path of this package.
+var sqlfPackage = reflect.TypeOf(options{}).PkgPath()
+
+// setCaller records the location of the code that is building a
+// command, if caller annotation is turned on.
+func (o *options) setCaller() {
+	if o.withCaller || atomic.LoadInt32(&annotateAll) != 0 {
+		o.caller = callerOutside()
+	}
+}
+
+// callerOutside returns the location of the first function in the call
+// stack that is not in this package, or blank if it cannot be determined.
+func callerOutside() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !inPackage(frame.Function, sqlfPackage) || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", path.Join(path.Base(path.Dir(frame.File)), path.Base(frame.File)), frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// inPackage reports whether the fully qualified function name
+// (eg "github.com/a/b.(*T).Method") is in the package.
+func inPackage(function string, pkg string) bool {
+	if !strings.HasPrefix(function, pkg) {
+		return false
+	}
+	return strings.HasPrefix(function[len(pkg):], ".")
+}
+
+// annotate returns the command with the caller appended as a comment,
+// if there is a caller.
+func (o options) annotate(command string) string {
+	if o.caller == "" {
+		return command
+	}
+	return fmt.Sprintf("%s /* %s */", command, strings.Replace(o.caller, "*/", "* /", -1))
+}
@@ -0,0 +1,53 @@
+package sqlf_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCaller(t *testing.T) {
+	assert := assert.New(t)
+	sink := &testSink{}
+	sqlf.SetMetricsSink(sink)
+	defer sqlf.SetMetricsSink(nil)
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	caller := regexp.MustCompile(`(^|/)caller_test\.go:\d+$`)
+
+	query := sqlf.Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName, sqlf.WithCaller())
+	assert.Regexp(`^select .* from `+"`table1`"+` /\* (\S*/)?caller_test\.go:\d+ \*/$`, query.Command())
+
+	var rows []Row1
+	err = query.Select(db, &rows)
+	var sqlfErr *sqlf.Error
+	if assert.True(errors.As(err, &sqlfErr)) {
+		assert.Regexp(caller, sqlfErr.Caller)
+		assert.Contains(err.Error(), ", caller "+sqlfErr.Caller+")")
+	}
+	if assert.Len(sink.events, 1) {
+		assert.Regexp(caller, sink.events[0].Caller)
+	}
+
+	// commands are not annotated by default
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.Equal("insert into `table1`(`given_name`,`family_name`,`Date_of_Birth`) values(?,?,?)", insert.Command())
+
+	sqlf.AnnotateCallers(true)
+	defer sqlf.AnnotateCallers(false)
+	insert = sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.Regexp(`values\(\?,\?,\?\) /\* (\S*/)?caller_test\.go:\d+ \*/$`, insert.Command())
+	update := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	assert.Regexp(`/\* (\S*/)?caller_test\.go:\d+ \*/$`, update.Command())
+	exec := sqlf.Execf("delete from %s", tbl.Delete.TableName)
+	assert.Regexp(`^delete from `+"`table1`"+` /\* (\S*/)?caller_test\.go:\d+ \*/$`, exec.Command())
+}
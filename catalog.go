@@ -0,0 +1,128 @@
+package sqlf
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// tableComments contains the descriptions of tables,
+// keyed by table name, see DescribeTable.
+var tableComments = struct {
+	mutex sync.RWMutex
+	m     map[string]string
+}{m: make(map[string]string)}
+
+// DescribeTable sets the description of the table, which is included in
+// the catalog written by WriteCatalog. Columns are described using the
+// "comment" tag:
+//
+//	type Customer struct {
+//		ID    int64  `sql:"primary_key"`
+//		Email string `sql:"comment:Login and contact address, unique"`
+//	}
+//
+//	var customers = sqlf.Table("customers", Customer{})
+//
+//	func init() {
+//		sqlf.DescribeTable(customers, "Customers who have placed an order")
+//	}
+func DescribeTable(table *TableInfo, description string) {
+	tableComments.mutex.Lock()
+	defer tableComments.mutex.Unlock()
+	tableComments.m[table.Name] = description
+}
+
+// Comment returns the description of the table set using DescribeTable.
+func (ti *TableInfo) Comment() string {
+	tableComments.mutex.RLock()
+	defer tableComments.mutex.RUnlock()
+	return tableComments.m[ti.Name]
+}
+
+// Catalog describes the tables and named commands of a program.
+// It is created using NewCatalog.
+type Catalog struct {
+	Tables   []CatalogTable   `json:"tables"`
+	Commands []CatalogCommand `json:"commands"`
+}
+
+// CatalogTable describes a table in a Catalog.
+type CatalogTable struct {
+	Name    string          `json:"name"`
+	Comment string          `json:"comment,omitempty"`
+	Columns []CatalogColumn `json:"columns"`
+}
+
+// CatalogColumn describes a column of a table in a Catalog.
+type CatalogColumn struct {
+	Name          string `json:"name"`
+	Field         string `json:"field"`
+	GoType        string `json:"goType"`
+	DBType        string `json:"dbType,omitempty"`
+	PrimaryKey    bool   `json:"primaryKey,omitempty"`
+	AutoIncrement bool   `json:"autoIncrement,omitempty"`
+	NotNull       bool   `json:"notNull,omitempty"`
+	MaxLen        int    `json:"maxLen,omitempty"`
+	Comment       string `json:"comment,omitempty"`
+}
+
+// CatalogCommand describes a named command in a Catalog.
+type CatalogCommand struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql"`
+}
+
+// NewCatalog returns a catalog of every table created using Table, in the
+// order in which they were created, and every command added to the registry
+// using Register, sorted by name. A table created more than once with the
+// same name is only included once.
+func NewCatalog() *Catalog {
+	c := &Catalog{
+		Tables:   []CatalogTable{},
+		Commands: []CatalogCommand{},
+	}
+	seen := make(map[string]bool)
+	for _, ti := range Tables() {
+		if seen[ti.Name] {
+			continue
+		}
+		seen[ti.Name] = true
+		table := CatalogTable{
+			Name:    ti.Name,
+			Comment: ti.Comment(),
+			Columns: []CatalogColumn{},
+		}
+		for _, col := range ti.Columns() {
+			table.Columns = append(table.Columns, CatalogColumn{
+				Name:          col.Name,
+				Field:         col.FieldName,
+				GoType:        col.FieldType.String(),
+				DBType:        col.DBType,
+				PrimaryKey:    col.PrimaryKey,
+				AutoIncrement: col.AutoIncrement,
+				NotNull:       col.NotNull,
+				MaxLen:        col.MaxLen,
+				Comment:       col.Comment,
+			})
+		}
+		c.Tables = append(c.Tables, table)
+	}
+	for _, rc := range Commands() {
+		c.Commands = append(c.Commands, CatalogCommand{
+			Name: rc.Name,
+			SQL:  rc.Cmd.Command(),
+		})
+	}
+	return c
+}
+
+// WriteCatalog writes the catalog returned by NewCatalog to w as indented
+// JSON. This provides a machine-readable description of the database
+// schema and SQL of the program for data dictionaries and developer
+// portals, generated from the row structs and commands themselves.
+func WriteCatalog(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewCatalog())
+}
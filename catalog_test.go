@@ -0,0 +1,51 @@
+package sqlf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	assert := assert.New(t)
+	type CatalogCustomer struct {
+		ID    int64  `sql:"primary_key;auto_increment"`
+		Email string `sql:"comment:Login and contact address, unique;not null"`
+	}
+	customers := sqlf.Table("catalog_customers", CatalogCustomer{})
+	sqlf.DescribeTable(customers, "Customers who have placed an order")
+	getCustomer := sqlf.Queryf("select %s from %s where id = ?",
+		customers.Select.Columns, customers.Select.TableName)
+	sqlf.Register("CatalogGetCustomer", getCustomer)
+
+	assert.Equal("Customers who have placed an order", customers.WithDialect(sqlf.DialectPG).Comment())
+	assert.Equal("Login and contact address, unique", customers.Columns()[1].Comment)
+
+	var buf bytes.Buffer
+	assert.NoError(sqlf.WriteCatalog(&buf))
+	var catalog sqlf.Catalog
+	assert.NoError(json.Unmarshal(buf.Bytes(), &catalog))
+	var table *sqlf.CatalogTable
+	for i := range catalog.Tables {
+		if catalog.Tables[i].Name == "catalog_customers" {
+			table = &catalog.Tables[i]
+		}
+	}
+	if assert.NotNil(table) {
+		assert.Equal(sqlf.CatalogTable{
+			Name:    "catalog_customers",
+			Comment: "Customers who have placed an order",
+			Columns: []sqlf.CatalogColumn{
+				{Name: "id", Field: "ID", GoType: "int64", PrimaryKey: true, AutoIncrement: true},
+				{Name: "email", Field: "Email", GoType: "string", NotNull: true, Comment: "Login and contact address, unique"},
+			},
+		}, *table)
+	}
+	assert.Contains(catalog.Commands, sqlf.CatalogCommand{
+		Name: "CatalogGetCustomer",
+		SQL:  getCustomer.Command(),
+	})
+}
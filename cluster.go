@@ -0,0 +1,243 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjeffery/sqlf/scan"
+	"github.com/jmoiron/sqlx"
+)
+
+// defaultReplicaLag is the time that a session reads from the
+// primary after a write, unless changed using WithReplicaLag.
+const defaultReplicaLag = time.Second
+
+// Cluster is a primary database and its read replicas, which have the same
+// schema and driver. Commands are executed using a session of the cluster,
+// which sends writes to the primary and reads to the replicas, except for
+// the reads that follow a write in the same session. See Cluster.Session.
+type Cluster struct {
+	primary  *sqlx.DB
+	replicas []*sqlx.DB
+	lag      time.Duration
+}
+
+// NewCluster returns a cluster with a primary database and any number
+// of read replicas. If there are no replicas, all commands are sent to
+// the primary.
+func NewCluster(primary *sqlx.DB, replicas ...*sqlx.DB) *Cluster {
+	return &Cluster{
+		primary:  primary,
+		replicas: replicas,
+		lag:      defaultReplicaLag,
+	}
+}
+
+// WithReplicaLag returns a copy of the cluster whose sessions read from
+// the primary for lag after a write, which should be longer than the time
+// the replicas usually take to apply a change made on the primary. The
+// default is one second.
+func (c *Cluster) WithReplicaLag(lag time.Duration) *Cluster {
+	c2 := *c
+	c2.lag = lag
+	return &c2
+}
+
+// Primary returns the primary database of the cluster.
+func (c *Cluster) Primary() *sqlx.DB {
+	return c.primary
+}
+
+// Session returns a new session, which is a database handle that gives
+// read-your-writes consistency: after the session writes to the primary,
+// it sends its reads to the primary until the replicas have had time to
+// catch up (see WithReplicaLag). Other reads are sent to a replica chosen
+// at random. This avoids the common bug where a row that has just been
+// created is not found, because it was read from a replica.
+//
+// A session is usually created for each request, and can be used with
+// ForContext and with Transact:
+//
+//	session := cluster.Session()
+//	err := insertOrder.Exec(session, &order)
+//	// ...
+//	err = getOrder.Get(session, &order, order.ID) // reads from the primary
+//
+// A statement is a read if it is a select statement (including one with a
+// "with" clause) that does not insert, update, delete or lock rows. All
+// other statements, and all transactions, are sent to the primary and
+// count as writes. For a transaction begun using Transact, the write
+// happens when the transaction is committed, and for one begun using
+// Beginx, when it is begun. A session is safe for concurrent use.
+func (c *Cluster) Session() *ClusterSession {
+	return &ClusterSession{cluster: c}
+}
+
+// Resume returns a session whose last write was at lastWrite, which is
+// typically the value of ClusterSession.LastWrite stored in a cookie by an
+// earlier request, so that a client that is redirected after a write reads
+// the row it has just written.
+func (c *Cluster) Resume(lastWrite time.Time) *ClusterSession {
+	return &ClusterSession{cluster: c, lastWrite: lastWrite}
+}
+
+// ClusterSession is a database handle for a Cluster that
+// gives read-your-writes consistency, see Cluster.Session.
+type ClusterSession struct {
+	cluster   *Cluster
+	mutex     sync.Mutex
+	lastWrite time.Time
+}
+
+// MarkWrite records a write made by the session, for a write that was not
+// made using the session itself, such as a transaction begun using the
+// primary database handle.
+func (s *ClusterSession) MarkWrite() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastWrite = time.Now()
+}
+
+// LastWrite returns the time of the last write made by the session,
+// or the zero time if the session has not written.
+func (s *ClusterSession) LastWrite() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastWrite
+}
+
+// pinned reports whether the session reads from the primary,
+// because the replicas may not have applied its last write.
+func (s *ClusterSession) pinned() bool {
+	last := s.LastWrite()
+	return !last.IsZero() && time.Since(last) < s.cluster.lag
+}
+
+// reader returns the database that executes the query.
+func (s *ClusterSession) reader(query string) *sqlx.DB {
+	if !isReadQuery(query) {
+		// a statement that writes using a query,
+		// eg "insert ... returning", is a write
+		return s.writer()
+	}
+	replicas := s.cluster.replicas
+	if len(replicas) == 0 || s.pinned() {
+		return s.cluster.primary
+	}
+	return replicas[rand.Intn(len(replicas))]
+}
+
+// writer returns the database that executes a write,
+// and records the write.
+func (s *ClusterSession) writer() *sqlx.DB {
+	s.MarkWrite()
+	return s.cluster.primary
+}
+
+// Replicas returns the read replicas of the cluster, or nil if the session
+// reads from the primary. It implements ReplicaSet, so that queries built
+// using WithHedging are hedged only when they would be sent to a replica.
+func (s *ClusterSession) Replicas() []*sqlx.DB {
+	if s.pinned() {
+		return nil
+	}
+	return s.cluster.replicas
+}
+
+// DriverName returns the driver name of the primary database.
+func (s *ClusterSession) DriverName() string {
+	return s.cluster.primary.DriverName()
+}
+
+// Rebind transforms a query from "?" bindvars to the
+// bindvars of the primary database's driver.
+func (s *ClusterSession) Rebind(query string) string {
+	return s.cluster.primary.Rebind(query)
+}
+
+// BindNamed binds a query using the primary database's driver.
+func (s *ClusterSession) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return s.cluster.primary.BindNamed(query, arg)
+}
+
+// Exec executes a statement using the primary database.
+func (s *ClusterSession) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.writer().Exec(query, args...)
+}
+
+// ExecContext executes a statement using the primary database.
+func (s *ClusterSession) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.writer().ExecContext(ctx, query, args...)
+}
+
+// Query executes a query using a replica or the primary database.
+func (s *ClusterSession) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.reader(query).Query(query, args...)
+}
+
+// QueryContext executes a query using a replica or the primary database.
+func (s *ClusterSession) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.reader(query).QueryContext(ctx, query, args...)
+}
+
+// Queryx executes a query using a replica or the primary database.
+func (s *ClusterSession) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return s.reader(query).Queryx(query, args...)
+}
+
+// QueryxContext executes a query using a replica or the primary database.
+func (s *ClusterSession) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return s.reader(query).QueryxContext(ctx, query, args...)
+}
+
+// QueryRowx executes a query using a replica or the primary database.
+func (s *ClusterSession) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	return s.reader(query).QueryRowx(query, args...)
+}
+
+// QueryRowxContext executes a query using a replica or the primary database.
+func (s *ClusterSession) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return s.reader(query).QueryRowxContext(ctx, query, args...)
+}
+
+// Beginx begins a transaction using the primary database.
+func (s *ClusterSession) Beginx() (*sqlx.Tx, error) {
+	return s.writer().Beginx()
+}
+
+// BeginTxx begins a transaction using the primary database. The write
+// is recorded when the transaction is committed by Transact.
+func (s *ClusterSession) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return s.cluster.primary.BeginTxx(ctx, opts)
+}
+
+// isReadQuery reports whether the query only reads rows, so
+// that it can be sent to a replica, see Cluster.Session.
+func isReadQuery(query string) bool {
+	var first string
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		if tok != scan.IDENT {
+			continue
+		}
+		word := strings.ToLower(lit)
+		if first == "" {
+			first = word
+		}
+		switch word {
+		case "insert", "update", "delete", "merge", "into", "lock", "share":
+			// "into" is for "select ... into", and the others for
+			// "for update", "for share" and "lock in share mode"
+			return false
+		}
+	}
+	return first == "select" || first == "with"
+}
@@ -0,0 +1,78 @@
+package sqlf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterSession(t *testing.T) {
+	assert := assert.New(t)
+	open := func(name string) *sqlx.DB {
+		db, err := sqlx.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec(`create table servers(id integer primary key, name text);
+			insert into servers values(1, ?)`, name); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	primary, replica := open("primary"), open("replica")
+	defer primary.Close()
+	defer replica.Close()
+	cluster := sqlf.NewCluster(primary, replica)
+
+	getName := sqlf.Queryf("select name from servers where id = 1")
+	rename := sqlf.Execf("update servers set name = name || '*' where id = 1")
+	name := func(db sqlx.Queryer) string {
+		var s string
+		assert.NoError(getName.Get(db, &s))
+		return s
+	}
+
+	// reads go to the replica until the session writes
+	session := cluster.Session()
+	assert.Equal("replica", name(session))
+	assert.True(session.LastWrite().IsZero())
+	_, err := rename.Exec(session)
+	assert.NoError(err)
+	assert.False(session.LastWrite().IsZero())
+	assert.Equal("primary*", name(session))
+	assert.Nil(session.Replicas())
+
+	// other sessions are not affected
+	assert.Equal("replica", name(cluster.Session()))
+
+	// the replicas have caught up
+	assert.Equal("replica", name(cluster.Resume(time.Now().Add(-time.Hour))))
+	session = cluster.WithReplicaLag(0).Session()
+	_, err = rename.Exec(session)
+	assert.NoError(err)
+	assert.Equal("replica", name(session))
+	assert.Len(session.Replicas(), 1)
+
+	// a statement that is not a select is a write
+	session = cluster.Session()
+	rows, err := session.Queryx("delete from servers where id = 2")
+	assert.NoError(err)
+	rows.Close()
+	assert.Equal("primary**", name(session))
+
+	// a transaction is a write when committed
+	session = cluster.Session()
+	err = sqlf.Transact(session, func(tx sqlx.Ext) error {
+		_, err := rename.Exec(tx)
+		return err
+	})
+	assert.NoError(err)
+	assert.Equal("primary***", name(session))
+
+	// without replicas, everything is sent to the primary
+	assert.Equal("primary***", name(sqlf.NewCluster(primary).Session()))
+}
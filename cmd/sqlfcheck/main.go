@@ -0,0 +1,20 @@
+// Command sqlfcheck checks calls to the sqlf package for format string
+// and argument errors. See the sqlfcheck package for the checks made.
+//
+// Usage:
+//
+//	sqlfcheck [packages]
+//
+// The analyzer can also be run using go vet:
+//
+//	go vet -vettool=$(which sqlfcheck) ./...
+package main
+
+import (
+	"github.com/jjeffery/sqlf/sqlfcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(sqlfcheck.Analyzer)
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jjeffery/sqlf"
+)
+
+// Config controls the code that is generated.
+type Config struct {
+	Package  string // name of the generated package
+	Singular bool   // use the singular form of table names for struct names
+}
+
+// goType describes the Go type used for a column.
+type goType struct {
+	name     string // Go type
+	nullable string // Go type if the column is nullable
+	imp      string // import path required, if any
+}
+
+var goTypes = []struct {
+	re  *regexp.Regexp
+	typ goType
+}{
+	{regexp.MustCompile(`^(tiny|small|medium|big)?int(eger)?[248]?\b|^(small|big)?serial[248]?\b`),
+		goType{name: "int64", nullable: "sql.NullInt64"}},
+	{regexp.MustCompile(`^(bool|boolean|bit)\b`),
+		goType{name: "bool", nullable: "sql.NullBool"}},
+	{regexp.MustCompile(`^(real|float[48]?|double)\b`),
+		goType{name: "float64", nullable: "sql.NullFloat64"}},
+	{regexp.MustCompile(`^(date|datetime|timestamp|time)\b`),
+		goType{name: "time.Time", nullable: "sql.NullTime", imp: "time"}},
+	{regexp.MustCompile(`^(blob|tinyblob|mediumblob|longblob|bytea|binary|varbinary)\b`),
+		goType{name: "[]byte", nullable: "[]byte"}},
+}
+
+// defaultGoType is used for all other columns, including character
+// columns and numeric/decimal columns, which would lose precision
+// if scanned into a float64.
+var defaultGoType = goType{name: "string", nullable: "sql.NullString"}
+
+// typeFor returns the Go type for the column.
+func typeFor(c *Column) (name string, imp string) {
+	typ := defaultGoType
+	sqlType := strings.ToLower(c.Type)
+	for _, t := range goTypes {
+		if t.re.MatchString(sqlType) {
+			typ = t.typ
+			break
+		}
+	}
+	if c.Nullable {
+		if strings.HasPrefix(typ.nullable, "sql.") {
+			return typ.nullable, "database/sql"
+		}
+		return typ.nullable, typ.imp
+	}
+	return typ.name, typ.imp
+}
+
+// goName converts a database name such as "given_name" into an
+// exported Go name such as "GivenName".
+func goName(name string) string {
+	var buf bytes.Buffer
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	for _, word := range words {
+		upper := strings.ToUpper(word)
+		if isInitialism(upper) {
+			buf.WriteString(upper)
+			continue
+		}
+		buf.WriteString(upper[:1])
+		buf.WriteString(word[1:])
+	}
+	s := buf.String()
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "X" + s
+	}
+	return s
+}
+
+// commonInitialisms are the same as those used by sqlf.ToDBName,
+// which in turn come from golint.
+var commonInitialisms = []string{"API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA", "SMTP", "SSH", "TLS", "TTL", "UI", "UID", "UUID", "URI", "URL", "UTF8", "VM", "XML", "XSRF", "XSS"}
+
+func isInitialism(s string) bool {
+	for _, initialism := range commonInitialisms {
+		if s == initialism {
+			return true
+		}
+	}
+	return false
+}
+
+// singular returns the singular form of an English plural noun.
+// It only handles the most common cases.
+func singular(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"),
+		strings.HasSuffix(lower, "is"):
+		return s
+	case strings.HasSuffix(lower, "s") && len(s) > 1:
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// field is a field in a generated struct.
+type field struct {
+	name string
+	typ  string
+	tags []string
+}
+
+// Generate returns the Go source code for the tables.
+func Generate(cfg Config, tables []*Table) ([]byte, error) {
+	imports := map[string]bool{"github.com/jjeffery/sqlf": true}
+	var body bytes.Buffer
+	for _, table := range tables {
+		generateTable(&body, cfg, table, imports)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by sqlfgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", cfg.Package)
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	buf.WriteString("import (\n")
+	for _, std := range []bool{true, false} {
+		for _, path := range paths {
+			// standard library packages do not have a dot in the first element
+			if isStd := !strings.Contains(strings.Split(path, "/")[0], "."); isStd == std {
+				fmt.Fprintf(&buf, "%q\n", path)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format generated code: %v", err)
+	}
+	return src, nil
+}
+
+func generateTable(w *bytes.Buffer, cfg Config, table *Table, imports map[string]bool) {
+	typeName := goName(table.Name)
+	if cfg.Singular {
+		typeName = singular(typeName)
+	}
+	tableVar := typeName + "Table"
+
+	var fields []field
+	used := make(map[string]bool)
+	for _, c := range table.Columns {
+		f := field{name: goName(c.Name)}
+		for used[f.name] {
+			f.name += "_"
+		}
+		used[f.name] = true
+
+		var imp string
+		f.typ, imp = typeFor(c)
+		if imp != "" {
+			imports[imp] = true
+		}
+		if sqlf.ToDBName(f.name) != c.Name {
+			f.tags = append(f.tags, "column:"+c.Name)
+		}
+		// sqlf treats a field named "ID" as the primary key by default
+		if table.isPrimaryKey(c) && (len(table.PrimaryKey) > 1 || !strings.EqualFold(f.name, "id")) {
+			f.tags = append(f.tags, "primary_key")
+		}
+		if c.AutoIncrement {
+			f.tags = append(f.tags, "auto_increment")
+		}
+		if c.ReadOnly {
+			f.tags = append(f.tags, "readonly")
+		}
+		// the maxlen constraint only applies to string and []byte fields
+		if n := c.maxLen(); n > 0 && (f.typ == "string" || f.typ == "[]byte") {
+			f.tags = append(f.tags, fmt.Sprintf("maxlen:%d", n))
+		}
+		fields = append(fields, f)
+	}
+
+	fmt.Fprintf(w, "\n// %s is a row in the %s table.\n", typeName, table.Name)
+	fmt.Fprintf(w, "type %s struct {\n", typeName)
+	for _, f := range fields {
+		if len(f.tags) > 0 {
+			fmt.Fprintf(w, "%s %s `sql:\"%s\"`\n", f.name, f.typ, strings.Join(f.tags, ";"))
+		} else {
+			fmt.Fprintf(w, "%s %s\n", f.name, f.typ)
+		}
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	fmt.Fprintf(w, "// %s contains the table information for %s.\n", tableVar, typeName)
+	fmt.Fprintf(w, "var %s = sqlf.Table(%q, %s{})\n\n", tableVar, table.Name, typeName)
+
+	fmt.Fprintf(w, "// Commands for the %s table.\n", table.Name)
+	fmt.Fprintf(w, "var (\n")
+	name := "Insert" + typeName
+	fmt.Fprintf(w, "%s = sqlf.InsertRowf(\"insert into %%s(%%s) values(%%s)\",\n", name)
+	fmt.Fprintf(w, "%[1]s.Insert.TableName, %[1]s.Insert.Columns, %[1]s.Insert.Values,\n", tableVar)
+	fmt.Fprintf(w, "sqlf.WithName(%q))\n", name)
+
+	if len(table.PrimaryKey) == 0 {
+		fmt.Fprintf(w, ")\n")
+		return
+	}
+
+	name = "Update" + typeName
+	fmt.Fprintf(w, "%s = sqlf.UpdateRowf(\"update %%s set %%s where %%s\",\n", name)
+	fmt.Fprintf(w, "%[1]s.Update.TableName, %[1]s.Update.SetColumns, %[1]s.Update.WhereColumns,\n", tableVar)
+	fmt.Fprintf(w, "sqlf.WithName(%q))\n", name)
+
+	name = "Delete" + typeName
+	fmt.Fprintf(w, "%s = sqlf.UpdateRowf(\"delete from %%s where %%s\",\n", name)
+	fmt.Fprintf(w, "%[1]s.Update.TableName, %[1]s.Update.WhereColumns,\n", tableVar)
+	fmt.Fprintf(w, "sqlf.WithName(%q))\n", name)
+
+	name = "Get" + typeName + "ByPK"
+	var conds []string
+	var args []string
+	for _, pk := range table.PrimaryKey {
+		conds = append(conds, pk+" = %s")
+		args = append(args, tableVar+".Select.Placeholder()")
+	}
+	fmt.Fprintf(w, "%s = sqlf.Queryf(%q,\n", name, "select %s from %s where "+strings.Join(conds, " and "))
+	fmt.Fprintf(w, "%[1]s.Select.Columns, %[1]s.Select.TableName, %[2]s,\n", tableVar, strings.Join(args, ", "))
+	fmt.Fprintf(w, "sqlf.WithName(%q))\n", name)
+	fmt.Fprintf(w, ")\n")
+}
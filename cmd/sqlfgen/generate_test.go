@@ -0,0 +1,113 @@
+package main
+
+import (
+	goparser "go/parser"
+	gotoken "go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+	tables := []*Table{
+		{
+			Name:       "users",
+			PrimaryKey: []string{"id"},
+			Columns: []*Column{
+				{Name: "id", Type: "integer", AutoIncrement: true},
+				{Name: "email", Type: "varchar(100)"},
+				{Name: "Date_of_Birth", Type: "date", Nullable: true},
+				{Name: "home_url", Type: "text", Nullable: true},
+			},
+		},
+		{
+			Name:       "order_lines",
+			PrimaryKey: []string{"order_id", "line_no"},
+			Columns: []*Column{
+				{Name: "order_id", Type: "bigint"},
+				{Name: "line_no", Type: "int"},
+				{Name: "amount", Type: "numeric(10,2)"},
+				{Name: "weight", Type: "double precision", Nullable: true},
+				{Name: "created_at", Type: "timestamp with time zone"},
+			},
+		},
+		{
+			Name: "audit_log",
+			Columns: []*Column{
+				{Name: "message", Type: "text"},
+			},
+		},
+	}
+
+	src, err := Generate(Config{Package: "models", Singular: true}, tables)
+	if !assert.NoError(err) {
+		return
+	}
+	code := string(src)
+	_, err = goparser.ParseFile(gotoken.NewFileSet(), "tables.go", src, 0)
+	assert.NoError(err)
+
+	assert.Contains(code, "// Code generated by sqlfgen. DO NOT EDIT.")
+	assert.Contains(code, "package models")
+	assert.Contains(code, "\t\"database/sql\"\n")
+	assert.Contains(code, "\t\"time\"\n\n\t\"github.com/jjeffery/sqlf\"\n")
+
+	assert.Contains(code, "type User struct {")
+	assert.Contains(code, "ID          int64        `sql:\"auto_increment\"`")
+	assert.Contains(code, "Email       string       `sql:\"maxlen:100\"`")
+	assert.Contains(code, "DateOfBirth sql.NullTime `sql:\"column:Date_of_Birth\"`")
+	assert.Contains(code, "HomeURL     sql.NullString\n")
+	assert.Contains(code, `var UserTable = sqlf.Table("users", User{})`)
+	assert.Contains(code, `InsertUser = sqlf.InsertRowf("insert into %s(%s) values(%s)",`)
+	assert.Contains(code, `UpdateUser = sqlf.UpdateRowf("update %s set %s where %s",`)
+	assert.Contains(code, `DeleteUser = sqlf.UpdateRowf("delete from %s where %s",`)
+	assert.Contains(code, `GetUserByPK = sqlf.Queryf("select %s from %s where id = %s",`)
+	assert.Contains(code, `sqlf.WithName("GetUserByPK"))`)
+
+	assert.Contains(code, "type OrderLine struct {")
+	assert.Contains(code, "OrderID   int64 `sql:\"primary_key\"`")
+	assert.Contains(code, "Amount    string\n")
+	assert.Contains(code, "Weight    sql.NullFloat64\n")
+	assert.Contains(code, "CreatedAt time.Time\n")
+	assert.Contains(code, `"select %s from %s where order_id = %s and line_no = %s"`)
+	assert.Contains(code, "OrderLineTable.Select.Placeholder(), OrderLineTable.Select.Placeholder(),")
+
+	// no primary key, so only an insert command
+	assert.Contains(code, "type AuditLog struct {")
+	assert.Contains(code, "InsertAuditLog = sqlf.InsertRowf(")
+	assert.NotContains(code, "UpdateAuditLog")
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"given_name":    "GivenName",
+		"Date_of_Birth": "DateOfBirth",
+		"user_id":       "UserID",
+		"html_url":      "HTMLURL",
+		"2fa_enabled":   "X2faEnabled",
+		"camelCase":     "CamelCase",
+	}
+	for name, want := range tests {
+		if got := goName(name); got != want {
+			t.Errorf("goName(%q): got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := map[string]string{
+		"Users":      "User",
+		"Categories": "Category",
+		"Addresses":  "Address",
+		"Boxes":      "Box",
+		"Status":     "Status",
+		"Analysis":   "Analysis",
+		"Data":       "Data",
+	}
+	for name, want := range tests {
+		if got := singular(name); got != want {
+			t.Errorf("singular(%q): got %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// LoadSchema reads the tables in the database. SQLite databases are
+// read using "pragma table_info". PostgreSQL and MySQL databases are
+// read using information_schema, and only include the tables in the
+// current schema.
+func LoadSchema(db *sql.DB, driverName string) ([]*Table, error) {
+	switch driverName {
+	case "sqlite3":
+		return loadSQLite(db)
+	case "postgres", "pgx":
+		return loadInformationSchema(db, "current_schema()",
+			"(column_default like 'nextval(%' or is_identity = 'YES')",
+			"is_generated = 'ALWAYS'")
+	case "mysql":
+		return loadInformationSchema(db, "database()",
+			"extra like '%auto_increment%'",
+			"extra like '%GENERATED%'")
+	}
+	return nil, fmt.Errorf("unsupported driver: %s", driverName)
+}
+
+func loadSQLite(db *sql.DB) ([]*Table, error) {
+	var names []string
+	rows, err := db.Query(`select name from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var tables []*Table
+	for _, name := range names {
+		table, err := loadSQLiteTable(db, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func loadSQLiteTable(db *sql.DB, name string) (*Table, error) {
+	rows, err := db.Query(fmt.Sprintf("pragma table_info(%s)", quoteIdent(name)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := &Table{Name: name}
+	pkPositions := make(map[int]string)
+	for rows.Next() {
+		var (
+			cid       int
+			column    Column
+			notNull   bool
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &column.Name, &column.Type, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		column.Nullable = !notNull && pk == 0
+		table.Columns = append(table.Columns, &column)
+		if pk > 0 {
+			pkPositions[pk] = column.Name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := 1; i <= len(pkPositions); i++ {
+		table.PrimaryKey = append(table.PrimaryKey, pkPositions[i])
+	}
+
+	// an "integer primary key" column is an alias for the rowid
+	if len(table.PrimaryKey) == 1 {
+		if c := table.column(table.PrimaryKey[0]); strings.EqualFold(c.Type, "integer") {
+			c.AutoIncrement = true
+		}
+	}
+	return table, nil
+}
+
+// loadInformationSchema reads the tables using the information_schema
+// views, which are similar enough in PostgreSQL and MySQL for the same
+// queries to work. The SQL expressions passed identify the schema, and
+// test for auto-increment and generated columns.
+func loadInformationSchema(db *sql.DB, schema, autoIncrement, generated string) ([]*Table, error) {
+	rows, err := db.Query(fmt.Sprintf(`select table_name, column_name, data_type,
+		character_maximum_length, is_nullable = 'YES', %s, %s
+		from information_schema.columns
+		where table_schema = %s
+		order by table_name, ordinal_position`, autoIncrement, generated, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []*Table
+	byName := make(map[string]*Table)
+	for rows.Next() {
+		var (
+			tableName string
+			column    Column
+			maxLen    sql.NullInt64
+		)
+		err := rows.Scan(&tableName, &column.Name, &column.Type, &maxLen,
+			&column.Nullable, &column.AutoIncrement, &column.ReadOnly)
+		if err != nil {
+			return nil, err
+		}
+		if maxLen.Valid && maxLen.Int64 > 0 {
+			column.Type = fmt.Sprintf("%s(%d)", column.Type, maxLen.Int64)
+		}
+		table := byName[tableName]
+		if table == nil {
+			table = &Table{Name: tableName}
+			byName[tableName] = table
+			tables = append(tables, table)
+		}
+		table.Columns = append(table.Columns, &column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(fmt.Sprintf(`select kcu.table_name, kcu.column_name
+		from information_schema.table_constraints tc
+		join information_schema.key_column_usage kcu
+		on kcu.constraint_name = tc.constraint_name
+		and kcu.table_schema = tc.table_schema
+		and kcu.table_name = tc.table_name
+		where tc.constraint_type = 'PRIMARY KEY'
+		and tc.table_schema = %s
+		order by kcu.table_name, kcu.ordinal_position`, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, columnName string
+		if err := rows.Scan(&tableName, &columnName); err != nil {
+			return nil, err
+		}
+		if table := byName[tableName]; table != nil {
+			table.PrimaryKey = append(table.PrimaryKey, columnName)
+		}
+	}
+	return tables, rows.Err()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
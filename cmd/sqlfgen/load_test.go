@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSchemaSQLite(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`create table users(id integer primary key, email varchar(100) not null, given_name text)`,
+		`create table user_roles(user_id integer, role text, primary key(user_id, role))`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tables, err := LoadSchema(db, "sqlite3")
+	if !assert.NoError(err) || !assert.Len(tables, 2) {
+		return
+	}
+
+	roles := tables[0]
+	assert.Equal("user_roles", roles.Name)
+	assert.Equal([]string{"user_id", "role"}, roles.PrimaryKey)
+	assert.Equal([]*Column{
+		{Name: "user_id", Type: "INTEGER"},
+		{Name: "role", Type: "TEXT"},
+	}, roles.Columns)
+
+	users := tables[1]
+	assert.Equal("users", users.Name)
+	assert.Equal([]string{"id"}, users.PrimaryKey)
+	assert.Equal([]*Column{
+		{Name: "id", Type: "INTEGER", AutoIncrement: true},
+		{Name: "email", Type: "varchar(100)"},
+		{Name: "given_name", Type: "TEXT", Nullable: true},
+	}, users.Columns)
+
+	_, err = LoadSchema(db, "oracle")
+	assert.Error(err)
+}
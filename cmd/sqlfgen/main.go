@@ -0,0 +1,105 @@
+// Command sqlfgen generates Go row structs and sqlf commands
+// from a database schema.
+//
+// The schema is read from a file containing CREATE TABLE statements,
+// such as the output of pg_dump --schema-only or mysqldump --no-data:
+//
+//	sqlfgen -schema schema.sql -package models -o models/tables.go
+//
+// or from a database:
+//
+//	sqlfgen -driver postgres -dsn "postgres://localhost/mydb" -package models
+//
+// For each table, sqlfgen generates a row struct with the tags required
+// by sqlf, a *sqlf.TableInfo variable, and commands to insert a row. If
+// the table has a primary key, it also generates commands to update and
+// delete a row, and to query a row by its primary key.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	var (
+		schemaFile = flag.String("schema", "", "file containing CREATE TABLE statements")
+		driverName = flag.String("driver", "", "database driver: sqlite3, postgres or mysql")
+		dsn        = flag.String("dsn", "", "database data source name")
+		pkg        = flag.String("package", "models", "name of the generated package")
+		output     = flag.String("o", "", "output file (default stdout)")
+		tableNames = flag.String("tables", "", "comma-separated list of tables (default all)")
+		singular   = flag.Bool("singular", true, "use singular table names for struct names")
+	)
+	flag.Parse()
+
+	if err := run(*schemaFile, *driverName, *dsn, *pkg, *output, *tableNames, *singular); err != nil {
+		fmt.Fprintln(os.Stderr, "sqlfgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaFile, driverName, dsn, pkg, output, tableNames string, singular bool) error {
+	var tables []*Table
+	var err error
+	switch {
+	case schemaFile != "":
+		var f *os.File
+		if f, err = os.Open(schemaFile); err != nil {
+			return err
+		}
+		defer f.Close()
+		tables, err = ParseDDL(f)
+	case driverName != "":
+		if driverName == "postgres" {
+			// the pgx driver is registered as "pgx"
+			driverName = "pgx"
+		}
+		var db *sql.DB
+		if db, err = sql.Open(driverName, dsn); err != nil {
+			return err
+		}
+		defer db.Close()
+		tables, err = LoadSchema(db, driverName)
+	default:
+		return fmt.Errorf("specify -schema or -driver")
+	}
+	if err != nil {
+		return err
+	}
+
+	if tableNames != "" {
+		want := make(map[string]bool)
+		for _, name := range strings.Split(tableNames, ",") {
+			want[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+		var filtered []*Table
+		for _, t := range tables {
+			if want[strings.ToLower(t.Name)] {
+				filtered = append(filtered, t)
+			}
+		}
+		tables = filtered
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no tables found")
+	}
+
+	src, err := Generate(Config{Package: pkg, Singular: singular}, tables)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
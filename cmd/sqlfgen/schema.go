@@ -0,0 +1,427 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// Table describes a database table.
+type Table struct {
+	Name       string
+	Columns    []*Column
+	PrimaryKey []string
+}
+
+// Column describes a column in a database table.
+type Column struct {
+	Name          string
+	Type          string // SQL type, eg "varchar(100)"
+	Nullable      bool
+	AutoIncrement bool
+	ReadOnly      bool // computed by the database
+}
+
+// column returns the named column, or nil if not found.
+func (t *Table) column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// isPrimaryKey reports whether the column is part of the primary key.
+func (t *Table) isPrimaryKey(c *Column) bool {
+	for _, name := range t.PrimaryKey {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+var typeLengthRE = regexp.MustCompile(`^(?:n?varchar|n?char|character varying|character|varbinary)\s*\(\s*(\d+)\s*\)`)
+
+// maxLen returns the maximum length of a character column,
+// or zero if the type does not specify a length.
+func (c *Column) maxLen() int {
+	m := typeLengthRE.FindStringSubmatch(strings.ToLower(c.Type))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// token is a significant token in a DDL statement.
+type token struct {
+	tok scan.Token
+	lit string
+}
+
+func (t token) is(keywords ...string) bool {
+	if t.tok != scan.IDENT {
+		return false
+	}
+	for _, kw := range keywords {
+		if strings.EqualFold(t.lit, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t token) isOp(op string) bool {
+	return t.tok == scan.OP && t.lit == op
+}
+
+// name returns the identifier, unquoted if necessary.
+func (t token) name() string {
+	return scan.Unquote(t.lit)
+}
+
+// tokenize splits the DDL into statements, each consisting of its
+// significant tokens. White space and comments are discarded.
+func tokenize(r io.Reader) [][]token {
+	var stmts [][]token
+	var stmt []token
+	var inComment bool
+	var prev token
+	scanner := scan.NewScanner(r)
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		if tok == scan.WS || tok == scan.COMMENT {
+			prev = token{}
+			continue
+		}
+		t := token{tok: tok, lit: lit}
+		// block comments, including MySQL "/*!40101 ... */" directives
+		if inComment {
+			if t.isOp("/") && prev.isOp("*") {
+				inComment = false
+			}
+			prev = t
+			continue
+		}
+		if t.isOp("*") && prev.isOp("/") {
+			inComment = true
+			stmt = stmt[:len(stmt)-1]
+			prev = token{}
+			continue
+		}
+		prev = t
+		if t.isOp(";") {
+			if len(stmt) > 0 {
+				stmts = append(stmts, stmt)
+			}
+			stmt = nil
+			continue
+		}
+		stmt = append(stmt, t)
+	}
+	if len(stmt) > 0 {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// ParseDDL reads the tables from a schema dump containing
+// CREATE TABLE statements. Primary keys added using
+// "ALTER TABLE ... ADD [CONSTRAINT name] PRIMARY KEY (...)", as
+// output by pg_dump, are also recognized. All other statements
+// are ignored.
+func ParseDDL(r io.Reader) ([]*Table, error) {
+	var tables []*Table
+	byName := make(map[string]*Table)
+	for _, stmt := range tokenize(r) {
+		p := &ddlParser{tokens: stmt}
+		switch {
+		case p.accept("create"):
+			p.accept("temp", "temporary")
+			if !p.accept("table") {
+				continue
+			}
+			if p.accept("if") {
+				p.accept("not")
+				p.accept("exists")
+			}
+			table, err := p.createTable()
+			if err != nil {
+				return nil, err
+			}
+			if table == nil {
+				continue
+			}
+			tables = append(tables, table)
+			byName[strings.ToLower(table.Name)] = table
+		case p.accept("alter"):
+			if !p.accept("table") {
+				continue
+			}
+			p.accept("only")
+			table := byName[strings.ToLower(p.qualifiedName())]
+			if table == nil || !p.accept("add") {
+				continue
+			}
+			if p.accept("constraint") {
+				p.next()
+			}
+			if p.accept("primary") && p.accept("key") {
+				table.PrimaryKey = p.nameList()
+			}
+		}
+	}
+	return tables, nil
+}
+
+type ddlParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *ddlParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{tok: scan.EOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *ddlParser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the keywords.
+func (p *ddlParser) accept(keywords ...string) bool {
+	if p.peek().is(keywords...) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// acceptOp consumes the next token if it is the operator.
+func (p *ddlParser) acceptOp(op string) bool {
+	if p.peek().isOp(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// qualifiedName reads a name such as "public.users",
+// returning the last part only.
+func (p *ddlParser) qualifiedName() string {
+	name := p.next().name()
+	for p.acceptOp(".") {
+		name = p.next().name()
+	}
+	return name
+}
+
+// nameList reads a list of names in parentheses.
+func (p *ddlParser) nameList() []string {
+	var names []string
+	if !p.acceptOp("(") {
+		return nil
+	}
+	for {
+		t := p.next()
+		if t.tok == scan.EOF || t.isOp(")") {
+			return names
+		}
+		if t.tok == scan.IDENT {
+			names = append(names, t.name())
+			// skip any length or sort order, eg "name(10) desc"
+			p.skipParens()
+			p.accept("asc", "desc")
+		}
+	}
+}
+
+// skipParens skips a parenthesized expression, if there is one.
+func (p *ddlParser) skipParens() {
+	if !p.peek().isOp("(") {
+		return
+	}
+	depth := 0
+	for {
+		t := p.next()
+		switch {
+		case t.tok == scan.EOF:
+			return
+		case t.isOp("("):
+			depth++
+		case t.isOp(")"):
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// elements splits the remaining tokens of a CREATE TABLE statement into
+// the column and constraint definitions within the outer parentheses.
+func (p *ddlParser) elements() [][]token {
+	var elems [][]token
+	var elem []token
+	depth := 0
+	for {
+		t := p.next()
+		if t.tok == scan.EOF {
+			break
+		}
+		switch {
+		case t.isOp("("):
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case t.isOp(")"):
+			depth--
+			if depth == 0 {
+				if len(elem) > 0 {
+					elems = append(elems, elem)
+				}
+				return elems
+			}
+		case t.isOp(",") && depth == 1:
+			elems = append(elems, elem)
+			elem = nil
+			continue
+		}
+		if depth > 0 {
+			elem = append(elem, t)
+		}
+	}
+	return elems
+}
+
+// columnKeywords are the keywords that end the type of a column definition.
+var columnKeywords = []string{
+	"not", "null", "primary", "default", "references", "unique", "check",
+	"auto_increment", "autoincrement", "generated", "collate", "constraint",
+	"comment", "identity", "on", "character", "charset",
+}
+
+func (p *ddlParser) createTable() (*Table, error) {
+	table := &Table{Name: p.qualifiedName()}
+	if table.Name == "" {
+		return nil, fmt.Errorf("create table: missing table name")
+	}
+	for _, elem := range p.elements() {
+		ep := &ddlParser{tokens: elem}
+		if ep.accept("constraint") {
+			ep.next()
+		}
+		switch {
+		case ep.accept("primary"):
+			ep.accept("key")
+			table.PrimaryKey = ep.nameList()
+			continue
+		case ep.peek().is("unique", "key", "index", "foreign", "check", "fulltext", "spatial", "exclude"):
+			continue
+		}
+		column, pk := ep.columnDef()
+		if column == nil {
+			continue
+		}
+		table.Columns = append(table.Columns, column)
+		if pk {
+			table.PrimaryKey = []string{column.Name}
+		}
+	}
+	if len(table.Columns) == 0 {
+		// eg "create table t as select ..."
+		return nil, nil
+	}
+	for _, name := range table.PrimaryKey {
+		if c := table.column(name); c != nil {
+			c.Nullable = false
+		}
+	}
+	return table, nil
+}
+
+// columnDef parses a column definition, returning the column and
+// whether the column is declared as the primary key.
+func (p *ddlParser) columnDef() (column *Column, pk bool) {
+	t := p.next()
+	if t.tok != scan.IDENT {
+		return nil, false
+	}
+	column = &Column{Name: t.name(), Nullable: true}
+
+	// The type continues until the first column constraint. The first
+	// token is always part of the type, so "character varying(10)" is a
+	// type, but in "varchar(10) character set utf8" the type ends before
+	// the character set.
+	var typ strings.Builder
+	for first := true; p.peek().tok != scan.EOF && (first || !p.peek().is(columnKeywords...)); first = false {
+		t := p.next()
+		switch {
+		case t.isOp("(") || t.isOp(")") || t.isOp(","):
+			typ.WriteString(t.lit)
+		default:
+			if s := typ.String(); s != "" && !strings.HasSuffix(s, "(") && !strings.HasSuffix(s, ",") {
+				typ.WriteByte(' ')
+			}
+			typ.WriteString(t.lit)
+		}
+	}
+	column.Type = typ.String()
+
+	lowerType := strings.ToLower(column.Type)
+	if strings.HasSuffix(lowerType, "serial") || strings.HasPrefix(lowerType, "serial") {
+		column.AutoIncrement = true
+	}
+
+	for p.peek().tok != scan.EOF {
+		t := p.next()
+		switch {
+		case t.is("not"):
+			if p.accept("null") {
+				column.Nullable = false
+			}
+		case t.is("primary"):
+			p.accept("key")
+			pk = true
+		case t.is("auto_increment", "autoincrement", "identity"):
+			column.AutoIncrement = true
+		case t.is("generated"):
+			// "generated always as identity" or "generated always as (expr)"
+			p.accept("always", "by")
+			p.accept("default")
+			p.accept("as")
+			if p.accept("identity") {
+				column.AutoIncrement = true
+			} else {
+				column.ReadOnly = true
+			}
+		case t.is("default"):
+			if p.peek().is("nextval") {
+				column.AutoIncrement = true
+			}
+		}
+	}
+
+	// In SQLite a column declared "integer primary key" is
+	// an alias for the rowid, and is assigned automatically.
+	if pk && lowerType == "integer" {
+		column.AutoIncrement = true
+	}
+	return column, pk
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDDL(t *testing.T) {
+	assert := assert.New(t)
+	ddl := `
+-- SQLite
+create table users(
+  id integer primary key,
+  email varchar(100) not null unique,
+  given_name text,
+  "Date_of_Birth" datetime
+);
+
+/* PostgreSQL, as output by pg_dump */
+CREATE TABLE public.order_lines (
+    order_id integer NOT NULL,
+    line_no integer NOT NULL,
+    description character varying(200) COLLATE pg_catalog."default",
+    amount numeric(10, 2) DEFAULT 0 NOT NULL,
+    total numeric GENERATED ALWAYS AS (amount * 2) STORED
+);
+
+ALTER TABLE ONLY public.order_lines
+    ADD CONSTRAINT order_lines_pkey PRIMARY KEY (order_id, line_no);
+
+/*!40101 SET NAMES utf8 */;
+CREATE TABLE IF NOT EXISTS ` + "`categories`" + ` (
+  ` + "`id`" + ` int(11) NOT NULL AUTO_INCREMENT,
+  ` + "`name`" + ` varchar(50) CHARACTER SET utf8 DEFAULT NULL,
+  PRIMARY KEY (` + "`id`" + `),
+  KEY ` + "`name_idx`" + ` (` + "`name`" + `)
+) ENGINE=InnoDB;
+
+create table report as select * from users;
+`
+	tables, err := ParseDDL(strings.NewReader(ddl))
+	if !assert.NoError(err) || !assert.Len(tables, 3) {
+		return
+	}
+
+	users := tables[0]
+	assert.Equal("users", users.Name)
+	assert.Equal([]string{"id"}, users.PrimaryKey)
+	if assert.Len(users.Columns, 4) {
+		assert.Equal(&Column{Name: "id", Type: "integer", AutoIncrement: true}, users.Columns[0])
+		assert.Equal(&Column{Name: "email", Type: "varchar(100)"}, users.Columns[1])
+		assert.Equal(100, users.Columns[1].maxLen())
+		assert.Equal(&Column{Name: "given_name", Type: "text", Nullable: true}, users.Columns[2])
+		assert.Equal(&Column{Name: "Date_of_Birth", Type: "datetime", Nullable: true}, users.Columns[3])
+	}
+
+	lines := tables[1]
+	assert.Equal("order_lines", lines.Name)
+	assert.Equal([]string{"order_id", "line_no"}, lines.PrimaryKey)
+	if assert.Len(lines.Columns, 5) {
+		assert.Equal(&Column{Name: "description", Type: "character varying(200)", Nullable: true}, lines.Columns[2])
+		assert.Equal(200, lines.Columns[2].maxLen())
+		assert.Equal(&Column{Name: "amount", Type: "numeric(10,2)"}, lines.Columns[3])
+		assert.Equal(&Column{Name: "total", Type: "numeric", Nullable: true, ReadOnly: true}, lines.Columns[4])
+	}
+
+	categories := tables[2]
+	assert.Equal("categories", categories.Name)
+	assert.Equal([]string{"id"}, categories.PrimaryKey)
+	if assert.Len(categories.Columns, 2) {
+		assert.Equal(&Column{Name: "id", Type: "int(11)", AutoIncrement: true}, categories.Columns[0])
+		assert.Equal(&Column{Name: "name", Type: "varchar(50)", Nullable: true}, categories.Columns[1])
+	}
+}
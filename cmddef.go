@@ -0,0 +1,288 @@
+package sqlf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// CommandDef is the definition of a command: its SQL, dialect, and a
+// description of each placeholder. It is created using DefineCommand, and
+// can be encoded as JSON (or any other encoding of its exported fields)
+// and sent to another process, which rebuilds the command using
+// CommandDef.ExecCommand or CommandDef.QueryCommand. This allows a fleet
+// of workers to execute commands that are defined centrally:
+//
+//	// in the service that defines the commands
+//	def, err := sqlf.DefineCommand(getOrdersByCustomer)
+//	data, err := json.Marshal(def)
+//
+//	// in the worker
+//	var def sqlf.CommandDef
+//	err := json.Unmarshal(data, &def)
+//	query, err := def.QueryCommand()
+//	rows, err := query.QueryStruct(db, map[string]interface{}{"customer_id": 1})
+//
+// The worker does not need the row structs of the tables: the args are
+// passed in order, or in a map keyed by column name using ExecStruct or
+// QueryStruct. Because the worker does not have the field tags, the values
+// of the args are passed to the database driver as supplied, without the
+// codecs of the columns, and the rows are scanned in the usual way for a
+// struct without table information.
+type CommandDef struct {
+	Name    string     `json:"name,omitempty"`  // see WithName
+	Query   bool       `json:"query,omitempty"` // the command returns rows
+	Dialect string     `json:"dialect"`         // name of the dialect, eg "postgres"
+	SQL     string     `json:"sql"`
+	Inputs  []InputDef `json:"inputs,omitempty"`  // one for each placeholder
+	Columns []InputDef `json:"columns,omitempty"` // selected columns of a query
+}
+
+// InputDef describes a placeholder of a command in a CommandDef.
+// An input with none of its fields set is an arg passed in order.
+type InputDef struct {
+	Column string      `json:"column,omitempty"` // column whose value is the arg
+	Field  string      `json:"field,omitempty"`  // field of the column
+	Tenant bool        `json:"tenant,omitempty"` // bound to the tenant, see TenantScope
+	Fixed  bool        `json:"fixed,omitempty"`  // the arg is Value
+	Value  interface{} `json:"value,omitempty"`
+	Like   string      `json:"like,omitempty"` // "prefix", "suffix" or "contains", see Placeholder.LikePrefix
+}
+
+// likeOps are the values of InputDef.Like.
+var likeOps = map[placeholderOp]string{
+	placeholderLikePrefix:   "prefix",
+	placeholderLikeSuffix:   "suffix",
+	placeholderLikeContains: "contains",
+}
+
+// DefineCommand returns the definition of a command built using Execf,
+// Queryf, InsertRowf or UpdateRowf. A command built using InsertRowf or
+// UpdateRowf is defined as a command whose args are the values of its
+// columns. The options of the command are not included, except for its
+// name, and the fixed args of any fragments must be strings, numbers,
+// booleans or nil. DefineCommand returns an error if the command cannot
+// be defined, or has an error.
+func DefineCommand(cmd Commander) (*CommandDef, error) {
+	var def CommandDef
+	var inputs []positioner
+	var d Dialect
+	var opts options
+	switch c := cmd.(type) {
+	case execCommand:
+		if c.err != nil {
+			return nil, c.err
+		}
+		inputs, d, opts = c.inputs, c.dialect, c.opts
+	case *queryCommand:
+		if c.opts.binding != nil && c.opts.binding.err != nil {
+			return nil, c.opts.binding.err
+		}
+		inputs, d, opts = c.inputs, c.dialect, c.opts
+		def.Query = true
+		for _, ci := range c.columns {
+			def.Columns = append(def.Columns, InputDef{Column: ci.outputName(), Field: ci.fieldName})
+		}
+	case insertRowCommand:
+		if c.err != nil {
+			return nil, c.err
+		}
+		inputs, d, opts = c.rowInputs(), c.table.Dialect(), c.opts
+	case updateRowCommand:
+		if c.err != nil {
+			return nil, c.err
+		}
+		inputs, d, opts = c.rowInputs(), c.table.Dialect(), c.opts
+	default:
+		return nil, fmt.Errorf("DefineCommand: cannot define %T", cmd)
+	}
+	def.Name = opts.name
+	def.Dialect = DialectName(commandDialect(d))
+	def.SQL = cmd.Command()
+
+	for i, input := range inputs {
+		var id InputDef
+		if value, ok := fixedInput(input); ok {
+			switch value.(type) {
+			case nil, string, bool, int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64:
+			default:
+				return nil, fmt.Errorf("DefineCommand: cannot define arg %d of type %T", i+1, value)
+			}
+			id.Fixed, id.Value = true, value
+		} else if isTenantInput(input) {
+			id.Tenant = true
+		} else if ci, ok := input.(*columnInfo); ok {
+			id.Column, id.Field = ci.columnName, ci.fieldName
+		} else if ph, ok := input.(*Placeholder); ok {
+			id.Like = likeOps[ph.op]
+		}
+		def.Inputs = append(def.Inputs, id)
+	}
+	return &def, nil
+}
+
+// rowInputs returns the inputs of a row command, including its fixed args.
+func (cmd execRowCommand) rowInputs() []positioner {
+	var inputs []positioner
+	for _, ra := range cmd.rowArgs() {
+		if ra.ci == nil {
+			inputs = append(inputs, &fixedArg{value: ra.value})
+		} else {
+			inputs = append(inputs, ra.ci)
+		}
+	}
+	return inputs
+}
+
+// ExecCommand returns the command defined by def, built using the options,
+// which can include options that are not part of the definition, such as
+// WithTimeout.
+func (def *CommandDef) ExecCommand(opts ...Option) (ExecCommand, error) {
+	o := newOptions(opts)
+	d, inputs, err := def.build(&o)
+	if err != nil {
+		return nil, err
+	}
+	cmd := execCommand{
+		command: def.SQL,
+		dialect: d,
+		inputs:  inputs,
+		rebind:  &rebindCache{},
+		opts:    o,
+	}
+	cmd.opts.tenant = newTenantBinding(cmd.inputs)
+	cmd.opts.binding = newArgBinding(cmd.inputs)
+	cmd.opts.setArgLabels(cmd.inputs)
+	return cmd, nil
+}
+
+// QueryCommand returns the query defined by def, in the same way
+// as ExecCommand. The query has no slots, and its placeholders are
+// not renumbered if it is embedded in another command.
+func (def *CommandDef) QueryCommand(opts ...Option) (QueryCommand, error) {
+	o := newOptions(opts)
+	d, inputs, err := def.build(&o)
+	if err != nil {
+		return nil, err
+	}
+	if o.mapper == nil && len(def.Columns) > 0 {
+		o.mapper = def.mapper()
+	}
+	cmd := &queryCommand{
+		command: def.SQL,
+		format:  strings.Replace(def.SQL, "%", "%%", -1),
+		dialect: d,
+		inputs:  inputs,
+		opts:    o,
+	}
+	cmd.opts.tenant = newTenantBinding(cmd.inputs)
+	cmd.opts.binding = newArgBinding(cmd.inputs)
+	cmd.opts.setArgLabels(cmd.inputs)
+	return cmd, nil
+}
+
+// build returns the dialect and inputs of the command, and sets its name.
+func (def *CommandDef) build(o *options) (Dialect, []positioner, error) {
+	d := dialectForDriver(def.Dialect)
+	if d == nil {
+		return nil, nil, fmt.Errorf("CommandDef: unknown dialect %q", def.Dialect)
+	}
+	if o.name == "" {
+		o.name = def.Name
+	}
+	if o.dialect == nil {
+		o.dialect = d
+	}
+
+	// the columns belong to a table without a row type,
+	// so that the args are obtained from any struct or map
+	table := &TableInfo{rowType: reflect.TypeOf(struct{}{})}
+	table.settings.Dialect = d
+	var inputs []positioner
+	for i, id := range def.Inputs {
+		var input positioner
+		switch {
+		case id.Fixed:
+			value := id.Value
+			if n, ok := value.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					value = i
+				} else if f, err := n.Float64(); err == nil {
+					value = f
+				}
+			} else if f, ok := value.(float64); ok && f == float64(int64(f)) {
+				// JSON numbers are decoded as float64
+				value = int64(f)
+			}
+			input = &fixedArg{value: value}
+		case id.Tenant:
+			input = &tenantInput{}
+		case id.Column != "":
+			field := id.Field
+			if field == "" {
+				field = id.Column
+			}
+			input = &columnInfo{table: table, columnName: id.Column, fieldName: field}
+		case id.Like != "":
+			ph := &Placeholder{}
+			for op, like := range likeOps {
+				if like == id.Like {
+					ph.op = op
+				}
+			}
+			if ph.op == placeholderValue {
+				return nil, nil, fmt.Errorf("CommandDef: input %d: unknown like %q", i+1, id.Like)
+			}
+			input = ph
+		default:
+			input = &Placeholder{}
+		}
+		input.setPosition(i + 1)
+		inputs = append(inputs, input)
+	}
+	return d, inputs, nil
+}
+
+// mapper returns the mapper for the selected columns of a query, which
+// maps each field to its column in the same way as the query's own mapper.
+func (def *CommandDef) mapper() *reflectx.Mapper {
+	m := make(map[string]string)
+	dups := make(map[string]bool)
+	for _, column := range def.Columns {
+		if _, ok := m[column.Field]; ok {
+			dups[column.Field] = true
+			continue
+		}
+		m[column.Field] = column.Column
+	}
+	for name := range dups {
+		delete(m, name)
+	}
+	return reflectx.NewMapperFunc("", func(name string) string {
+		if column, ok := m[name]; ok {
+			return column
+		}
+		return name
+	})
+}
+
+// fixedArg is an input of a command whose arg is a fixed value,
+// see CommandDef.
+type fixedArg struct {
+	value interface{}
+}
+
+func (fa *fixedArg) setPosition(n int) {}
+
+// newOptions returns the options specified by opts.
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.setCaller()
+	return o
+}
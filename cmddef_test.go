@@ -0,0 +1,104 @@
+package sqlf_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandDef(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("create table tasks(id integer primary key, tenant_id integer, title text, status text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	type Task struct {
+		ID       int64 `sql:"primary key"`
+		TenantID int64 `sql:"tenant"`
+		Title    string
+		Status   string
+	}
+	tbl := sqlf.Table("tasks", Task{}).WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	done := sqlf.Execf("update %s set %s where %s",
+		tbl.Update.TableName, sqlf.Set("status", "?", "done"), tbl.Update.WhereColumns,
+		sqlf.WithName("tasks.done"))
+	search := sqlf.Queryf("select %s from %s where title %s order by %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder().LikePrefix(), tbl.Select.OrderBy)
+
+	// the definitions are sent to the worker as JSON
+	transfer := func(cmd sqlf.Commander) *sqlf.CommandDef {
+		def, err := sqlf.DefineCommand(cmd)
+		if !assert.NoError(err) {
+			t.FailNow()
+		}
+		data, err := json.Marshal(def)
+		assert.NoError(err)
+		var decoded sqlf.CommandDef
+		assert.NoError(json.Unmarshal(data, &decoded))
+		return &decoded
+	}
+
+	insDef := transfer(ins)
+	assert.Equal("sqlite3", insDef.Dialect)
+	assert.Equal([]sqlf.InputDef{
+		{Column: "id", Field: "ID"},
+		{Tenant: true},
+		{Column: "title", Field: "Title"},
+		{Column: "status", Field: "Status"},
+	}, insDef.Inputs)
+	doneDef := transfer(done)
+	assert.Equal(sqlf.CommandDef{
+		Name:    "tasks.done",
+		Dialect: "sqlite3",
+		SQL:     "update `tasks` set `status`=? where `id`=? and `tenant_id`=?",
+		Inputs: []sqlf.InputDef{
+			{Fixed: true, Value: "done"},
+			{Column: "id", Field: "ID"},
+			{Tenant: true},
+		},
+	}, *doneDef)
+	searchDef := transfer(search)
+	assert.True(searchDef.Query)
+	assert.Equal("prefix", searchDef.Inputs[len(searchDef.Inputs)-1].Like)
+
+	// the worker rebuilds the commands without the row struct
+	tenantDB := sqlf.ForTenant(sqlf.ContextWithTenant(context.Background(), int64(7)), db)
+	insert, err := insDef.ExecCommand()
+	assert.NoError(err)
+	_, err = insert.ExecStruct(tenantDB, map[string]interface{}{"id": 1, "title": "write tests", "status": "open"})
+	assert.NoError(err)
+	_, err = insert.Exec(tenantDB, 2, "fix bugs", "open")
+	assert.NoError(err)
+
+	markDone, err := doneDef.ExecCommand()
+	assert.NoError(err)
+	assert.Equal(done.Command(), markDone.Command())
+	result, err := markDone.ExecStruct(tenantDB, struct{ ID int64 }{ID: 1})
+	assert.NoError(err)
+	n, _ := result.RowsAffected()
+	assert.Equal(int64(1), n)
+
+	query, err := searchDef.QueryCommand()
+	assert.NoError(err)
+	var tasks []Task
+	assert.NoError(query.Select(tenantDB, &tasks, "write"))
+	assert.Equal([]Task{{ID: 1, TenantID: 7, Title: "write tests", Status: "done"}}, tasks)
+
+	// rebuilt queries still require the tenant
+	assert.Error(query.Select(db, &tasks, "write"))
+
+	_, err = sqlf.DefineCommand(sqlf.Execf("delete from t where %s", sqlf.Set("x", "?", []int{1})))
+	assert.Error(err)
+	_, err = (&sqlf.CommandDef{Dialect: "oracle"}).ExecCommand()
+	assert.Error(err)
+}
@@ -0,0 +1,93 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec transforms column values as they are written to, and read
+// from, the database. Uses include field-level encryption, compression
+// of large values and unit conversion.
+//
+// A codec is registered with a name using RegisterCodec, and is attached
+// to a column using the "codec" field tag. For example:
+//
+//	type Customer struct {
+//		ID    int64
+//		TaxID string `sql:"codec:encrypt"`
+//	}
+//
+// Codecs are applied to the arguments of row commands, and when scanning
+// rows using QueryCommand.Select and Row.StructScan. See BoolInt and
+// EnumCodec for the codecs provided by this package.
+type Codec interface {
+	// Encode converts the field value into the value stored
+	// in the database.
+	Encode(value interface{}) (driver.Value, error)
+
+	// Decode converts the value read from the database into a value
+	// that can be assigned to the field. The database value is one of
+	// the types returned by database drivers, including nil.
+	Decode(dbValue interface{}) (interface{}, error)
+}
+
+var codecs = struct {
+	mutex sync.RWMutex
+	m     map[string]Codec
+}{m: map[string]Codec{"boolint": BoolInt}}
+
+// RegisterCodec registers a codec with the specified name, so that it
+// can be referred to in field tags. Codecs should be registered before
+// any tables that refer to them are created, typically during program
+// initialization.
+func RegisterCodec(name string, codec Codec) {
+	codecs.mutex.Lock()
+	defer codecs.mutex.Unlock()
+	codecs.m[name] = codec
+}
+
+// lookupCodec returns the codec registered with the name.
+// It panics if no codec is registered, in the same way that Table
+// panics for an invalid row type.
+func lookupCodec(field reflect.StructField, name string) Codec {
+	codecs.mutex.RLock()
+	defer codecs.mutex.RUnlock()
+	codec, ok := codecs.m[name]
+	if !ok {
+		panic(fmt.Sprintf("sqlf.Table: unknown codec for field %s: %q", field.Name, name))
+	}
+	return codec
+}
+
+// codecScanner implements sql.Scanner for scanning a column value
+// into a field via its codec.
+type codecScanner struct {
+	ctx    context.Context
+	codec  Codec
+	column *columnInfo
+	field  reflect.Value
+}
+
+func (cs *codecScanner) Scan(src interface{}) error {
+	value, err := decode(cs.ctx, cs.codec, src)
+	if err != nil {
+		return fmt.Errorf("cannot decode %s: %v", cs.column.columnName, err)
+	}
+	if value == nil {
+		cs.field.Set(reflect.Zero(cs.field.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(cs.field.Type()):
+		cs.field.Set(v)
+	case v.Type().ConvertibleTo(cs.field.Type()):
+		cs.field.Set(v.Convert(cs.field.Type()))
+	default:
+		return fmt.Errorf("cannot decode %s: cannot assign %s to %s", cs.column.columnName, v.Type(), cs.field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package sqlf
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// reverseCodec is a trivial codec for testing: it stores strings reversed
+type reverseCodec struct{}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func (reverseCodec) Encode(value interface{}) (driver.Value, error) {
+	return reverse(value.(string)), nil
+}
+
+func (reverseCodec) Decode(dbValue interface{}) (interface{}, error) {
+	switch v := dbValue.(type) {
+	case []byte:
+		return reverse(string(v)), nil
+	case string:
+		return reverse(v), nil
+	}
+	return dbValue, nil
+}
+
+func TestCodec(t *testing.T) {
+	assert := assert.New(t)
+	RegisterCodec("reverse", reverseCodec{})
+
+	type Row struct {
+		ID         int `sql:"primary_key;auto_increment"`
+		GivenName  string
+		FamilyName string `sql:"codec:reverse"`
+	}
+
+	db := createDatabase(t, "")
+	tbl := Table("users", Row{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	args, err := ins.Args(Row{GivenName: "John", FamilyName: "Citizen"})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"John", "nezitiC"}, args)
+	assert.NoError(ins.Exec(db, &Row{GivenName: "John", FamilyName: "Citizen"}))
+
+	var stored string
+	assert.NoError(db.Get(&stored, "select family_name from users"))
+	assert.Equal("nezitiC", stored)
+
+	sel := Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName)
+	var rows []Row
+	assert.NoError(sel.Select(db, &rows))
+	if assert.Equal(1, len(rows)) {
+		assert.Equal("Citizen", rows[0].FamilyName)
+	}
+	var row Row
+	assert.NoError(sel.QueryRow(db).StructScan(&row))
+	assert.Equal("Citizen", row.FamilyName)
+
+	assert.Panics(func() {
+		type Bad struct {
+			ID   int
+			Name string `sql:"codec:unknown"`
+		}
+		Table("bad", Bad{})
+	})
+}
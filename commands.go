@@ -1,15 +1,22 @@
 package sqlf
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/reflectx"
 )
 
+var errTableNotSpecified = errors.New("table not specified")
+
 // InsertRowCommand contains all the information required to insert
 // a single row into a database table based on the contents of a Go struct..
 type InsertRowCommand interface {
@@ -20,11 +27,83 @@ type InsertRowCommand interface {
 	// based on the contents of the row.
 	Args(row interface{}) ([]interface{}, error)
 
+	// BindArgs returns the args for executing the command for the row using
+	// a database interface other than database/sql, such as pgx (see package
+	// sqlfpgx), in the same way as ExecCommand.BindArgs. The field values
+	// are encoded using ctx.
+	BindArgs(ctx context.Context, row interface{}) ([]interface{}, error)
+
+	// Validate checks the contents of the row against any constraints
+	// declared in the field tags (eg "not null", "maxlen:n", "enum:a,b,c").
+	// If validation fails, the error returned is a ValidationErrors.
+	Validate(row interface{}) error
+
 	// Exec executes the SQL insert statement with the arguments
 	// appropriate for the contents of the row. If the row has
 	// an auto-increment column, it will be populated with the value
 	// generated by the database server.
 	Exec(db sqlx.Execer, row interface{}) error
+
+	// ExecResult is identical to Exec, except that it also returns
+	// the result of the SQL insert statement. This is useful for statements
+	// where the number of rows affected can vary, such as "insert ignore"
+	// and upsert statements.
+	ExecResult(db sqlx.Execer, row interface{}) (sql.Result, error)
+
+	// ExecInserted is identical to Exec, except that it also reports whether
+	// the row was inserted. It is intended for commands built using the
+	// WithIgnoreDuplicates option, which skip a row that would violate a
+	// unique constraint instead of returning an error, and for tables with
+	// an idempotency key. When the row is skipped, the auto-increment column
+	// is not populated.
+	//
+	// A column is tagged as the idempotency key of its table using
+	// `sql:"idempotency"`, for example the ID of an event delivered by a
+	// webhook. A command that inserts the key skips a row whose key already
+	// exists, so that an event processed more than once inserts one row.
+	// The key column must have a unique constraint. The insert statement is
+	// modified to suit the dialect of the table: "on conflict(key) do nothing"
+	// for PostgreSQL and SQLite, and "insert ignore" for MySQL, which also
+	// skips a row that violates any other unique constraint. For other
+	// dialects, the command checks for a row with the key before inserting
+	// the row. The key is ignored by commands built using WithUpsert or
+	// WithIgnoreDuplicates.
+	ExecInserted(db sqlx.Execer, row interface{}) (inserted bool, err error)
+
+	// Bind returns the command bound to db, so that
+	// it can be executed without the handle.
+	Bind(db sqlx.Execer) BoundInsertRow
+
+	// NamedCommand returns the SQL insert statement with a named parameter
+	// for each column (eg ":email"), in the format used by sqlx.NamedExec
+	// and sqlx.PrepareNamed. This allows code that uses sqlx named
+	// statements, with structs or maps, to use the statement built by the
+	// command while it is migrated to sqlf. Any other colons in the
+	// statement are escaped, as sqlx expects.
+	NamedCommand() (string, error)
+
+	// ExecMaps executes the SQL insert statement for each of the rows,
+	// which are maps from column name to value, and returns the number
+	// of rows inserted. Each row must have a value for every column
+	// inserted by the command. Values are encoded in the same way as the
+	// fields of a row struct, eg using the column's codec. If db is a
+	// *sqlx.DB, the rows are inserted in a transaction, and if an error
+	// occurs, none of the rows are inserted.
+	ExecMaps(db sqlx.Execer, rows []map[string]interface{}) (rowCount int64, err error)
+
+	// Interpolate returns the SQL insert statement with the placeholders
+	// replaced by the argument values for the row, formatted as SQL literals.
+	// It is intended for debugging and logging only: the result should
+	// never be executed.
+	Interpolate(row interface{}) (string, error)
+
+	// Verify checks that the placeholders in the SQL statement match the
+	// columns and args that supply their values, and returns an error
+	// describing the first mismatch found. Verify does not access the
+	// database, so it can be called from a unit test for each command.
+	// When built with the "sqlfverify" tag, every command is verified
+	// as it is built, and a command that fails verification panics.
+	Verify() error
 }
 
 // UpdateRowCommand contains all the information required to update
@@ -38,11 +117,40 @@ type UpdateRowCommand interface {
 	// based on the contents of the row.
 	Args(row interface{}) ([]interface{}, error)
 
+	// BindArgs returns the args for executing the command for the row using
+	// a database interface other than database/sql, such as pgx (see package
+	// sqlfpgx), in the same way as ExecCommand.BindArgs. The field values
+	// are encoded using ctx.
+	BindArgs(ctx context.Context, row interface{}) ([]interface{}, error)
+
+	// Validate checks the contents of the row against any constraints
+	// declared in the field tags (eg "not null", "maxlen:n", "enum:a,b,c").
+	// If validation fails, the error returned is a ValidationErrors.
+	Validate(row interface{}) error
+
 	// Exec executes the SQL update/delete statement with the arguments
 	// appropriate for the contents of the row. Returns the number
 	// of rows updated, which should be zero or one. The contents of the
 	// row struct are unchanged.
 	Exec(db sqlx.Execer, row interface{}) (rowCount int, err error)
+
+	// Bind returns the command bound to db, so that
+	// it can be executed without the handle.
+	Bind(db sqlx.Execer) BoundUpdateRow
+
+	// Interpolate returns the SQL update/delete statement with the placeholders
+	// replaced by the argument values for the row, formatted as SQL literals.
+	// It is intended for debugging and logging only: the result should
+	// never be executed.
+	Interpolate(row interface{}) (string, error)
+
+	// Verify checks that the placeholders in the SQL statement match the
+	// columns and args that supply their values, and returns an error
+	// describing the first mismatch found. Verify does not access the
+	// database, so it can be called from a unit test for each command.
+	// When built with the "sqlfverify" tag, every command is verified
+	// as it is built, and a command that fails verification panics.
+	Verify() error
 }
 
 // ExecCommand contains all the information required to perform an
@@ -55,6 +163,54 @@ type ExecCommand interface {
 
 	// Exec executes the SQL statement with the arguments given.
 	Exec(db sqlx.Execer, args ...interface{}) (sql.Result, error)
+
+	// ExecStruct executes the SQL statement with the arguments obtained from
+	// arg, which is a struct, a pointer to a struct, or a map with string keys.
+	// There is one argument for each input column in the command (for example
+	// Update.WhereColumns). For the row type of the column's table, the value
+	// is the column's field. For other struct types the value is the field with
+	// the same name, and for a map the value is keyed by the column name (or
+	// failing that, the field name). Placeholders that are not associated with
+	// a column (for example Update.Placeholder) cannot be used with ExecStruct.
+	ExecStruct(db sqlx.Execer, arg interface{}) (sql.Result, error)
+
+	// ExecBatch executes the command once for each of the argument sets,
+	// and returns the total number of rows affected, or -1 if not known.
+	// If db can begin a transaction (eg *sqlx.DB), the statements are
+	// executed in a transaction, which is rolled back if any statement fails.
+	// Otherwise rowCount is the number of rows affected by the statements
+	// executed before the failure. See WithProgress for reporting progress
+	// and interrupting the batch. The statement is prepared once and
+	// executed for each argument set, unless the command was built using
+	// WithMultiStatements. See the sqlfpgx package for batching using the
+	// pgx batch API.
+	ExecBatch(db sqlx.Execer, argSets [][]interface{}) (rowCount int64, err error)
+
+	// Bind returns the command bound to db, so that
+	// it can be executed without the handle.
+	Bind(db sqlx.Execer) BoundExec
+
+	// Interpolate returns the SQL statement with the placeholders replaced
+	// by the arguments given, formatted as SQL literals. It is intended for
+	// debugging and logging only: the result should never be executed.
+	Interpolate(args ...interface{}) (string, error)
+
+	// BindArgs returns the args for executing the command using a database
+	// interface other than database/sql, such as pgx (see package sqlfpgx).
+	// The args supplied by the command, such as the args of a Sqlizer, are
+	// inserted, and the args of placeholders such as Placeholder.LikePrefix
+	// are converted, in the same way as when the command is executed. The
+	// tenant stored in ctx (see ContextWithTenant) is bound in the same way
+	// as for a TenantScope, and BindArgs returns ErrNoTenant if the command
+	// requires a tenant and ctx has none. It also returns any error from
+	// building the command.
+	BindArgs(ctx context.Context, args ...interface{}) ([]interface{}, error)
+
+	// Verify checks that the placeholders in the SQL statement match the
+	// args that supply their values, including a format string with more
+	// or fewer "%s" verbs than args, and returns an error describing the
+	// first mismatch found. Verify does not access the database.
+	Verify() error
 }
 
 // QueryCommand contains all the information required to perform an
@@ -65,42 +221,206 @@ type QueryCommand interface {
 	// Command returns the SQL select statement with placeholders for arguments..
 	Command() string
 
+	// Columns returns a description of each column selected using the
+	// Select.Columns of a table, in the order selected. It is intended for
+	// building user interfaces, such as data grids, for any query. Columns
+	// selected using SQL text in the format string are not included.
+	Columns() []ResultColumn
+
 	// Query executes the query with the arguments given.
-	Query(db sqlx.Queryer, args ...interface{}) (*sqlx.Rows, error)
+	// The rows must be closed once they have been read, see QueryRows.
+	Query(db sqlx.Queryer, args ...interface{}) (*QueryRows, error)
+
+	// QueryStruct executes the query with the arguments obtained from arg,
+	// in the same way as ExecCommand.ExecStruct.
+	QueryStruct(db sqlx.Queryer, arg interface{}) (*QueryRows, error)
+
+	// Interpolate returns the SQL select statement with the placeholders
+	// replaced by the arguments given, formatted as SQL literals. It is intended
+	// for debugging and logging only: the result should never be executed.
+	Interpolate(args ...interface{}) (string, error)
+
+	// BindArgs returns the args for executing the command using a database
+	// interface other than database/sql, such as pgx (see package sqlfpgx).
+	// The args supplied by the command, such as the args of a Sqlizer, are
+	// inserted, and the args of placeholders such as Placeholder.LikePrefix
+	// are converted, in the same way as when the command is executed. The
+	// tenant stored in ctx (see ContextWithTenant) is bound in the same way
+	// as for a TenantScope, and BindArgs returns ErrNoTenant if the command
+	// requires a tenant and ctx has none. It also returns any error from
+	// building the command.
+	BindArgs(ctx context.Context, args ...interface{}) ([]interface{}, error)
+
+	// Verify checks the placeholders in the SQL select statement,
+	// in the same way as ExecCommand.Verify.
+	Verify() error
+
+	// With returns a copy of the query with the values of its slots,
+	// created using Slot, replaced by the values in args, which are keyed
+	// by slot name. It panics if a key is not the name of a slot.
+	With(args map[string]interface{}) QueryCommand
 
 	// QueryRow executes the query, which is expected to return at most one row.
 	// QueryRow always returns a non-nil value. Errors are deferred until the Scan
 	// method is called on the Row.
-	QueryRow(db sqlx.Queryer, args ...interface{}) *sqlx.Row
+	QueryRow(db sqlx.Queryer, args ...interface{}) *Row
 
 	// Select executes a query using the provided Queryer, and StructScans each
 	// row into dest, which must be a slice. If the slice elements are scannable,
 	// then the result set must have only one column. Otherwise StructScan is
 	// used. The *sql.Rows are closed automatically.
+	//
+	// When the query selects columns from more than one table, the slice
+	// element can be a struct with one field for each table. Columns are
+	// scanned into the field whose type matches the table's row type, so
+	// tables with identically named fields (eg "ID") can be selected together.
 	Select(db sqlx.Queryer, dest interface{}, args ...interface{}) error
+
+	// SelectRows scans all of the rows into dest in the same way as Select.
+	// It is used with rows obtained from a database interface other than
+	// sqlx.Queryer (eg pgx). The rows are not closed.
+	SelectRows(rows Rows, dest interface{}) error
+
+	// SelectParallel selects the rows into dest in the same way as Select,
+	// except that the rows are selected by more than one query running
+	// concurrently, which can reduce the time taken to read a large table.
+	// The shard column is an integer column in the result set, typically the
+	// primary key. The range of its values is divided into the number of
+	// shards, and the rows for each shard are selected using a separate query.
+	// At most GOMAXPROCS queries run at the same time. The rows in dest are
+	// in order of the shards, and in the order returned by the query within
+	// each shard.
+	//
+	// Each query is executed using db, so db should be a connection pool
+	// (eg *sqlx.DB), and not a transaction.
+	SelectParallel(db sqlx.Queryer, dest interface{}, shards int, shardColumn string, args ...interface{}) error
+
+	// Get executes the query, which is expected to return exactly one row,
+	// and scans the row into dest, which is a pointer to a struct or to a
+	// scannable value. Columns are matched with struct fields in the same way
+	// as Select. If the query returns no rows, Get returns sql.ErrNoRows. If
+	// the query returns more than one row, Get returns ErrTooManyRows, and
+	// dest contains the first row.
+	Get(db sqlx.Queryer, dest interface{}, args ...interface{}) error
+
+	// ExportCSV executes the query and writes the rows to w in CSV format,
+	// with a header record containing the column names. Values are written
+	// without scanning into structs, so any query can be exported. NULL
+	// values are written as empty fields, and times use RFC 3339 format.
+	ExportCSV(db sqlx.Queryer, w io.Writer, args ...interface{}) error
+
+	// ExportNDJSON executes the query and writes the rows to w as
+	// newline-delimited JSON, one object per row, with a property
+	// for each column in the order selected.
+	ExportNDJSON(db sqlx.Queryer, w io.Writer, args ...interface{}) error
+
+	// WriteJSON executes the query and writes the rows to w as a JSON
+	// array of objects, with a property for each column in the order
+	// selected. The rows are written as they are read, so the result set
+	// is not held in memory, which suits API endpoints that return the
+	// result of a query, where w is the http.ResponseWriter. If the query
+	// returns no rows, an empty array is written.
+	WriteJSON(db sqlx.Queryer, w io.Writer, args ...interface{}) error
+
+	// Bind returns the command bound to db, so that
+	// it can be executed without the handle.
+	Bind(db sqlx.Queryer) BoundQuery
+
+	// Cursor executes the query using a server-side cursor, which fetches
+	// the rows from the database server in batches of batchSize rows. This
+	// makes it possible to iterate through very large result sets without
+	// the server or the client holding the entire result in memory.
+	// Server-side cursors are only supported for PostgreSQL.
+	//
+	// A cursor must be declared inside a transaction. If db is a *sqlx.Tx,
+	// the cursor uses that transaction. Otherwise a transaction is started,
+	// and it is committed when the cursor is closed.
+	Cursor(db sqlx.Ext, batchSize int, args ...interface{}) (*Cursor, error)
 }
 
 // cloneArgs takes a deep copy of all arguments so that they can be
-// modified before preparing the SQL statement.
-func cloneArgs(args []interface{}) []interface{} {
+// modified before preparing the SQL statement. The cloned tables use
+// the dialect and table name specified in opts, if any, ignore their
+// tenant column if opts specifies WithoutTenant, and do not select their
+// sensitive columns if opts specifies WithMasking.
+func cloneArgs(args []interface{}, opts options) []interface{} {
+	var rename map[string]string
+	if opts.tableName != "" {
+		if ti := firstTable(args); ti != nil {
+			rename = map[string]string{ti.Name: opts.tableName}
+		}
+	}
+	return cloneArgsFor(args, rename, opts)
+}
+
+// cloneArgsFor takes a deep copy of all arguments. If opts specifies a
+// dialect, the cloned tables use it. Tables whose name is a key of rename
+// are renamed to the corresponding value.
+func cloneArgsFor(args []interface{}, rename map[string]string, opts options) []interface{} {
+	d := opts.dialect
 	args2 := make([]interface{}, len(args))
 	tableClones := map[*TableInfo]*TableInfo{}
 	tableClone := func(ti *TableInfo) *TableInfo {
 		ti2 := tableClones[ti]
 		if ti2 == nil {
 			ti2 = ti.clone()
+			if d != nil {
+				ti2.settings.Dialect = d
+			}
+			if name, ok := rename[ti.Name]; ok {
+				ti2.Name = name
+			}
+			if opts.withoutTenant {
+				ti2.withoutTenant = true
+			}
+			if opts.masking {
+				ti2.masked = true
+			}
 			tableClones[ti] = ti2
 		}
 		return ti2
 	}
+	tableNameClone := func(tn TableName) TableName {
+		tn2 := tn.clone(tableClone(tn.table))
+		tn2.tenant = nil
+		if tn2.clause == clauseSelectFrom && tn2.table.tenantColumn() != nil {
+			tn2.tenant = &tenantInput{}
+		}
+		return tn2
+	}
+	dialect := d
+	if dialect == nil {
+		dialect = dialectFor(args)
+	}
 
 	for i, arg := range args {
 		if tn, ok := arg.(TableName); ok {
-			args2[i] = tn.clone(tableClone(tn.table))
+			args2[i] = tableNameClone(tn)
 		} else if cil, ok := arg.(ColumnList); ok {
 			args2[i] = cil.clone(tableClone(cil.table))
 		} else if ph, ok := arg.(*Placeholder); ok {
 			args2[i] = ph.clone(tableClone(ph.table))
+		} else if ci, ok := arg.(*CaseInsensitive); ok {
+			args2[i] = ci.clone()
+		} else if sl, ok := arg.(*SortList); ok {
+			args2[i] = sl.clone(tableClone(sl.table))
+		} else if fl, ok := arg.(*FilterList); ok {
+			args2[i] = fl.clone(dialect)
+		} else if fr, ok := arg.(*Fragment); ok {
+			args2[i] = fr.clone(dialect)
+		} else if qc, ok := arg.(*queryCommand); ok {
+			args2[i] = &subquery{
+				format: qc.format,
+				args:   cloneArgsFor(qc.args, rename, opts),
+			}
+		} else if c, ok := arg.(*CTE); ok {
+			args2[i] = c.clone(func(args []interface{}) []interface{} {
+				return cloneArgsFor(args, rename, opts)
+			})
+		} else if l, ok := arg.(*LatestRows); ok {
+			args2[i] = l.clone(tableNameClone(l.from))
+		} else if s, ok := arg.(Sqlizer); ok {
+			args2[i] = sqlizerFragment(s, dialect)
 		} else {
 			args2[i] = arg
 		}
@@ -108,43 +428,281 @@ func cloneArgs(args []interface{}) []interface{} {
 	return args2
 }
 
+// dialectFor returns the dialect of the first table referenced in args.
+// If no table is referenced, dialectFor returns nil, and the default dialect
+// will be used.
+func dialectFor(args []interface{}) Dialect {
+	if ti := firstTable(args); ti != nil {
+		return ti.Dialect()
+	}
+	return nil
+}
+
+// firstTable returns the first table referenced in args, or nil
+// if no table is referenced.
+func firstTable(args []interface{}) *TableInfo {
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case TableName:
+			return a.table
+		case ColumnList:
+			return a.table
+		case *Placeholder:
+			return a.table
+		case *LatestRows:
+			return a.from.table
+		case *subquery:
+			if ti := firstTable(a.args); ti != nil {
+				return ti
+			}
+		case *CTE:
+			for _, e := range a.ctes {
+				if ti := firstTable(e.query.args); ti != nil {
+					return ti
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// commandDialect returns the dialect to use for a command, which
+// is the default dialect if the command does not reference any tables.
+func commandDialect(d Dialect) Dialect {
+	if d == nil {
+		return Default.dialect()
+	}
+	return d
+}
+
+// positioner is implemented by any argument that renders a placeholder
+// whose position is only known once the whole command has been built.
+type positioner interface {
+	setPosition(n int)
+}
+
+// PositionalArg is implemented by argument types that render placeholders
+// in the SQL statement, such as custom expression builders. When a command
+// is built using Execf or Queryf, the placeholders of each PositionalArg are
+// numbered along with the placeholders of the other arguments (eg
+// Update.WhereColumns), in the order that the arguments appear.
+//
+// SetPosition is called before the command is formatted, with the position
+// of the first placeholder and the dialect of the command. The argument
+// renders its placeholders using dialect.Placeholder(n),
+// dialect.Placeholder(n+1) and so on, typically in its String method:
+//
+//	type between struct {
+//		column string
+//		pos    int
+//		d      sqlf.Dialect
+//	}
+//
+//	func (b *between) NumInputs() int { return 2 }
+//
+//	func (b *between) SetPosition(n int, d sqlf.Dialect) { b.pos, b.d = n, d }
+//
+//	func (b *between) String() string {
+//		return fmt.Sprintf("%s between %s and %s",
+//			b.column, b.d.Placeholder(b.pos), b.d.Placeholder(b.pos+1))
+//	}
+//
+// Because SetPosition modifies the argument, a PositionalArg value should
+// not be used to build more than one command concurrently.
+type PositionalArg interface {
+	// NumInputs returns the number of placeholders rendered by the argument.
+	NumInputs() int
+
+	// SetPosition sets the position of the first placeholder
+	// rendered by the argument, and the dialect of the command.
+	SetPosition(n int, dialect Dialect)
+}
+
+// positionalInput is a positioner for one of the placeholders
+// rendered by a PositionalArg.
+type positionalInput struct {
+	arg     PositionalArg
+	index   int
+	dialect Dialect
+}
+
+func (pi *positionalInput) setPosition(n int) {
+	if pi.index == 0 {
+		pi.arg.SetPosition(n, pi.dialect)
+	}
+}
+
+// inputPositioners returns all of the input placeholders in args in the
+// order that they will appear in the SQL statement. Any embedded subquery
+// contributes its placeholders at the point that it appears. The dialect d
+// is the dialect of the command, which is passed to any PositionalArg.
+func inputPositioners(args []interface{}, d Dialect) []positioner {
+	var inputs []positioner
+	for _, arg := range args {
+		if tn, ok := arg.(TableName); ok {
+			if tn.tenant != nil {
+				inputs = append(inputs, tn.tenant)
+			}
+		} else if cil, ok := arg.(ColumnList); ok {
+			if cil.clause.isInput() {
+				for _, ci := range cil.filtered() {
+					inputs = append(inputs, ci)
+				}
+			}
+		} else if ph, ok := arg.(*Placeholder); ok {
+			inputs = append(inputs, ph)
+			for i := 1; i < ph.numInputs(); i++ {
+				inputs = append(inputs, &placeholderInput{ph: ph})
+			}
+		} else if fl, ok := arg.(*FilterList); ok {
+			for _, fc := range fl.conds {
+				inputs = append(inputs, fc)
+			}
+		} else if fr, ok := arg.(*Fragment); ok {
+			for i := 0; i < fr.NumInputs(); i++ {
+				inputs = append(inputs, &fragmentInput{frag: fr, index: i})
+			}
+		} else if sq, ok := arg.(*subquery); ok {
+			inputs = append(inputs, inputPositioners(sq.args, d)...)
+		} else if c, ok := arg.(*CTE); ok {
+			for _, e := range c.ctes {
+				inputs = append(inputs, inputPositioners(e.query.args, d)...)
+			}
+		} else if l, ok := arg.(*LatestRows); ok {
+			if l.from.tenant != nil {
+				inputs = append(inputs, l.from.tenant)
+			}
+		} else if pa, ok := arg.(PositionalArg); ok {
+			for i := 0; i < pa.NumInputs(); i++ {
+				inputs = append(inputs, &positionalInput{arg: pa, index: i, dialect: d})
+			}
+		}
+	}
+	return inputs
+}
+
+// subquery is a query command that has been embedded as an argument
+// in another command. It is rendered using the placeholder positions
+// of the enclosing command.
+type subquery struct {
+	format string
+	args   []interface{}
+}
+
+// String renders the subquery SQL, so that it can be formatted
+// using "%s" in the enclosing command.
+func (sq *subquery) String() string {
+	return fmt.Sprintf(sq.format, sq.args...)
+}
+
 type execRowCommand struct {
 	command string
 	table   *TableInfo
 	inputs  []*columnInfo
+	rebind  *rebindCache
+	opts    options
+
+	// fixed contains the position index and value of each arg
+	// supplied by a fragment of the command, see Set
+	fixed map[int]interface{}
+
+	// argPlan contains the args of the command, see newRowArgs
+	argPlan []rowArg
+
+	// err is set if a problem was found while building the
+	// command, and is reported when the command is executed
+	err error
+
+	// verifyErr is set if the command fails a check that can
+	// only be made while building it, see Verify
+	verifyErr error
 }
 
 func (cmd execRowCommand) Command() string {
 	return cmd.command
 }
 
-func (cmd execRowCommand) Args(row interface{}) ([]interface{}, error) {
-	if cmd.table == nil {
-		return nil, errors.New("table not specified")
+func (cmd execRowCommand) Interpolate(row interface{}) (string, error) {
+	args, err := cmd.inputArgs(context.Background(), row)
+	if err != nil {
+		return "", err
 	}
-	var args []interface{}
+	args = cmd.withFixed(redact(cmd.inputs, args))
+	return interpolate(cmd.table.Dialect(), cmd.command, args)
+}
 
-	rowVal := reflect.ValueOf(row)
-	for rowVal.Type().Kind() == reflect.Ptr {
-		rowVal = rowVal.Elem()
+func (cmd execRowCommand) BindArgs(ctx context.Context, row interface{}) ([]interface{}, error) {
+	if cmd.err != nil {
+		return nil, cmd.err
 	}
-	if rowVal.Type() != cmd.table.rowType {
-		return nil, fmt.Errorf("Args: expected type %s.%s or pointer", cmd.table.rowType.PkgPath(), cmd.table.rowType.Name())
+	args, err := cmd.args(ctx, row)
+	if err != nil {
+		return nil, err
 	}
+	return cmd.opts.bindArgsContext(ctx, args)
+}
+
+func (cmd execRowCommand) Args(row interface{}) ([]interface{}, error) {
+	return cmd.args(context.Background(), row)
+}
+
+// args returns the args for the row, encoded using ctx.
+func (cmd execRowCommand) args(ctx context.Context, row interface{}) ([]interface{}, error) {
+	return cmd.appendArgs(ctx, make([]interface{}, 0, len(cmd.inputs)+len(cmd.fixed)), row, true)
+}
 
-	for _, ci := range cmd.inputs {
-		args = append(args, reflectx.FieldByIndexesReadOnly(rowVal, ci.fields).Interface())
+// withFixed returns the args with the fixed args inserted.
+func (cmd execRowCommand) withFixed(args []interface{}) []interface{} {
+	if len(cmd.fixed) == 0 {
+		return args
 	}
+	all := make([]interface{}, 0, len(args)+len(cmd.fixed))
+	next := 0
+	for i := 0; i < len(args)+len(cmd.fixed); i++ {
+		if value, ok := cmd.fixed[i]; ok {
+			all = append(all, value)
+		} else if next < len(args) {
+			all = append(all, args[next])
+			next++
+		}
+	}
+	return all
+}
 
-	return args, nil
+// inputArgs returns the args for the input columns of the row, encoded using ctx.
+func (cmd execRowCommand) inputArgs(ctx context.Context, row interface{}) ([]interface{}, error) {
+	return cmd.appendArgs(ctx, make([]interface{}, 0, len(cmd.inputs)), row, false)
 }
 
-func (cmd execRowCommand) doExec(db sqlx.Execer, row interface{}) (sql.Result, error) {
-	args, err := cmd.Args(row)
+func (cmd execRowCommand) doExec(db sqlx.Execer, op string, row interface{}) (sql.Result, error) {
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	ctx := contextOf(db)
+	pooled := getArgs(db)
+	args, err := cmd.appendArgs(ctx, *pooled, row, true)
 	if err != nil {
 		return nil, err
 	}
-	return db.Exec(cmd.Command(), args...)
+	defer putArgs(db, pooled, args)
+	cmd.opts.debugBindings(op, cmd.command, cmd.inputs, args)
+	start := time.Now()
+	result, err := cmd.opts.exec(db, cmd.queryFor(db), args)
+	cmd.opts.report(ctx, op, cmd.command, start, rowsAffected(result), err)
+	return result, cmd.opts.wrapError(op, cmd.command, args, err)
+}
+
+// queryFor returns the SQL command, translated if necessary for the
+// dialect of the database driver used by db, or the SQL registered for
+// the dialect using RegisterOverride.
+func (cmd execRowCommand) queryFor(db interface{}) string {
+	if query, ok := lookupOverride(cmd.opts.name, db); ok {
+		return query
+	}
+	if cmd.table == nil {
+		return cmd.command
+	}
+	return cmd.rebind.queryFor(db, cmd.table.Dialect(), cmd.command)
 }
 
 func (cmd execRowCommand) getRowValue(row interface{}) (reflect.Value, error) {
@@ -161,9 +719,38 @@ func (cmd execRowCommand) getRowValue(row interface{}) (reflect.Value, error) {
 // insertRowCommand handles inserting a single table at a time.
 type insertRowCommand struct {
 	execRowCommand
+
+	// returning is the upsert statement with a returning clause
+	// for the auto-increment column, see WithUpsert
+	returning       string
+	returningRebind *rebindCache
+
+	// idempotent is set if the table has an idempotency key, and exists
+	// is the query that checks for an existing row with the key, if the
+	// insert statement cannot skip the row
+	idempotent   bool
+	exists       string
+	existsRebind *rebindCache
 }
 
 func (cmd insertRowCommand) Exec(db sqlx.Execer, row interface{}) error {
+	_, err := cmd.ExecResult(db, row)
+	return err
+}
+
+func (cmd insertRowCommand) ExecInserted(db sqlx.Execer, row interface{}) (bool, error) {
+	result, err := cmd.ExecResult(db, row)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (cmd insertRowCommand) ExecResult(db sqlx.Execer, row interface{}) (sql.Result, error) {
 	// find the auto-increment column, if any
 	var autoInc *columnInfo
 	for _, ci := range cmd.table.columns {
@@ -191,25 +778,45 @@ func (cmd insertRowCommand) Exec(db sqlx.Execer, row interface{}) error {
 			rowVal := reflect.ValueOf(row)
 			field = reflectx.FieldByIndexes(rowVal, autoInc.fields)
 			if !field.CanSet() {
-				return fmt.Errorf("Cannot set auto-increment value for type %s", rowVal.Type().Name())
+				return nil, fmt.Errorf("Cannot set auto-increment value for type %s", rowVal.Type().Name())
 			}
 		}
 	}
 
-	result, err := cmd.doExec(db, row)
+	if field.IsValid() && cmd.returning != "" && cmd.err == nil {
+		if q, ok := db.(sqlx.Queryer); ok {
+			return cmd.execReturning(q, row, field)
+		}
+	}
+
+	if cmd.exists != "" && cmd.err == nil {
+		exists, err := cmd.keyExists(db, row)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return returnedResult{}, nil
+		}
+	}
+
+	result, err := cmd.doExec(db, OpInsertRow, row)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	if (cmd.opts.ignoreDuplicates || cmd.idempotent) && rowsAffected(result) == 0 {
+		// the row was skipped, so there is no auto-increment value
+		return result, nil
+	}
 	if field.IsValid() {
 		n, err := result.LastInsertId()
 		if err != nil {
-			return nil
+			return result, nil
 		}
 		// TODO: could catch a panic here if the type is not int8, 1nt16, int32, int64
 		field.SetInt(n)
 	}
-	return nil
+	return result, nil
 }
 
 // InsertRowf builds up a command for inserting a single row in the database
@@ -218,8 +825,11 @@ func (cmd insertRowCommand) Exec(db sqlx.Execer, row interface{}) error {
 // TODO: need an example
 func InsertRowf(format string, args ...interface{}) InsertRowCommand {
 	// take a clone of the args so that we can modify them
-	args = cloneArgs(args)
+	args, opts := splitOptions(args)
+	args = cloneArgs(args, opts)
 	cmd := insertRowCommand{}
+	cmd.rebind = &rebindCache{}
+	cmd.opts = opts
 
 	for _, arg := range args {
 		if tn, ok := arg.(TableName); ok {
@@ -239,9 +849,49 @@ func InsertRowf(format string, args ...interface{}) InsertRowCommand {
 	for i, ci := range cmd.inputs {
 		ci.setPosition(i + 1)
 	}
+	cmd.opts.tenant = newTenantBinding(cmd.inputs)
+	cmd.opts.setArgLabels(cmd.inputs)
+	cmd.err = checkTenantInputs(args)
+	if err := sqlizerError(args); err != nil {
+		cmd.err = err
+	}
 
 	// generate the SQL statement
 	cmd.command = fmt.Sprintf(format, args...)
+	var d Dialect
+	if cmd.table != nil {
+		d = cmd.table.Dialect()
+	}
+	if opts.ignoreDuplicates && opts.upsert {
+		cmd.err = errors.New("WithIgnoreDuplicates and WithUpsert cannot be used together")
+	}
+	if opts.ignoreDuplicates && cmd.err == nil {
+		cmd.command, cmd.err = ignoreDuplicates(commandDialect(d), cmd.command)
+	}
+	if key := cmd.idempotencyKey(); key != nil && !opts.ignoreDuplicates && !opts.upsert && cmd.err == nil {
+		cmd.idempotent = true
+		cmd.command, cmd.exists, cmd.err = idempotentInsert(commandDialect(d), cmd.table, key, cmd.command)
+		cmd.existsRebind = &rebindCache{}
+	}
+	if len(opts.hints) > 0 && cmd.err == nil {
+		cmd.command, cmd.err = addHints(commandDialect(d), cmd.command, opts.hints)
+	}
+	if opts.upsert && cmd.err == nil {
+		cmd.command, cmd.returning, cmd.err = upsert(commandDialect(d), cmd.table, cmd.inputs, opts.upsertColumns, cmd.command)
+		if cmd.returning != "" {
+			cmd.returning = opts.annotate(cmd.returning)
+			cmd.returningRebind = &rebindCache{}
+		}
+	}
+	cmd.command = opts.annotate(cmd.command)
+	cmd.argPlan = newRowArgs(cmd.inputs, cmd.fixed)
+	cmd.verifyErr = verifyInsertLists(args)
+	if opts.strict && cmd.err == nil {
+		cmd.err = cmd.strictErr("InsertRowf")
+	}
+	if cmd.err == nil || opts.strict {
+		mustVerify(cmd, opts.strict)
+	}
 
 	return cmd
 }
@@ -252,7 +902,7 @@ type updateRowCommand struct {
 }
 
 func (cmd updateRowCommand) Exec(db sqlx.Execer, row interface{}) (rowsUpdated int, err error) {
-	result, err := cmd.doExec(db, row)
+	result, err := cmd.doExec(db, OpUpdateRow, row)
 	if err != nil {
 		return 0, err
 	}
@@ -269,198 +919,443 @@ func (cmd updateRowCommand) Exec(db sqlx.Execer, row interface{}) (rowsUpdated i
 // TODO: example needed.
 func UpdateRowf(format string, args ...interface{}) UpdateRowCommand {
 	// take a clone of the args so that we can modify them
-	args = cloneArgs(args)
+	args, opts := splitOptions(args)
+	args = cloneArgs(args, opts)
 	cmd := updateRowCommand{}
+	cmd.rebind = &rebindCache{}
+	cmd.opts = opts
 
 	for _, arg := range args {
 		if tn, ok := arg.(TableName); ok {
-			if tn.clause == clauseUpdateTable {
+			if tn.clause == clauseUpdateTable || tn.clause == clauseDeleteTable {
 				cmd.table = tn.table
 			}
 		}
+	}
+
+	// the placeholders of each input parameter, and of any fragments,
+	// such as those created using Set, in the order that they appear
+	var positioners []positioner
+	var positions []int // position index of each input parameter
+	for _, arg := range args {
 		if cil, ok := arg.(ColumnList); ok {
 			if cil.clause.isInput() {
 				// input parameters for the UPDATE statement
-				cmd.inputs = append(cmd.inputs, cil.filtered()...)
+				for _, ci := range cil.filtered() {
+					positions = append(positions, len(positioners))
+					positioners = append(positioners, ci)
+					cmd.inputs = append(cmd.inputs, ci)
+				}
+			}
+		}
+		if f, ok := arg.(*Fragment); ok {
+			for i := 0; i < f.NumInputs(); i++ {
+				if f.fixed {
+					if cmd.fixed == nil {
+						cmd.fixed = make(map[int]interface{})
+					}
+					cmd.fixed[len(positioners)] = f.args[i]
+				} else if ci := cmd.fragmentColumn(f); ci != nil {
+					// the placeholder is bound to the field of the column
+					positions = append(positions, len(positioners))
+					cmd.inputs = append(cmd.inputs, ci)
+				} else if cmd.err == nil {
+					cmd.err = fmt.Errorf("UpdateRowf: no value for the placeholders in %q", f.text())
+				}
+				positioners = append(positioners, &fragmentInput{frag: f, index: i})
 			}
 		}
 	}
 
 	// apply placeholders to each of the input parameters
-	for i, ci := range cmd.inputs {
-		ci.setPosition(i + 1)
+	for i, p := range positioners {
+		p.setPosition(i + 1)
+	}
+	cmd.opts.tenant = newTenantBinding(cmd.inputs)
+	cmd.opts.setArgLabels(cmd.inputs)
+	if tb := cmd.opts.tenant; tb != nil && len(cmd.fixed) > 0 {
+		// the tenant replaces the arg in its position, which
+		// is after any fixed args that come before it
+		replaced := make(map[int]bool, len(tb.replaced))
+		for i := range tb.replaced {
+			replaced[positions[i]] = true
+		}
+		tb.replaced = replaced
+	}
+	if labels := cmd.opts.argLabels; labels != nil && len(cmd.fixed) > 0 {
+		// the fixed args are inserted before the command is executed
+		cmd.opts.argLabels = make([]argLabel, len(positioners))
+		for i := range cmd.opts.argLabels {
+			cmd.opts.argLabels[i] = argLabel{column: "-", field: "-"}
+		}
+		for i, label := range labels {
+			cmd.opts.argLabels[positions[i]] = label
+		}
+	}
+	if err := checkTenantInputs(args); err != nil {
+		cmd.err = err
+	}
+	if err := sqlizerError(args); err != nil {
+		cmd.err = err
 	}
 
 	// generate the SQL statement
 	cmd.command = fmt.Sprintf(format, args...)
+	if len(opts.hints) > 0 && cmd.err == nil && cmd.table != nil {
+		cmd.command, cmd.err = addHints(cmd.table.Dialect(), cmd.command, opts.hints)
+	}
+	cmd.command = opts.annotate(cmd.command)
+	cmd.argPlan = newRowArgs(cmd.inputs, cmd.fixed)
+	if opts.strict && cmd.err == nil {
+		cmd.err = cmd.strictErr("UpdateRowf")
+	}
+	if cmd.err == nil || opts.strict {
+		mustVerify(cmd, opts.strict)
+	}
 
 	return cmd
 }
 
+// fragmentColumn returns the column whose field supplies the args
+// for the placeholders of a fragment created using Set, or nil if
+// there is no such column.
+func (cmd updateRowCommand) fragmentColumn(f *Fragment) *columnInfo {
+	if f.set == "" || cmd.table == nil {
+		return nil
+	}
+	return cmd.table.columnNamed(f.set)
+}
+
 type execCommand struct {
 	command string
+	dialect Dialect
+	inputs  []positioner
+	rebind  *rebindCache
+	opts    options
+
+	// err is set if a problem was found while building the
+	// command, and is reported when the command is executed
+	err error
 }
 
 func (cmd execCommand) Command() string {
 	return cmd.command
 }
 
+func (cmd execCommand) BindArgs(ctx context.Context, args ...interface{}) ([]interface{}, error) {
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	return cmd.opts.bindArgsContext(ctx, args)
+}
+
+func (cmd execCommand) Interpolate(args ...interface{}) (string, error) {
+	if cmd.err != nil {
+		return "", cmd.err
+	}
+	return interpolate(commandDialect(cmd.dialect), cmd.command, redact(cmd.inputs, cmd.opts.binding.convert(args)))
+}
+
 func (cmd execCommand) Exec(db sqlx.Execer, args ...interface{}) (sql.Result, error) {
-	return db.Exec(cmd.Command(), args...)
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	cmd.opts.debugBindings(OpExec, cmd.command, cmd.inputs, args)
+	start := time.Now()
+	result, err := cmd.opts.exec(db, cmd.queryFor(db), args)
+	cmd.opts.report(contextOf(db), OpExec, cmd.command, start, rowsAffected(result), err)
+	return result, cmd.opts.wrapError(OpExec, cmd.command, args, err)
+}
+
+func (cmd execCommand) ExecStruct(db sqlx.Execer, arg interface{}) (sql.Result, error) {
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	args, err := structArgs(contextOf(db), cmd.inputs, arg)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Exec(db, args...)
+}
+
+// queryFor returns the SQL command, translated if necessary for the
+// dialect of the database driver used by db, or the SQL registered for
+// the dialect using RegisterOverride.
+func (cmd execCommand) queryFor(db interface{}) string {
+	if query, ok := lookupOverride(cmd.opts.name, db); ok {
+		return query
+	}
+	return cmd.rebind.queryFor(db, commandDialect(cmd.dialect), cmd.command)
 }
 
 // Execf formats an SQL command that does not return any rows.
 func Execf(format string, args ...interface{}) ExecCommand {
-	args = cloneArgs(args)
-	cmd := execCommand{}
-	var inputs []interface {
-		setPosition(n int)
-	}
+	return newExecCommand(format, args)
+}
 
-	for _, arg := range args {
-		if cil, ok := arg.(ColumnList); ok {
-			if cil.clause.isInput() {
-				for _, ci := range cil.filtered() {
-					inputs = append(inputs, ci)
-				}
-			}
-		} else if ph, ok := arg.(*Placeholder); ok {
-			inputs = append(inputs, ph)
-		}
+func newExecCommand(format string, args []interface{}) execCommand {
+	args, opts := splitOptions(args)
+	args = cloneArgs(args, opts)
+	cmd := execCommand{
+		dialect: opts.dialectFor(args),
+		rebind:  &rebindCache{},
+		opts:    opts,
 	}
 
 	// apply placeholders to each of the input parameters
-	for i, input := range inputs {
+	cmd.inputs = inputPositioners(args, commandDialect(cmd.dialect))
+	for i, input := range cmd.inputs {
 		input.setPosition(i + 1)
 	}
+	cmd.opts.tenant = newTenantBinding(cmd.inputs)
+	cmd.opts.binding = newArgBinding(cmd.inputs)
+	cmd.opts.setArgLabels(cmd.inputs)
+	cmd.err = checkTenantInputs(args)
+	if err := sqlizerError(args); err != nil {
+		cmd.err = err
+	}
 
 	// generate the SQL statement
 	cmd.command = fmt.Sprintf(format, args...)
+	if len(opts.hints) > 0 && cmd.err == nil {
+		cmd.command, cmd.err = addHints(commandDialect(cmd.dialect), cmd.command, opts.hints)
+	}
+	cmd.command = opts.annotate(cmd.command)
+	if cmd.err == nil || opts.strict {
+		mustVerify(cmd, opts.strict)
+	}
 
 	return cmd
 }
 
-// updateRowCommand handles inserting a single table at a time.
+// queryCommand handles queries that return rows.
 type queryCommand struct {
 	command string
+	dialect Dialect
 	columns []*columnInfo
-	inputs  []*columnInfo
-	mapper  *reflectx.Mapper
+	inputs  []positioner
+	rebind  rebindCache
+	opts    options
+
+	// mapper is built lazily, see getMapper
+	mapperOnce sync.Once
+	mapper     *reflectx.Mapper
+	mapperErr  error
+
+	// format and args are kept so that the query can be
+	// embedded in another command as a subquery
+	format string
+	args   []interface{}
+
+	// template and slotValues are kept so that
+	// the query can be rebuilt, see With
+	template   []interface{}
+	slotValues map[string]interface{}
 }
 
+// getMapper returns the mapper for scanning rows returned by the query.
+// The mapper is built the first time it is needed. Because query commands
+// are typically built once and then used by many goroutines, this
+// lazy initialization must be safe for concurrent use.
 func (cmd *queryCommand) getMapper() (*reflectx.Mapper, error) {
+	if cmd.opts.mapper != nil {
+		return cmd.opts.mapper, nil
+	}
+	cmd.mapperOnce.Do(func() {
+		cmd.mapper, cmd.mapperErr = cmd.newMapper()
+	})
+	return cmd.mapper, cmd.mapperErr
+}
+
+func (cmd *queryCommand) newMapper() (*reflectx.Mapper, error) {
 	m := make(map[string]*columnInfo)
-	if cmd.mapper == nil {
-		for _, ci := range cmd.columns {
-			if _, ok := m[ci.fieldName]; ok {
-				// TODO: need to modify the github.com/jmoiron/sqlx/reflectx package
-				// to solve this problem.
-				return nil, fmt.Errorf("Cannot process query: multiple fields named %s", ci.fieldName)
-			}
-			m[ci.fieldName] = ci
+	dups := make(map[string]bool)
+	for _, ci := range cmd.columns {
+		if _, ok := m[ci.fieldName]; ok {
+			// More than one selected column has the same field name,
+			// which happens when joining tables with similar structures.
+			// The mapper cannot tell these apart, but Select and
+			// Row.StructScan use the table information to do so.
+			dups[ci.fieldName] = true
+			continue
 		}
-		mapFunc := func(name string) string {
-			if ci, ok := m[name]; ok {
-				if ci.hasColumnAlias() {
-					return ci.columnAlias()
-				}
-				return ci.columnName
-			}
-			return name
+		m[ci.fieldName] = ci
+	}
+	for name := range dups {
+		delete(m, name)
+	}
+	mapFunc := func(name string) string {
+		if ci, ok := m[name]; ok {
+			return ci.outputName()
 		}
-		cmd.mapper = reflectx.NewMapperFunc("", mapFunc)
+		return name
 	}
-	return cmd.mapper, nil
-
+	return reflectx.NewMapperFunc("", mapFunc), nil
 }
 
 func (cmd *queryCommand) Command() string {
 	return cmd.command
 }
 
-func (cmd *queryCommand) Query(db sqlx.Queryer, args ...interface{}) (*sqlx.Rows, error) {
+// queryFor returns the SQL query, translated if necessary for the
+// dialect of the database driver used by db, or the SQL registered for
+// the dialect using RegisterOverride.
+func (cmd *queryCommand) queryFor(db interface{}) string {
+	if query, ok := lookupOverride(cmd.opts.name, db); ok {
+		return query
+	}
+	return cmd.rebind.queryFor(db, commandDialect(cmd.dialect), cmd.command)
+}
+
+func (cmd *queryCommand) BindArgs(ctx context.Context, args ...interface{}) ([]interface{}, error) {
+	return cmd.opts.bindArgsContext(ctx, args)
+}
+
+func (cmd *queryCommand) Interpolate(args ...interface{}) (string, error) {
+	return interpolate(commandDialect(cmd.dialect), cmd.command, redact(cmd.inputs, cmd.opts.binding.convert(args)))
+}
+
+func (cmd *queryCommand) Query(db sqlx.Queryer, args ...interface{}) (*QueryRows, error) {
 	mapper, err := cmd.getMapper()
 	if err != nil {
 		return nil, err
 	}
-
-	rows, err := db.Query(cmd.Command(), args...)
+	cmd.opts.debugBindings(OpQuery, cmd.command, cmd.inputs, args)
+	start := time.Now()
+	// the rows are read after Query returns, so the context and
+	// any settings are released when the rows are closed
+	rows, cancel, err := cmd.opts.query(db, cmd.queryFor(db), args)
+	cmd.opts.report(contextOf(db), OpQuery, cmd.command, start, -1, err)
 	if err != nil {
-		return nil, err
+		return nil, cmd.opts.wrapError(OpQuery, cmd.command, args, err)
 	}
-	return &sqlx.Rows{
-		Rows:   rows,
-		Mapper: mapper,
+	return &QueryRows{
+		Rows: &sqlx.Rows{
+			Rows:   rows,
+			Mapper: mapper,
+		},
+		cancel: cancel,
 	}, nil
 
 }
 
-func (cmd *queryCommand) QueryRow(db sqlx.Queryer, args ...interface{}) *sqlx.Row {
-	mapper, err := cmd.getMapper()
+func (cmd *queryCommand) QueryStruct(db sqlx.Queryer, arg interface{}) (*QueryRows, error) {
+	args, err := structArgs(contextOf(db), cmd.inputs, arg)
 	if err != nil {
-		// TODO
-		panic(err.Error())
+		return nil, err
 	}
-	row := db.QueryRowx(cmd.Command(), args...)
-	row.Mapper = mapper
-	return row
+	return cmd.Query(db, args...)
 }
 
-func (cmd *queryCommand) Select(db sqlx.Queryer, dest interface{}, args ...interface{}) error {
-	q := queryer{
-		cmd: cmd,
-		db:  db,
+func (cmd *queryCommand) QueryRow(db sqlx.Queryer, args ...interface{}) *Row {
+	mapper, err := cmd.getMapper()
+	if err != nil {
+		return &Row{err: err}
 	}
-	return sqlx.Select(q, dest, "unused", args...)
-}
-
-// queryer implements the sqlx.Queryer interface. In all methods, the
-// query string is ignored and the actual query is taken from the query command.
-type queryer struct {
-	cmd *queryCommand
-	db  sqlx.Queryer
-}
-
-func (q queryer) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return q.db.Query(q.cmd.Command(), args...)
-}
-
-func (q queryer) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
-	return q.cmd.Query(q.db, args...)
-}
-
-func (q queryer) QueryRowx(query string, args ...interface{}) *sqlx.Row {
-	return q.cmd.QueryRow(q.db, args)
+	cmd.opts.debugBindings(OpQueryRow, cmd.command, cmd.inputs, args)
+	start := time.Now()
+	row, cancel, err := cmd.opts.queryRow(db, cmd.queryFor(db), args)
+	if err == nil {
+		err = row.Err()
+		row.Mapper = mapper
+	}
+	cmd.opts.report(contextOf(db), OpQueryRow, cmd.command, start, -1, err)
+	return &Row{row: row, cmd: cmd, ctx: contextOf(db), cancel: cancel, err: cmd.opts.wrapError(OpQueryRow, cmd.command, args, err)}
 }
 
 // Queryf builds a command to query one or more rows from the database
 // using a familiar "printf"-style syntax.
 //
+// A QueryCommand can itself be passed as an argument, in which case it is
+// formatted as a subquery (or CTE, depending on the format string). The
+// placeholders of the embedded query are renumbered to suit their position
+// in the new command, and the arguments for the embedded query are supplied
+// in the same order in which its placeholders appear.
+//
 // TODO: example needed.
 func Queryf(format string, args ...interface{}) QueryCommand {
+	args, opts := splitOptions(args)
+	return newQuery(format, args, nil, opts)
+}
+
+// aliasDuplicateColumns generates an alias for each of the selected
+// columns whose name is the same as a column selected from another table,
+// so that the columns can be told apart in the result set. The alias is
+// the table name and the column name, eg "users_id" and "teams_id".
+// Columns of tables with an alias already have a distinct name.
+func aliasDuplicateColumns(columns []*columnInfo) {
+	byName := make(map[string][]*columnInfo)
+	for _, ci := range columns {
+		name := ci.outputName()
+		byName[name] = append(byName[name], ci)
+	}
+	for _, list := range byName {
+		var shared bool
+		for _, ci := range list[1:] {
+			shared = shared || ci.table != list[0].table
+		}
+		if !shared {
+			continue
+		}
+		for _, ci := range list {
+			if !ci.hasTableAlias() {
+				alias := strings.ReplaceAll(ci.table.Name, ".", "_") + "_" + ci.columnName
+				ci.outputAlias = strings.ToLower(alias)
+			}
+		}
+	}
+}
+
+// newQuery builds a query from the format string and args, which can
+// contain slots that are replaced by the values, see Slot.
+func newQuery(format string, template []interface{}, values map[string]interface{}, opts options) *queryCommand {
 	// take a clone of the args so that we can modify them
-	args = cloneArgs(args)
-	cmd := queryCommand{}
+	args := cloneArgs(fillSlots(template, values), opts)
+	cmd := queryCommand{
+		format:     format,
+		args:       args,
+		template:   template,
+		slotValues: values,
+		dialect:    opts.dialectFor(args),
+		opts:       opts,
+	}
 
 	for _, arg := range args {
 		if cil, ok := arg.(ColumnList); ok {
-			if cil.clause.isInput() {
-				// input parameters for the SELECT statement
-				cmd.inputs = append(cmd.inputs, cil.filtered()...)
-			}
 			if cil.clause == clauseSelectColumns {
 				cmd.columns = append(cmd.columns, cil.filtered()...)
 			}
 		}
 	}
+	aliasDuplicateColumns(cmd.columns)
 
 	// apply placeholders to each of the input parameters
-	for i, ci := range cmd.inputs {
-		ci.setPosition(i + 1)
+	cmd.inputs = inputPositioners(args, commandDialect(cmd.dialect))
+	for i, input := range cmd.inputs {
+		input.setPosition(i + 1)
 	}
+	cmd.opts.tenant = newTenantBinding(cmd.inputs)
+	cmd.opts.binding = newArgBinding(cmd.inputs)
+	cmd.opts.setArgLabels(cmd.inputs)
+	err := sqlizerError(args)
 
 	// generate the SQL statement
 	cmd.command = fmt.Sprintf(format, args...)
+	if len(opts.hints) > 0 {
+		var hintErr error
+		cmd.command, hintErr = addHints(commandDialect(cmd.dialect), cmd.command, opts.hints)
+		if err == nil {
+			err = hintErr
+		}
+	}
+	cmd.command = opts.annotate(cmd.command)
+	if err != nil {
+		// reported when the query is executed
+		cmd.opts.binding = &argBinding{err: err}
+	}
+	if err == nil || opts.strict {
+		mustVerify(&cmd, opts.strict)
+	}
 
 	return &cmd
 }
@@ -0,0 +1,191 @@
+package sqlf_test
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryfSubquery(t *testing.T) {
+	assert := assert.New(t)
+	row1 := Row1Table.WithDialect(sqlf.DialectPG)
+	row2 := Row2Table.WithDialect(sqlf.DialectPG)
+
+	inner := sqlf.Queryf("select user_id from %s where search_term like %s",
+		row2.Select.TableName,
+		row2.Select.Placeholder(),
+	)
+	assert.Equal(`select user_id from "table2" where search_term like $1`, inner.Command())
+
+	outer := sqlf.Queryf("select %s from %s where %s > %s and id in (%s) and id < %s",
+		row1.Select.Columns,
+		row1.Select.TableName,
+		"id",
+		row1.Select.Placeholder(),
+		inner,
+		row1.Select.Placeholder(),
+	)
+	assert.Equal(`select "id","given_name","family_name","Date_of_Birth" from "table1" `+
+		`where id > $1 and id in (select user_id from "table2" where search_term like $2) and id < $3`,
+		outer.Command())
+
+	// the embedded command is unchanged
+	assert.Equal(`select user_id from "table2" where search_term like $1`, inner.Command())
+
+	// commands can be nested more than one level deep
+	cte := sqlf.Queryf("with t as (%s) select * from t where user_id <> %s", outer, row1.Select.Placeholder())
+	assert.Contains(cte.Command(), "search_term like $2")
+	assert.Contains(cte.Command(), "id < $3) select * from t where user_id <> $4")
+
+	del := sqlf.Execf("delete from %s where id in (%s)", row1.Delete.TableName, inner)
+	assert.Equal(`delete from "table1" where id in (select user_id from "table2" where search_term like $1)`, del.Command())
+}
+
+func TestQueryConcurrent(t *testing.T) {
+	db, err := sqlx.Open("sqlite3", "file:concurrent?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	for i := 0; i < 10; i++ {
+		if err := ins.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the command is shared by all goroutines, in the same way
+	// as a command stored in a package-level variable
+	sel := sqlf.Queryf("select %s from %s order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rows []Row1
+			if err := sel.Select(db, &rows); err != nil {
+				t.Error(err)
+				return
+			}
+			if len(rows) != 10 {
+				t.Errorf("expected 10 rows, got %d", len(rows))
+			}
+			var row Row1
+			if err := sel.QueryRow(db).StructScan(&row); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestQueryfWithMapper(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table sales(region text, amount integer);
+		insert into sales values('north', 10), ('north', 20), ('south', 5)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// an ad-hoc struct for a report, which does not correspond to a table
+	type RegionTotal struct {
+		RegionName  string
+		TotalAmount int
+	}
+	report := sqlf.Queryf("select region as region_name, sum(amount) as total_amount from sales group by region order by region",
+		sqlf.WithMapperFunc(sqlf.ToDBName))
+	var totals []RegionTotal
+	assert.NoError(report.Select(db, &totals))
+	assert.Equal([]RegionTotal{{"north", 30}, {"south", 5}}, totals)
+
+	var total RegionTotal
+	assert.NoError(report.QueryRow(db).StructScan(&total))
+	assert.Equal(RegionTotal{"north", 30}, total)
+
+	// without the mapper, the columns do not match the field names
+	plain := sqlf.Queryf("select region as region_name, sum(amount) as total_amount from sales group by region")
+	assert.Error(plain.Select(db, &totals))
+}
+
+func TestQueryGet(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen"}))
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "Jane", FamilyName: "Citizen"}))
+
+	get := sqlf.Queryf("select %s from %s where %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Update.WhereColumns)
+	var row Row1
+	assert.NoError(get.Get(db, &row, 2))
+	assert.Equal("Jane", row.GivenName)
+
+	assert.Equal(sql.ErrNoRows, get.Get(db, &row, 3))
+
+	byFamily := sqlf.Queryf("select %s from %s where family_name = ? order by id", tbl.Select.Columns, tbl.Select.TableName)
+	assert.Equal(sqlf.ErrTooManyRows, byFamily.Get(db, &row, "Citizen"))
+	assert.Equal("John", row.GivenName)
+
+	// scannable values
+	var count int
+	assert.NoError(sqlf.Queryf("select count(*) from table1").Get(db, &count))
+	assert.Equal(2, count)
+
+	assert.Error(get.Get(db, row, 1))
+}
+
+// between is a PositionalArg that renders a "between" condition.
+type between struct {
+	column string
+	pos    int
+	d      sqlf.Dialect
+}
+
+func (b *between) NumInputs() int { return 2 }
+
+func (b *between) SetPosition(n int, d sqlf.Dialect) { b.pos, b.d = n, d }
+
+func (b *between) String() string {
+	return fmt.Sprintf("%s between %s and %s", b.column, b.d.Placeholder(b.pos), b.d.Placeholder(b.pos+1))
+}
+
+func TestPositionalArg(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Row1Table.WithDialect(sqlf.DialectPG)
+
+	sel := sqlf.Queryf("select %s from %s where %s and family_name = %s",
+		tbl.Select.Columns, tbl.Select.TableName, &between{column: "id"}, tbl.Select.Placeholder())
+	assert.Equal(`select "id","given_name","family_name","Date_of_Birth" from "table1" where id between $1 and $2 and family_name = $3`,
+		sel.Command())
+
+	del := sqlf.Execf("delete from %s where given_name = %s and %s",
+		tbl.Delete.TableName, tbl.Delete.Placeholder(), &between{column: "id"})
+	assert.Equal(`delete from "table1" where given_name = $1 and id between $2 and $3`, del.Command())
+
+	// the placeholders of an embedded query are renumbered
+	outer := sqlf.Execf("delete from %s where %s and id in (%s)", tbl.Delete.TableName, &between{column: "id"},
+		sqlf.Queryf("select id from %s where %s", tbl.Select.TableName, &between{column: "Date_of_Birth"}))
+	assert.Equal(`delete from "table1" where id between $1 and $2 and id in (select id from "table1" where Date_of_Birth between $3 and $4)`,
+		outer.Command())
+}
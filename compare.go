@@ -0,0 +1,84 @@
+package sqlf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CaseInsensitive is a condition for a WHERE clause that compares a column
+// with a value, ignoring differences in case. It is created using CI or
+// CILike, and passed as an argument to Queryf or Execf. It renders one
+// placeholder, for the value to compare with.
+type CaseInsensitive struct {
+	column   string
+	like     bool
+	position int
+	dialect  Dialect
+}
+
+// CI returns a condition that tests whether the column is equal to
+// a value, ignoring case. This is typically used for looking up rows
+// by email address or user name:
+//
+//	getUser := sqlf.Queryf("select %s from %s where %s",
+//		users.Select.Columns, users.Select.TableName, sqlf.CI("email"))
+//	err := getUser.Get(db, &user, email)
+//
+// The condition is rendered to suit the dialect of the command. For SQLite
+// it is "email = ? collate nocase", and for other databases it is
+// "lower(email) = lower(?)". An index on the expression "lower(email)"
+// can be used to make the comparison efficient.
+func CI(column string) *CaseInsensitive {
+	return &CaseInsensitive{column: column}
+}
+
+// CILike returns a condition that tests whether the column matches a
+// "like" pattern, ignoring case. For PostgreSQL it is rendered using
+// "ilike", for SQLite using "like" (which ignores case for ASCII letters),
+// and for other databases it is "lower(column) like lower(?)".
+func CILike(column string) *CaseInsensitive {
+	return &CaseInsensitive{column: column, like: true}
+}
+
+// clone returns a copy of the condition, so that each command
+// that uses it can set its own position and dialect.
+func (c *CaseInsensitive) clone() *CaseInsensitive {
+	c2 := *c
+	return &c2
+}
+
+// NumInputs implements PositionalArg.
+func (c *CaseInsensitive) NumInputs() int {
+	return 1
+}
+
+// SetPosition implements PositionalArg.
+func (c *CaseInsensitive) SetPosition(n int, dialect Dialect) {
+	c.position = n
+	c.dialect = dialect
+}
+
+// String renders the condition.
+func (c *CaseInsensitive) String() string {
+	column, placeholder, name := c.column, "?", ""
+	if c.dialect != nil {
+		parts := strings.Split(c.column, ".")
+		for i, part := range parts {
+			parts[i] = c.dialect.Quote(part)
+		}
+		column = strings.Join(parts, ".")
+		placeholder = c.dialect.Placeholder(c.position)
+		name = DialectName(c.dialect)
+	}
+	switch {
+	case c.like && name == "postgres":
+		return fmt.Sprintf("%s ilike %s", column, placeholder)
+	case c.like && name == "sqlite3":
+		return fmt.Sprintf("%s like %s", column, placeholder)
+	case c.like:
+		return fmt.Sprintf("lower(%s) like lower(%s)", column, placeholder)
+	case name == "sqlite3":
+		return fmt.Sprintf("%s = %s collate nocase", column, placeholder)
+	}
+	return fmt.Sprintf("lower(%s) = lower(%s)", column, placeholder)
+}
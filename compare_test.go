@@ -0,0 +1,68 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCI(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		dialect sqlf.Dialect
+		ci      string
+		like    string
+	}{
+		{sqlf.DialectPG, `lower("given_name") = lower($2)`, `"given_name" ilike $2`},
+		{sqlf.DialectMySQL, "lower(`given_name`) = lower(?)", "lower(`given_name`) like lower(?)"},
+		{sqlf.DialectSQLite, "`given_name` = ? collate nocase", "`given_name` like ?"},
+		{sqlf.DialectMSSQL, "lower([given_name]) = lower(?)", "lower([given_name]) like lower(?)"},
+	}
+	for _, tt := range tests {
+		tbl := Row1Table.WithDialect(tt.dialect)
+		cmd := sqlf.Queryf("select id from %s where id > %s and %s", tbl.Select.TableName, tbl.Select.Placeholder(), sqlf.CI("given_name"))
+		assert.Contains(cmd.Command(), "and "+tt.ci, sqlf.DialectName(tt.dialect))
+		cmd = sqlf.Queryf("select id from %s where id > %s and %s", tbl.Select.TableName, tbl.Select.Placeholder(), sqlf.CILike("given_name"))
+		assert.Contains(cmd.Command(), "and "+tt.like, sqlf.DialectName(tt.dialect))
+	}
+}
+
+func TestCIShared(t *testing.T) {
+	assert := assert.New(t)
+	email := sqlf.CI("u.email")
+	pg := sqlf.Queryf("select id from users u where u.id > %s and %s",
+		Row1Table.WithDialect(sqlf.DialectPG).Select.Placeholder(), email)
+	mysql := sqlf.Queryf("select id from users u where %s", email, sqlf.WithDialect(sqlf.DialectMySQL))
+
+	// each command has its own position and dialect,
+	// and the condition passed to the commands is unchanged
+	assert.Equal(`select id from users u where u.id > $1 and lower("u"."email") = lower($2)`, pg.Command())
+	assert.Equal("select id from users u where lower(`u`.`email`) = lower(?)", mysql.Command())
+	assert.Equal("lower(u.email) = lower(?)", email.String())
+}
+
+func TestCIQuery(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen"}))
+
+	var row Row1
+	get := sqlf.Queryf("select %s from %s where %s", tbl.Select.Columns, tbl.Select.TableName, sqlf.CI("family_name"))
+	assert.NoError(get.Get(db, &row, "CITIZEN"))
+	assert.Equal("John", row.GivenName)
+
+	like := sqlf.Queryf("select %s from %s where %s", tbl.Select.Columns, tbl.Select.TableName, sqlf.CILike("given_name"))
+	assert.NoError(like.Get(db, &row, "jo%"))
+	assert.Equal("John", row.GivenName)
+}
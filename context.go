@@ -0,0 +1,140 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ContextDB is a database handle that executes commands using a context.
+// The context is used to cancel commands, and it is passed to the code
+// that the commands call: the AfterScan method of rows, codecs that
+// implement ContextCodec, and the metrics sink (see CommandEvent.Context).
+// This makes per-request values, such as the user stored using
+// ContextWithUser, available to all of them.
+//
+// A ContextDB is usually created for each request:
+//
+//	db := sqlf.ForContext(r.Context(), pool)
+//	err := listOrders.Select(db, &orders)
+//
+// A ContextDB cannot begin a transaction. To use a transaction, begin it
+// using the underlying handle and then call ForContext with the transaction.
+type ContextDB struct {
+	sqlx.Ext
+	ctx context.Context
+}
+
+// ForContext returns a handle that executes commands using db and ctx.
+func ForContext(ctx context.Context, db sqlx.Ext) *ContextDB {
+	return &ContextDB{Ext: db, ctx: ctx}
+}
+
+// Context returns the context of the handle.
+func (c *ContextDB) Context() context.Context {
+	return c.ctx
+}
+
+// handleContext returns the context of db if it is a ContextDB or a
+// TenantScope, and false if db does not have a context.
+func handleContext(db interface{}) (context.Context, bool) {
+	switch db := db.(type) {
+	case *ContextDB:
+		return db.ctx, true
+	case *TenantScope:
+		return db.ctx, true
+	}
+	return context.Background(), false
+}
+
+// contextOf returns the context of db, or the background
+// context if db does not have a context.
+func contextOf(db interface{}) context.Context {
+	ctx, _ := handleContext(db)
+	return ctx
+}
+
+// unwrapHandle returns the underlying database handle
+// if db is a ContextDB or a TenantScope.
+func unwrapHandle(db interface{}) interface{} {
+	switch h := db.(type) {
+	case *ContextDB:
+		return h.Ext
+	case *TenantScope:
+		return h.Ext
+	}
+	return db
+}
+
+// rewrapHandle returns a handle for inner with the same context, and for
+// a TenantScope the same tenant, as db. It is used to execute commands
+// using a transaction begun by the underlying handle of db.
+func rewrapHandle(db interface{}, inner sqlx.Ext) sqlx.Ext {
+	switch h := db.(type) {
+	case *ContextDB:
+		return ForContext(h.ctx, inner)
+	case *TenantScope:
+		return &TenantScope{ContextDB: ContextDB{Ext: inner, ctx: h.ctx}, tenant: h.tenant, ok: h.ok}
+	}
+	return inner
+}
+
+type userKey struct{}
+type localeKey struct{}
+
+// ContextWithUser returns a copy of ctx that contains the user making
+// the request, typically the user's ID, for use in audit columns and
+// by codecs. See ForContext.
+func ContextWithUser(ctx context.Context, user interface{}) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user stored in ctx using
+// ContextWithUser, and false if there is none.
+func UserFromContext(ctx context.Context) (interface{}, bool) {
+	user := ctx.Value(userKey{})
+	return user, user != nil
+}
+
+// ContextWithLocale returns a copy of ctx that contains the locale of
+// the request (eg "en-AU"), for use by codecs that format or translate
+// values. See ForContext.
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored in ctx using
+// ContextWithLocale, and false if there is none.
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// ContextCodec is implemented by codecs that need the context of the
+// command, for example to encrypt values using a key for the tenant or
+// to format values for the locale. When a codec implements ContextCodec,
+// its EncodeContext and DecodeContext methods are called instead of
+// Encode and Decode, with the context of the handle used to execute the
+// command (see ForContext), or the background context if there is none.
+type ContextCodec interface {
+	Codec
+	EncodeContext(ctx context.Context, value interface{}) (driver.Value, error)
+	DecodeContext(ctx context.Context, dbValue interface{}) (interface{}, error)
+}
+
+// encode converts the field value using the codec.
+func encode(ctx context.Context, codec Codec, value interface{}) (driver.Value, error) {
+	if cc, ok := codec.(ContextCodec); ok {
+		return cc.EncodeContext(ctx, value)
+	}
+	return codec.Encode(value)
+}
+
+// decode converts the database value using the codec.
+func decode(ctx context.Context, codec Codec, dbValue interface{}) (interface{}, error) {
+	if cc, ok := codec.(ContextCodec); ok {
+		return cc.DecodeContext(ctx, dbValue)
+	}
+	return codec.Decode(dbValue)
+}
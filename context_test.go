@@ -0,0 +1,107 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// localeCodec is a context codec for testing: it stores strings
+// prefixed with the locale of the request
+type localeCodec struct{ reverseCodec }
+
+func (localeCodec) EncodeContext(ctx context.Context, value interface{}) (driver.Value, error) {
+	locale, _ := LocaleFromContext(ctx)
+	return locale + ":" + value.(string), nil
+}
+
+func (localeCodec) DecodeContext(ctx context.Context, dbValue interface{}) (interface{}, error) {
+	locale, _ := LocaleFromContext(ctx)
+	var s string
+	switch v := dbValue.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	}
+	return strings.TrimPrefix(s, locale+":"), nil
+}
+
+type contextRow struct {
+	ID         int `sql:"primary_key;auto_increment"`
+	GivenName  string
+	FamilyName string      `sql:"codec:locale"`
+	ScannedBy  interface{} `sql:"-"`
+}
+
+func (r *contextRow) AfterScan(ctx context.Context) error {
+	r.ScannedBy, _ = UserFromContext(ctx)
+	return nil
+}
+
+type contextSink struct {
+	users []interface{}
+}
+
+func (s *contextSink) CommandExecuted(event *CommandEvent) {
+	user, _ := UserFromContext(event.Context)
+	s.users = append(s.users, user)
+}
+
+func TestForContext(t *testing.T) {
+	assert := assert.New(t)
+	RegisterCodec("locale", localeCodec{})
+	sink := &contextSink{}
+	SetMetricsSink(sink)
+	defer SetMetricsSink(nil)
+
+	ctx := ContextWithLocale(ContextWithUser(context.Background(), "alice"), "en-AU")
+	user, ok := UserFromContext(ctx)
+	assert.True(ok)
+	assert.Equal("alice", user)
+	locale, ok := LocaleFromContext(ctx)
+	assert.True(ok)
+	assert.Equal("en-AU", locale)
+	_, ok = UserFromContext(context.Background())
+	assert.False(ok)
+
+	db := createDatabase(t, "")
+	cdb := ForContext(ctx, db)
+	assert.Equal(ctx, cdb.Context())
+
+	tbl := Table("users", contextRow{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(cdb, &contextRow{GivenName: "John", FamilyName: "Citizen"}))
+
+	var stored string
+	assert.NoError(db.Get(&stored, "select family_name from users"))
+	assert.Equal("en-AU:Citizen", stored)
+
+	sel := Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName)
+	var rows []*contextRow
+	assert.NoError(sel.Select(cdb, &rows))
+	if assert.Equal(1, len(rows)) {
+		assert.Equal("Citizen", rows[0].FamilyName)
+		assert.Equal("alice", rows[0].ScannedBy)
+	}
+	var row contextRow
+	assert.NoError(sel.QueryRow(cdb).StructScan(&row))
+	assert.Equal("Citizen", row.FamilyName)
+	assert.Equal("alice", row.ScannedBy)
+
+	// without a context handle, codecs and hooks get the background context
+	row = contextRow{}
+	assert.NoError(sel.Get(db, &row))
+	assert.Equal("en-AU:Citizen", row.FamilyName)
+	assert.Nil(row.ScannedBy)
+
+	assert.Equal([]interface{}{"alice", "alice", "alice", nil}, sink.users)
+
+	// a canceled context cancels the command
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(sel.Select(ForContext(canceled, db), &rows))
+}
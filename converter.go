@@ -0,0 +1,106 @@
+package sqlf
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// RegisterConverter registers functions that convert values of a Go type
+// to and from the values stored in the database. Fields of the type, or
+// of a pointer to the type, are converted automatically in the same way
+// as fields with a codec (see Codec), without needing a field tag. This
+// is useful for domain types such as money amounts and identifiers, and
+// particularly for types from other packages, which cannot be given
+// Value and Scan methods:
+//
+//	sqlf.RegisterConverter(reflect.TypeOf(decimal.Decimal{}),
+//		func(v interface{}) (driver.Value, error) {
+//			return v.(decimal.Decimal).String(), nil
+//		},
+//		func(v interface{}) (interface{}, error) {
+//			return decimal.NewFromString(string(v.([]byte)))
+//		})
+//
+// The toDB function is passed a value of the type, and the fromDB function
+// is passed a non-nil value returned by the database driver. A nil database
+// value sets a pointer field to nil, and any other field to its zero value.
+// A field with a "codec" tag uses its codec instead, and a field tagged
+// "scan:scanner" uses the Value and Scan methods of its type.
+//
+// Converters should be registered before any tables that refer to them are
+// created, typically during program initialization. RegisterConverter panics
+// if either function is nil.
+func RegisterConverter(typ reflect.Type, toDB func(value interface{}) (driver.Value, error), fromDB func(dbValue interface{}) (interface{}, error)) {
+	if toDB == nil || fromDB == nil {
+		panic(fmt.Sprintf("sqlf.RegisterConverter: nil function for type %s", typ))
+	}
+	converters.mutex.Lock()
+	defer converters.mutex.Unlock()
+	converters.m[typ] = &converter{typ: typ, toDB: toDB, fromDB: fromDB}
+}
+
+var converters = struct {
+	mutex sync.RWMutex
+	m     map[reflect.Type]*converter
+}{m: map[reflect.Type]*converter{}}
+
+// converter is a registered converter for a type.
+type converter struct {
+	typ    reflect.Type
+	toDB   func(value interface{}) (driver.Value, error)
+	fromDB func(dbValue interface{}) (interface{}, error)
+}
+
+// lookupConverter returns a codec for a field of type t using the
+// registered converter for t or, if t is a pointer, for its element
+// type. It returns nil if no converter is registered.
+func lookupConverter(t reflect.Type) Codec {
+	converters.mutex.RLock()
+	defer converters.mutex.RUnlock()
+	if c := converters.m[t]; c != nil {
+		return converterCodec{converter: c}
+	}
+	if t.Kind() == reflect.Ptr {
+		if c := converters.m[t.Elem()]; c != nil {
+			return converterCodec{converter: c, ptr: true}
+		}
+	}
+	return nil
+}
+
+// converterCodec is the codec for a field whose type
+// has a registered converter.
+type converterCodec struct {
+	*converter
+	ptr bool // field is a pointer to the type
+}
+
+func (cc converterCodec) Encode(value interface{}) (driver.Value, error) {
+	if cc.ptr {
+		v := reflect.ValueOf(value)
+		if v.IsNil() {
+			return nil, nil
+		}
+		value = v.Elem().Interface()
+	}
+	return cc.toDB(value)
+}
+
+func (cc converterCodec) Decode(dbValue interface{}) (interface{}, error) {
+	if dbValue == nil {
+		return nil, nil
+	}
+	value, err := cc.fromDB(dbValue)
+	if err != nil || !cc.ptr {
+		return value, err
+	}
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(cc.typ) {
+		return nil, fmt.Errorf("converter returned %s, expected %s", v.Type(), cc.typ)
+	}
+	ptr := reflect.New(cc.typ)
+	ptr.Elem().Set(v)
+	return ptr.Interface(), nil
+}
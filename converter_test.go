@@ -0,0 +1,74 @@
+package sqlf
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// money is a domain type stored as a string, eg "12.34"
+type money struct {
+	cents int64
+}
+
+func TestRegisterConverter(t *testing.T) {
+	assert := assert.New(t)
+	RegisterConverter(reflect.TypeOf(money{}),
+		func(v interface{}) (driver.Value, error) {
+			m := v.(money)
+			return fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100), nil
+		},
+		func(v interface{}) (interface{}, error) {
+			var s string
+			switch v := v.(type) {
+			case []byte:
+				s = string(v)
+			case string:
+				s = v
+			default:
+				return nil, fmt.Errorf("unexpected %T", v)
+			}
+			cents, err := strconv.ParseInt(strings.Replace(s, ".", "", 1), 10, 64)
+			return money{cents: cents}, err
+		})
+
+	type Row struct {
+		ID        int `sql:"primary_key;auto_increment"`
+		GivenName string
+		Balance   money
+		Credit    *money
+	}
+
+	db := createDatabase(t, "")
+	if _, err := db.Exec(`create table accounts(id integer primary key autoincrement, given_name text, balance text, credit text)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Table("accounts", Row{})
+	assert.Equal("`id`,`given_name`,`balance`,`credit`", tbl.Select.Columns.String())
+
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	args, err := ins.Args(Row{GivenName: "John", Balance: money{1234}})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"John", "12.34", nil}, args)
+	assert.NoError(ins.Exec(db, &Row{GivenName: "John", Balance: money{1234}, Credit: &money{5}}))
+	assert.NoError(ins.Exec(db, &Row{GivenName: "Jane", Balance: money{100}}))
+
+	sel := Queryf("select %s from %s order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy)
+	var rows []Row
+	assert.NoError(sel.Select(db, &rows))
+	if assert.Equal(2, len(rows)) {
+		assert.Equal(money{1234}, rows[0].Balance)
+		assert.Equal(&money{5}, rows[0].Credit)
+		assert.Equal(money{100}, rows[1].Balance)
+		assert.Nil(rows[1].Credit)
+	}
+
+	assert.Panics(func() {
+		RegisterConverter(reflect.TypeOf(money{}), nil, nil)
+	})
+}
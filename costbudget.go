@@ -0,0 +1,201 @@
+package sqlf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrQueryTooExpensive is matched by errors.Is for any QueryCostError.
+var ErrQueryTooExpensive = errors.New("sqlf: query too expensive")
+
+// CostBudget is the largest query plan estimate allowed for a command
+// that has the WithCostBudget option. A zero field is not checked.
+type CostBudget struct {
+	MaxCost float64 // Estimated cost, in the planner's units
+	MaxRows float64 // Estimated number of rows
+}
+
+// QueryCostError is the error returned when a command is not executed
+// because the database estimates that it exceeds its cost budget, see
+// WithCostBudget. It is the Err of the Error returned by the command.
+type QueryCostError struct {
+	Cost   float64    // Estimated cost
+	Rows   float64    // Estimated number of rows
+	Budget CostBudget // Budget exceeded
+}
+
+// Error implements the error interface.
+func (e *QueryCostError) Error() string {
+	return fmt.Sprintf("query too expensive: estimated cost %g, rows %g, exceeds budget of cost %g, rows %g",
+		e.Cost, e.Rows, e.Budget.MaxCost, e.Budget.MaxRows)
+}
+
+// Is reports whether target is ErrQueryTooExpensive.
+func (e *QueryCostError) Is(target error) bool {
+	return target == ErrQueryTooExpensive
+}
+
+// WithCostBudget causes a command to be checked using EXPLAIN each time
+// before it is executed, and not executed if the estimated cost or number
+// of rows exceeds the budget. The command returns a *QueryCostError instead,
+// which errors.Is matches with ErrQueryTooExpensive. This protects a shared
+// database from queries that are built dynamically, such as those with
+// filters and sort orders chosen by the user, and that would scan much
+// more of a table than expected:
+//
+//	cmd := sqlf.Queryf("select %s from %s where %s order by %s",
+//		orders.Select.Columns, orders.Select.TableName, filters, sortList,
+//		sqlf.WithCostBudget(sqlf.CostBudget{MaxCost: 50000, MaxRows: 10000}))
+//	err := cmd.Select(db, &rows, filters.Args()...)
+//	if errors.Is(err, sqlf.ErrQueryTooExpensive) {
+//		// respond with status 422, and ask for a narrower search
+//	}
+//
+// The estimates are the "Total Cost" and "Plan Rows" of the top node of
+// the plan for PostgreSQL, and the "query_cost" and the largest
+// "rows_produced_per_join" of the plan for MySQL (5.7 or later). They
+// depend on the database statistics, so a budget should be chosen with
+// some margin from the plans of the production database. Other dialects,
+// including SQLite, do not estimate the cost of a plan, and commands with
+// a budget return an error.
+//
+// EXPLAIN takes a round trip to the database, and time to plan the query,
+// so the option is intended for ad-hoc and dynamic commands, rather than
+// for the fixed commands of a program, whose plans can be checked in tests
+// using the sqlftest package.
+func WithCostBudget(budget CostBudget) Option {
+	return func(o *options) {
+		o.costBudget = &budget
+	}
+}
+
+// checkCost returns a *QueryCostError if the plan estimates for executing
+// the query with args using db exceed the cost budget of the command.
+func (o options) checkCost(ctx context.Context, db interface{}, query string, args []interface{}) error {
+	if o.costBudget == nil {
+		return nil
+	}
+	var name string
+	if dn, ok := db.(driverNamer); ok {
+		name = dn.DriverName()
+	}
+	d := dialectForDriver(name)
+	if d == nil {
+		d = commandDialect(o.dialect)
+	}
+
+	var explain string
+	switch DialectName(d) {
+	case "postgres":
+		explain = "explain (format json) "
+	case "mysql":
+		explain = "explain format=json "
+	default:
+		return fmt.Errorf("WithCostBudget: not supported for dialect %q", DialectName(d))
+	}
+	var row *sqlx.Row
+	if qc, ok := db.(sqlx.QueryerContext); ok {
+		row = qc.QueryRowxContext(ctx, explain+query, args...)
+	} else if q, ok := db.(sqlx.Queryer); ok {
+		row = q.QueryRowx(explain+query, args...)
+	} else {
+		return fmt.Errorf("WithCostBudget: cannot explain using %T", db)
+	}
+	var plan []byte
+	if err := row.Scan(&plan); err != nil {
+		return err
+	}
+
+	var cost, rows float64
+	var err error
+	if DialectName(d) == "postgres" {
+		cost, rows, err = pgPlanEstimates(plan)
+	} else {
+		cost, rows, err = mysqlPlanEstimates(plan)
+	}
+	if err != nil {
+		return fmt.Errorf("WithCostBudget: %v", err)
+	}
+	budget := *o.costBudget
+	if (budget.MaxCost > 0 && cost > budget.MaxCost) || (budget.MaxRows > 0 && rows > budget.MaxRows) {
+		return &QueryCostError{Cost: cost, Rows: rows, Budget: budget}
+	}
+	return nil
+}
+
+// pgPlanEstimates returns the estimates of the top node of a query plan
+// from "explain (format json)" in PostgreSQL.
+func pgPlanEstimates(plan []byte) (cost, rows float64, err error) {
+	var nodes []struct {
+		Plan *struct {
+			TotalCost float64 `json:"Total Cost"`
+			PlanRows  float64 `json:"Plan Rows"`
+		}
+	}
+	if err := json.Unmarshal(plan, &nodes); err != nil {
+		return 0, 0, err
+	}
+	if len(nodes) == 0 || nodes[0].Plan == nil {
+		return 0, 0, errors.New("query plan has no estimates")
+	}
+	return nodes[0].Plan.TotalCost, nodes[0].Plan.PlanRows, nil
+}
+
+// mysqlPlanEstimates returns the estimates of a query plan from
+// "explain format=json" in MySQL, where the cost is in the cost_info
+// of the query_block, and the rows are the largest estimate of the
+// rows produced by a join in the plan.
+func mysqlPlanEstimates(plan []byte) (cost, rows float64, err error) {
+	var doc interface{}
+	if err := json.Unmarshal(plan, &doc); err != nil {
+		return 0, 0, err
+	}
+	found := false
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				switch key {
+				case "query_cost":
+					if n, ok := planNumber(value); ok && n > cost {
+						cost, found = n, true
+					}
+				case "rows_produced_per_join":
+					if n, ok := planNumber(value); ok && n > rows {
+						rows, found = n, true
+					}
+				default:
+					walk(value)
+				}
+			}
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	walk(doc)
+	if !found {
+		return 0, 0, errors.New("query plan has no estimates")
+	}
+	return cost, rows, nil
+}
+
+// planNumber returns the value of a number in a MySQL query plan,
+// which is a string for costs and a number for rows.
+func planNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
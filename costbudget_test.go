@@ -0,0 +1,65 @@
+package sqlf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCostBudget(t *testing.T) {
+	assert := assert.New(t)
+	budget := sqlf.WithCostBudget(sqlf.CostBudget{MaxCost: 1000, MaxRows: 500})
+	search := sqlf.Queryf("select id from orders where customer like ?", budget)
+
+	rec := sqlftest.NewWithDriverName("postgres")
+	rec.Expect("explain (format json) select id from orders where customer like $1").
+		WillReturnRows([]string{"QUERY PLAN"}, []interface{}{`[{"Plan": {"Node Type": "Seq Scan", "Total Cost": 12.5, "Plan Rows": 20}}]`})
+	rec.Expect("select id from orders where customer like $1").
+		WillReturnRows([]string{"id"}, []interface{}{int64(1)})
+	var ids []int64
+	assert.NoError(search.Select(rec, &ids, "a%"))
+	assert.Equal([]int64{1}, ids)
+	assert.Equal([]sqlftest.Command{
+		{Query: "explain (format json) select id from orders where customer like $1", Args: []interface{}{"a%"}},
+		{Query: "select id from orders where customer like $1", Args: []interface{}{"a%"}},
+	}, rec.Commands())
+
+	rec = sqlftest.NewWithDriverName("postgres")
+	rec.Expect("explain (format json) select id from orders where customer like $1").
+		WillReturnRows([]string{"QUERY PLAN"}, []interface{}{`[{"Plan": {"Node Type": "Seq Scan", "Total Cost": 35811.2, "Plan Rows": 20}}]`})
+	err := search.Select(rec, &ids, "%a")
+	assert.True(errors.Is(err, sqlf.ErrQueryTooExpensive))
+	var costErr *sqlf.QueryCostError
+	if assert.True(errors.As(err, &costErr)) {
+		assert.Equal(35811.2, costErr.Cost)
+		assert.Equal(float64(20), costErr.Rows)
+	}
+	assert.Len(rec.Commands(), 1)
+
+	// MySQL, with too many rows
+	rec = sqlftest.NewWithDriverName("mysql")
+	rec.Expect("explain format=json select id from orders where customer like ?").
+		WillReturnRows([]string{"EXPLAIN"}, []interface{}{`{"query_block": {"select_id": 1, "cost_info": {"query_cost": "101.25"},
+			"table": {"table_name": "orders", "rows_examined_per_scan": 1000, "rows_produced_per_join": 1000,
+			"cost_info": {"read_cost": "1.25", "eval_cost": "100.00", "prefix_cost": "101.25"}}}}`})
+	err = search.QueryRow(rec, "%a").Err()
+	if assert.True(errors.As(err, &costErr)) {
+		assert.Equal(101.25, costErr.Cost)
+		assert.Equal(float64(1000), costErr.Rows)
+	}
+	assert.Len(rec.Commands(), 1)
+
+	// SQLite does not estimate costs
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = sqlf.Execf("create table orders(id integer primary key, customer text)", budget).Exec(db)
+	assert.Error(err)
+	assert.False(errors.Is(err, sqlf.ErrQueryTooExpensive))
+}
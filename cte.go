@@ -0,0 +1,108 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CTE is a WITH clause containing one or more common table expressions,
+// which are named queries that can be referred to in a command. It is
+// created using With, and passed as an argument to Queryf or Execf:
+//
+//	recent := sqlf.With("recent", sqlf.Queryf(
+//		"select %s from %s where created_at > ?",
+//		orders.Select.Columns, orders.Select.TableName))
+//
+//	listRecent := sqlf.Queryf("%s select * from recent where customer_id = ?", recent)
+//
+//	err := listRecent.Select(db, &rows, since, customerID)
+//
+// The placeholders of the queries are renumbered to suit their position in
+// the command, and their arguments are passed to the command in the order
+// in which they appear: the arguments of the common table expressions come
+// before the arguments of the rest of the command.
+//
+// A recursive query, which refers to its own name, requires a recursive
+// WITH clause (see CTE.Recursive):
+//
+//	tree := sqlf.With("tree(id, parent_id)", sqlf.Queryf(
+//		`select id, parent_id from categories where id = ?
+//		 union all
+//		 select c.id, c.parent_id from categories c join tree t on c.parent_id = t.id`,
+//	)).Recursive()
+//
+//	subtree := sqlf.Queryf("%s select id from tree", tree)
+//
+// A CTE is immutable, and the With and Recursive methods return a new CTE.
+type CTE struct {
+	recursive bool
+	ctes      []cte
+}
+
+// cte is a single common table expression.
+type cte struct {
+	name  string
+	query *subquery
+}
+
+// With returns a WITH clause for a common table expression with the name,
+// which can include a list of column names, eg "tree(id, parent_id)". The
+// name is not quoted. The query must be built using Queryf. With panics if
+// it is not, in the same way that Table panics for an invalid row type.
+func With(name string, query QueryCommand) *CTE {
+	return (&CTE{}).With(name, query)
+}
+
+// With returns a copy of the WITH clause with another common table
+// expression, which can refer to the common table expressions before it.
+func (c *CTE) With(name string, query QueryCommand) *CTE {
+	qc, ok := query.(*queryCommand)
+	if !ok {
+		panic(fmt.Sprintf("sqlf.With: expected query built using Queryf, got %T", query))
+	}
+	c2 := &CTE{recursive: c.recursive}
+	c2.ctes = append(append(c2.ctes, c.ctes...), cte{
+		name:  name,
+		query: &subquery{format: qc.format, args: qc.args},
+	})
+	return c2
+}
+
+// Recursive returns a copy of the WITH clause that is recursive, so that
+// its common table expressions can refer to themselves.
+func (c *CTE) Recursive() *CTE {
+	return &CTE{recursive: true, ctes: c.ctes}
+}
+
+// clone returns a copy of the WITH clause for a command, with the
+// arguments of each query cloned using cloneArgsFor.
+func (c *CTE) clone(clone func(args []interface{}) []interface{}) *CTE {
+	c2 := &CTE{recursive: c.recursive}
+	for _, e := range c.ctes {
+		c2.ctes = append(c2.ctes, cte{
+			name:  e.name,
+			query: &subquery{format: e.query.format, args: clone(e.query.args)},
+		})
+	}
+	return c2
+}
+
+// String renders the WITH clause, so that it can be formatted
+// using "%s" in the enclosing command.
+func (c *CTE) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("with ")
+	if c.recursive {
+		buf.WriteString("recursive ")
+	}
+	for i, e := range c.ctes {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(e.name)
+		buf.WriteString(" as (")
+		buf.WriteString(e.query.String())
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
@@ -0,0 +1,73 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWith(t *testing.T) {
+	assert := assert.New(t)
+
+	tbl := Row1Table.WithDialect(sqlf.DialectPG)
+	named := sqlf.With("named", sqlf.Queryf("select %s from %s where %s = %s",
+		tbl.Select.Columns.Include("Id"), tbl.Select.TableName,
+		tbl.Select.Columns.Include("FamilyName"), tbl.Select.Placeholder()))
+	older := named.With("older", sqlf.Queryf("select id from named where id > %s", tbl.Select.Placeholder()))
+	cmd := sqlf.Queryf("%s select %s from %s where id in (select id from older) and %s = %s",
+		older, tbl.Select.Columns.Include("Id"), tbl.Select.TableName,
+		tbl.Select.Columns.Include("GivenName"), tbl.Select.Placeholder())
+	assert.Equal(`with named as (select "id" from "table1" where "family_name" = $1), `+
+		`older as (select id from named where id > $2) `+
+		`select "id" from "table1" where id in (select id from older) and "given_name" = $3`, cmd.Command())
+
+	// the CTE is not modified by the command, and With returns a copy
+	assert.Equal(`with named as (select "id" from "table1" where "family_name" = $1)`, named.String())
+
+	assert.Panics(func() {
+		sqlf.With("bad", nil)
+	})
+}
+
+func TestWithRecursive(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table categories(id integer primary key, parent_id integer, name text);
+		insert into categories values(1, null, 'root'), (2, 1, 'a'), (3, 2, 'b'), (4, 1, 'c'), (5, null, 'other')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Category struct {
+		ID       int64
+		ParentID *int64
+		Name     string
+	}
+	tbl := sqlf.Table("categories", Category{}).WithDialect(sqlf.DialectSQLite)
+	tree := sqlf.With("tree(id)", sqlf.Queryf(
+		"select id from %s where id = ? union all select c.id from %s c join tree t on c.parent_id = t.id",
+		tbl.Select.TableName, tbl.Select.TableName)).Recursive()
+	subtree := sqlf.Queryf("%s select %s from %s where id in (select id from tree) and name <> ? order by %s",
+		tree, tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy)
+	assert.Equal("with recursive tree(id) as (select id from `categories` where id = ? union all "+
+		"select c.id from `categories` c join tree t on c.parent_id = t.id) "+
+		"select `id`,`parent_id`,`name` from `categories` where id in (select id from tree) and name <> ? order by `id`",
+		subtree.Command())
+
+	var rows []Category
+	assert.NoError(subtree.Select(db, &rows, 2, "x"))
+	var names []string
+	for _, row := range rows {
+		names = append(names, row.Name)
+	}
+	assert.Equal([]string{"a", "b"}, names)
+
+	rows = nil
+	assert.NoError(subtree.Select(db, &rows, 1, "c"))
+	assert.Len(rows, 3)
+}
@@ -0,0 +1,217 @@
+package sqlf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// cursorCount is used to give each cursor a unique name.
+var cursorCount int64
+
+// Cursor iterates through the rows of a query using a server-side cursor.
+// It is returned by QueryCommand.Cursor. A cursor is used in the same way
+// as *sql.Rows:
+//
+//	cursor, err := cmd.Cursor(db, 1000, args...)
+//	if err != nil {
+//		return err
+//	}
+//	defer cursor.Close()
+//	for cursor.Next() {
+//		var row Row
+//		if err := cursor.StructScan(&row); err != nil {
+//			return err
+//		}
+//		// ... process row
+//	}
+//	return cursor.Close()
+//
+// Cursor implements the Rows interface.
+type Cursor struct {
+	cmd       *queryCommand
+	db        sqlx.Ext
+	ctx       context.Context // passed to codecs and AfterScan
+	tx        *sqlx.Tx        // transaction started by the cursor, if any
+	name      string
+	batchSize int
+	rows      *sqlx.Rows
+	count     int // number of rows read from the current batch
+	done      bool
+	closed    bool
+	err       error
+
+	// traversals for the most recent StructScan destination type
+	scanType   reflect.Type
+	traversals []traversal
+}
+
+// txBeginner is implemented by *sqlx.DB.
+type txBeginner interface {
+	Beginx() (*sqlx.Tx, error)
+}
+
+func (cmd *queryCommand) Cursor(db sqlx.Ext, batchSize int, args ...interface{}) (*Cursor, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("Cursor: invalid batch size %d", batchSize)
+	}
+	ctx := contextOf(db)
+	if err := cmd.opts.checkArgs(args); err != nil {
+		return nil, cmd.opts.wrapError(OpCursor, cmd.command, args, err)
+	}
+	args, err := cmd.opts.bindArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	scoped, args, err := cmd.opts.bindTenant(db, args)
+	if err != nil {
+		return nil, err
+	}
+	db = scoped.(sqlx.Ext)
+	if d := dialectForDriver(db.DriverName()); d == nil || DialectName(d) != "postgres" {
+		return nil, fmt.Errorf("Cursor: server-side cursors are not supported for driver %s", db.DriverName())
+	}
+	c := &Cursor{
+		cmd:       cmd,
+		db:        db,
+		ctx:       ctx,
+		name:      fmt.Sprintf("sqlf_cursor_%d", atomic.AddInt64(&cursorCount, 1)),
+		batchSize: batchSize,
+	}
+	if b, ok := db.(txBeginner); ok {
+		tx, err := b.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		c.db = tx
+		c.tx = tx
+	}
+
+	cmd.opts.debugBindings(OpCursor, cmd.command, cmd.inputs, args)
+	start := time.Now()
+	query := fmt.Sprintf("declare %s no scroll cursor for %s", c.name, cmd.queryFor(db))
+	_, err = c.db.Exec(query, args...)
+	cmd.opts.report(ctx, OpCursor, cmd.command, start, -1, err)
+	if err != nil {
+		if c.tx != nil {
+			c.tx.Rollback()
+		}
+		return nil, cmd.opts.wrapError(OpCursor, cmd.command, args, err)
+	}
+	return c, nil
+}
+
+// Next prepares the next row for reading with Scan or StructScan,
+// fetching the next batch of rows from the server when required.
+// It returns false when there are no more rows, or if an error occurs.
+func (c *Cursor) Next() bool {
+	if c.err != nil || c.done || c.closed {
+		return false
+	}
+	for {
+		if c.rows != nil {
+			if c.rows.Next() {
+				c.count++
+				return true
+			}
+			if err := c.rows.Err(); err != nil {
+				c.err = err
+				return false
+			}
+			c.rows.Close()
+			c.rows = nil
+			if c.count < c.batchSize {
+				// a partial batch means there are no more rows
+				c.done = true
+				return false
+			}
+		}
+		rows, err := c.db.Queryx(fmt.Sprintf("fetch forward %d from %s", c.batchSize, c.name))
+		if err != nil {
+			c.err = err
+			return false
+		}
+		c.rows = rows
+		c.count = 0
+	}
+}
+
+// Columns returns the column names of the result set.
+func (c *Cursor) Columns() ([]string, error) {
+	if c.rows == nil {
+		return nil, errors.New("Cursor: Columns called without calling Next")
+	}
+	return c.rows.Columns()
+}
+
+// Scan copies the columns in the current row into the values pointed at by dest.
+func (c *Cursor) Scan(dest ...interface{}) error {
+	if c.rows == nil {
+		return errors.New("Cursor: Scan called without calling Next")
+	}
+	return c.rows.Scan(dest...)
+}
+
+// StructScan scans the current row into the struct pointed to by dest,
+// in the same way as QueryCommand.Select.
+func (c *Cursor) StructScan(dest interface{}) error {
+	if c.rows == nil {
+		return errors.New("Cursor: StructScan called without calling Next")
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("must pass a non-nil pointer to StructScan")
+	}
+	if v.Type() != c.scanType {
+		columns, err := c.rows.Columns()
+		if err != nil {
+			return err
+		}
+		traversals, err := c.cmd.traversals(v.Type(), columns)
+		if err != nil {
+			return err
+		}
+		c.scanType = v.Type()
+		c.traversals = traversals
+	}
+	if err := scanStruct(c.ctx, c.rows.Scan, v, c.traversals); err != nil {
+		return err
+	}
+	return afterScan(c.ctx, v)
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Close closes the cursor. If the cursor started a transaction, the
+// transaction is committed, or rolled back if an error has occurred.
+// Close returns any error encountered during iteration. It is safe to
+// call Close more than once.
+func (c *Cursor) Close() error {
+	if c.closed {
+		return c.err
+	}
+	c.closed = true
+	if c.rows != nil {
+		c.rows.Close()
+		c.rows = nil
+	}
+	if _, err := c.db.Exec("close " + c.name); err != nil && c.err == nil {
+		c.err = err
+	}
+	if c.tx != nil {
+		if c.err != nil {
+			c.tx.Rollback()
+		} else if err := c.tx.Commit(); err != nil {
+			c.err = err
+		}
+	}
+	return c.err
+}
@@ -0,0 +1,173 @@
+package sqlf
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// cursorDriver is a fake database driver that understands just enough
+// of the PostgreSQL cursor statements to test Cursor.
+type cursorDriver struct {
+	mutex sync.Mutex
+	log   []string
+	rows  [][]driver.Value
+	pos   int
+}
+
+func (d *cursorDriver) Open(name string) (driver.Conn, error) {
+	return &cursorConn{d: d}, nil
+}
+
+func (d *cursorDriver) record(s string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.log = append(d.log, s)
+}
+
+type cursorConn struct {
+	d *cursorDriver
+}
+
+func (c *cursorConn) Prepare(query string) (driver.Stmt, error) {
+	return &cursorStmt{d: c.d, query: query}, nil
+}
+
+func (c *cursorConn) Close() error { return nil }
+
+func (c *cursorConn) Begin() (driver.Tx, error) {
+	c.d.record("begin")
+	return &cursorTx{d: c.d}, nil
+}
+
+type cursorTx struct {
+	d *cursorDriver
+}
+
+func (tx *cursorTx) Commit() error {
+	tx.d.record("commit")
+	return nil
+}
+
+func (tx *cursorTx) Rollback() error {
+	tx.d.record("rollback")
+	return nil
+}
+
+type cursorStmt struct {
+	d     *cursorDriver
+	query string
+}
+
+func (s *cursorStmt) Close() error  { return nil }
+func (s *cursorStmt) NumInput() int { return -1 }
+
+func (s *cursorStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(fmt.Sprint(s.query, " ", args))
+	if strings.HasPrefix(s.query, "declare") {
+		s.d.pos = 0
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *cursorStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.record(s.query)
+	var n int
+	if _, err := fmt.Sscanf(s.query, "fetch forward %d", &n); err != nil {
+		return nil, err
+	}
+	end := s.d.pos + n
+	if end > len(s.d.rows) {
+		end = len(s.d.rows)
+	}
+	rows := &cursorRows{rows: s.d.rows[s.d.pos:end]}
+	s.d.pos = end
+	return rows, nil
+}
+
+type cursorRows struct {
+	rows [][]driver.Value
+}
+
+func (r *cursorRows) Columns() []string { return []string{"id", "given_name", "family_name"} }
+func (r *cursorRows) Close() error      { return nil }
+
+func (r *cursorRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var fakeCursorDriver = &cursorDriver{}
+
+func init() {
+	sql.Register("sqlfcursortest", fakeCursorDriver)
+}
+
+func TestCursor(t *testing.T) {
+	assert := assert.New(t)
+	fakeCursorDriver.rows = nil
+	for i := 1; i <= 5; i++ {
+		fakeCursorDriver.rows = append(fakeCursorDriver.rows,
+			[]driver.Value{int64(i), fmt.Sprintf("Given%d", i), "Family"})
+	}
+	fakeCursorDriver.log = nil
+
+	sqldb, err := sql.Open("sqlfcursortest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sqlx.NewDb(sqldb, "postgres")
+	defer db.Close()
+
+	tbl := Table("users", User{}).WithDialect(DialectPG)
+	cmd := Queryf("select %s from %s where family_name = %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder())
+
+	cursor, err := cmd.Cursor(db, 2, "Family")
+	if !assert.NoError(err) {
+		return
+	}
+	var users []User
+	for cursor.Next() {
+		var u User
+		if !assert.NoError(cursor.StructScan(&u)) {
+			break
+		}
+		users = append(users, u)
+	}
+	assert.NoError(cursor.Err())
+	assert.NoError(cursor.Close())
+	assert.NoError(cursor.Close())
+
+	assert.Len(users, 5)
+	assert.Equal(User{ID: 5, GivenName: "Given5", FamilyName: "Family"}, users[4])
+	name := cursor.name
+	assert.Equal([]string{
+		"begin",
+		`declare ` + name + ` no scroll cursor for select "id","given_name","family_name" from "users" where family_name = $1 [Family]`,
+		"fetch forward 2 from " + name,
+		"fetch forward 2 from " + name,
+		"fetch forward 2 from " + name,
+		"close " + name + " []",
+		"commit",
+	}, fakeCursorDriver.log)
+
+	// only supported for PostgreSQL
+	sqlite := createDatabase(t, "")
+	defer sqlite.Close()
+	_, err = cmd.Cursor(sqlite, 2)
+	assert.EqualError(err, "Cursor: server-side cursors are not supported for driver sqlite3")
+	_, err = cmd.Cursor(db, 0)
+	assert.Error(err)
+}
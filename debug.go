@@ -0,0 +1,146 @@
+package sqlf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"text/tabwriter"
+)
+
+// DebugEnv is the environment variable that turns on argument binding
+// diagnostics for all commands. For example:
+//
+//	SQLF_DEBUG=1 go test ./...
+//
+// See WithDebug for a description of the diagnostics.
+const DebugEnv = "SQLF_DEBUG"
+
+var debugOutput = struct {
+	mutex sync.Mutex
+	w     io.Writer
+	all   bool
+}{
+	w:   os.Stderr,
+	all: os.Getenv(DebugEnv) != "",
+}
+
+// WithDebug turns on argument binding diagnostics for a command. Each
+// time the command is executed, a table is written to the debug output
+// (see SetDebugOutput) showing the position of each placeholder, and the
+// column, field and type of the argument bound to it:
+//
+//	sqlf: updaterow: update users set email=?,name=? where id=?
+//	  pos  column  field       type
+//	  1    email   User.Email  string
+//	  2    name    User.Name   string
+//	  3    id      User.ID     int64
+//
+// Diagnostics can be turned on for all commands by setting the SQLF_DEBUG
+// environment variable.
+func WithDebug() Option {
+	return func(o *options) {
+		o.debug = true
+	}
+}
+
+// SetDebugOutput sets the writer that receives argument binding
+// diagnostics. The default is os.Stderr.
+func SetDebugOutput(w io.Writer) {
+	debugOutput.mutex.Lock()
+	defer debugOutput.mutex.Unlock()
+	debugOutput.w = w
+}
+
+// debugBindings writes the argument binding diagnostics for the command,
+// if they are turned on. The inputs are either []positioner or
+// []*columnInfo, in placeholder order.
+func (o options) debugBindings(op string, command string, inputs interface{}, args []interface{}) {
+	debugOutput.mutex.Lock()
+	defer debugOutput.mutex.Unlock()
+	if !o.debug && !debugOutput.all || debugOutput.w == nil {
+		return
+	}
+
+	var columns, fields []string
+	switch inputs := inputs.(type) {
+	case []*columnInfo:
+		for _, ci := range inputs {
+			label := columnLabel(ci)
+			columns = append(columns, label.column)
+			fields = append(fields, label.field)
+		}
+	case []positioner:
+		for _, input := range inputs {
+			label := inputLabel(input)
+			columns = append(columns, label.column)
+			fields = append(fields, label.field)
+		}
+	}
+
+	name := op
+	if o.name != "" {
+		name += " " + o.name
+	}
+	fmt.Fprintf(debugOutput.w, "sqlf: %s: %s\n", name, command)
+	tw := tabwriter.NewWriter(debugOutput.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  pos\tcolumn\tfield\ttype")
+	n := len(args)
+	if len(columns) > n {
+		n = len(columns)
+	}
+	for i := 0; i < n; i++ {
+		column, field, typ := "-", "-", "(missing)"
+		if i < len(columns) {
+			column, field = columns[i], fields[i]
+		}
+		if i < len(args) {
+			typ = argType(args[i])
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", i+1, column, field, typ)
+	}
+	tw.Flush()
+}
+
+// argLabel describes the column and field of the arg
+// bound to a placeholder, "-" if not known.
+type argLabel struct {
+	column string
+	field  string
+}
+
+// columnLabel returns the label for the arg bound to the column.
+func columnLabel(ci *columnInfo) argLabel {
+	return argLabel{column: ci.columnName, field: ci.table.rowType.Name() + "." + ci.fieldName}
+}
+
+// inputLabel returns the label for the arg bound to the input.
+func inputLabel(input positioner) argLabel {
+	switch input := input.(type) {
+	case *columnInfo:
+		return columnLabel(input)
+	case *filterCond:
+		return argLabel{column: input.column, field: "-"}
+	case *tenantInput:
+		return argLabel{column: "(tenant)", field: "-"}
+	case *fragmentInput:
+		column := input.frag.columns[input.index]
+		if column == "" {
+			column = "-"
+		}
+		return argLabel{column: column, field: "-"}
+	}
+	return argLabel{column: "-", field: "-"}
+}
+
+// argType returns the name of the type of an argument.
+func argType(arg interface{}) string {
+	if arg == nil {
+		return "nil"
+	}
+	if av, ok := arg.(arrayValue); ok {
+		arg = av.value
+	}
+	return reflect.TypeOf(arg).String()
+}
@@ -0,0 +1,54 @@
+package sqlf_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDebug(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	sqlf.SetDebugOutput(&buf)
+	defer sqlf.SetDebugOutput(os.Stderr)
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+
+	// commands without the option do not write diagnostics
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "John"}))
+	assert.Equal("", buf.String())
+
+	upd := sqlf.UpdateRowf("update %s set %s where %s",
+		tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns,
+		sqlf.WithDebug(), sqlf.WithName("UpdateRow1"))
+	_, err = upd.Exec(db, &Row1{Id: 1, GivenName: "Jane"})
+	assert.NoError(err)
+	assert.Equal("sqlf: updaterow UpdateRow1: update `table1` set `given_name`=?,`family_name`=?,`Date_of_Birth`=? where `id`=?\n"+
+		"  pos  column         field            type\n"+
+		"  1    given_name     Row1.GivenName   string\n"+
+		"  2    family_name    Row1.FamilyName  string\n"+
+		"  3    Date_of_Birth  Row1.DOB         time.Time\n"+
+		"  4    id             Row1.Id          int64\n", buf.String())
+
+	buf.Reset()
+	sel := sqlf.Queryf("select %s from %s where family_name = %s and id > ?",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder(), sqlf.WithDebug())
+	var rows []Row1
+	assert.NoError(sel.Select(db, &rows, "", 0, "extra"))
+	assert.Contains(buf.String(), "  1    -       -      string\n")
+	assert.Contains(buf.String(), "  2    -       -      int\n")
+	assert.Contains(buf.String(), "  3    -       -      string\n")
+}
@@ -0,0 +1,148 @@
+package sqlf
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decimal is implemented by pointers to decimal number types that can be
+// stored in a numeric or decimal column without loss of precision, such as
+// *decimal.Decimal in the github.com/shopspring/decimal package. String
+// returns the number in decimal notation, eg "-12.345", and UnmarshalText
+// parses it.
+//
+// A field whose type (or a pointer to it) implements Decimal, including
+// big.Rat, is stored as a decimal number using the "decimal" field tag:
+//
+//	type Invoice struct {
+//		ID     int64
+//		Amount decimal.Decimal `sql:"decimal:2"`
+//		Rate   *big.Rat        `sql:"decimal"`
+//	}
+//
+// The value is passed to the database driver as a string, and is scanned
+// from the text of the column, so that money amounts do not round-trip
+// through float64. (SQLite stores decimal values as REAL unless the column
+// is declared with TEXT affinity, so the value read back is exact only for
+// a TEXT column.) A field with a pointer type is NULL when it is nil.
+//
+// The tag can specify the scale of the column, which is the number of
+// digits after the decimal point. A value with more digits is not rounded:
+// the command returns an error instead. A big.Rat value that does not have
+// an exact decimal representation, such as 1/3, requires a scale, and is
+// rounded to it.
+type Decimal interface {
+	String() string
+	UnmarshalText(text []byte) error
+}
+
+var decimalType = reflect.TypeOf((*Decimal)(nil)).Elem()
+
+// decimalCodec is the codec for a field with the "decimal" tag.
+type decimalCodec struct {
+	fieldType reflect.Type // type of the field
+	baseType  reflect.Type // fieldType, or the type it points to
+	scale     int          // -1 if not specified
+}
+
+// fieldDecimal returns the codec for a field with the "decimal" tag.
+// It panics if the field's type is not a decimal type, or the tag is invalid.
+func fieldDecimal(field reflect.StructField, value string) Codec {
+	codec := &decimalCodec{fieldType: field.Type, baseType: field.Type, scale: -1}
+	if codec.baseType.Kind() == reflect.Ptr {
+		codec.baseType = codec.baseType.Elem()
+	}
+	if !reflect.PtrTo(codec.baseType).Implements(decimalType) {
+		panic(fmt.Sprintf("sqlf.Table: field %s: decimal tag requires %s to implement sqlf.Decimal", field.Name, field.Type))
+	}
+	if value = strings.TrimSpace(value); value != "" && value != "DECIMAL" {
+		scale, err := strconv.Atoi(value)
+		if err != nil || scale < 0 {
+			panic(fmt.Sprintf("sqlf.Table: field %s: invalid decimal scale %q", field.Name, value))
+		}
+		codec.scale = scale
+	}
+	return codec
+}
+
+func (c *decimalCodec) Encode(value interface{}) (driver.Value, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+	} else {
+		// the String method may have a pointer receiver
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+
+	var text string
+	if r, ok := v.Interface().(*big.Rat); ok {
+		text = c.ratString(r)
+		if text == "" {
+			return nil, fmt.Errorf("%s has no exact decimal representation", r.RatString())
+		}
+	} else {
+		text = v.Interface().(Decimal).String()
+	}
+	if c.scale >= 0 {
+		if i := strings.IndexByte(text, '.'); i >= 0 && len(strings.TrimRight(text[i+1:], "0")) > c.scale {
+			return nil, fmt.Errorf("%s has more than %d decimal places", text, c.scale)
+		}
+	}
+	return text, nil
+}
+
+// ratString returns r in decimal notation, rounded to the scale if
+// there is one, or "" if r has no exact decimal representation.
+func (c *decimalCodec) ratString(r *big.Rat) string {
+	if c.scale >= 0 {
+		return r.FloatString(c.scale)
+	}
+	// a decimal representation with n digits after the point
+	// is exact if r * 10^n is an integer, and n is at most the
+	// number of bits in the denominator
+	x := new(big.Rat).Set(r)
+	ten := big.NewRat(10, 1)
+	for n := 0; n <= r.Denom().BitLen(); n++ {
+		if x.IsInt() {
+			return r.FloatString(n)
+		}
+		x.Mul(x, ten)
+	}
+	return ""
+}
+
+func (c *decimalCodec) Decode(dbValue interface{}) (interface{}, error) {
+	var text string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		// the shortest text that reads back as the same float64,
+		// which is the text written for a REAL column in SQLite
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a decimal", dbValue)
+	}
+	ptr := reflect.New(c.baseType)
+	if err := ptr.Interface().(Decimal).UnmarshalText([]byte(text)); err != nil {
+		return nil, err
+	}
+	if c.fieldType.Kind() == reflect.Ptr {
+		return ptr.Interface(), nil
+	}
+	return ptr.Elem().Interface(), nil
+}
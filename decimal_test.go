@@ -0,0 +1,107 @@
+package sqlf_test
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// decimalText is a decimal type with a pointer receiver for UnmarshalText,
+// in the same way as decimal.Decimal in github.com/shopspring/decimal.
+type decimalText struct {
+	text string
+}
+
+func (a decimalText) String() string {
+	if a.text == "" {
+		return "0"
+	}
+	return a.text
+}
+
+func (a *decimalText) UnmarshalText(text []byte) error {
+	if _, err := strconv.ParseFloat(string(text), 64); err != nil {
+		return err
+	}
+	a.text = string(text)
+	return nil
+}
+
+func TestDecimal(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table invoices(id integer primary key, amount text, rate text, fee numeric)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Invoice struct {
+		ID     int64       `sql:"primary_key;auto_increment"`
+		Amount decimalText `sql:"decimal:2"`
+		Rate   *big.Rat    `sql:"decimal"`
+		Fee    big.Rat     `sql:"decimal:4"`
+	}
+	invoices := sqlf.Table("invoices", Invoice{}).WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		invoices.Insert.TableName, invoices.Insert.Columns, invoices.Insert.Values)
+	get := sqlf.Queryf("select %s from %s where id = ?", invoices.Select.Columns, invoices.Select.TableName)
+
+	// more digits than a float64 can hold
+	inv := Invoice{Amount: decimalText{"12345678901234567.89"}, Rate: big.NewRat(1, 8)}
+	inv.Fee.SetFrac64(5, 2)
+	assert.NoError(insert.Exec(db, &inv))
+	args, err := insert.Args(&inv)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"12345678901234567.89", "0.125", "2.5000"}, args)
+
+	var got Invoice
+	assert.NoError(get.Get(db, &got, inv.ID))
+	assert.Equal("12345678901234567.89", got.Amount.String())
+	if assert.NotNil(got.Rate) {
+		assert.Equal("1/8", got.Rate.RatString())
+	}
+	assert.Equal("5/2", got.Fee.RatString())
+
+	// NULL for a nil pointer
+	inv = Invoice{Amount: decimalText{"1"}}
+	assert.NoError(insert.Exec(db, &inv))
+	got = Invoice{Rate: big.NewRat(1, 1)}
+	assert.NoError(get.Get(db, &got, inv.ID))
+	assert.Nil(got.Rate)
+
+	// values are not rounded
+	inv = Invoice{Amount: decimalText{"1.005"}}
+	assert.Error(insert.Exec(db, &inv))
+	inv = Invoice{Amount: decimalText{"1.50"}, Rate: big.NewRat(1, 3)}
+	assert.Error(insert.Exec(db, &inv))
+
+	// except for a big.Rat with a scale
+	inv = Invoice{Amount: decimalText{"1"}}
+	inv.Fee.SetFrac64(2, 3)
+	args, err = insert.Args(&inv)
+	assert.NoError(err)
+	assert.Equal("0.6667", args[2])
+
+	assert.Panics(func() {
+		sqlf.Table("invoices", struct {
+			Amount float64 `sql:"decimal"`
+		}{})
+	})
+	assert.Panics(func() {
+		sqlf.Table("invoices", struct {
+			Amount decimalText `sql:"decimal:x"`
+		}{})
+	})
+	assert.Panics(func() {
+		sqlf.Table("invoices", struct {
+			Amount decimalText `sql:"decimal;codec:boolint"`
+		}{})
+	})
+}
@@ -41,10 +41,15 @@ func placeholderFunc(format string) func(n int) string {
 }
 
 type dialect struct {
+	driverName      string
 	quoteFunc       func(name string) string
 	placeholderFunc func(n int) string
 }
 
+func (d dialect) name() string {
+	return d.driverName
+}
+
 func (d dialect) Quote(name string) string {
 	return d.quoteFunc(name)
 }
@@ -62,22 +67,55 @@ func (d dialect) Placeholder(n int) string {
 // SQL drivers. For programs where only one database driver is loaded,
 // this is a pretty good guess. If multiple drivers are loaded, then
 // the program should set DefaultDialect explicitly.
+//
+// When a command is executed using a database handle that reports its
+// driver name (eg *sqlx.DB, *sqlx.Tx), and the driver is for a different
+// dialect, the placeholders and quoted identifiers in the command are
+// translated to suit the driver. The translation is cached with the command.
 var (
 	DefaultDialect Dialect // Default dialect
 	DialectMySQL   Dialect // MySQL dialect
 	DialectMSSQL   Dialect // Microsoft SQL Server dialect
 	DialectPG      Dialect // PostgreSQL
 	DialectSQLite  Dialect
+
+	// SQL Server dialect for the "sqlserver" driver, which
+	// requires numbered placeholders (eg "@p1").
+	dialectSQLServer Dialect
 )
 
 func init() {
-	DialectMySQL = dialect{quoteFunc: quoteFunc("`", "`")}
-	DialectSQLite = dialect{quoteFunc: quoteFunc("`", "`")}
-	DialectMSSQL = dialect{quoteFunc: quoteFunc("[", "]")}
+	DialectMySQL = dialect{driverName: "mysql", quoteFunc: quoteFunc("`", "`")}
+	DialectSQLite = dialect{driverName: "sqlite3", quoteFunc: quoteFunc("`", "`")}
+	DialectMSSQL = dialect{driverName: "mssql", quoteFunc: quoteFunc("[", "]")}
 	DialectPG = dialect{
+		driverName:      "postgres",
 		quoteFunc:       quoteFunc("\"", "\""),
 		placeholderFunc: placeholderFunc("$%d"),
 	}
+	dialectSQLServer = dialect{
+		driverName:      "sqlserver",
+		quoteFunc:       quoteFunc("[", "]"),
+		placeholderFunc: placeholderFunc("@p%d"),
+	}
+}
+
+// namedDialect is implemented by the dialects provided by this package.
+type namedDialect interface {
+	name() string
+}
+
+// DialectName returns the name of the dialect, which is the same as the
+// name of the database driver for the dialect (eg "postgres", "mysql").
+// It returns an empty string if d is nil, or if d is not one of the
+// dialects provided by this package (or derived from one, see
+// QuoteIfNeeded). Dialect-specific SQL falls back to standard SQL for
+// dialects without a name.
+func DialectName(d Dialect) string {
+	if nd, ok := d.(namedDialect); ok {
+		return nd.name()
+	}
+	return ""
 }
 
 func defaultDialect() Dialect {
@@ -85,15 +123,50 @@ func defaultDialect() Dialect {
 		return DefaultDialect
 	}
 	for _, d := range sql.Drivers() {
-		if strings.Contains(strings.ToLower(d), "mysql") {
-			return DialectMySQL
-		} else if d == "mssql" {
-			return DialectMSSQL
-		} else if d == "sqlite3" {
-			return DialectSQLite
-		} else if d == "postgres" {
-			return DialectPG
+		if dialect := dialectForDriver(d); dialect != nil {
+			return dialect
 		}
 	}
 	panic("Cannot determine default dialect. Set DefaultDialect")
 }
+
+// MaxParams returns the maximum number of bind parameters that the
+// database server for the dialect accepts in a single statement:
+//
+//	PostgreSQL  65535
+//	MySQL       65535
+//	SQL Server  2100
+//	SQLite      999
+//
+// SQLite 3.32 and later accept 32766 parameters, but earlier versions,
+// and builds with a different SQLITE_MAX_VARIABLE_NUMBER, accept fewer,
+// so the conservative limit is used. For other dialects the limit is 999.
+//
+// LoadRelated, which expands a list of key values into parameters, splits
+// its query into several statements so that each statement has fewer
+// parameters than the limit. It is the only function in this package that
+// does so. ExecBatch and ExecChunks bind one arg set per statement, and
+// do not build multi-row statements. Programs that build statements with
+// a parameter for each of many values, such as a multi-row insert, should
+// use MaxParams to decide how many values to include in each statement.
+func MaxParams(d Dialect) int {
+	switch DialectName(d) {
+	case "postgres", "mysql":
+		return 65535
+	case "mssql", "sqlserver":
+		return 2100
+	}
+	return 999
+}
+
+// maxParamsFor returns the maximum number of bind parameters for a
+// statement executed using db, see MaxParams. The dialect is d, unless
+// db reports the name of a driver for a different dialect.
+func maxParamsFor(db interface{}, d Dialect) int {
+	if dn, ok := unwrapHandle(db).(driverNamer); ok {
+		if dd := dialectForDriver(dn.DriverName()); dd != nil {
+			d = dd
+		}
+	}
+	return MaxParams(d)
+}
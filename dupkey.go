@@ -0,0 +1,246 @@
+package sqlf
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrDuplicateKey is matched by errors.Is for any DuplicateKeyError.
+var ErrDuplicateKey = errors.New("sqlf: duplicate key")
+
+// DuplicateKeyError is the error returned when a command fails because it
+// would violate a primary key or unique constraint. It is the Err of the
+// Error returned by the command, and it wraps the database driver's error.
+// Use errors.As to access it:
+//
+//	err := insertUser.Exec(db, &user)
+//	var dupErr *sqlf.DuplicateKeyError
+//	if errors.As(err, &dupErr) {
+//		// dupErr.Constraint is the name of the constraint violated
+//	}
+//
+// Duplicate key errors are recognized for the PostgreSQL (lib/pq and pgx),
+// MySQL, SQLite and SQL Server drivers. Not all drivers report all of the
+// details: the constraint name and columns are set where they can be
+// determined from the driver's error, or from the constraints registered
+// using RegisterConstraint.
+type DuplicateKeyError struct {
+	Table      string   // Table name, if known
+	Constraint string   // Name of the constraint or unique index, if known
+	Columns    []string // Column names, if known
+	Err        error    // Database driver error
+}
+
+// Error implements the error interface.
+func (e *DuplicateKeyError) Error() string {
+	msg := "duplicate key"
+	if e.Constraint != "" {
+		msg += " violates " + e.Constraint
+	}
+	if len(e.Columns) > 0 {
+		msg += " (" + strings.Join(e.Columns, ",") + ")"
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Unwrap returns the database driver error.
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrDuplicateKey.
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+type uniqueConstraint struct {
+	table   string
+	columns []string
+}
+
+var uniqueConstraints = struct {
+	mutex sync.RWMutex
+	m     map[string]uniqueConstraint
+}{m: make(map[string]uniqueConstraint)}
+
+// RegisterConstraint registers the name of a primary key, unique constraint
+// or unique index of the table, along with its columns. When a command fails
+// because it would violate the constraint, the DuplicateKeyError returned
+// includes the table and columns. For example:
+//
+//	func init() {
+//		sqlf.RegisterConstraint(users, "users_email_key", "email")
+//	}
+//
+// RegisterConstraint panics if the table does not have one of the columns,
+// in the same way that Table panics for an invalid row type.
+func RegisterConstraint(table *TableInfo, name string, columns ...string) {
+	for _, column := range columns {
+		table.columnByName("RegisterConstraint", column)
+	}
+	uniqueConstraints.mutex.Lock()
+	defer uniqueConstraints.mutex.Unlock()
+	uniqueConstraints.m[name] = uniqueConstraint{
+		table:   table.Name,
+		columns: append([]string(nil), columns...),
+	}
+}
+
+var (
+	pgDetailRE    = regexp.MustCompile(`^Key \((.*)\)=`)
+	mysqlKeyRE    = regexp.MustCompile(`for key '([^']*)'`)
+	mssqlKeyRE    = regexp.MustCompile(`(?:constraint|unique index) '([^']*)'`)
+	mssqlObjectRE = regexp.MustCompile(`in object '([^']*)'`)
+	sqliteRE      = regexp.MustCompile(`(?:UNIQUE|PRIMARY KEY) constraint failed: (.*)$`)
+)
+
+// duplicateKeyError returns a DuplicateKeyError if err is a database
+// driver error for a duplicate key, or nil otherwise. The driver errors
+// are recognized by their fields, so that this package does not depend
+// on any of the drivers.
+func duplicateKeyError(err error) *DuplicateKeyError {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if dup := driverDuplicateKey(e); dup != nil {
+			dup.Err = err
+			if c, ok := lookupConstraint(dup.Constraint); ok {
+				if dup.Table == "" {
+					dup.Table = c.table
+				}
+				if len(dup.Columns) == 0 {
+					dup.Columns = c.columns
+				}
+			}
+			return dup
+		}
+	}
+	return nil
+}
+
+func lookupConstraint(name string) (uniqueConstraint, bool) {
+	if name == "" {
+		return uniqueConstraint{}, false
+	}
+	uniqueConstraints.mutex.RLock()
+	defer uniqueConstraints.mutex.RUnlock()
+	c, ok := uniqueConstraints.m[name]
+	return c, ok
+}
+
+// driverDuplicateKey checks for a duplicate key error from each of the
+// supported drivers, identified by the fields of its error type:
+//
+//	PostgreSQL  Code "23505", ConstraintName (pgx) or Constraint (lib/pq)
+//	MySQL       Number 1062
+//	SQLite      ExtendedCode 2067 (unique) or 1555 (primary key)
+//	SQL Server  Number 2627 (constraint) or 2601 (unique index)
+func driverDuplicateKey(err error) *DuplicateKeyError {
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if code, ok := stringField(v, "Code"); ok && code == "23505" {
+		dup := &DuplicateKeyError{}
+		dup.Table, _ = stringField(v, "TableName")
+		if dup.Table == "" {
+			dup.Table, _ = stringField(v, "Table")
+		}
+		dup.Constraint, _ = stringField(v, "ConstraintName")
+		if dup.Constraint == "" {
+			dup.Constraint, _ = stringField(v, "Constraint")
+		}
+		if detail, _ := stringField(v, "Detail"); detail != "" {
+			if m := pgDetailRE.FindStringSubmatch(detail); m != nil {
+				dup.Columns = splitColumns(m[1])
+			}
+		}
+		return dup
+	}
+
+	if code, ok := intField(v, "ExtendedCode"); ok {
+		if code != 2067 && code != 1555 {
+			return nil
+		}
+		dup := &DuplicateKeyError{}
+		if m := sqliteRE.FindStringSubmatch(err.Error()); m != nil {
+			for _, column := range splitColumns(m[1]) {
+				if i := strings.LastIndex(column, "."); i >= 0 {
+					dup.Table = column[:i]
+					column = column[i+1:]
+				}
+				dup.Columns = append(dup.Columns, column)
+			}
+		}
+		return dup
+	}
+
+	if number, ok := intField(v, "Number"); ok {
+		message, _ := stringField(v, "Message")
+		switch number {
+		case 1062:
+			dup := &DuplicateKeyError{}
+			if m := mysqlKeyRE.FindStringSubmatch(message); m != nil {
+				// MySQL 8.0 qualifies the key with the table name
+				dup.Constraint = m[1]
+				if i := strings.LastIndex(m[1], "."); i >= 0 {
+					dup.Table, dup.Constraint = m[1][:i], m[1][i+1:]
+				}
+			}
+			return dup
+		case 2627, 2601:
+			dup := &DuplicateKeyError{}
+			if m := mssqlKeyRE.FindStringSubmatch(message); m != nil {
+				dup.Constraint = m[1]
+			}
+			if m := mssqlObjectRE.FindStringSubmatch(message); m != nil {
+				dup.Table = m[1]
+			}
+			return dup
+		}
+	}
+	return nil
+}
+
+// splitColumns splits a comma-separated list of column names.
+func splitColumns(s string) []string {
+	var columns []string
+	for _, column := range strings.Split(s, ",") {
+		columns = append(columns, strings.Trim(strings.TrimSpace(column), `"`))
+	}
+	return columns
+}
+
+// stringField returns the value of the named field of v
+// if it is a string type.
+func stringField(v reflect.Value, name string) (string, bool) {
+	f := v.FieldByName(name)
+	if !f.IsValid() || f.Kind() != reflect.String {
+		return "", false
+	}
+	return f.String(), true
+}
+
+// intField returns the value of the named field of v
+// if it is an integer type.
+func intField(v reflect.Value, name string) (int64, bool) {
+	f := v.FieldByName(name)
+	if !f.IsValid() {
+		return 0, false
+	}
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(f.Uint()), true
+	}
+	return 0, false
+}
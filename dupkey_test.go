@@ -0,0 +1,117 @@
+package sqlf
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fake driver errors, with the same fields as the real ones
+type fakePgError struct {
+	Code           string
+	Detail         string
+	TableName      string
+	ConstraintName string
+}
+
+func (e *fakePgError) Error() string { return "ERROR: duplicate key value violates unique constraint" }
+
+type fakeMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *fakeMySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+type fakeMSSQLError struct {
+	Number  int32
+	Message string
+}
+
+func (e fakeMSSQLError) Error() string { return "mssql: " + e.Message }
+
+func TestDuplicateKeyError(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	_, err := db.Exec("create unique index users_names on users(given_name, family_name)")
+	assert.NoError(err)
+
+	tbl := Table("users", User{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &User{GivenName: "John", FamilyName: "Citizen"}))
+	err = ins.Exec(db, &User{GivenName: "John", FamilyName: "Citizen"})
+	assert.True(errors.Is(err, ErrDuplicateKey))
+	var dupErr *DuplicateKeyError
+	if assert.True(errors.As(err, &dupErr)) {
+		assert.Equal("users", dupErr.Table)
+		assert.Equal([]string{"given_name", "family_name"}, dupErr.Columns)
+	}
+	assert.Equal("duplicate_key", ErrorClass(err))
+
+	exec := Execf("insert into users(id, given_name) values(?, ?)")
+	_, err = exec.Exec(db, 1, "Jane")
+	if assert.True(errors.As(err, &dupErr)) {
+		assert.Equal([]string{"id"}, dupErr.Columns)
+	}
+
+	// other errors are not duplicate key errors
+	_, err = exec.Exec(db, 2)
+	assert.Error(err)
+	assert.False(errors.Is(err, ErrDuplicateKey))
+
+	RegisterConstraint(tbl, "users_email_key", "given_name")
+	assert.Panics(func() {
+		RegisterConstraint(tbl, "users_bad", "no_such_column")
+	})
+
+	tests := []struct {
+		err        error
+		table      string
+		constraint string
+		columns    []string
+	}{
+		{
+			err:        &fakePgError{Code: "23505", Detail: "Key (email)=(a@b.com) already exists.", TableName: "users", ConstraintName: "users_email_key"},
+			table:      "users",
+			constraint: "users_email_key",
+			columns:    []string{"email"},
+		},
+		{
+			err:        &fakePgError{Code: "23505", ConstraintName: "users_email_key"},
+			table:      "users",
+			constraint: "users_email_key",
+			columns:    []string{"given_name"},
+		},
+		{
+			err:        &fakeMySQLError{Number: 1062, Message: "Duplicate entry 'a@b.com' for key 'users.users_email_key'"},
+			table:      "users",
+			constraint: "users_email_key",
+			columns:    []string{"given_name"},
+		},
+		{
+			err:        fakeMSSQLError{Number: 2627, Message: "Violation of UNIQUE KEY constraint 'UQ_users'. Cannot insert duplicate key in object 'dbo.users'."},
+			table:      "dbo.users",
+			constraint: "UQ_users",
+		},
+		{
+			err:        fmt.Errorf("wrapped: %w", fakeMSSQLError{Number: 2601, Message: "Cannot insert duplicate key row in object 'dbo.users' with unique index 'IX_users'."}),
+			table:      "dbo.users",
+			constraint: "IX_users",
+		},
+	}
+	for _, tt := range tests {
+		dup := duplicateKeyError(tt.err)
+		if assert.NotNil(dup, tt.err.Error()) {
+			assert.Equal(tt.table, dup.Table)
+			assert.Equal(tt.constraint, dup.Constraint)
+			assert.Equal(tt.columns, dup.Columns)
+			assert.True(errors.Is(dup, tt.err))
+		}
+	}
+
+	assert.Nil(duplicateKeyError(&fakePgError{Code: "23503"}))
+	assert.Nil(duplicateKeyError(&fakeMySQLError{Number: 1064}))
+	assert.Nil(duplicateKeyError(errors.New("duplicate")))
+}
@@ -0,0 +1,78 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// maxErrorCommandLen is the maximum length of the SQL command
+// included in the message returned by Error.Error.
+const maxErrorCommandLen = 200
+
+// Error is the error returned when the database returns an error while
+// executing a command. It records the command and the types of its
+// arguments, so that the error is useful when it is logged. The argument
+// values are not recorded, as they may contain sensitive information.
+//
+// Use errors.As to access the Error, and errors.Is or errors.As to test
+// the underlying database error. The sql.ErrNoRows error returned when
+// scanning a row is not wrapped. If the database error is for a duplicate
+// key, Err is a *DuplicateKeyError, which wraps the database error.
+type Error struct {
+	Op       string   // Operation, for example OpExec or OpSelect
+	Name     string   // Name of the command set using WithName, if any
+	Command  string   // SQL command
+	Caller   string   // Location that built the command, see WithCaller
+	ArgTypes []string // Types of the arguments passed to the command
+	Err      error    // Underlying error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	var buf bytes.Buffer
+	buf.WriteString("sqlf: ")
+	buf.WriteString(e.Op)
+	if e.Name != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(e.Name)
+	}
+	buf.WriteString(": ")
+	buf.WriteString(e.Err.Error())
+	command := e.Command
+	if len(command) > maxErrorCommandLen {
+		command = command[:maxErrorCommandLen] + "..."
+	}
+	fmt.Fprintf(&buf, " (command %q, args %v", command, e.ArgTypes)
+	if e.Caller != "" {
+		fmt.Fprintf(&buf, ", caller %s", e.Caller)
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapError returns err wrapped in an *Error, or nil if err is nil.
+func (o options) wrapError(op string, command string, args []interface{}, err error) error {
+	if err == nil {
+		return nil
+	}
+	if dup := duplicateKeyError(err); dup != nil {
+		err = dup
+	}
+	argTypes := make([]string, len(args))
+	for i, arg := range args {
+		argTypes[i] = argType(arg)
+	}
+	return &Error{
+		Op:       op,
+		Name:     o.name,
+		Command:  command,
+		Caller:   o.caller,
+		ArgTypes: argTypes,
+		Err:      err,
+	}
+}
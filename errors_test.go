@@ -0,0 +1,69 @@
+package sqlf_test
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, name text)`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sqlf.Execf("insert into missing(id, name) values(?, ?)", sqlf.WithName("InsertMissing")).
+		Exec(db, 1, "secret value")
+	var serr *sqlf.Error
+	if assert.True(errors.As(err, &serr)) {
+		assert.Equal(sqlf.OpExec, serr.Op)
+		assert.Equal("InsertMissing", serr.Name)
+		assert.Equal("insert into missing(id, name) values(?, ?)", serr.Command)
+		assert.Equal([]string{"int", "string"}, serr.ArgTypes)
+		assert.Equal(serr.Err, errors.Unwrap(err))
+		assert.Contains(err.Error(), "no such table")
+		assert.Contains(err.Error(), "[int string]")
+		assert.NotContains(err.Error(), "secret value")
+	}
+
+	query := sqlf.Queryf("select id, name from missing where id = ?")
+	_, err = query.Query(db, int64(1))
+	if assert.True(errors.As(err, &serr)) {
+		assert.Equal(sqlf.OpQuery, serr.Op)
+		assert.Equal([]string{"int64"}, serr.ArgTypes)
+	}
+	var rows []struct {
+		ID   int
+		Name string
+	}
+	err = query.Select(db, &rows, nil)
+	if assert.True(errors.As(err, &serr)) {
+		assert.Equal(sqlf.OpSelect, serr.Op)
+		assert.Equal([]string{"nil"}, serr.ArgTypes)
+	}
+	var id int
+	err = query.QueryRow(db, 1).Scan(&id)
+	if assert.True(errors.As(err, &serr)) {
+		assert.Equal(sqlf.OpQueryRow, serr.Op)
+	}
+
+	// sql.ErrNoRows is not wrapped
+	err = sqlf.Queryf("select id from table1 where id = ?").QueryRow(db, 1).Scan(&id)
+	assert.Equal(sql.ErrNoRows, err)
+
+	// long commands are truncated in the message
+	long := "select " + strings.Repeat("x, ", 100) + "x from missing"
+	err = sqlf.Queryf(long).Select(db, &rows)
+	assert.Contains(err.Error(), "...")
+	assert.NotContains(err.Error(), long)
+}
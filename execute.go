@@ -0,0 +1,329 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stmtCache contains the prepared statements for a command
+// created using the WithPrepared option.
+type stmtCache struct {
+	mutex sync.Mutex
+	m     map[stmtKey]*sqlx.Stmt
+}
+
+type stmtKey struct {
+	db    *sqlx.DB
+	query string
+}
+
+// get returns the prepared statement for the query, or nil if
+// the statement should not be prepared for db.
+func (c *stmtCache) get(db interface{}, query string) (*sqlx.Stmt, error) {
+	if c == nil {
+		return nil, nil
+	}
+	sdb, ok := db.(*sqlx.DB)
+	if !ok {
+		return nil, nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := stmtKey{db: sdb, query: query}
+	if stmt := c.m[key]; stmt != nil {
+		return stmt, nil
+	}
+	stmt, err := sdb.Preparex(query)
+	if err != nil {
+		return nil, err
+	}
+	if c.m == nil {
+		c.m = make(map[stmtKey]*sqlx.Stmt)
+	}
+	c.m[key] = stmt
+	return stmt, nil
+}
+
+// argBinding records the args of a command that are supplied or converted
+// by the command, rather than by the caller. The args of any Sqlizer or
+// CommandDef input embedded in the command are inserted, and the args of
+// placeholders such as Placeholder.LikePrefix are converted. The arg
+// indexes do not count the args bound to the tenant, which are inserted
+// afterwards by bindTenant. The binding also records any error building
+// a query, which is reported when the query is executed.
+type argBinding struct {
+	fixed     map[int]interface{}                   // arg index and value of each inserted arg
+	converted map[int]func(interface{}) interface{} // arg index and conversion of each converted arg
+	err       error                                 // error from a Sqlizer or WithHints
+}
+
+// newArgBinding returns the arg binding for the inputs of a command, in
+// placeholder order, or nil if none of the args are supplied or converted
+// by the command.
+func newArgBinding(inputs []positioner) *argBinding {
+	ab := &argBinding{
+		fixed:     map[int]interface{}{},
+		converted: map[int]func(interface{}) interface{}{},
+	}
+	i := 0
+	for _, input := range inputs {
+		if isTenantInput(input) {
+			continue
+		}
+		if value, ok := fixedInput(input); ok {
+			ab.fixed[i] = value
+		} else if convert := convertedInput(input); convert != nil {
+			ab.converted[i] = convert
+		}
+		i++
+	}
+	if len(ab.fixed) == 0 && len(ab.converted) == 0 {
+		return nil
+	}
+	return ab
+}
+
+// bind returns the args with the fixed args inserted
+// and the converted args replaced.
+func (ab *argBinding) bind(args []interface{}) []interface{} {
+	if ab == nil {
+		return args
+	}
+	bound := make([]interface{}, 0, len(args)+len(ab.fixed))
+	next := 0
+	for i := 0; next < len(args) || ab.isFixed(i); i++ {
+		if value, ok := ab.fixed[i]; ok {
+			bound = append(bound, value)
+			continue
+		}
+		if convert := ab.converted[i]; convert != nil {
+			bound = append(bound, convert(args[next]))
+		} else {
+			bound = append(bound, args[next])
+		}
+		next++
+	}
+	return bound
+}
+
+// convert returns the args supplied to the command with the converted
+// args replaced, for interpolating the command. The fixed args are not
+// included.
+func (ab *argBinding) convert(args []interface{}) []interface{} {
+	if ab == nil || len(ab.converted) == 0 {
+		return args
+	}
+	converted := append([]interface{}(nil), args...)
+	next := 0
+	for i := 0; next < len(args); i++ {
+		if ab.isFixed(i) {
+			continue
+		}
+		if convert := ab.converted[i]; convert != nil {
+			converted[next] = convert(args[next])
+		}
+		next++
+	}
+	return converted
+}
+
+// isFixed reports whether bind inserts the arg with index i.
+func (ab *argBinding) isFixed(i int) bool {
+	_, ok := ab.fixed[i]
+	return ok
+}
+
+// numFixed returns the number of args inserted by bind.
+func (ab *argBinding) numFixed() int {
+	if ab == nil {
+		return 0
+	}
+	return len(ab.fixed)
+}
+
+// bindArgs returns the args for executing a command, with the args
+// supplied or converted by the command bound. It returns the error, if
+// any, from building the command.
+func (o options) bindArgs(args []interface{}) ([]interface{}, error) {
+	if o.binding == nil {
+		return args, nil
+	}
+	if o.binding.err != nil {
+		return nil, o.binding.err
+	}
+	return o.binding.bind(args), nil
+}
+
+// bindArgsContext returns the args for executing a command using a
+// database interface other than database/sql, see ExecCommand.BindArgs.
+func (o options) bindArgsContext(ctx context.Context, args []interface{}) ([]interface{}, error) {
+	if err := o.checkArgs(args); err != nil {
+		return nil, err
+	}
+	args, err := o.bindArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	tenant, ok := TenantFromContext(ctx)
+	return o.bindTenantValue(tenant, ok, args)
+}
+
+// context returns the context for executing a command, derived from
+// parent, which has a deadline if the command has a timeout.
+func (o options) context(parent context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(parent, o.timeout)
+	}
+	return parent, func() {}
+}
+
+// exec executes a command that does not return rows.
+func (o options) exec(db sqlx.Execer, query string, args []interface{}) (sql.Result, error) {
+	parent, hasContext := handleContext(db)
+	defer forgetMemo(parent)
+	if err := o.checkArgs(args); err != nil {
+		return nil, err
+	}
+	args, err := o.bindArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	scoped, args, err := o.bindTenant(db, args)
+	if err != nil {
+		return nil, err
+	}
+	db = scoped.(sqlx.Execer)
+	ctx, cancel := o.context(parent)
+	defer cancel()
+	if settings := o.sessionSettings(parent); len(settings) > 0 {
+		handle, release, err := o.withSettings(ctx, db, settings)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		db = handle.(sqlx.Execer)
+	}
+	if err := o.checkCost(ctx, db, query, args); err != nil {
+		return nil, err
+	}
+	stmt, err := o.stmts.get(db, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	if ec, ok := db.(sqlx.ExecerContext); ok && (o.timeout > 0 || hasContext) {
+		return ec.ExecContext(ctx, query, args...)
+	}
+	return db.Exec(query, args...)
+}
+
+// query executes a command that returns rows. The returned
+// function must be called once the rows have been read.
+func (o options) query(db sqlx.Queryer, query string, args []interface{}) (*sql.Rows, context.CancelFunc, error) {
+	parent, hasContext := handleContext(db)
+	if err := o.checkArgs(args); err != nil {
+		return nil, nil, err
+	}
+	args, err := o.bindArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	scoped, args, err := o.bindTenant(db, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	db = scoped.(sqlx.Queryer)
+	ctx, cancel := o.context(parent)
+	if settings := o.sessionSettings(parent); len(settings) > 0 {
+		handle, release, err := o.withSettings(ctx, db, settings)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		db = handle.(sqlx.Queryer)
+		cancelContext := cancel
+		cancel = func() { release(); cancelContext() }
+	}
+	if err := o.checkCost(ctx, db, query, args); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if rs, ok := db.(ReplicaSet); ok && o.hedgeDelay > 0 {
+		if replicas := rs.Replicas(); len(replicas) > 1 {
+			rows, done, err := o.hedgedQuery(ctx, replicas, query, args)
+			if err != nil {
+				cancel()
+				return nil, nil, err
+			}
+			return rows, func() { done(); cancel() }, nil
+		}
+	}
+	stmt, err := o.stmts.get(db, query)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	var rows *sql.Rows
+	if stmt != nil {
+		rows, err = stmt.QueryContext(ctx, args...)
+	} else if qc, ok := db.(sqlx.QueryerContext); ok && (o.timeout > 0 || hasContext) {
+		rows, err = qc.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = db.Query(query, args...)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return rows, cancel, nil
+}
+
+// queryRow executes a command that returns at most one row. The
+// returned function must be called once the row has been read.
+func (o options) queryRow(db sqlx.Queryer, query string, args []interface{}) (*sqlx.Row, context.CancelFunc, error) {
+	parent, hasContext := handleContext(db)
+	if err := o.checkArgs(args); err != nil {
+		return nil, nil, err
+	}
+	args, err := o.bindArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	scoped, args, err := o.bindTenant(db, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	db = scoped.(sqlx.Queryer)
+	ctx, cancel := o.context(parent)
+	if settings := o.sessionSettings(parent); len(settings) > 0 {
+		handle, release, err := o.withSettings(ctx, db, settings)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		db = handle.(sqlx.Queryer)
+		cancelContext := cancel
+		cancel = func() { release(); cancelContext() }
+	}
+	if err := o.checkCost(ctx, db, query, args); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	stmt, err := o.stmts.get(db, query)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if stmt != nil {
+		return stmt.QueryRowxContext(ctx, args...), cancel, nil
+	}
+	if qc, ok := db.(sqlx.QueryerContext); ok && (o.timeout > 0 || hasContext) {
+		return qc.QueryRowxContext(ctx, query, args...), cancel, nil
+	}
+	return db.QueryRowx(query, args...), cancel, nil
+}
@@ -0,0 +1,199 @@
+package sqlf
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// exporter writes the rows of a result set in an export format.
+type exporter interface {
+	header(columns []string) error
+	row(values []interface{}) error
+	flush() error
+}
+
+func (cmd *queryCommand) ExportCSV(db sqlx.Queryer, w io.Writer, args ...interface{}) error {
+	return cmd.export(db, &csvExporter{w: csv.NewWriter(w)}, args)
+}
+
+func (cmd *queryCommand) ExportNDJSON(db sqlx.Queryer, w io.Writer, args ...interface{}) error {
+	return cmd.export(db, &ndjsonExporter{w: bufio.NewWriter(w)}, args)
+}
+
+func (cmd *queryCommand) WriteJSON(db sqlx.Queryer, w io.Writer, args ...interface{}) error {
+	return cmd.export(db, &ndjsonExporter{w: bufio.NewWriter(w), array: true}, args)
+}
+
+func (cmd *queryCommand) export(db sqlx.Queryer, e exporter, args []interface{}) (err error) {
+	start := time.Now()
+	rowCount := int64(-1)
+	defer func() {
+		cmd.opts.report(contextOf(db), OpExport, cmd.command, start, rowCount, err)
+	}()
+	cmd.opts.debugBindings(OpExport, cmd.command, cmd.inputs, args)
+	rows, cancel, err := cmd.opts.query(db, cmd.queryFor(db), args)
+	if err != nil {
+		return cmd.opts.wrapError(OpExport, cmd.command, args, err)
+	}
+	defer cancel()
+	defer rows.Close()
+	counter := &countingRows{Rows: rows}
+	if err = cmd.exportRows(contextOf(db), counter, e); err == nil {
+		rowCount = counter.count
+	}
+	return err
+}
+
+// exportRows writes each of the rows using the exporter. Values of columns
+// selected from a table that have a codec are decoded using ctx.
+func (cmd *queryCommand) exportRows(ctx context.Context, rows Rows, e exporter) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*columnInfo)
+	for _, ci := range cmd.columns {
+		byName[ci.outputName()] = ci
+	}
+	if err := e.header(columns); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, column := range columns {
+			if ci := byName[column]; ci != nil && ci.codec != nil {
+				value, err := decode(ctx, ci.codec, values[i])
+				if err != nil {
+					return fmt.Errorf("cannot decode %s: %v", ci.columnName, err)
+				}
+				values[i] = value
+			}
+		}
+		if err := e.row(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+type csvExporter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvExporter) header(columns []string) error {
+	e.record = make([]string, len(columns))
+	return e.w.Write(columns)
+}
+
+func (e *csvExporter) row(values []interface{}) error {
+	for i, value := range values {
+		e.record[i] = csvField(value)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvExporter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvField formats a value returned by the database driver as a CSV field.
+func csvField(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// ndjsonExporter writes newline-delimited JSON objects,
+// or if array is set, a JSON array of the objects.
+type ndjsonExporter struct {
+	w        *bufio.Writer
+	columns  [][]byte
+	array    bool
+	rowCount int
+}
+
+func (e *ndjsonExporter) header(columns []string) error {
+	e.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		b, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		e.columns[i] = b
+	}
+	if e.array {
+		e.w.WriteByte('[')
+	}
+	return nil
+}
+
+func (e *ndjsonExporter) row(values []interface{}) error {
+	if e.array && e.rowCount > 0 {
+		e.w.WriteByte(',')
+	}
+	e.rowCount++
+	e.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.w.Write(e.columns[i])
+		e.w.WriteByte(':')
+		if b, ok := value.([]byte); ok {
+			// text returned as bytes, rather than base64
+			value = string(b)
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("cannot export column %s: %v", e.columns[i], err)
+		}
+		e.w.Write(b)
+	}
+	e.w.WriteByte('}')
+	if e.array {
+		return nil
+	}
+	_, err := e.w.WriteString("\n")
+	return err
+}
+
+func (e *ndjsonExporter) flush() error {
+	if e.array {
+		e.w.WriteByte(']')
+	}
+	return e.w.Flush()
+}
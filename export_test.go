@@ -0,0 +1,62 @@
+package sqlf_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	dob := time.Date(1970, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen, Jr", DOB: dob}))
+	_, err = db.Exec(`insert into table1(given_name) values('Jane "JJ"')`)
+	assert.NoError(err)
+
+	sel := sqlf.Queryf("select %s, 1.5 as score from %s order by id", tbl.Select.Columns, tbl.Select.TableName)
+
+	var buf bytes.Buffer
+	assert.NoError(sel.ExportCSV(db, &buf))
+	assert.Equal("id,given_name,family_name,Date_of_Birth,score\n"+
+		"1,John,\"Citizen, Jr\",1970-01-02T03:04:05Z,1.5\n"+
+		"2,\"Jane \"\"JJ\"\"\",,,1.5\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(sel.ExportNDJSON(db, &buf))
+	assert.Equal(`{"id":1,"given_name":"John","family_name":"Citizen, Jr","Date_of_Birth":"1970-01-02T03:04:05Z","score":1.5}`+"\n"+
+		`{"id":2,"given_name":"Jane \"JJ\"","family_name":null,"Date_of_Birth":null,"score":1.5}`+"\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(sel.WriteJSON(db, &buf))
+	assert.Equal(`[{"id":1,"given_name":"John","family_name":"Citizen, Jr","Date_of_Birth":"1970-01-02T03:04:05Z","score":1.5},`+
+		`{"id":2,"given_name":"Jane \"JJ\"","family_name":null,"Date_of_Birth":null,"score":1.5}]`, buf.String())
+
+	// no rows
+	buf.Reset()
+	empty := sqlf.Queryf("select %s from %s where id < 0", tbl.Select.Columns, tbl.Select.TableName)
+	assert.NoError(empty.ExportCSV(db, &buf))
+	assert.Equal("id,given_name,family_name,Date_of_Birth\n", buf.String())
+	buf.Reset()
+	assert.NoError(empty.ExportNDJSON(db, &buf))
+	assert.Equal("", buf.String())
+	buf.Reset()
+	assert.NoError(empty.WriteJSON(db, &buf))
+	assert.Equal("[]", buf.String())
+
+	bad := sqlf.Queryf("select no_such_column from %s", tbl.Select.TableName)
+	assert.Error(bad.ExportCSV(db, &buf))
+}
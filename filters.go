@@ -0,0 +1,164 @@
+package sqlf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FilterList is a WHERE clause condition built from a struct of optional
+// filters. It is created using Filters, and passed as an argument to
+// Queryf or Execf.
+type FilterList struct {
+	conds   []*filterCond
+	dialect Dialect
+}
+
+// filterCond is a single condition in a filter list. Its placeholder
+// is positioned when the command is built.
+type filterCond struct {
+	column   string
+	op       string
+	value    interface{}
+	dialect  Dialect
+	position int
+}
+
+func (fc *filterCond) setPosition(n int) {
+	fc.position = n
+}
+
+// filterOps contains the operators that can be specified using the
+// "op" field tag. The value is the operator as rendered in SQL.
+var filterOps = map[string]string{
+	"=":        "=",
+	"<>":       "<>",
+	"!=":       "<>",
+	"<":        "<",
+	"<=":       "<=",
+	">":        ">",
+	">=":       ">=",
+	"like":     " like ",
+	"not like": " not like ",
+	"ilike":    " ilike ",
+}
+
+// Filters builds a condition for a WHERE clause from a struct of optional
+// filters. There is one condition for each field that has a non-zero value,
+// or for a pointer field, a non-nil value. Conditions test for equality
+// unless the field has an "op" tag, which can be one of "=", "<>", "<",
+// "<=", ">", ">=", "like", "not like" and "ilike". The column name is
+// derived from the field name in the same way as for Table, and can be
+// overridden using the same tags. Fields tagged `sql:"-"` are ignored.
+//
+//	type UserFilter struct {
+//		FamilyName *string
+//		Email      string `op:"like"`
+//		MinAge     int    `op:">=" sql:"column:age"`
+//	}
+//
+//	filters := sqlf.Filters(UserFilter{Email: "%@example.com"})
+//	cmd := sqlf.Queryf("select %s from %s where %s",
+//		users.Select.Columns, users.Select.TableName, filters)
+//	err := cmd.Select(db, &rows, filters.Args()...)
+//
+// The conditions are joined using "and". If there are no conditions, the
+// filter list renders as "1=1", so that it is always valid in a WHERE clause.
+// The values of the conditions are returned by the Args method, and must be
+// passed to the command in the position where the filter list appears.
+//
+// Filters panics if filter is not a struct or pointer to a struct, or if
+// a field has an invalid "op" tag.
+func Filters(filter interface{}) *FilterList {
+	v := reflect.ValueOf(filter)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("sqlf.Filters: expected struct, got %T", filter))
+	}
+	fl := &FilterList{}
+	fl.addConds(v)
+	return fl
+}
+
+func (fl *FilterList) addConds(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if len(field.PkgPath) != 0 && !field.Anonymous {
+			// ignore unexported field
+			continue
+		}
+		tagSettings := parseTagSetting(field.Tag)
+		if _, ok := tagSettings["-"]; ok {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fl.addConds(fv)
+			continue
+		}
+
+		op := "="
+		if tag, ok := field.Tag.Lookup("op"); ok {
+			op, ok = filterOps[strings.ToLower(strings.TrimSpace(tag))]
+			if !ok {
+				panic(fmt.Sprintf("sqlf.Filters: invalid op %q for field %s", tag, field.Name))
+			}
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if fv.IsZero() {
+			continue
+		}
+
+		column := Default.columnName(field.Name)
+		if value, ok := tagSettings["COLUMN"]; ok && value != "" {
+			column = value
+		}
+		fl.conds = append(fl.conds, &filterCond{
+			column: column,
+			op:     op,
+			value:  fv.Interface(),
+		})
+	}
+}
+
+// clone returns a copy of the filter list for a command
+// using the dialect.
+func (fl *FilterList) clone(dialect Dialect) *FilterList {
+	fl2 := &FilterList{dialect: dialect}
+	for _, fc := range fl.conds {
+		fc2 := *fc
+		fc2.dialect = dialect
+		fl2.conds = append(fl2.conds, &fc2)
+	}
+	return fl2
+}
+
+// Args returns the values of the conditions in the filter list, in the
+// order of their placeholders.
+func (fl *FilterList) Args() []interface{} {
+	args := make([]interface{}, 0, len(fl.conds))
+	for _, fc := range fl.conds {
+		args = append(args, fc.value)
+	}
+	return args
+}
+
+// String returns the filter list formatted as an SQL condition.
+func (fl *FilterList) String() string {
+	if len(fl.conds) == 0 {
+		return "1=1"
+	}
+	dialect := commandDialect(fl.dialect)
+	var conds []string
+	for _, fc := range fl.conds {
+		conds = append(conds, dialect.Quote(fc.column)+fc.op+dialect.Placeholder(fc.position))
+	}
+	return strings.Join(conds, " and ")
+}
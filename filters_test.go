@@ -0,0 +1,73 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type row1Filter struct {
+	ID         *int64 `sql:"column:id"`
+	GivenName  string `op:"like"`
+	FamilyName *string
+	MinID      int64  `op:">=" sql:"column:id"`
+	Ignored    string `sql:"-"`
+}
+
+func TestFilters(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Row1Table.WithDialect(sqlf.DialectPG)
+	citizen := "Citizen"
+
+	filters := sqlf.Filters(&row1Filter{GivenName: "J%", FamilyName: &citizen, Ignored: "x"})
+	assert.Equal([]interface{}{"J%", "Citizen"}, filters.Args())
+	query := sqlf.Queryf("select %s from %s where id > %s and %s",
+		"id", tbl.Select.TableName, tbl.Select.Placeholder(), filters)
+	assert.Equal(`select id from "table1" where id > $1 and "given_name" like $2 and "family_name"=$3`, query.Command())
+
+	// pointer fields are included even when the value is zero
+	var zero int64
+	filters = sqlf.Filters(row1Filter{ID: &zero, MinID: 10})
+	query = sqlf.Queryf("select %s from %s where %s", "id", tbl.Select.TableName, filters)
+	assert.Equal(`select id from "table1" where "id"=$1 and "id">=$2`, query.Command())
+	assert.Equal([]interface{}{int64(0), int64(10)}, filters.Args())
+
+	// no filters
+	filters = sqlf.Filters(row1Filter{})
+	query = sqlf.Queryf("select %s from %s where %s", "id", tbl.Select.TableName, filters)
+	assert.Equal(`select id from "table1" where 1=1`, query.Command())
+	assert.Len(filters.Args(), 0)
+
+	assert.Panics(func() { sqlf.Filters(1) })
+	assert.Panics(func() {
+		sqlf.Filters(struct {
+			Name string `op:"~"`
+		}{})
+	})
+}
+
+func TestFiltersSelect(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table2(user_id integer, search_term text);
+		insert into table2 values(1, 'apple'), (1, 'banana'), (2, 'apricot')`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row2Table.WithDialect(sqlf.DialectSQLite)
+
+	filters := sqlf.Filters(struct {
+		SearchTerm string `op:"like"`
+		UserId     int
+	}{SearchTerm: "a%"})
+	query := sqlf.Queryf("select %s from %s where %s order by %s",
+		tbl.Select.Columns, tbl.Select.TableName, filters, "search_term")
+	var rows []Row2
+	assert.NoError(query.Select(db, &rows, filters.Args()...))
+	assert.Equal([]Row2{{1, "apple"}, {2, "apricot"}}, rows)
+}
@@ -0,0 +1,108 @@
+package sqlf
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// primaryKeyIndex is the name of the index for
+// the primary key columns, see Finder.
+const primaryKeyIndex = "primary_key"
+
+// Finder returns a query command that selects the rows of the table
+// whose columns match the columns of the named index, ordered by the
+// primary key. The args for the query are the values of the index
+// columns, in the order of the fields in the row struct.
+//
+// Columns are added to an index using the "index" and "unique_index"
+// tags. An index that is not named has the name of its column, and the
+// columns of an index with more than one column are tagged with the same
+// name. A column in more than one index is tagged with a comma-separated
+// list of names. The index "primary_key" contains the primary key columns:
+//
+//	type Order struct {
+//		ID         int64  `sql:"primary_key"`
+//		Number     string `sql:"unique_index"`
+//		CustomerID int64  `sql:"index:customer_id,customer_status"`
+//		Status     string `sql:"index:customer_status"`
+//	}
+//
+//	var orders = sqlf.Table("orders", Order{})
+//
+//	// select ... from orders where number=? order by id
+//	err := orders.Finder("number").Get(db, &order, number)
+//
+//	// select ... from orders where customer_id=? and status=? order by id
+//	err := orders.Finder("customer_status").Select(db, &rows, customerID, "open")
+//
+// The query command is built the first time that Finder is called for
+// the index, and it is returned by subsequent calls. Finder panics if the
+// table has no index with the name, in the same way that Table panics
+// for an invalid row type.
+func (ti *TableInfo) Finder(index string) QueryCommand {
+	key := finderKey{table: ti, index: index}
+	finders.mutex.Lock()
+	defer finders.mutex.Unlock()
+	if cmd, ok := finders.m[key]; ok {
+		return cmd
+	}
+	where := ti.Update.WhereColumns.applyFilter(func(ci *columnInfo) bool {
+		return ci.inIndex(index)
+	})
+	if len(where.filtered()) == 0 {
+		panic(fmt.Sprintf("sqlf.Finder: table %s has no index %q", ti.Name, index))
+	}
+	cmd := Queryf("select %s from %s where %s order by %s",
+		ti.Select.Columns, ti.Select.TableName, where, ti.Select.OrderBy,
+		WithName(fmt.Sprintf("%s.Finder(%s)", ti.Name, index)))
+	finders.m[key] = cmd
+	return cmd
+}
+
+type finderKey struct {
+	table *TableInfo
+	index string
+}
+
+var finders = struct {
+	mutex sync.Mutex
+	m     map[finderKey]QueryCommand
+}{m: make(map[finderKey]QueryCommand)}
+
+// parseIndexes returns the names of the indexes that the column is in,
+// from the "index" and "unique_index" tags. The value of each tag is
+// the name of an index, or a comma-separated list of names.
+func parseIndexes(columnName string, tagSettings map[string]string) []string {
+	var indexes []string
+	for _, tag := range []string{"INDEX", "UNIQUE_INDEX"} {
+		value, ok := tagSettings[tag]
+		if !ok {
+			continue
+		}
+		if value == tag {
+			// no name
+			indexes = append(indexes, columnName)
+			continue
+		}
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				indexes = append(indexes, name)
+			}
+		}
+	}
+	return indexes
+}
+
+// inIndex reports whether the column is in the named index.
+func (ci *columnInfo) inIndex(index string) bool {
+	if index == primaryKeyIndex {
+		return ci.primaryKey
+	}
+	for _, name := range ci.indexes {
+		if name == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package sqlf_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinder(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table orders(id integer primary key, number text, customer_id integer, status text)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`insert into orders values
+		(1, 'A1', 10, 'open'), (2, 'A2', 10, 'closed'), (3, 'A3', 20, 'open'), (4, 'A4', 10, 'open')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Order struct {
+		ID         int64  `sql:"primary_key"`
+		Number     string `sql:"unique_index"`
+		CustomerID int64  `sql:"index:customer_id,customer_status"`
+		Status     string `sql:"index:customer_status"`
+	}
+	orders := sqlf.Table("orders", Order{}).WithDialect(sqlf.DialectSQLite)
+
+	byNumber := orders.Finder("number")
+	assert.Equal("select `id`,`number`,`customer_id`,`status` from `orders` where `number`=? order by `id`", byNumber.Command())
+	assert.True(byNumber == orders.Finder("number"))
+	var order Order
+	assert.NoError(byNumber.Get(db, &order, "A3"))
+	assert.Equal(Order{ID: 3, Number: "A3", CustomerID: 20, Status: "open"}, order)
+	assert.Equal(sql.ErrNoRows, byNumber.Get(db, &order, "A9"))
+
+	assert.NoError(orders.Finder("primary_key").Get(db, &order, 2))
+	assert.Equal("A2", order.Number)
+
+	var rows []Order
+	assert.NoError(orders.Finder("customer_id").Select(db, &rows, 10))
+	assert.Len(rows, 3)
+
+	byStatus := orders.Finder("customer_status")
+	assert.Equal("select `id`,`number`,`customer_id`,`status` from `orders` where `customer_id`=? and `status`=? order by `id`", byStatus.Command())
+	rows = nil
+	assert.NoError(byStatus.Select(db, &rows, 10, "open"))
+	assert.Equal([]Order{
+		{ID: 1, Number: "A1", CustomerID: 10, Status: "open"},
+		{ID: 4, Number: "A4", CustomerID: 10, Status: "open"},
+	}, rows)
+
+	assert.Panics(func() { orders.Finder("status") })
+}
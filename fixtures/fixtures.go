@@ -0,0 +1,215 @@
+// Package fixtures loads test data into a database from YAML or JSON files.
+//
+// A fixture file contains a list of tables, each with a list of rows.
+// Each row maps column names to values:
+//
+//	# testdata/users.yaml
+//	- table: users
+//	  rows:
+//	    - id: 1
+//	      email: john@example.com
+//	      created_at: 2020-01-02
+//	- table: posts
+//	  rows:
+//	    - id: 10
+//	      user_id: 1
+//	      title: Hello
+//
+// The same data can be written in JSON, which is a subset of YAML.
+//
+// Each table must have been created using sqlf.Table. Rows are inserted
+// using the row struct for the table, so that the field tags, including
+// column names and codecs, are understood in the same way as the rest of
+// the program.
+//
+// Tables are inserted in an order that respects the foreign keys between
+// them, which are read from the database for SQLite, PostgreSQL and MySQL.
+// For other databases, and for tables that are not related, the order of
+// the tables in the fixture files is used.
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/yaml.v3"
+)
+
+// Loader loads fixtures into the database.
+type Loader struct {
+	tables map[string]*sqlf.TableInfo
+}
+
+// New returns a loader for the tables. If no tables are specified, the
+// loader uses all tables created using sqlf.Table. If more than one table
+// has the same name, the first is used.
+func New(tables ...*sqlf.TableInfo) *Loader {
+	if len(tables) == 0 {
+		tables = sqlf.Tables()
+	}
+	l := &Loader{tables: make(map[string]*sqlf.TableInfo)}
+	for _, ti := range tables {
+		if _, ok := l.tables[ti.Name]; !ok {
+			l.tables[ti.Name] = ti
+		}
+	}
+	return l
+}
+
+// fixture is the contents of a fixture file for one table.
+type fixture struct {
+	Table string                   `yaml:"table"`
+	Rows  []map[string]interface{} `yaml:"rows"`
+}
+
+// Load reads the fixture files and inserts the rows into the database.
+func (l *Loader) Load(db sqlx.Ext, filenames ...string) error {
+	var fixtures []*fixture
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		var ff []*fixture
+		if err := yaml.Unmarshal(data, &ff); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+		fixtures = append(fixtures, ff...)
+	}
+	return l.insert(db, fixtures)
+}
+
+// LoadData inserts the rows in data, which is the contents
+// of a fixture file, into the database.
+func (l *Loader) LoadData(db sqlx.Ext, data []byte) error {
+	var fixtures []*fixture
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		return err
+	}
+	return l.insert(db, fixtures)
+}
+
+func (l *Loader) insert(db sqlx.Ext, fixtures []*fixture) error {
+	var tableNames []string
+	for _, f := range fixtures {
+		if _, ok := l.tables[f.Table]; !ok {
+			return fmt.Errorf("fixtures: unknown table %q", f.Table)
+		}
+		tableNames = append(tableNames, f.Table)
+	}
+	order, err := sortTables(db, tableNames)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(fixtures, func(i, j int) bool {
+		return order[fixtures[i].Table] < order[fixtures[j].Table]
+	})
+
+	for _, f := range fixtures {
+		ti := l.tables[f.Table]
+		for i, values := range f.Rows {
+			if err := insertRow(db, ti, values); err != nil {
+				return fmt.Errorf("fixtures: %s row %d: %v", f.Table, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// insertRow inserts a single row into the table.
+func insertRow(db sqlx.Ext, ti *sqlf.TableInfo, values map[string]interface{}) error {
+	row := reflect.New(ti.RowType())
+	var fieldNames []string
+	for column, value := range values {
+		fieldName, index, ok := ti.ColumnField(column)
+		if !ok {
+			return fmt.Errorf("unknown column %q", column)
+		}
+		if err := setField(row.Elem().FieldByIndex(index), value); err != nil {
+			return fmt.Errorf("column %s: %v", column, err)
+		}
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+	cmd := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		ti.Insert.TableName,
+		ti.Insert.Columns.Include(fieldNames...),
+		ti.Insert.Values.Include(fieldNames...))
+	return cmd.Exec(db, row.Interface())
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts are the layouts accepted for time values.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// setField sets the field from a value read from a fixture file.
+// Values are converted by way of JSON, so that any field type that
+// can be unmarshaled from JSON can be loaded.
+func setField(field reflect.Value, value interface{}) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	t := field.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := value.(string); ok && t == timeType {
+		for _, layout := range timeLayouts {
+			if tm, err := time.Parse(layout, s); err == nil {
+				value = tm
+				break
+			}
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, field.Addr().Interface())
+}
+
+// Truncate deletes all rows from the tables. Tables are deleted in an
+// order that respects the foreign keys between them.
+func (l *Loader) Truncate(db sqlx.Ext, tableNames ...string) error {
+	order, err := sortTables(db, tableNames)
+	if err != nil {
+		return err
+	}
+	sorted := append([]string(nil), tableNames...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order[sorted[i]] > order[sorted[j]]
+	})
+	for _, tableName := range sorted {
+		ti, ok := l.tables[tableName]
+		if !ok {
+			return fmt.Errorf("fixtures: unknown table %q", tableName)
+		}
+		if _, err := sqlf.Execf("delete from %s", ti.Delete.TableName).Exec(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TruncateAll deletes all rows from all of the loader's tables.
+func (l *Loader) TruncateAll(db sqlx.Ext) error {
+	var tableNames []string
+	for tableName := range l.tables {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	return l.Truncate(db, tableNames...)
+}
@@ -0,0 +1,109 @@
+package fixtures_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/fixtures"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type User struct {
+	ID        int64 `sql:"auto_increment"`
+	Email     string
+	CreatedAt time.Time
+}
+
+type Post struct {
+	ID     int64 `sql:"auto_increment"`
+	UserID int64 `sql:"column:user_id"`
+	Title  string
+	Tags   []string
+}
+
+type Comment struct {
+	ID     int64
+	PostID int64  `sql:"column:post_id"`
+	Body   string `sql:"column:body"`
+}
+
+var (
+	users    = sqlf.Table("users", User{})
+	posts    = sqlf.Table("posts", Post{})
+	comments = sqlf.Table("comments", Comment{})
+)
+
+func openDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`pragma foreign_keys = on;
+		create table users(id integer primary key, email text, created_at datetime);
+		create table posts(id integer primary key, user_id integer references users(id), title text, tags text);
+		create table comments(id integer primary key, post_id integer references posts(id), body text);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+	db := openDB(t)
+	defer db.Close()
+	users := users.WithDialect(sqlf.DialectSQLite)
+	posts := posts.WithDialect(sqlf.DialectSQLite)
+	loader := fixtures.New(users, posts, comments.WithDialect(sqlf.DialectSQLite))
+
+	// comments are listed first, but are inserted after posts
+	if !assert.NoError(loader.Load(db, "testdata/comments.json", "testdata/users.yaml")) {
+		return
+	}
+
+	var userRows []User
+	assert.NoError(sqlf.Queryf("select %s from %s order by id", users.Select.Columns, users.Select.TableName).Select(db, &userRows))
+	assert.Equal([]User{
+		{ID: 1, Email: "john@example.com", CreatedAt: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Email: "jane@example.com", CreatedAt: time.Date(2020, 1, 3, 10, 0, 0, 0, time.UTC)},
+	}, userRows)
+
+	var postRows []Post
+	assert.NoError(sqlf.Queryf("select %s from %s order by id", posts.Select.Columns, posts.Select.TableName).Select(db, &postRows))
+	assert.Equal([]Post{
+		{ID: 10, UserID: 1, Title: "Hello", Tags: []string{"greeting", "first"}},
+		{ID: 11, UserID: 2, Title: "World"},
+	}, postRows)
+
+	// tables are deleted in reverse order of their foreign keys
+	assert.NoError(loader.TruncateAll(db))
+	var count int
+	assert.NoError(db.Get(&count, "select count(*) from users"))
+	assert.Equal(0, count)
+}
+
+func TestLoadErrors(t *testing.T) {
+	assert := assert.New(t)
+	db := openDB(t)
+	defer db.Close()
+	loader := fixtures.New(users.WithDialect(sqlf.DialectSQLite))
+
+	assert.EqualError(loader.LoadData(db, []byte(`[{"table": "missing", "rows": []}]`)),
+		`fixtures: unknown table "missing"`)
+	assert.EqualError(loader.LoadData(db, []byte(`[{"table": "users", "rows": [{"name": "x"}]}]`)),
+		`fixtures: users row 1: unknown column "name"`)
+	assert.Error(loader.LoadData(db, []byte(`[{"table": "users", "rows": [{"id": "x"}]}]`)))
+	assert.Error(loader.Load(db, "testdata/missing.yaml"))
+	assert.EqualError(loader.Truncate(db, "posts"), `fixtures: unknown table "posts"`)
+}
+
+func TestDefaultTables(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+	// the tables created using sqlf.Table are used by default
+	assert.NoError(t, fixtures.New().LoadData(db, []byte("- table: users\n  rows:\n    - id: 5\n      email: x@example.com\n")))
+}
@@ -0,0 +1,125 @@
+package fixtures
+
+import (
+	"fmt"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+)
+
+// sortTables returns the rank of each table, such that a table ranks
+// after all of the tables it references using foreign keys. Otherwise
+// tables rank in the order given.
+func sortTables(db sqlx.Ext, tableNames []string) (map[string]int, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range tableNames {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	refs, err := foreignKeys(db, names)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make(map[string]int)
+	for len(order) < len(names) {
+		progress := false
+		for _, name := range names {
+			if _, ok := order[name]; ok {
+				continue
+			}
+			ready := true
+			for _, ref := range refs[name] {
+				if _, ok := order[ref]; !ok && ref != name && seen[ref] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order[name] = len(order)
+				progress = true
+			}
+		}
+		if !progress {
+			// circular references: use the order given for the rest
+			for _, name := range names {
+				if _, ok := order[name]; !ok {
+					order[name] = len(order)
+				}
+			}
+		}
+	}
+	return order, nil
+}
+
+// foreignKeys returns the tables referenced by each table.
+func foreignKeys(db sqlx.Ext, tableNames []string) (map[string][]string, error) {
+	refs := make(map[string][]string)
+	d := sqlf.DialectForDriver(db.DriverName())
+	if d == nil {
+		return refs, nil
+	}
+
+	var query string
+	switch sqlf.DialectName(d) {
+	case "sqlite3":
+		for _, tableName := range tableNames {
+			rows, err := db.Queryx(fmt.Sprintf("pragma foreign_key_list(%s)", d.Quote(tableName)))
+			if err != nil {
+				return nil, err
+			}
+			for rows.Next() {
+				m := make(map[string]interface{})
+				if err := rows.MapScan(m); err != nil {
+					rows.Close()
+					return nil, err
+				}
+				refs[tableName] = append(refs[tableName], toString(m["table"]))
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+		}
+		return refs, nil
+	case "postgres":
+		query = `select tc.table_name, ccu.table_name
+			from information_schema.table_constraints tc
+			join information_schema.constraint_column_usage ccu
+			on ccu.constraint_schema = tc.constraint_schema
+			and ccu.constraint_name = tc.constraint_name
+			where tc.constraint_type = 'FOREIGN KEY'
+			and tc.table_schema = current_schema()`
+	case "mysql":
+		query = `select table_name, referenced_table_name
+			from information_schema.key_column_usage
+			where table_schema = database()
+			and referenced_table_name is not null`
+	default:
+		return refs, nil
+	}
+
+	rows, err := db.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, refName string
+		if err := rows.Scan(&tableName, &refName); err != nil {
+			return nil, err
+		}
+		refs[tableName] = append(refs[tableName], refName)
+	}
+	return refs, rows.Err()
+}
+
+func toString(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
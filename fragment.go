@@ -0,0 +1,163 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// Fragment is a reusable piece of SQL, such as a condition for a WHERE
+// clause, with "?" placeholders for its arguments. It is created using
+// NewFragment, and passed as an argument to Queryf or Execf, where its
+// placeholders are renumbered to suit their position in the command.
+// This makes it easy to share a condition between many commands:
+//
+//	var tenantFilter = sqlf.NewFragment("tenant_id = ? and deleted_at is null")
+//
+//	var listOrders = sqlf.Queryf("select %s from %s where %s order by id",
+//		orders.Select.Columns, orders.Select.TableName, tenantFilter)
+//
+//	err := listOrders.Select(db, &rows, tenantID)
+//
+// The arguments for the fragment are passed to the command in the position
+// where the fragment appears. Fixed argument values can be supplied when
+// the fragment is created, in which case they are returned by Args.
+type Fragment struct {
+	parts   []string // text between placeholders, one more than inputs
+	columns []string // column compared with each placeholder, if known
+	args    []interface{}
+	fixed   bool   // args are inserted by the command, see Sqlizer and Set
+	err     error  // error from the Sqlizer, if any
+	set     string // column assigned by the fragment, see Set
+
+	// set when the fragment is cloned for a command
+	dialect   Dialect
+	positions []int
+}
+
+// fragmentInput is the input for one placeholder of a fragment.
+type fragmentInput struct {
+	frag  *Fragment
+	index int
+}
+
+func (fi *fragmentInput) setPosition(n int) {
+	fi.frag.positions[fi.index] = n
+}
+
+// fragmentKeywords are not mistaken for the column
+// compared with a placeholder.
+var fragmentKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "is": true,
+	"like": true, "between": true, "any": true, "all": true,
+}
+
+// NewFragment returns a fragment for the SQL text. The args, if any, are
+// the values for the placeholders in the text, and are returned by Args.
+// NewFragment panics if args are supplied, but their number does not match
+// the number of placeholders.
+func NewFragment(text string, args ...interface{}) *Fragment {
+	f := &Fragment{args: args}
+	var buf bytes.Buffer
+	var lastIdent string
+	scanner := scan.NewScanner(strings.NewReader(text))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		switch tok {
+		case scan.PLACEHOLDER:
+			f.parts = append(f.parts, buf.String())
+			f.columns = append(f.columns, lastIdent)
+			buf.Reset()
+			lastIdent = ""
+			continue
+		case scan.IDENT:
+			if !fragmentKeywords[strings.ToLower(lit)] {
+				lastIdent = lit
+			}
+		}
+		buf.WriteString(lit)
+	}
+	f.parts = append(f.parts, buf.String())
+	if len(args) > 0 && len(args) != f.NumInputs() {
+		panic(fmt.Sprintf("sqlf.NewFragment: %d placeholders but %d args", f.NumInputs(), len(args)))
+	}
+	return f
+}
+
+// Set returns a fragment for a SET clause that assigns the value of an
+// SQL expression to a column. The expression can refer to columns and
+// call functions, and the fragment can be used in a command built using
+// UpdateRowf with the set columns of a table:
+//
+//	incrementVisits := sqlf.UpdateRowf("update %s set %s,%s,%s where %s",
+//		pages.Update.TableName,
+//		pages.Update.SetColumns.Exclude("Visits", "UpdatedAt"),
+//		sqlf.Set("visits", "visits + ?", 1),
+//		sqlf.Set("updated_at", "current_timestamp"),
+//		pages.Update.WhereColumns)
+//
+// The column name is quoted. The args, if any, are the values for the
+// placeholders in the expression, and they are supplied when the command
+// is executed, in the position where the fragment appears. If no args
+// are supplied, the placeholders of a command built using UpdateRowf are
+// bound to the field of the column, for example to add the value of the
+// Visits field to the visits column. For a command built using Execf, the
+// args are passed to the command in the position where the fragment
+// appears.
+//
+// Set panics if args are supplied, but their number does not match
+// the number of placeholders.
+func Set(column string, expr string, args ...interface{}) *Fragment {
+	f := NewFragment(expr, args...)
+	f.set = column
+	f.fixed = len(args) > 0
+	return f
+}
+
+// text returns the SQL text of the fragment, with "?" placeholders.
+func (f *Fragment) text() string {
+	return strings.Join(f.parts, "?")
+}
+
+// NumInputs returns the number of placeholders in the fragment.
+func (f *Fragment) NumInputs() int {
+	return len(f.parts) - 1
+}
+
+// Args returns the argument values supplied when the fragment was created,
+// in the order of their placeholders.
+func (f *Fragment) Args() []interface{} {
+	return f.args
+}
+
+// clone returns a copy of the fragment for a command using the dialect.
+func (f *Fragment) clone(dialect Dialect) *Fragment {
+	f2 := *f
+	f2.dialect = dialect
+	f2.positions = make([]int, f.NumInputs())
+	return &f2
+}
+
+// String returns the fragment formatted as SQL.
+func (f *Fragment) String() string {
+	var buf bytes.Buffer
+	if f.set != "" {
+		buf.WriteString(commandDialect(f.dialect).Quote(f.set))
+		buf.WriteRune('=')
+	}
+	for i, part := range f.parts {
+		buf.WriteString(part)
+		if i < len(f.positions) {
+			buf.WriteString(commandDialect(f.dialect).Placeholder(f.positions[i]))
+		} else if i < len(f.parts)-1 {
+			// not cloned for a command
+			buf.WriteString("?")
+		}
+	}
+	return buf.String()
+}
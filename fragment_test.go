@@ -0,0 +1,148 @@
+package sqlf_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFragment(t *testing.T) {
+	assert := assert.New(t)
+	active := sqlf.NewFragment("family_name = ? and given_name is not null")
+
+	for _, tt := range []struct {
+		dialect sqlf.Dialect
+		want    string
+	}{
+		{sqlf.DialectPG, `select "id" from "table1" where id > $1 and family_name = $2 and given_name is not null and id < $3`},
+		{sqlf.DialectMySQL, "select `id` from `table1` where id > ? and family_name = ? and given_name is not null and id < ?"},
+	} {
+		tbl := Row1Table.WithDialect(tt.dialect)
+		cmd := sqlf.Queryf("select %s from %s where id > %s and %s and id < %s",
+			tbl.Select.Columns.Include("Id"), tbl.Select.TableName,
+			tbl.Select.Placeholder(), active, tbl.Select.Placeholder())
+		assert.Equal(tt.want, cmd.Command())
+	}
+
+	// the fragment is not modified by the commands
+	assert.Equal("family_name = ? and given_name is not null", active.String())
+	assert.Equal(1, active.NumInputs())
+	assert.Nil(active.Args())
+
+	fixed := sqlf.NewFragment("given_name in (?, ?)", "John", "Jane")
+	assert.Equal([]interface{}{"John", "Jane"}, fixed.Args())
+	assert.Panics(func() {
+		sqlf.NewFragment("given_name = ?", "John", "Jane")
+	})
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen"}))
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "Jane", FamilyName: "Other"}))
+
+	var buf bytes.Buffer
+	sqlf.SetDebugOutput(&buf)
+	defer sqlf.SetDebugOutput(os.Stderr)
+	sel := sqlf.Queryf("select %s from %s where %s and %s", tbl.Select.Columns, tbl.Select.TableName, fixed, active, sqlf.WithDebug())
+	var rows []Row1
+	assert.NoError(sel.Select(db, &rows, append(fixed.Args(), "Citizen")...))
+	if assert.Len(rows, 1) {
+		assert.Equal("John", rows[0].GivenName)
+	}
+	assert.Contains(buf.String(), "3    family_name  -      string")
+
+	del := sqlf.Execf("delete from %s where %s", tbl.Delete.TableName, active)
+	result, err := del.Exec(db, "Other")
+	assert.NoError(err)
+	n, _ := result.RowsAffected()
+	assert.Equal(int64(1), n)
+}
+
+func TestSet(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table pages(id integer primary key, title text, visits integer, updated_at text);
+		insert into pages values(1, 'Home', 10, null), (2, 'About', 5, null)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Page struct {
+		ID        int64
+		Title     string
+		Visits    int64
+		UpdatedAt *string
+	}
+	pages := sqlf.Table("pages", Page{})
+
+	pg := pages.WithDialect(sqlf.DialectPG)
+	cmd := sqlf.UpdateRowf("update %s set %s,%s,%s where %s",
+		pg.Update.TableName,
+		pg.Update.SetColumns.Exclude("Visits", "UpdatedAt"),
+		sqlf.Set("visits", "visits + ?", 1),
+		sqlf.Set("updated_at", "current_timestamp"),
+		pg.Update.WhereColumns)
+	assert.Equal(`update "pages" set "title"=$1,"visits"=visits + $2,"updated_at"=current_timestamp where "id"=$3`, cmd.Command())
+	args, err := cmd.Args(&Page{ID: 1, Title: "Home"})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Home", 1, int64(1)}, args)
+	s, err := cmd.Interpolate(&Page{ID: 1, Title: "Home"})
+	assert.NoError(err)
+	assert.Equal(`update "pages" set "title"='Home',"visits"=visits + 1,"updated_at"=current_timestamp where "id"=1`, s)
+
+	tbl := pages.WithDialect(sqlf.DialectSQLite)
+	visit := sqlf.UpdateRowf("update %s set %s, %s where %s",
+		tbl.Update.TableName,
+		sqlf.Set("visits", "visits + ?", 1),
+		tbl.Update.SetColumns.Include("Title"),
+		tbl.Update.WhereColumns)
+	n, err := visit.Exec(db, &Page{ID: 1, Title: "Welcome"})
+	assert.NoError(err)
+	assert.Equal(1, n)
+
+	// without args, the placeholder is bound to the field of the column
+	add := sqlf.UpdateRowf("update %s set %s where %s",
+		tbl.Update.TableName, sqlf.Set("visits", "visits + ?"), tbl.Update.WhereColumns)
+	_, err = add.Exec(db, &Page{ID: 2, Visits: 3})
+	assert.NoError(err)
+
+	var rows []Page
+	assert.NoError(sqlf.Queryf("select %s from %s order by %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy).Select(db, &rows))
+	if assert.Len(rows, 2) {
+		assert.Equal("Welcome", rows[0].Title)
+		assert.Equal(int64(11), rows[0].Visits)
+		assert.Equal(int64(8), rows[1].Visits)
+	}
+
+	// Execf passes the args in the position of the fragment
+	_, err = sqlf.Execf("update %s set %s where id = ?", tbl.Update.TableName, sqlf.Set("visits", "visits * ?")).Exec(db, 2, 1)
+	assert.NoError(err)
+	var visits int64
+	assert.NoError(db.Get(&visits, "select visits from pages where id = 1"))
+	assert.Equal(int64(22), visits)
+
+	bad := sqlf.UpdateRowf("update %s set %s where %s",
+		tbl.Update.TableName, sqlf.Set("hits", "hits + ?"), tbl.Update.WhereColumns)
+	_, err = bad.Exec(db, &Page{ID: 1})
+	assert.Error(err)
+
+	assert.Panics(func() {
+		sqlf.Set("visits", "visits + ?", 1, 2)
+	})
+}
@@ -0,0 +1,119 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReplicaSet is implemented by database handles for a cluster that has
+// several read replicas, so that queries built with the WithHedging option
+// can be sent to more than one of them. The handle is used as a normal
+// sqlx.Queryer by queries without the option.
+type ReplicaSet interface {
+	sqlx.Queryer
+
+	// Replicas returns the read replicas of the cluster.
+	Replicas() []*sqlx.DB
+}
+
+// WithHedging causes a query to be sent to a second read replica if the
+// first replica has not responded after the delay, which reduces the
+// latency of the slowest queries at the cost of some extra load. The first
+// replica to respond is used, and the query on the other replica is canceled.
+// If the first replica returns an error before the delay, the query is sent
+// to the second replica immediately.
+//
+// Hedging applies when the database handle is a ReplicaSet with at least two
+// replicas, and the query is executed using Select, Get, Query, ExportCSV or
+// ExportNDJSON. The replicas are chosen at random for each query. A replica
+// has responded when its driver returns the rows of the result, which for
+// most drivers is when the first rows are received, and the rest of the
+// rows are then read from that replica only.
+func WithHedging(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedgeDelay = delay
+	}
+}
+
+// hedgeResult is the outcome of a query sent to one replica.
+type hedgeResult struct {
+	attempt int
+	rows    *sql.Rows
+	err     error
+}
+
+// hedgedQuery sends the query to up to two of the replicas, as described
+// for WithHedging. The returned function must be called once the rows have
+// been read.
+func (o options) hedgedQuery(ctx context.Context, replicas []*sqlx.DB, query string, args []interface{}) (*sql.Rows, context.CancelFunc, error) {
+	start := rand.Intn(len(replicas))
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		attempt := len(cancels)
+		db := replicas[(start+attempt)%len(replicas)]
+		actx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			rows, err := o.queryReplica(actx, db, query, args)
+			results <- hedgeResult{attempt: attempt, rows: rows, err: err}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(o.hedgeDelay)
+	defer timer.Stop()
+	var firstErr error
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			if len(cancels) < 2 {
+				launch()
+				pending++
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				for i, cancel := range cancels {
+					if i != r.attempt {
+						cancel()
+					}
+				}
+				if pending > 0 {
+					// the other replica may still return rows
+					go func() {
+						if r := <-results; r.rows != nil {
+							r.rows.Close()
+						}
+					}()
+				}
+				return r.rows, cancels[r.attempt], nil
+			}
+			cancels[r.attempt]()
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if len(cancels) < 2 {
+				launch()
+				pending++
+			}
+		}
+	}
+	return nil, nil, firstErr
+}
+
+// queryReplica executes the query on one of the replicas.
+func (o options) queryReplica(ctx context.Context, db *sqlx.DB, query string, args []interface{}) (*sql.Rows, error) {
+	stmt, err := o.stmts.get(db, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return db.QueryContext(ctx, query, args...)
+}
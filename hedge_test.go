@@ -0,0 +1,78 @@
+package sqlf_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// testReplicas is a ReplicaSet that uses the first replica
+// for queries that are not hedged.
+type testReplicas struct {
+	*sqlx.DB
+	replicas []*sqlx.DB
+}
+
+func (r *testReplicas) Replicas() []*sqlx.DB {
+	return r.replicas
+}
+
+func TestWithHedging(t *testing.T) {
+	assert := assert.New(t)
+
+	open := func(create string) *sqlx.DB {
+		db, err := sqlx.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec(create); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	// the only connection to the slow replica is busy, so
+	// queries wait until they are canceled
+	slow := open(`create table numbers as select 1 as n`)
+	defer slow.Close()
+	conn, err := slow.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fast := open(`create table numbers as select 42 as n`)
+	defer fast.Close()
+	broken := open(`create table other(n integer)`)
+	defer broken.Close()
+
+	sel := sqlf.Queryf("select n from numbers", sqlf.WithHedging(10*time.Millisecond))
+
+	// the fast replica responds, whichever replica is tried first
+	for i := 0; i < 4; i++ {
+		start := time.Now()
+		var n []int
+		assert.NoError(sel.Select(&testReplicas{DB: slow, replicas: []*sqlx.DB{slow, fast}}, &n))
+		assert.Equal([]int{42}, n)
+		assert.True(time.Since(start) < 5*time.Second)
+	}
+
+	// an error from the first replica is not returned
+	for i := 0; i < 4; i++ {
+		var n int
+		assert.NoError(sel.Get(&testReplicas{DB: broken, replicas: []*sqlx.DB{broken, fast}}, &n))
+		assert.Equal(42, n)
+	}
+
+	// the first error is returned if all replicas fail
+	var n int
+	assert.Error(sel.Get(&testReplicas{DB: broken, replicas: []*sqlx.DB{broken, broken}}, &n))
+
+	// without hedging, the handle is used
+	var ns []int
+	assert.NoError(sqlf.Queryf("select n from numbers").Select(&testReplicas{DB: fast, replicas: []*sqlx.DB{slow, fast}}, &ns))
+	assert.Equal([]int{42}, ns)
+}
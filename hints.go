@@ -0,0 +1,77 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// WithHints adds optimizer hints to a command, in the position and format
+// expected by the dialect that the command is built for:
+//
+//	PostgreSQL  /*+ hint ... */ before the statement (for pg_hint_plan)
+//	MySQL       /*+ hint ... */ after the first SELECT, INSERT, UPDATE,
+//	            DELETE or REPLACE keyword
+//	SQL Server  OPTION (hint, ...) at the end of the statement
+//
+// Each hint is written as the database expects it. For example:
+//
+//	var listOrders = sqlf.Queryf("select %s from %s where customer_id = ?",
+//		orders.Select.Columns, orders.Select.TableName,
+//		sqlf.WithHints("IndexScan(orders idx_orders_customer)"))
+//
+// This gives DBAs one place to find and change the hints of a command,
+// without editing the SQL text. SQLite does not have optimizer hints, so
+// a command with hints built for SQLite reports an error when executed.
+func WithHints(hints ...string) Option {
+	return func(o *options) {
+		o.hints = append(o.hints, hints...)
+	}
+}
+
+// addHints returns the query with the optimizer hints
+// in the format expected by dialect d.
+func addHints(d Dialect, query string, hints []string) (string, error) {
+	if len(hints) == 0 {
+		return query, nil
+	}
+	name := DialectName(d)
+	comment := "/*+ " + strings.Replace(strings.Join(hints, " "), "*/", "* /", -1) + " */"
+	switch name {
+	case "postgres":
+		return comment + " " + query, nil
+	case "mysql":
+		var buf bytes.Buffer
+		var inserted bool
+		var depth int
+		scanner := scan.NewScanner(strings.NewReader(query))
+		for {
+			tok, lit := scanner.Scan()
+			if tok == scan.EOF {
+				break
+			}
+			switch {
+			case tok == scan.OP && lit == "(":
+				depth++
+			case tok == scan.OP && lit == ")":
+				depth--
+			case tok == scan.IDENT && !inserted && depth == 0:
+				switch strings.ToLower(lit) {
+				case "select", "insert", "update", "delete", "replace":
+					inserted = true
+					lit += " " + comment
+				}
+			}
+			buf.WriteString(lit)
+		}
+		if !inserted {
+			return query, fmt.Errorf("WithHints: cannot find statement keyword: %q", query)
+		}
+		return buf.String(), nil
+	case "mssql", "sqlserver":
+		return query + " option (" + strings.Join(hints, ", ") + ")", nil
+	}
+	return query, fmt.Errorf("WithHints: not supported for dialect %q", name)
+}
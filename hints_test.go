@@ -0,0 +1,69 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHints(t *testing.T) {
+	assert := assert.New(t)
+	type Order struct {
+		ID         int64 `sql:"primary_key"`
+		CustomerID int64
+	}
+	orders := sqlf.Table("orders", Order{})
+	hint := sqlf.WithHints("IndexScan(orders idx_orders_customer)")
+
+	tests := []struct {
+		d    sqlf.Dialect
+		want string
+	}{
+		{
+			d:    sqlf.DialectPG,
+			want: `/*+ IndexScan(orders idx_orders_customer) */ select "id","customer_id" from "orders" where customer_id = $1`,
+		},
+		{
+			d:    sqlf.DialectMySQL,
+			want: "select /*+ IndexScan(orders idx_orders_customer) */ `id`,`customer_id` from `orders` where customer_id = ?",
+		},
+		{
+			d:    sqlf.DialectMSSQL,
+			want: "select [id],[customer_id] from [orders] where customer_id = ? option (IndexScan(orders idx_orders_customer))",
+		},
+	}
+	for _, tt := range tests {
+		tbl := orders.WithDialect(tt.d)
+		query := sqlf.Queryf("select %s from %s where customer_id = %s",
+			tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder(), hint)
+		assert.Equal(tt.want, query.Command(), sqlf.DialectName(tt.d))
+	}
+
+	mysql := orders.WithDialect(sqlf.DialectMySQL)
+	update := sqlf.UpdateRowf("update %s set %s where %s",
+		mysql.Update.TableName, mysql.Update.SetColumns, mysql.Update.WhereColumns,
+		sqlf.WithHints("NO_INDEX_MERGE(orders)", "MAX_EXECUTION_TIME(1000)"))
+	assert.Equal("update /*+ NO_INDEX_MERGE(orders) MAX_EXECUTION_TIME(1000) */ `orders` set `customer_id`=? where `id`=?", update.Command())
+	insert := sqlf.InsertRowf("with x as (select 1) insert into %s(%s) values(%s)",
+		mysql.Insert.TableName, mysql.Insert.Columns, mysql.Insert.Values, sqlf.WithHints("SET_VAR(foreign_key_checks=OFF)"))
+	assert.Equal("with x as (select 1) insert /*+ SET_VAR(foreign_key_checks=OFF) */ into `orders`(`id`,`customer_id`) values(?,?)", insert.Command())
+
+	// not supported by SQLite
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlite := orders.WithDialect(sqlf.DialectSQLite)
+	query := sqlf.Queryf("select %s from %s", sqlite.Select.Columns, sqlite.Select.TableName, hint)
+	var rows []Order
+	err = query.Select(db, &rows)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), `WithHints: not supported for dialect "sqlite3"`)
+	}
+	exec := sqlf.Execf("delete from orders", sqlf.WithDialect(sqlf.DialectSQLite), hint)
+	_, err = exec.Exec(db)
+	assert.Error(err)
+}
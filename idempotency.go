@@ -0,0 +1,91 @@
+package sqlf
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// idempotencyKey returns the idempotency key column of the table,
+// or nil if it has none. See InsertRowCommand.ExecInserted.
+func (ti *TableInfo) idempotencyKey() *columnInfo {
+	for _, ci := range ti.columns {
+		if ci.idempotency {
+			return ci
+		}
+	}
+	return nil
+}
+
+// idempotencyKey returns the idempotency key column of the
+// table, or nil if the command does not insert it.
+func (cmd insertRowCommand) idempotencyKey() *columnInfo {
+	if cmd.table == nil {
+		return nil
+	}
+	key := cmd.table.idempotencyKey()
+	for _, ci := range cmd.inputs {
+		if ci == key {
+			return key
+		}
+	}
+	return nil
+}
+
+// idempotentInsert modifies the insert statement so that it skips a row
+// whose idempotency key already exists. For dialects that cannot do this
+// in the statement, the statement is not modified, and the query that
+// checks for an existing row is returned.
+func idempotentInsert(d Dialect, ti *TableInfo, key *columnInfo, query string) (result string, exists string, err error) {
+	switch DialectName(d) {
+	case "postgres", "sqlite3":
+		clause := fmt.Sprintf("on conflict(%s) do nothing", d.Quote(key.columnName))
+		result, _, ok := insertClause(query, clause)
+		if !ok {
+			return query, "", fmt.Errorf("idempotency key: not an insert statement: %q", query)
+		}
+		return result, "", nil
+	case "mysql":
+		result, err := ignoreDuplicates(d, query)
+		return result, "", err
+	}
+	exists = fmt.Sprintf("select count(*) from %s where %s = %s",
+		d.Quote(ti.Name), d.Quote(key.columnName), d.Placeholder(1))
+	return query, exists, nil
+}
+
+// keyExists reports whether a row with the idempotency key of
+// the row already exists, using the query built by idempotentInsert.
+func (cmd insertRowCommand) keyExists(db sqlx.Execer, row interface{}) (bool, error) {
+	q, ok := db.(sqlx.Queryer)
+	if !ok {
+		return false, fmt.Errorf("idempotency key: cannot query using %T", db)
+	}
+	rowVal, err := cmd.getRowValue(row)
+	if err != nil {
+		return false, err
+	}
+	key := cmd.table.idempotencyKey()
+	value := reflectx.FieldByIndexesReadOnly(rowVal, key.fields).Interface()
+	arg, err := key.encodeArg(contextOf(db), value)
+	if err != nil {
+		return false, err
+	}
+
+	// the args of the query are not bound to the tenant
+	opts := cmd.opts
+	opts.tenant = nil
+	opts.argLabels = nil
+	query := cmd.existsRebind.queryFor(db, cmd.table.Dialect(), cmd.exists)
+	r, cancel, err := opts.queryRow(q, query, []interface{}{arg})
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+	var n int
+	if err := r.Scan(&n); err != nil {
+		return false, cmd.opts.wrapError(OpInsertRow, cmd.exists, []interface{}{arg}, err)
+	}
+	return n > 0, nil
+}
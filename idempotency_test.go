@@ -0,0 +1,69 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`create table events(id integer primary key autoincrement, event_id text unique, payload text)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Event struct {
+		ID      int64  `sql:"primary_key;auto_increment"`
+		EventID string `sql:"idempotency"`
+		Payload string
+	}
+	events := sqlf.Table("events", Event{})
+	count := func() int {
+		var n int
+		assert.NoError(db.Get(&n, "select count(*) from events"))
+		return n
+	}
+
+	for _, d := range []sqlf.Dialect{sqlf.DialectSQLite, sqlf.DialectMSSQL} {
+		_, err := db.Exec("delete from events")
+		assert.NoError(err)
+		tbl := events.WithDialect(d)
+		ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+
+		row := Event{EventID: "evt_1", Payload: "first"}
+		inserted, err := ins.ExecInserted(db, &row)
+		assert.NoError(err)
+		assert.True(inserted)
+		assert.NotZero(row.ID)
+
+		dup := Event{EventID: "evt_1", Payload: "second"}
+		inserted, err = ins.ExecInserted(db, &dup)
+		assert.NoError(err)
+		assert.False(inserted, sqlf.DialectName(d))
+		assert.Zero(dup.ID)
+		assert.NoError(ins.Exec(db, &dup))
+		assert.Equal(1, count())
+
+		inserted, err = ins.ExecInserted(db, &Event{EventID: "evt_2"})
+		assert.NoError(err)
+		assert.True(inserted)
+		assert.Equal(2, count())
+	}
+
+	tbl := events.WithDialect(sqlf.DialectPG)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.Equal(`insert into "events"("event_id","payload") values($1,$2) on conflict("event_id") do nothing`, ins.Command())
+
+	// the key is ignored when it is not inserted
+	ins = sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName,
+		tbl.Insert.Columns.Exclude("EventID"), tbl.Insert.Values.Exclude("EventID"))
+	assert.Equal(`insert into "events"("payload") values($1)`, ins.Command())
+}
@@ -0,0 +1,87 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// ignoreDuplicates modifies the insert statement so that
+// it skips rows that would violate a unique constraint.
+func ignoreDuplicates(d Dialect, query string) (string, error) {
+	name := DialectName(d)
+	var modifier string
+	switch name {
+	case "mysql":
+		modifier = " ignore"
+	case "sqlite3":
+		modifier = " or ignore"
+	case "postgres":
+		result, _, ok := insertClause(query, "on conflict do nothing")
+		if !ok {
+			return query, fmt.Errorf("WithIgnoreDuplicates: not an insert statement: %q", query)
+		}
+		return result, nil
+	default:
+		return query, fmt.Errorf("WithIgnoreDuplicates: not supported for dialect %q", name)
+	}
+
+	var buf bytes.Buffer
+	var inserted bool
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		if tok == scan.IDENT && !inserted && strings.ToLower(lit) == "insert" {
+			inserted = true
+			lit += modifier
+		}
+		buf.WriteString(lit)
+	}
+	if !inserted {
+		return query, fmt.Errorf("WithIgnoreDuplicates: not an insert statement: %q", query)
+	}
+	return buf.String(), nil
+}
+
+// insertClause returns the insert statement with the clause added at the
+// end, or before the RETURNING clause if the statement has one, which is
+// where PostgreSQL and SQLite expect an ON CONFLICT clause. It also reports
+// whether the statement has a RETURNING clause, and returns false if it is
+// not an insert statement.
+func insertClause(query string, clause string) (result string, returning bool, ok bool) {
+	var buf bytes.Buffer
+	var inserted bool
+	var depth int
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		keyword := strings.ToLower(lit)
+		switch {
+		case tok == scan.OP && lit == "(":
+			depth++
+		case tok == scan.OP && lit == ")":
+			depth--
+		case tok == scan.IDENT && keyword == "insert":
+			inserted = true
+		case tok == scan.IDENT && inserted && !returning && depth == 0 && keyword == "returning":
+			buf.WriteString(clause + " ")
+			returning = true
+		}
+		buf.WriteString(lit)
+	}
+	if !inserted {
+		return query, false, false
+	}
+	if !returning {
+		buf.WriteString(" " + clause)
+	}
+	return buf.String(), returning, true
+}
@@ -0,0 +1,87 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIgnoreDuplicatesCommand(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		dialect sqlf.Dialect
+		format  string
+		want    string
+	}{
+		{
+			dialect: sqlf.DialectMySQL,
+			format:  "insert into %s(%s) values(%s)",
+			want:    "insert ignore into `table1`(`given_name`,`family_name`,`Date_of_Birth`) values(?,?,?)",
+		},
+		{
+			dialect: sqlf.DialectSQLite,
+			format:  "INSERT INTO %s(%s) VALUES(%s)",
+			want:    "INSERT or ignore INTO `table1`(`given_name`,`family_name`,`Date_of_Birth`) VALUES(?,?,?)",
+		},
+		{
+			dialect: sqlf.DialectPG,
+			format:  "insert into %s(%s) values(%s)",
+			want:    `insert into "table1"("given_name","family_name","Date_of_Birth") values($1,$2,$3) on conflict do nothing`,
+		},
+		{
+			dialect: sqlf.DialectPG,
+			format:  "insert into %s(%s) values(%s) returning id",
+			want:    `insert into "table1"("given_name","family_name","Date_of_Birth") values($1,$2,$3) on conflict do nothing returning id`,
+		},
+	}
+	for _, tt := range tests {
+		tbl := Row1Table.WithDialect(tt.dialect)
+		cmd := sqlf.InsertRowf(tt.format, tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithIgnoreDuplicates())
+		assert.Equal(tt.want, cmd.Command())
+	}
+
+	tbl := Row1Table.WithDialect(sqlf.DialectMSSQL)
+	cmd := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithIgnoreDuplicates())
+	assert.Error(cmd.Exec(nil, &Row1{}))
+}
+
+func TestWithIgnoreDuplicatesExec(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text unique, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithIgnoreDuplicates())
+
+	row := Row1{GivenName: "John"}
+	inserted, err := insert.ExecInserted(db, &row)
+	assert.NoError(err)
+	assert.True(inserted)
+	assert.Equal(int64(1), row.Id)
+
+	row2 := Row1{GivenName: "Jane"}
+	inserted, err = insert.ExecInserted(db, &row2)
+	assert.NoError(err)
+	assert.True(inserted)
+	assert.Equal(int64(2), row2.Id)
+
+	// a duplicate is skipped, and the auto-increment field is unchanged
+	dup := Row1{GivenName: "John"}
+	inserted, err = insert.ExecInserted(db, &dup)
+	assert.NoError(err)
+	assert.False(inserted)
+	assert.Equal(int64(0), dup.Id)
+
+	// without the option, a duplicate is an error
+	plain := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	_, err = plain.ExecInserted(db, &Row1{GivenName: "John"})
+	assert.Error(err)
+}
@@ -0,0 +1,99 @@
+package sqlf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// InsertSelectf builds a command that inserts rows into a table using
+// the rows returned by a query. The format string should include the
+// insert column list of the target table and an embedded query command,
+// for example:
+//
+//	archive := sqlf.InsertSelectf("insert into %s(%s) %s",
+//	    archiveTable.Insert.TableName,
+//	    archiveTable.Insert.Columns,
+//	    selectOldOrders)
+//
+// The placeholders of the embedded query are renumbered to suit the
+// insert statement. The number of insert columns is checked against the
+// number of columns selected by the query: if they do not match, the
+// error is reported when the command is executed.
+func InsertSelectf(format string, args ...interface{}) ExecCommand {
+	cmd := newExecCommand(format, args)
+
+	insertCount := -1
+	var queries []*queryCommand
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case ColumnList:
+			if a.clause == clauseInsertColumns {
+				if insertCount < 0 {
+					insertCount = 0
+				}
+				insertCount += len(a.filtered())
+			}
+		case *queryCommand:
+			queries = append(queries, a)
+		}
+	}
+
+	if insertCount < 0 {
+		cmd.err = fmt.Errorf("InsertSelectf: missing insert column list")
+	} else if len(queries) != 1 {
+		cmd.err = fmt.Errorf("InsertSelectf: expected one query, found %d", len(queries))
+	} else if n, ok := selectListLen(queries[0].command); ok && n != insertCount {
+		cmd.err = fmt.Errorf("InsertSelectf: %d insert columns but query selects %d columns", insertCount, n)
+	}
+
+	return cmd
+}
+
+// selectListLen returns the number of items in the select list of the
+// first SELECT in the query. Returns false if the number of items cannot
+// be determined, for example "select * from ...".
+func selectListLen(query string) (int, bool) {
+	var inSelect bool
+	var depth, count int
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			return 0, false
+		}
+		switch tok {
+		case scan.IDENT:
+			keyword := strings.ToLower(lit)
+			if !inSelect {
+				if keyword == "select" {
+					inSelect = true
+					count = 1
+				}
+				continue
+			}
+			if depth == 0 && keyword == "from" {
+				return count, true
+			}
+		case scan.OP:
+			if !inSelect {
+				continue
+			}
+			switch lit {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			case ",":
+				if depth == 0 {
+					count++
+				}
+			case "*":
+				if depth == 0 {
+					return 0, false
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package sqlf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertSelectf(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	_, err := db.Exec(`create table users_archive(id integer primary key, family_name text, given_name text)`)
+	assert.NoError(err)
+
+	tbl := Table("users", User{}).WithDialect(DialectSQLite)
+	archive := Table("users_archive", User{}).WithDialect(DialectSQLite)
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		assert.NoError(ins.Exec(db, &User{GivenName: name, FamilyName: "Citizen"}))
+	}
+
+	sel := Queryf("select %s from %s where %s > %s", tbl.Select.Columns, tbl.Select.TableName, "id", tbl.Select.Placeholder())
+	cmd := InsertSelectf("insert into %s(%s) %s", archive.Insert.TableName, archive.Insert.Columns.All(), sel)
+	assert.Equal("insert into `users_archive`(`id`,`given_name`,`family_name`) select `id`,`given_name`,`family_name` from `users` where id > ?", cmd.Command())
+	result, err := cmd.Exec(db, 1)
+	assert.NoError(err)
+	n, err := result.RowsAffected()
+	assert.NoError(err)
+	assert.Equal(int64(2), n)
+
+	// column count mismatch
+	cmd = InsertSelectf("insert into %s(%s) %s", archive.Insert.TableName, archive.Insert.Columns, sel)
+	_, err = cmd.Exec(db, 1)
+	assert.EqualError(err, "InsertSelectf: 2 insert columns but query selects 3 columns")
+
+	// column count cannot be determined
+	sel = Queryf("select * from %s", tbl.Select.TableName)
+	cmd = InsertSelectf("insert into %s(%s) %s", archive.Insert.TableName, archive.Insert.Columns, sel)
+	assert.NoError(cmd.(execCommand).err)
+
+	n2, ok := selectListLen("select a, coalesce(b, c), 'x,y' from t")
+	assert.True(ok)
+	assert.Equal(3, n2)
+}
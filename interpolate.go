@@ -0,0 +1,134 @@
+package sqlf
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// interpolate returns the query with each placeholder replaced with
+// the corresponding argument value formatted as an SQL literal.
+// Placeholders can be positional ("?"), in which case the arguments
+// are used in order, or numbered (eg "$1").
+//
+// The result is intended for debugging and logging only. It should
+// never be executed, as placeholders are the only safe way to pass
+// values to the database server.
+func interpolate(d Dialect, query string, args []interface{}) (string, error) {
+	var buf bytes.Buffer
+	var next int
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		if tok != scan.PLACEHOLDER {
+			buf.WriteString(lit)
+			continue
+		}
+		var index int
+		if len(lit) > 1 {
+			n, err := strconv.Atoi(lit[1:])
+			if err != nil {
+				return "", fmt.Errorf("invalid placeholder %s", lit)
+			}
+			index = n - 1
+		} else {
+			index = next
+			next++
+		}
+		if index < 0 || index >= len(args) {
+			return "", fmt.Errorf("no argument for placeholder %s: %d args", lit, len(args))
+		}
+		s, err := quoteLiteral(d, args[index])
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(s)
+	}
+	return buf.String(), nil
+}
+
+// quoteLiteral formats the value as an SQL literal for the dialect.
+func quoteLiteral(d Dialect, v interface{}) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return "NULL", nil
+		}
+		dv, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		v = dv
+	}
+	if v == nil {
+		return "NULL", nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		rv = rv.Elem()
+	}
+	v = rv.Interface()
+
+	switch val := v.(type) {
+	case string:
+		return quoteString(d, val), nil
+	case []byte:
+		return quoteBytes(d, val), nil
+	case bool:
+		if DialectName(d) == "postgres" {
+			return strconv.FormatBool(val), nil
+		}
+		if val {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		if DialectName(d) == "postgres" {
+			return quoteString(d, val.Format("2006-01-02 15:04:05.999999-07:00")), nil
+		}
+		return quoteString(d, val.Format("2006-01-02 15:04:05.999999")), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return quoteString(d, rv.String()), nil
+	}
+	return quoteString(d, fmt.Sprint(v)), nil
+}
+
+func quoteString(d Dialect, s string) string {
+	if DialectName(d) == "mysql" {
+		// MySQL treats backslash as an escape character by default
+		s = strings.Replace(s, `\`, `\\`, -1)
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func quoteBytes(d Dialect, b []byte) string {
+	switch DialectName(d) {
+	case "postgres":
+		return `'\x` + hex.EncodeToString(b) + "'"
+	case "mssql", "sqlserver":
+		return "0x" + hex.EncodeToString(b)
+	}
+	return "X'" + hex.EncodeToString(b) + "'"
+}
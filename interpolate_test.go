@@ -0,0 +1,72 @@
+package sqlf_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	assert := assert.New(t)
+
+	tbl := Row1Table.WithDialect(sqlf.DialectMySQL)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	s, err := ins.Interpolate(&Row1{
+		GivenName:  "John",
+		FamilyName: `O'Brien\`,
+		DOB:        time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	assert.NoError(err)
+	assert.Equal("insert into `table1`(`given_name`,`family_name`,`Date_of_Birth`) values('John','O''Brien\\\\','2000-01-02 03:04:05')", s)
+
+	tblpg := Row1Table.WithDialect(sqlf.DialectPG)
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tblpg.Update.TableName, tblpg.Update.SetColumns, tblpg.Update.WhereColumns)
+	s, err = upd.Interpolate(Row1{Id: 12, GivenName: "Jane", FamilyName: `O'Brien\`, DOB: time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)})
+	assert.NoError(err)
+	assert.Equal(`update "table1" set "given_name"='Jane',"family_name"='O''Brien\',"Date_of_Birth"='2000-01-02 03:04:05+00:00' where "id"=12`, s)
+
+	sel := sqlf.Queryf("select %s from %s where %s = %s and id > %s",
+		tblpg.Select.Columns, tblpg.Select.TableName, "family_name",
+		tblpg.Select.Placeholder(), tblpg.Select.Placeholder())
+	s, err = sel.Interpolate(sql.NullString{}, 5)
+	assert.NoError(err)
+	assert.Contains(s, `where family_name = NULL and id > 5`)
+
+	_, err = sel.Interpolate("x")
+	assert.Error(err)
+
+	exec := sqlf.Execf("update %s set x = %s, y = %s, z = '?'", tbl.Update.TableName, tbl.Update.Placeholder(), tbl.Update.Placeholder())
+	s, err = exec.Interpolate([]byte{0xde, 0xad}, true)
+	assert.NoError(err)
+	assert.Equal("update `table1` set x = X'dead', y = 1, z = '?'", s)
+}
+
+// ansiDialect is a dialect that is not provided by the sqlf package.
+type ansiDialect struct{}
+
+func (ansiDialect) Quote(name string) string { return `"` + name + `"` }
+func (ansiDialect) Placeholder(n int) string { return "?" }
+
+func TestInterpolateDialects(t *testing.T) {
+	tests := []struct {
+		d    sqlf.Dialect
+		want string
+	}{
+		{sqlf.DialectMSSQL, "update [table1] set x = 0xdead, y = 'it''s'"},
+		{sqlf.DialectForDriver("sqlserver"), "update [table1] set x = 0xdead, y = 'it''s'"},
+		{sqlf.DialectPG, `update "table1" set x = '\xdead', y = 'it''s'`},
+		{ansiDialect{}, `update "table1" set x = X'dead', y = 'it''s'`},
+	}
+	for _, tt := range tests {
+		tbl := Row1Table.WithDialect(tt.d)
+		// positional placeholders, as the scanner does not recognize "@p1"
+		exec := sqlf.Execf("update %s set x = ?, y = ?", tbl.Update.TableName)
+		s, err := exec.Interpolate([]byte{0xde, 0xad}, "it's")
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, s)
+	}
+	assert.Equal(t, "", sqlf.DialectName(ansiDialect{}))
+}
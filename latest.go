@@ -0,0 +1,103 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// LatestRows is a derived table containing the latest row of each group
+// of rows in a table, such as the most recent order of each customer. It
+// is created using Latest, and passed as an argument to Queryf in place
+// of the table name in the FROM clause:
+//
+//	lastOrders := sqlf.Latest(orders.Select.TableName, "created_at desc", "customer_id")
+//
+//	listLast := sqlf.Queryf("select %s from %s where status = ? order by %s",
+//		orders.Select.Columns, lastOrders, orders.Select.OrderBy)
+//
+//	err := listLast.Select(db, &rows, "open")
+//
+// The derived table is rendered to suit the dialect of the command. For
+// PostgreSQL it uses "distinct on":
+//
+//	(select distinct on ("customer_id") * from "orders"
+//	 order by "customer_id", created_at desc) as "orders"
+//
+// For other databases it uses the row_number() window function:
+//
+//	(select `id`,`customer_id`,... from (select `id`,`customer_id`,...,
+//	 row_number() over (partition by `customer_id` order by created_at desc)
+//	 as sqlf_row_number from `orders`) as sqlf_latest
+//	 where sqlf_row_number = 1) as `orders`
+//
+// The derived table has the same name (or alias) and columns as the table,
+// so the column lists of the table can be used with it. Note that any
+// conditions in the WHERE clause of the command apply to the latest rows,
+// and not to the rows of the table that the latest rows are chosen from.
+type LatestRows struct {
+	from    TableName
+	orderBy string
+	groupBy []string
+}
+
+// Latest returns a derived table containing the first row of each group
+// of rows in the table, when the rows of the group are sorted by orderBy.
+// The rows are grouped by the groupBy columns, whose names are quoted.
+// The orderBy expression is not quoted, and can include more than one
+// column and the sort direction, eg "created_at desc, id desc".
+//
+// The table name must be the Select.TableName of a table, and at least one
+// groupBy column must be specified. Latest panics if they are not, in the
+// same way that Table panics for an invalid row type.
+func Latest(from TableName, orderBy string, groupBy ...string) *LatestRows {
+	if from.clause != clauseSelectFrom {
+		panic("sqlf.Latest: expected Select.TableName")
+	}
+	if len(groupBy) == 0 {
+		panic("sqlf.Latest: no groupBy columns")
+	}
+	return &LatestRows{
+		from:    from,
+		orderBy: orderBy,
+		groupBy: append([]string(nil), groupBy...),
+	}
+}
+
+// clone returns a copy of the derived table for a command,
+// whose table name is from.
+func (l *LatestRows) clone(from TableName) *LatestRows {
+	return &LatestRows{from: from, orderBy: l.orderBy, groupBy: l.groupBy}
+}
+
+// String renders the derived table, so that it can be formatted
+// using "%s" in the enclosing command.
+func (l *LatestRows) String() string {
+	ti := l.from.table
+	dialect := ti.Dialect()
+	alias := ti.alias
+	if alias == "" {
+		alias = dialect.Quote(ti.Name)
+	}
+	var groupBy bytes.Buffer
+	for i, column := range l.groupBy {
+		if i > 0 {
+			groupBy.WriteString(", ")
+		}
+		groupBy.WriteString(dialect.Quote(column))
+	}
+
+	if DialectName(dialect) == "postgres" {
+		return fmt.Sprintf("(select distinct on (%s) * from %s order by %s, %s) as %s",
+			groupBy.String(), l.from, groupBy.String(), l.orderBy, alias)
+	}
+
+	var columns bytes.Buffer
+	for i, ci := range ti.columns {
+		if i > 0 {
+			columns.WriteRune(',')
+		}
+		columns.WriteString(dialect.Quote(ci.columnName))
+	}
+	return fmt.Sprintf("(select %s from (select %s, row_number() over (partition by %s order by %s) as sqlf_row_number from %s) as sqlf_latest where sqlf_row_number = 1) as %s",
+		columns.String(), columns.String(), groupBy.String(), l.orderBy, l.from, alias)
+}
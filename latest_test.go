@@ -0,0 +1,61 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatest(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table orders(id integer primary key, customer_id integer, amount integer);
+		insert into orders values(1, 10, 100), (2, 20, 200), (3, 10, 300), (4, 20, 50), (5, 30, 75)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Order struct {
+		ID         int64
+		CustomerID int64
+		Amount     int64
+	}
+	orders := sqlf.Table("orders", Order{})
+
+	pg := orders.WithDialect(sqlf.DialectPG)
+	cmd := sqlf.Queryf("select %s from %s where amount > %s",
+		pg.Select.Columns, sqlf.Latest(pg.Select.TableName, "id desc", "customer_id"), pg.Select.Placeholder())
+	assert.Equal(`select "id","customer_id","amount" from `+
+		`(select distinct on ("customer_id") * from "orders" order by "customer_id", id desc) as "orders" `+
+		`where amount > $1`, cmd.Command())
+
+	// the dialect of the command is used
+	tbl := orders.WithDialect(sqlf.DialectSQLite)
+	last := sqlf.Latest(tbl.Select.TableName, "id desc", "customer_id")
+	cmd = sqlf.Queryf("select %s from %s where amount > ? order by %s",
+		tbl.Select.Columns, last, tbl.Select.OrderBy, sqlf.WithDialect(sqlf.DialectPG))
+	assert.Contains(cmd.Command(), `distinct on ("customer_id")`)
+
+	cmd = sqlf.Queryf("select %s from %s where amount > ? order by %s",
+		tbl.Select.Columns, last, tbl.Select.OrderBy)
+	assert.Equal("select `id`,`customer_id`,`amount` from "+
+		"(select `id`,`customer_id`,`amount` from (select `id`,`customer_id`,`amount`, "+
+		"row_number() over (partition by `customer_id` order by id desc) as sqlf_row_number from `orders`) "+
+		"as sqlf_latest where sqlf_row_number = 1) as `orders` where amount > ? order by `id`", cmd.Command())
+
+	var rows []Order
+	assert.NoError(cmd.Select(db, &rows, 60))
+	assert.Equal([]Order{{3, 10, 300}, {5, 30, 75}}, rows)
+
+	assert.Panics(func() {
+		sqlf.Latest(tbl.Select.TableName, "id desc")
+	})
+	assert.Panics(func() {
+		sqlf.Latest(tbl.Insert.TableName, "id desc", "customer_id")
+	})
+}
@@ -0,0 +1,69 @@
+package sqlf
+
+// WithMasking causes a query command to exclude the columns tagged as
+// sensitive from its select lists, so that personal and other sensitive
+// data is not read from the database:
+//
+//	type User struct {
+//		ID    int64
+//		Name  string
+//		Email string `sql:"sensitive"`
+//	}
+//
+//	listUsers := sqlf.Queryf("select %s from %s order by %s",
+//		users.Select.Columns, users.Select.TableName, users.Select.OrderBy,
+//		sqlf.WithMasking())
+//
+// The fields of the excluded columns are left unchanged when the rows are
+// scanned. Note that WithStrictScan reports them as fields without a
+// column, so the two options should not be used together.
+//
+// Regardless of WithMasking, the values of sensitive columns are never
+// printed by the Interpolate methods of commands, which print
+// "[redacted]" instead.
+func WithMasking() Option {
+	return func(o *options) {
+		o.masking = true
+	}
+}
+
+// redactedValue is printed by Interpolate in place of
+// the value of a sensitive column.
+const redactedValue = "[redacted]"
+
+// redact returns the args with the value of each sensitive column
+// replaced with redactedValue. The inputs are []positioner or
+// []*columnInfo, in placeholder order. The args do not include any
+// args inserted when the command is executed (see argBinding and
+// tenantBinding).
+func redact(inputs interface{}, args []interface{}) []interface{} {
+	var sensitive []bool
+	switch inputs := inputs.(type) {
+	case []*columnInfo:
+		for _, ci := range inputs {
+			sensitive = append(sensitive, ci.sensitive)
+		}
+	case []positioner:
+		for _, input := range inputs {
+			if _, ok := fixedInput(input); ok || isTenantInput(input) {
+				continue
+			}
+			ci, ok := input.(*columnInfo)
+			sensitive = append(sensitive, ok && ci.sensitive)
+		}
+	}
+
+	var redacted []interface{}
+	for i := range args {
+		if i < len(sensitive) && sensitive[i] {
+			if redacted == nil {
+				redacted = append([]interface{}(nil), args...)
+			}
+			redacted[i] = redactedValue
+		}
+	}
+	if redacted == nil {
+		return args
+	}
+	return redacted
+}
@@ -0,0 +1,55 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMasking(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table people(id integer primary key, name text, email text)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Person struct {
+		ID    int64
+		Name  string
+		Email string `sql:"sensitive"`
+	}
+	people := sqlf.Table("people", Person{}).WithDialect(sqlf.DialectSQLite)
+
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		people.Insert.TableName, people.Insert.Columns, people.Insert.Values)
+	assert.NoError(ins.Exec(db, &Person{ID: 1, Name: "John", Email: "john@example.com"}))
+	s, err := ins.Interpolate(&Person{ID: 2, Name: "Jane", Email: "jane@example.com"})
+	assert.NoError(err)
+	assert.Equal("insert into `people`(`id`,`name`,`email`) values(2,'Jane','[redacted]')", s)
+
+	list := sqlf.Queryf("select %s from %s order by %s",
+		people.Select.Columns, people.Select.TableName, people.Select.OrderBy, sqlf.WithMasking())
+	assert.Equal("select `id`,`name` from `people` order by `id`", list.Command())
+	var rows []Person
+	assert.NoError(list.Select(db, &rows))
+	assert.Equal([]Person{{ID: 1, Name: "John"}}, rows)
+
+	// without masking, all columns are selected
+	list = sqlf.Queryf("select %s from %s", people.Select.Columns, people.Select.TableName)
+	assert.Equal("select `id`,`name`,`email` from `people`", list.Command())
+
+	byEmail := sqlf.Queryf("select %s from %s where %s and %s",
+		people.Select.Columns, people.Select.TableName,
+		people.Update.WhereColumns.Include("Name"), people.Update.WhereColumns.Include("Email"),
+		sqlf.WithMasking())
+	assert.Equal("select `id`,`name` from `people` where `name`=? and `email`=?", byEmail.Command())
+	s, err = byEmail.Interpolate("John", "john@example.com")
+	assert.NoError(err)
+	assert.Equal("select `id`,`name` from `people` where `name`='John' and `email`='[redacted]'", s)
+}
@@ -0,0 +1,158 @@
+package sqlf
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync"
+)
+
+// WithMemo causes the results of a query command to be memoized for the
+// duration of a transaction or request. When the command's Select or Get
+// method is called using a handle whose context was created using
+// ContextWithMemo, and the command has already been called with the same
+// args and the same type of dest using a handle with that context, dest
+// is set to a copy of the previous result without querying the database.
+//
+// Any command that writes to the database using a handle with the same
+// context forgets all of the memoized results, so that the queries that
+// follow see the changes. Changes made using handles with a different
+// context are not visible, so the context should be no longer-lived than
+// a transaction or request:
+//
+//	tx, err := pool.Beginx()
+//	// ...
+//	db := sqlf.ForContext(sqlf.ContextWithMemo(ctx), tx)
+//	err = getCustomer.Get(db, &customer, order.CustomerID) // queries
+//	err = getCustomer.Get(db, &customer, order.CustomerID) // memoized
+//
+// Only results without errors are memoized. AfterScan is not called for
+// a memoized result, and the command is not reported to the metrics sink.
+func WithMemo() Option {
+	return func(o *options) {
+		o.memo = true
+	}
+}
+
+// ContextWithMemo returns a copy of ctx that contains a new, empty memo
+// for the results of commands built using WithMemo. See ForContext.
+func ContextWithMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, memoKey{}, &memo{})
+}
+
+type memoKey struct{}
+
+// memo contains the memoized results of query commands.
+type memo struct {
+	mutex   sync.Mutex
+	results map[memoEntry]reflect.Value
+}
+
+// memoEntry identifies a memoized result.
+type memoEntry struct {
+	cmd  *queryCommand
+	op   string
+	dest reflect.Type
+	args string
+}
+
+// memoFrom returns the memo in ctx, or nil if there is none.
+func memoFrom(ctx context.Context) *memo {
+	m, _ := ctx.Value(memoKey{}).(*memo)
+	return m
+}
+
+// forgetMemo forgets the memoized results in ctx, if any. It is called
+// for every command that writes to the database.
+func forgetMemo(ctx context.Context) {
+	if m := memoFrom(ctx); m != nil {
+		m.mutex.Lock()
+		m.results = nil
+		m.mutex.Unlock()
+	}
+}
+
+// memoEntry returns the memo for the command executed using db, and
+// the entry for its result, or nil if the result is not memoized.
+func (cmd *queryCommand) memoEntry(db interface{}, op string, dest interface{}, args []interface{}) (*memo, memoEntry) {
+	if !cmd.opts.memo {
+		return nil, memoEntry{}
+	}
+	m := memoFrom(contextOf(db))
+	if m == nil {
+		return nil, memoEntry{}
+	}
+	var buf bytes.Buffer
+	for i, arg := range args {
+		if i > 0 {
+			buf.WriteByte(0)
+		}
+		buf.WriteString(keyString(arg))
+	}
+	return m, memoEntry{cmd: cmd, op: op, dest: reflect.TypeOf(dest), args: buf.String()}
+}
+
+// load sets dest to a copy of the memoized result, and reports whether
+// there is one. For Select, the rows are appended to the slice in dest.
+func (m *memo) load(entry memoEntry, dest interface{}) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	result, ok := m.results[entry]
+	if ok {
+		v := reflect.ValueOf(dest).Elem()
+		if entry.op == OpSelect {
+			v.Set(reflect.AppendSlice(v, copyResult(result)))
+		} else {
+			v.Set(copyResult(result))
+		}
+	}
+	return ok
+}
+
+// store saves a copy of the result in dest. For Select, the result is
+// the rows appended to the slice in dest after its first n rows.
+func (m *memo) store(entry memoEntry, dest interface{}, n int) {
+	v := reflect.ValueOf(dest).Elem()
+	if entry.op == OpSelect {
+		v = v.Slice(n, v.Len())
+	}
+	result := copyResult(v)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.results == nil {
+		m.results = make(map[memoEntry]reflect.Value)
+	}
+	m.results[entry] = result
+}
+
+// copyResult returns a copy of v, so that changes made by the caller to
+// the result do not change the memoized copy. Slices, and pointers to the
+// rows in slices, are copied. The fields of the rows are not copied.
+func copyResult(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyResult(v.Index(i)))
+		}
+		return c
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(v.Elem())
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		return copyResult(v.Elem())
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
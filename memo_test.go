@@ -0,0 +1,83 @@
+package sqlf_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMemo(t *testing.T) {
+	assert := assert.New(t)
+	pool, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	pool.SetMaxOpenConns(1)
+	if _, err := pool.Exec(`create table colors(id integer primary key, name text)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Exec(`insert into colors(id, name) values(1, 'red'), (2, 'green')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Color struct {
+		ID   int64 `sql:"primary_key"`
+		Name string
+	}
+	colors := sqlf.Table("colors", Color{}).WithDialect(sqlf.DialectSQLite)
+	getColor := sqlf.Queryf("select %s from %s where %s",
+		colors.Select.Columns, colors.Select.TableName, colors.Update.WhereColumns, sqlf.WithMemo())
+	listColors := sqlf.Queryf("select %s from %s order by %s",
+		colors.Select.Columns, colors.Select.TableName, colors.Select.OrderBy, sqlf.WithMemo())
+	updateColor := sqlf.UpdateRowf("update %s set %s where %s",
+		colors.Update.TableName, colors.Update.SetColumns, colors.Update.WhereColumns)
+
+	tx, err := pool.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	db := sqlf.ForContext(sqlf.ContextWithMemo(context.Background()), tx)
+
+	var color Color
+	assert.NoError(getColor.Get(db, &color, 1))
+	assert.Equal(Color{ID: 1, Name: "red"}, color)
+	var list []*Color
+	assert.NoError(listColors.Select(db, &list))
+	assert.Len(list, 2)
+
+	// changes made using another handle are not visible
+	if _, err := tx.Exec(`update colors set name = 'blue' where id = 2`); err != nil {
+		t.Fatal(err)
+	}
+	color = Color{}
+	assert.NoError(getColor.Get(db, &color, int64(1)))
+	assert.Equal(Color{ID: 1, Name: "red"}, color)
+	assert.NoError(getColor.Get(db, &color, 2))
+	assert.Equal(Color{ID: 2, Name: "blue"}, color)
+
+	// the memoized rows are copies, and are appended to dest
+	list[0].Name = "changed"
+	list2 := []*Color{{ID: 99}}
+	assert.NoError(listColors.Select(db, &list2))
+	assert.Equal([]*Color{{ID: 99}, {ID: 1, Name: "red"}, {ID: 2, Name: "green"}}, list2)
+
+	// without a memo, the database is queried
+	list = nil
+	assert.NoError(listColors.Select(tx, &list))
+	assert.Equal([]*Color{{ID: 1, Name: "red"}, {ID: 2, Name: "blue"}}, list)
+
+	// a write using the handle forgets the memoized results
+	n, err := updateColor.Exec(db, &Color{ID: 1, Name: "yellow"})
+	assert.NoError(err)
+	assert.Equal(1, n)
+	assert.NoError(getColor.Get(db, &color, 1))
+	assert.Equal(Color{ID: 1, Name: "yellow"}, color)
+	list = nil
+	assert.NoError(listColors.Select(db, &list))
+	assert.Equal([]*Color{{ID: 1, Name: "yellow"}, {ID: 2, Name: "blue"}}, list)
+}
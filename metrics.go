@@ -0,0 +1,142 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Operations reported in the CommandEvent.Operation field.
+const (
+	OpInsertRow = "insertrow"
+	OpUpdateRow = "updaterow"
+	OpExec      = "exec"
+	OpQuery     = "query"
+	OpQueryRow  = "queryrow"
+	OpSelect    = "select"
+	OpGet       = "get"
+	OpCursor    = "cursor"
+	OpCall      = "call"
+	OpExport    = "export"
+	OpBatch     = "batch"
+)
+
+// CommandEvent describes a single execution of a command.
+type CommandEvent struct {
+	// Name is the name of the command set using WithName,
+	// or the empty string if the command has no name.
+	Name string
+
+	// Command is the SQL statement.
+	Command string
+
+	// Caller is the location of the code that built the command,
+	// or the empty string if it is not known. See WithCaller.
+	Caller string
+
+	// Operation is the method used to execute the command,
+	// for example OpExec or OpSelect.
+	Operation string
+
+	// Duration is the time taken to execute the command. For Select it
+	// includes the time taken to read all the rows. For Query and QueryRow
+	// it does not include the time taken to read the rows, which happens
+	// after the event is reported.
+	Duration time.Duration
+
+	// Rows is the number of rows affected by an insert, update or delete
+	// statement, or the number of rows scanned by Select. It is -1 if the
+	// number of rows is not known.
+	Rows int64
+
+	// Err is the error returned, or nil if the command succeeded.
+	Err error
+
+	// Context is the context of the handle used to execute the command
+	// (see ForContext), or the background context if it has none.
+	Context context.Context
+}
+
+// MetricsSink receives an event each time a command is executed.
+// Implementations must be safe for concurrent use. See the sqlfprom
+// package for an implementation that reports to Prometheus.
+type MetricsSink interface {
+	CommandExecuted(event *CommandEvent)
+}
+
+var metrics = struct {
+	mutex sync.RWMutex
+	sink  MetricsSink
+}{}
+
+// SetMetricsSink sets the metrics sink that receives an event for each
+// command executed. Setting a nil sink disables reporting. The sink is
+// typically set once during program initialization.
+func SetMetricsSink(sink MetricsSink) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.sink = sink
+}
+
+func metricsSink() MetricsSink {
+	metrics.mutex.RLock()
+	defer metrics.mutex.RUnlock()
+	return metrics.sink
+}
+
+// ErrorClass returns a short description of the class of error, suitable
+// for use as a metrics label. It returns the empty string for a nil error,
+// "no_rows" for sql.ErrNoRows, "canceled" and "timeout" for context errors,
+// "validation" for a ValidationErrors, "duplicate_key" for a
+// DuplicateKeyError, and "other" for all other errors.
+func ErrorClass(err error) string {
+	var verrs ValidationErrors
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, sql.ErrNoRows):
+		return "no_rows"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &verrs):
+		return "validation"
+	case errors.Is(err, ErrDuplicateKey):
+		return "duplicate_key"
+	}
+	return "other"
+}
+
+// report sends an event to the metrics sink, if there is one,
+// for a command that started executing at the start time.
+func (o options) report(ctx context.Context, op string, command string, start time.Time, rows int64, err error) {
+	sink := metricsSink()
+	if sink == nil {
+		return
+	}
+	sink.CommandExecuted(&CommandEvent{
+		Name:      o.name,
+		Command:   command,
+		Caller:    o.caller,
+		Operation: op,
+		Duration:  time.Since(start),
+		Rows:      rows,
+		Err:       err,
+		Context:   ctx,
+	})
+}
+
+// rowsAffected returns the number of rows affected, or -1 if not known.
+func rowsAffected(result sql.Result) int64 {
+	if result == nil {
+		return -1
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,105 @@
+package sqlf_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSink struct {
+	mutex  sync.Mutex
+	events []*sqlf.CommandEvent
+}
+
+func (s *testSink) CommandExecuted(event *sqlf.CommandEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event)
+}
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	sink := &testSink{}
+	sqlf.SetMetricsSink(sink)
+	defer sqlf.SetMetricsSink(nil)
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values,
+		sqlf.WithName("InsertRow1"))
+	assert.Equal("insert into `table1`(`given_name`,`family_name`,`Date_of_Birth`) values(?,?,?)", insert.Command())
+	for i := 0; i < 3; i++ {
+		assert.NoError(insert.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen"}))
+	}
+
+	query := sqlf.Queryf("select %s from %s where %s = ?",
+		tbl.Select.Columns, tbl.Select.TableName, "family_name",
+		sqlf.WithName("GetRow1ByFamilyName"))
+	var rows []Row1
+	assert.NoError(query.Select(db, &rows, "Citizen"))
+	assert.Len(rows, 3)
+
+	var row Row1
+	err = query.QueryRow(db, "Nobody").StructScan(&row)
+	assert.Equal(sql.ErrNoRows, err)
+
+	// commands without a name are reported too
+	_, err = sqlf.Execf("delete from %s", tbl.Delete.TableName).Exec(db)
+	assert.NoError(err)
+
+	if !assert.Len(sink.events, 6) {
+		return
+	}
+	for i := 0; i < 3; i++ {
+		e := sink.events[i]
+		assert.Equal("InsertRow1", e.Name)
+		assert.Equal(sqlf.OpInsertRow, e.Operation)
+		assert.Equal(insert.Command(), e.Command)
+		assert.Equal(int64(1), e.Rows)
+		assert.NoError(e.Err)
+	}
+	assert.Equal("GetRow1ByFamilyName", sink.events[3].Name)
+	assert.Equal(sqlf.OpSelect, sink.events[3].Operation)
+	assert.Equal(int64(3), sink.events[3].Rows)
+	assert.Equal(sqlf.OpQueryRow, sink.events[4].Operation)
+	assert.Equal(int64(-1), sink.events[4].Rows)
+	assert.Equal("", sink.events[5].Name)
+	assert.Equal(sqlf.OpExec, sink.events[5].Operation)
+	assert.Equal(int64(3), sink.events[5].Rows)
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{sql.ErrNoRows, "no_rows"},
+		{fmt.Errorf("wrapped: %w", sql.ErrNoRows), "no_rows"},
+		{context.Canceled, "canceled"},
+		{context.DeadlineExceeded, "timeout"},
+		{sqlf.ValidationErrors{{Column: "name", Field: "Name", Message: "cannot be empty"}}, "validation"},
+		{errors.New("syntax error"), "other"},
+	}
+	for _, tt := range tests {
+		if got := sqlf.ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v): got %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
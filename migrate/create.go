@@ -0,0 +1,266 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	bytesType  = reflect.TypeOf([]byte(nil))
+)
+
+const (
+	nullPrefix     = "Null" // sql.NullString, sql.NullInt64 etc
+	defaultVarchar = 255    // length of MySQL varchar columns without a maxlen tag
+)
+
+// CreateTable returns a command that creates the table. The type of each
+// column is taken from its "dbtype" tag, for example:
+//
+//	type User struct {
+//		ID      int64  `sql:"primary_key;auto_increment"`
+//		Email   string `sql:"dbtype:varchar(320)"`
+//		Balance int64  `sql:"dbtype:numeric(12,2)"`
+//	}
+//
+// Columns without a "dbtype" tag have a type chosen for the field type
+// and the dialect of the table. String columns with a "maxlen" tag are
+// varchar columns of that length. Columns are "not null", except for
+// pointer, slice and sql.NullXxx fields, which can hold a null value.
+//
+// CreateTable panics if the type of a column without a "dbtype" tag
+// cannot be determined, in the same way that sqlf.Table panics for an
+// invalid row type.
+func CreateTable(ti *sqlf.TableInfo) sqlf.ExecCommand {
+	return createTable(ti, "")
+}
+
+// CreateTableWithoutRowID returns a command that creates an SQLite table
+// without the implicit rowid column, which stores the rows in order of the
+// primary key. This reduces the size of tables with a non-integer or
+// composite primary key, and speeds up lookups by the primary key.
+//
+// CreateTableWithoutRowID panics if the table is not for SQLite, does not
+// have a primary key, or has an auto-increment column, which requires the
+// rowid.
+func CreateTableWithoutRowID(ti *sqlf.TableInfo) sqlf.ExecCommand {
+	if dialect := dialectName(ti); dialect != "sqlite3" {
+		panic(fmt.Sprintf("migrate.CreateTableWithoutRowID: table %s: not supported for dialect %q", ti.Name, dialect))
+	}
+	var hasPK bool
+	for _, col := range ti.Columns() {
+		if col.AutoIncrement {
+			panic(fmt.Sprintf("migrate.CreateTableWithoutRowID: table %s: auto-increment column %s requires a rowid", ti.Name, col.Name))
+		}
+		hasPK = hasPK || col.PrimaryKey
+	}
+	if !hasPK {
+		panic(fmt.Sprintf("migrate.CreateTableWithoutRowID: table %s: no primary key", ti.Name))
+	}
+	return createTable(ti, " without rowid")
+}
+
+// createTable returns a command that creates the table,
+// with the suffix appended to the statement.
+func createTable(ti *sqlf.TableInfo, suffix string) sqlf.ExecCommand {
+	dialect := dialectName(ti)
+	var buf bytes.Buffer
+	var pk []string
+	for _, col := range ti.Columns() {
+		if col.PrimaryKey {
+			pk = append(pk, col.Name)
+		}
+	}
+	for i, col := range ti.Columns() {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n\t")
+		buf.WriteString(ti.Dialect().Quote(col.Name))
+		buf.WriteString(" ")
+		buf.WriteString(columnDef(ti, dialect, col, len(pk) == 1))
+	}
+	if len(pk) > 1 || (len(pk) == 1 && !inlinePrimaryKey(dialect, ti)) {
+		for i, name := range pk {
+			pk[i] = ti.Dialect().Quote(name)
+		}
+		fmt.Fprintf(&buf, ",\n\tprimary key(%s)", strings.Join(pk, ","))
+	}
+	buf.WriteString("\n)")
+	buf.WriteString(suffix)
+
+	// the column definitions are not format strings
+	defs := strings.Replace(buf.String(), "%", "%%", -1)
+	return sqlf.Execf("create table %s("+defs, ti.Insert.TableName)
+}
+
+// dialectName returns the name of the table's dialect. Both
+// SQL Server dialects have the name "mssql".
+func dialectName(ti *sqlf.TableInfo) string {
+	d := ti.Dialect()
+	if d == nil {
+		return ""
+	}
+	if sqlf.DialectName(d) == "sqlserver" {
+		return "mssql"
+	}
+	return sqlf.DialectName(d)
+}
+
+// inlinePrimaryKey reports whether the single primary key column of the
+// table is declared "primary key" in its column definition. This is
+// required for SQLite auto-increment columns ("integer primary key").
+func inlinePrimaryKey(dialect string, ti *sqlf.TableInfo) bool {
+	if dialect != "sqlite3" {
+		return false
+	}
+	for _, col := range ti.Columns() {
+		if col.PrimaryKey && col.AutoIncrement {
+			return true
+		}
+	}
+	return false
+}
+
+// columnDef returns the column definition, excluding the column name.
+func columnDef(ti *sqlf.TableInfo, dialect string, col sqlf.Column, singlePK bool) string {
+	t := col.FieldType
+	nullable := t.Kind() == reflect.Ptr || (t.Kind() == reflect.Slice && t != bytesType) ||
+		(t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), nullPrefix))
+	if col.NotNull {
+		nullable = false
+	}
+
+	if col.AutoIncrement {
+		switch dialect {
+		case "postgres":
+			if col.DBType != "" {
+				return col.DBType + " not null"
+			}
+			if baseType(t).Kind() == reflect.Int64 {
+				return "bigserial not null"
+			}
+			return "serial not null"
+		case "sqlite3":
+			if singlePK && col.PrimaryKey {
+				return "integer primary key autoincrement"
+			}
+		}
+	}
+
+	dbType := col.DBType
+	if dbType == "" {
+		dbType = defaultType(dialect, t, col.MaxLen)
+		if dbType == "" {
+			panic(fmt.Sprintf("migrate.CreateTable: table %s: cannot determine the type of column %s: add a dbtype tag to field %s",
+				ti.Name, col.Name, col.FieldName))
+		}
+	}
+	def := dbType
+	if !nullable || col.PrimaryKey {
+		def += " not null"
+	}
+	if col.AutoIncrement && dialect == "mysql" {
+		def += " auto_increment"
+	}
+	return def
+}
+
+// baseType returns t without any pointer indirection.
+func baseType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// defaultType returns the column type for a field of type t,
+// or the empty string if there is no suitable type.
+func defaultType(dialect string, t reflect.Type, maxLen int) string {
+	t = baseType(t)
+	if t == timeType {
+		switch dialect {
+		case "postgres":
+			return "timestamp with time zone"
+		case "mssql":
+			return "datetime2"
+		}
+		return "datetime"
+	}
+	if t == bytesType {
+		switch dialect {
+		case "postgres":
+			return "bytea"
+		case "mssql":
+			return "varbinary(max)"
+		}
+		return "blob"
+	}
+	if t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), nullPrefix) && t.NumField() > 0 {
+		// sql.NullString etc: use the type of the first field
+		return defaultType(dialect, t.Field(0).Type, maxLen)
+	}
+	if t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType) {
+		// custom types need a dbtype tag
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		switch dialect {
+		case "mysql":
+			return "tinyint(1)"
+		case "mssql":
+			return "bit"
+		}
+		return "boolean"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint"
+	case reflect.Int32, reflect.Uint16:
+		return "integer"
+	case reflect.Int, reflect.Int64, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		if dialect == "sqlite3" {
+			return "integer"
+		}
+		return "bigint"
+	case reflect.Float32:
+		return "real"
+	case reflect.Float64:
+		switch dialect {
+		case "postgres":
+			return "double precision"
+		case "mssql":
+			return "float"
+		}
+		return "double"
+	case reflect.String:
+		if maxLen > 0 {
+			return fmt.Sprintf("varchar(%d)", maxLen)
+		}
+		switch dialect {
+		case "mysql":
+			return fmt.Sprintf("varchar(%d)", defaultVarchar)
+		case "mssql":
+			return "nvarchar(max)"
+		}
+		return "text"
+	case reflect.Slice:
+		// array columns, see sqlf.Table
+		if dialect == "postgres" {
+			if elem := defaultType(dialect, t.Elem(), 0); elem != "" {
+				return elem + "[]"
+			}
+			return ""
+		}
+		return "text"
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package migrate_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+type Account struct {
+	ID        int64  `sql:"primary_key;auto_increment"`
+	Email     string `sql:"maxlen:320;not null"`
+	Name      string
+	Balance   int64 `sql:"dbtype:numeric(12,2)"`
+	Active    bool
+	Score     *float64
+	Nickname  sql.NullString
+	Tags      []string
+	Avatar    []byte
+	CreatedAt time.Time
+}
+
+type Membership struct {
+	AccountID int64 `sql:"primary_key"`
+	GroupID   int64 `sql:"primary_key"`
+}
+
+func TestCreateTable(t *testing.T) {
+	accounts := sqlf.Table("accounts", Account{})
+	memberships := sqlf.Table("memberships", Membership{})
+	tests := []struct {
+		ti   *sqlf.TableInfo
+		want string
+	}{
+		{
+			ti: accounts.WithDialect(sqlf.DialectPG),
+			want: `create table "accounts"(
+	"id" bigserial not null,
+	"email" varchar(320) not null,
+	"name" text not null,
+	"balance" numeric(12,2) not null,
+	"active" boolean not null,
+	"score" double precision,
+	"nickname" text,
+	"tags" text[],
+	"avatar" bytea not null,
+	"created_at" timestamp with time zone not null,
+	primary key("id")
+)`,
+		},
+		{
+			ti: accounts.WithDialect(sqlf.DialectMySQL),
+			want: "create table `accounts`(\n" +
+				"\t`id` bigint not null auto_increment,\n" +
+				"\t`email` varchar(320) not null,\n" +
+				"\t`name` varchar(255) not null,\n" +
+				"\t`balance` numeric(12,2) not null,\n" +
+				"\t`active` tinyint(1) not null,\n" +
+				"\t`score` double,\n" +
+				"\t`nickname` varchar(255),\n" +
+				"\t`tags` text,\n" +
+				"\t`avatar` blob not null,\n" +
+				"\t`created_at` datetime not null,\n" +
+				"\tprimary key(`id`)\n" +
+				")",
+		},
+		{
+			ti: accounts.WithDialect(sqlf.DialectSQLite),
+			want: "create table `accounts`(\n" +
+				"\t`id` integer primary key autoincrement,\n" +
+				"\t`email` varchar(320) not null,\n" +
+				"\t`name` text not null,\n" +
+				"\t`balance` numeric(12,2) not null,\n" +
+				"\t`active` boolean not null,\n" +
+				"\t`score` double,\n" +
+				"\t`nickname` text,\n" +
+				"\t`tags` text,\n" +
+				"\t`avatar` blob not null,\n" +
+				"\t`created_at` datetime not null\n" +
+				")",
+		},
+		{
+			ti: memberships.WithDialect(sqlf.DialectPG),
+			want: `create table "memberships"(
+	"account_id" bigint not null,
+	"group_id" bigint not null,
+	primary key("account_id","group_id")
+)`,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, migrate.CreateTable(tt.ti).Command(), sqlf.DialectName(tt.ti.Dialect()))
+	}
+}
+
+func TestCreateTableUnknownType(t *testing.T) {
+	type Shape struct {
+		ID       int64
+		Position complex128
+	}
+	assert.Panics(t, func() {
+		migrate.CreateTable(sqlf.Table("shapes", Shape{}).WithDialect(sqlf.DialectPG))
+	})
+}
+
+func TestCreateTableWithoutRowID(t *testing.T) {
+	memberships := sqlf.Table("memberships", Membership{}).WithDialect(sqlf.DialectSQLite)
+	want := "create table `memberships`(\n" +
+		"\t`account_id` integer not null,\n" +
+		"\t`group_id` integer not null,\n" +
+		"\tprimary key(`account_id`,`group_id`)\n" +
+		") without rowid"
+	assert.Equal(t, want, migrate.CreateTableWithoutRowID(memberships).Command())
+
+	assert.Panics(t, func() {
+		migrate.CreateTableWithoutRowID(sqlf.Table("accounts", Account{}).WithDialect(sqlf.DialectSQLite))
+	})
+	assert.Panics(t, func() {
+		migrate.CreateTableWithoutRowID(memberships.WithDialect(sqlf.DialectPG))
+	})
+}
@@ -0,0 +1,165 @@
+// Package migrate creates and updates the tables of a database, using the
+// table information created by sqlf.Table, so that the table definitions
+// and the DDL are kept in one place.
+//
+// Each migration has a version number, and contains the commands that
+// are executed to apply it:
+//
+//	var migrations = migrate.New(
+//		migrate.Migration{
+//			Version:  1,
+//			Name:     "create users",
+//			Commands: []sqlf.ExecCommand{migrate.CreateTable(users)},
+//		},
+//		migrate.Migration{
+//			Version: 2,
+//			Name:    "add users.email index",
+//			Commands: []sqlf.ExecCommand{
+//				sqlf.Execf("create index users_email on %s(email)", users.Insert.TableName),
+//			},
+//		},
+//	)
+//
+//	err := migrations.Up(db)
+//
+// The versions of the migrations that have been applied are recorded in
+// the schema_migrations table, which is created if it does not exist.
+// Migrations must not be changed once they have been applied.
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultTableName is the name of the table that records the
+// versions of the migrations that have been applied.
+const DefaultTableName = "schema_migrations"
+
+// Migration is a change to the database schema.
+type Migration struct {
+	Version  int64              // Version number, must be positive and unique
+	Name     string             // Description of the migration
+	Commands []sqlf.ExecCommand // Commands executed to apply the migration
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	// TableName is the name of the table that records the versions
+	// of the migrations that have been applied. If blank, the table
+	// is DefaultTableName.
+	TableName string
+
+	migrations []Migration
+}
+
+// New returns a migrator for the migrations, which are applied in order
+// of their version numbers. New panics if a version number is not
+// positive, or if more than one migration has the same version number.
+func New(migrations ...Migration) *Migrator {
+	m := &Migrator{migrations: append([]Migration(nil), migrations...)}
+	sort.SliceStable(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	for i, mig := range m.migrations {
+		if mig.Version <= 0 {
+			panic(fmt.Sprintf("migrate.New: invalid version %d", mig.Version))
+		}
+		if i > 0 && m.migrations[i-1].Version == mig.Version {
+			panic(fmt.Sprintf("migrate.New: duplicate version %d", mig.Version))
+		}
+	}
+	return m
+}
+
+func (m *Migrator) tableName() string {
+	if m.TableName == "" {
+		return DefaultTableName
+	}
+	return m.TableName
+}
+
+// createTable creates the table that records the applied
+// migrations, if it does not already exist.
+func (m *Migrator) createTable(db sqlx.Execer) error {
+	_, err := db.Exec(fmt.Sprintf("create table if not exists %s("+
+		"version bigint not null primary key, "+
+		"name varchar(255) not null, "+
+		"applied_at timestamp not null)", m.tableName()))
+	return err
+}
+
+// Applied returns the versions of the migrations that have been applied
+// to the database, in ascending order.
+func (m *Migrator) Applied(db sqlx.Ext) ([]int64, error) {
+	if err := m.createTable(db); err != nil {
+		return nil, err
+	}
+	var versions []int64
+	query := fmt.Sprintf("select version from %s order by version", m.tableName())
+	if err := sqlx.Select(db, &versions, query); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Pending returns the migrations that have not been applied
+// to the database, in the order they will be applied.
+func (m *Migrator) Pending(db sqlx.Ext) ([]Migration, error) {
+	versions, err := m.Applied(db)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if !applied[mig.Version] {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order. Each migration is applied
+// in its own transaction, along with the record of its version, so that a
+// failed migration can be fixed and retried. Note that some databases,
+// including MySQL, commit any DDL statement (eg "create table") immediately,
+// so a failed migration may have been partially applied.
+func (m *Migrator) Up(db *sqlx.DB) error {
+	pending, err := m.Pending(db)
+	if err != nil {
+		return err
+	}
+	for _, mig := range pending {
+		if err := m.apply(db, mig); err != nil {
+			return fmt.Errorf("migrate: version %d (%s): %v", mig.Version, mig.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(db *sqlx.DB, mig Migration) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	for _, cmd := range mig.Commands {
+		if _, err := cmd.Exec(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	insert := fmt.Sprintf("insert into %s(version, name, applied_at) values(?, ?, ?)", m.tableName())
+	if _, err := tx.Exec(tx.Rebind(insert), mig.Version, mig.Name, time.Now().UTC()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
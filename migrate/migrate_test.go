@@ -0,0 +1,99 @@
+package migrate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/migrate"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type Note struct {
+	ID      int64 `sql:"primary_key;auto_increment"`
+	Title   string
+	Created time.Time
+}
+
+func TestMigrator(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	notes := sqlf.Table("notes", Note{}).WithDialect(sqlf.DialectSQLite)
+
+	m := migrate.New(
+		migrate.Migration{
+			Version: 2,
+			Name:    "index notes",
+			Commands: []sqlf.ExecCommand{
+				sqlf.Execf("create index notes_title on %s(title)", notes.Insert.TableName),
+			},
+		},
+		migrate.Migration{
+			Version:  1,
+			Name:     "create notes",
+			Commands: []sqlf.ExecCommand{migrate.CreateTable(notes)},
+		},
+	)
+
+	pending, err := m.Pending(db)
+	assert.NoError(err)
+	if assert.Len(pending, 2) {
+		assert.Equal(int64(1), pending[0].Version)
+		assert.Equal(int64(2), pending[1].Version)
+	}
+
+	assert.NoError(m.Up(db))
+	applied, err := m.Applied(db)
+	assert.NoError(err)
+	assert.Equal([]int64{1, 2}, applied)
+
+	// the table has been created
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		notes.Insert.TableName, notes.Insert.Columns, notes.Insert.Values)
+	note := Note{Title: "hello", Created: time.Now()}
+	assert.NoError(insert.Exec(db, &note))
+	assert.Equal(int64(1), note.ID)
+
+	// applying again does nothing
+	assert.NoError(m.Up(db))
+
+	// a failed migration is rolled back and not recorded
+	m2 := migrate.New(
+		migrate.Migration{Version: 1, Name: "create notes"},
+		migrate.Migration{Version: 2, Name: "index notes"},
+		migrate.Migration{
+			Version: 3,
+			Name:    "bad",
+			Commands: []sqlf.ExecCommand{
+				sqlf.Execf("delete from %s", notes.Delete.TableName),
+				sqlf.Execf("alter table no_such_table add column x integer"),
+			},
+		},
+	)
+	err = m2.Up(db)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "migrate: version 3 (bad)")
+	}
+	applied, err = m2.Applied(db)
+	assert.NoError(err)
+	assert.Equal([]int64{1, 2}, applied)
+	var count int
+	assert.NoError(db.Get(&count, "select count(*) from notes"))
+	assert.Equal(1, count)
+}
+
+func TestNewInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		migrate.New(migrate.Migration{Version: 1}, migrate.Migration{Version: 1})
+	})
+	assert.Panics(t, func() {
+		migrate.New(migrate.Migration{Version: 0})
+	})
+}
@@ -0,0 +1,108 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjeffery/sqlf/scan"
+	"github.com/jmoiron/sqlx"
+)
+
+// namedParamRE matches the column names that can be used as
+// the names of parameters in an sqlx named statement.
+var namedParamRE = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func (cmd insertRowCommand) NamedCommand() (string, error) {
+	if cmd.err != nil {
+		return "", cmd.err
+	}
+	var buf bytes.Buffer
+	var next int
+	scanner := scan.NewScanner(strings.NewReader(cmd.command))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		if tok != scan.PLACEHOLDER {
+			// a colon is escaped by doubling it
+			buf.WriteString(strings.Replace(lit, ":", "::", -1))
+			continue
+		}
+		next++
+		n := next
+		if len(lit) > 1 {
+			if i, err := strconv.Atoi(lit[1:]); err == nil {
+				n = i
+			}
+		}
+		if n < 1 || n > len(cmd.inputs) {
+			return "", fmt.Errorf("NamedCommand: placeholder %s does not match a column", lit)
+		}
+		name := cmd.inputs[n-1].columnName
+		if !namedParamRE.MatchString(name) {
+			return "", fmt.Errorf("NamedCommand: column %q cannot be a parameter name", name)
+		}
+		buf.WriteString(":" + name)
+	}
+	return buf.String(), nil
+}
+
+func (cmd insertRowCommand) ExecMaps(db sqlx.Execer, rows []map[string]interface{}) (rowCount int64, err error) {
+	if cmd.err != nil {
+		return 0, cmd.err
+	}
+	if b, ok := unwrapHandle(db).(txBeginner); ok && len(rows) > 1 {
+		tx, err := b.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		n, err := cmd.execMaps(rewrapHandle(db, tx), rows)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	return cmd.execMaps(db, rows)
+}
+
+// execMaps inserts each of the rows, and returns
+// the total number of rows affected.
+func (cmd insertRowCommand) execMaps(db sqlx.Execer, rows []map[string]interface{}) (int64, error) {
+	ctx := contextOf(db)
+	var total int64
+	for i, row := range rows {
+		args := make([]interface{}, 0, len(cmd.inputs))
+		for _, ci := range cmd.inputs {
+			value, ok := row[ci.columnName]
+			if !ok {
+				return total, fmt.Errorf("ExecMaps: row %d: missing column %s", i, ci.columnName)
+			}
+			arg, err := ci.encodeArg(ctx, value)
+			if err != nil {
+				return total, fmt.Errorf("ExecMaps: row %d: %v", i, err)
+			}
+			args = append(args, arg)
+		}
+		cmd.opts.debugBindings(OpInsertRow, cmd.command, cmd.inputs, args)
+		start := time.Now()
+		result, err := cmd.opts.exec(db, cmd.queryFor(db), args)
+		n := rowsAffected(result)
+		cmd.opts.report(ctx, OpInsertRow, cmd.command, start, n, err)
+		if err != nil {
+			return total, cmd.opts.wrapError(OpInsertRow, cmd.command, args, err)
+		}
+		if n > 0 {
+			total += n
+		}
+	}
+	return total, nil
+}
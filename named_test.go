@@ -0,0 +1,61 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedCommand(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table contacts(id integer primary key, name text, email text)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Contact struct {
+		ID    int64 `sql:"primary_key"`
+		Name  string
+		Email string
+	}
+	contacts := sqlf.Table("contacts", Contact{}).WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		contacts.Insert.TableName, contacts.Insert.Columns, contacts.Insert.Values)
+
+	named, err := insert.NamedCommand()
+	assert.NoError(err)
+	assert.Equal("insert into `contacts`(`id`,`name`,`email`) values(:id,:name,:email)", named)
+	_, err = db.NamedExec(named, map[string]interface{}{"id": 1, "name": "John", "email": "john@example.com"})
+	assert.NoError(err)
+
+	pg := contacts.WithDialect(sqlf.DialectPG)
+	named, err = sqlf.InsertRowf("insert into %s(%s) values(%s, 'a:b'::text)",
+		pg.Insert.TableName, pg.Insert.Columns.Exclude("ID"), pg.Insert.Values.Exclude("ID")).NamedCommand()
+	assert.NoError(err)
+	assert.Equal(`insert into "contacts"("name","email") values(:name,:email, 'a::b'::::text)`, named)
+
+	n, err := insert.ExecMaps(db, []map[string]interface{}{
+		{"id": 2, "name": "Jane", "email": "jane@example.com"},
+		{"id": 3, "name": "Alex", "email": "alex@example.com", "ignored": true},
+	})
+	assert.NoError(err)
+	assert.Equal(int64(2), n)
+
+	// no rows are inserted if any row fails
+	n, err = insert.ExecMaps(db, []map[string]interface{}{
+		{"id": 4, "name": "Sam", "email": "sam@example.com"},
+		{"id": 5, "name": "Kim"},
+	})
+	assert.EqualError(err, "ExecMaps: row 1: missing column email")
+	assert.Equal(int64(0), n)
+
+	var names []string
+	assert.NoError(db.Select(&names, "select name from contacts order by id"))
+	assert.Equal([]string{"John", "Jane", "Alex"}, names)
+}
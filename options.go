@@ -0,0 +1,187 @@
+package sqlf
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Option configures a command. Options can be passed anywhere in the
+// argument list of InsertRowf, UpdateRowf, Execf, Queryf, InsertSelectf
+// and Scriptf. They are not formatted into the SQL statement.
+//
+//	getUser := sqlf.Queryf("select %s from %s where email = ?",
+//		users.Select.Columns,
+//		users.Select.TableName,
+//		sqlf.WithName("GetUserByEmail"))
+type Option func(*options)
+
+// options contains the settings applied to a command by Option values.
+type options struct {
+	name    string
+	mapper  *reflectx.Mapper
+	dialect Dialect
+	timeout time.Duration
+	stmts   *stmtCache
+	debug   bool
+
+	tableName        string
+	ignoreDuplicates bool
+	upsert           bool
+	upsertColumns    []string // conflict columns, see WithUpsert
+	multiStatements  bool
+	strictScan       bool
+	withCaller       bool
+	caller           string // location that built the command, see WithCaller
+	withoutTenant    bool
+	binding          *argBinding    // args supplied or converted by the command, see argBinding
+	tenant           *tenantBinding // args bound to the tenant, see TenantScope
+	hedgeDelay       time.Duration  // see WithHedging
+	progress         func(done, total int)
+	masking          bool                     // see WithMasking
+	variantFactory   func(string) interface{} // see WithVariantFactory
+	memo             bool                     // see WithMemo
+	argCheck         bool                     // see WithArgCheck
+	argLabels        []argLabel               // label of each arg, see WithArgCheck
+	hints            []string                 // see WithHints
+	settings         []setting                // see WithSetting
+	costBudget       *CostBudget              // see WithCostBudget
+	strict           bool                     // see WithStrict
+}
+
+// WithName sets the name of a command. The name identifies the command
+// in the events reported to the metrics sink (see SetMetricsSink), and
+// selects the alternate SQL registered using RegisterOverride.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithDialect sets the dialect used to build a command, overriding the
+// dialect of the tables referenced by the command. It is useful for
+// building a command for a different database from the same tables.
+func WithDialect(dialect Dialect) Option {
+	return func(o *options) {
+		o.dialect = dialect
+	}
+}
+
+// WithTableName overrides the name of the table referenced by a command,
+// while using the same row struct and column information. It is useful for
+// partitioned tables whose names include a date suffix:
+//
+//	insertOrder := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+//		orders.Insert.TableName,
+//		orders.Insert.Columns,
+//		orders.Insert.Values,
+//		sqlf.WithTableName("orders_2024_10"))
+//
+// The name applies to the first table referenced by the command, and any
+// other references to a table with the same name. To rename a table in a
+// command that references more than one table, use TableInfo.WithName.
+func WithTableName(name string) Option {
+	return func(o *options) {
+		o.tableName = name
+	}
+}
+
+// WithTimeout sets the maximum time allowed for a command to execute.
+// The command is canceled if it has not completed by the time the timeout
+// expires. For Select and QueryRow the timeout includes the time taken to
+// read the rows. For Query, the rows are closed if they have not been read
+// by the time the timeout expires.
+//
+// The timeout only applies if the database handle supports contexts,
+// which includes *sqlx.DB and *sqlx.Tx.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithPrepared causes a command to be executed using a prepared statement.
+// The statement is prepared the first time the command is executed using a
+// *sqlx.DB, and reused for subsequent executions using the same *sqlx.DB.
+// When the command is executed using any other handle, such as a *sqlx.Tx,
+// the statement is not prepared.
+func WithPrepared() Option {
+	return func(o *options) {
+		o.stmts = &stmtCache{}
+	}
+}
+
+// WithIgnoreDuplicates causes a command built using InsertRowf to skip
+// a row that would violate a unique constraint, such as the primary key,
+// instead of returning an error. Use InsertRowCommand.ExecInserted to
+// find out whether the row was inserted. This is useful for idempotent
+// processing, such as ingesting events that may be delivered more than once.
+//
+// The insert statement is modified to suit the dialect of the table:
+// "insert ignore" for MySQL, "insert or ignore" for SQLite, and
+// "on conflict do nothing" for PostgreSQL. Other dialects are not supported,
+// and the command returns an error when it is executed.
+func WithIgnoreDuplicates() Option {
+	return func(o *options) {
+		o.ignoreDuplicates = true
+	}
+}
+
+// WithMapper sets the mapper used by a query command to match the
+// columns in the result set with the fields of the destination struct.
+// By default the mapper is built from the columns selected by the query,
+// and maps each field to the column of the same name. A custom mapper is
+// useful when the destination struct does not correspond to a table,
+// such as an ad-hoc struct for a report.
+//
+// Columns selected from a table using its Select.Columns are still
+// matched using the table information; the mapper is used for all
+// other columns. WithMapper only applies to commands built using Queryf.
+func WithMapper(mapper *reflectx.Mapper) Option {
+	return func(o *options) {
+		o.mapper = mapper
+	}
+}
+
+// WithMapperFunc is similar to WithMapper, except that the mapper uses
+// f to convert field names into column names. For example, passing
+// ToDBName maps the field "GivenName" to the column "given_name".
+// Field tags are not used.
+func WithMapperFunc(f func(fieldName string) string) Option {
+	return WithMapper(reflectx.NewMapperFunc("", f))
+}
+
+// dialectFor returns the dialect for a command that references args.
+func (o options) dialectFor(args []interface{}) Dialect {
+	if o.dialect != nil {
+		return o.dialect
+	}
+	return dialectFor(args)
+}
+
+// splitOptions removes any Option values from args, and returns
+// the remaining args along with the options they specify.
+func splitOptions(args []interface{}) ([]interface{}, options) {
+	var opts options
+	var found bool
+	for _, arg := range args {
+		if _, ok := arg.(Option); ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		opts.setCaller()
+		return args, opts
+	}
+	remaining := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if opt, ok := arg.(Option); ok {
+			opt(&opts)
+			continue
+		}
+		remaining = append(remaining, arg)
+	}
+	opts.setCaller()
+	return remaining, opts
+}
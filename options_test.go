@@ -0,0 +1,175 @@
+package sqlf_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDialect(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Row1Table.WithDialect(sqlf.DialectPG)
+
+	query := sqlf.Queryf("select %s from %s where id = %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder(),
+		sqlf.WithDialect(sqlf.DialectMySQL))
+	assert.Equal("select `id`,`given_name`,`family_name`,`Date_of_Birth` from `table1` where id = ?", query.Command())
+
+	update := sqlf.UpdateRowf("update %s set %s where %s",
+		tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns,
+		sqlf.WithDialect(sqlf.DialectSQLite))
+	assert.Equal("update `table1` set `given_name`=?,`family_name`=?,`Date_of_Birth`=? where `id`=?", update.Command())
+
+	// the table is unchanged
+	assert.Equal(`"table1"`, tbl.Select.TableName.String())
+}
+
+func TestWithTableName(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Row1Table.WithDialect(sqlf.DialectMySQL)
+	other := sqlf.Table("table2", &Row1{}).WithDialect(sqlf.DialectMySQL)
+
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values,
+		sqlf.WithTableName("table1_2024_10"))
+	assert.Equal("insert into `table1_2024_10`(`given_name`,`family_name`,`Date_of_Birth`) values(?,?,?)", insert.Command())
+
+	query := sqlf.Queryf("select %s from %s where id in (select id from %s) and id not in (%s)",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.TableName,
+		sqlf.Queryf("select id from %s", other.Select.TableName),
+		sqlf.WithTableName("table1_2024_10"))
+	assert.Equal("select `id`,`given_name`,`family_name`,`Date_of_Birth` from `table1_2024_10` "+
+		"where id in (select id from `table1_2024_10`) and id not in (select id from `table2`)", query.Command())
+
+	view := tbl.WithName("table1_view")
+	update := sqlf.UpdateRowf("update %s set %s where %s",
+		view.Update.TableName, view.Update.SetColumns, view.Update.WhereColumns)
+	assert.Equal("update `table1_view` set `given_name`=?,`family_name`=?,`Date_of_Birth`=? where `id`=?", update.Command())
+
+	// the tables are unchanged
+	assert.Equal("`table1`", tbl.Select.TableName.String())
+	assert.Equal("table1", tbl.Name)
+}
+
+func TestWithTimeout(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	create := sqlf.Execf(`create table %s(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`,
+		tbl.Insert.TableName, sqlf.WithTimeout(time.Second))
+	_, err = create.Exec(db)
+	assert.NoError(err)
+
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithTimeout(time.Second))
+	assert.NoError(insert.Exec(db, &Row1{GivenName: "John"}))
+
+	sel := sqlf.Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName, sqlf.WithTimeout(time.Second))
+	var rows []Row1
+	assert.NoError(sel.Select(db, &rows))
+	assert.Len(rows, 1)
+	var row Row1
+	assert.NoError(sel.QueryRow(db).StructScan(&row))
+	assert.Equal("John", row.GivenName)
+
+	// a command that takes longer than its timeout fails
+	slow := sqlf.Queryf(`with recursive r(n) as (select 1 union all select n+1 from r where n < 100000000) select count(*) from r`,
+		sqlf.WithTimeout(time.Millisecond))
+	var n int
+	assert.Error(slow.QueryRow(db).Scan(&n))
+}
+
+// contextQueryer records the context of the last query.
+type contextQueryer struct {
+	*sqlx.DB
+	ctx context.Context
+}
+
+func (q *contextQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	q.ctx = ctx
+	return q.DB.QueryContext(ctx, query, args...)
+}
+
+func TestWithTimeoutQuery(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	q := &contextQueryer{DB: db}
+
+	// the timeout is released when the rows are closed
+	sel := sqlf.Queryf("select 1 union all select 2", sqlf.WithTimeout(time.Minute))
+	rows, err := sel.Query(q)
+	if assert.NoError(err) {
+		assert.True(rows.Next())
+		assert.NoError(q.ctx.Err())
+		assert.NoError(rows.Close())
+		assert.Equal(context.Canceled, q.ctx.Err())
+	}
+
+	// or once all of the rows have been read
+	rows, err = sel.Query(q)
+	if assert.NoError(err) {
+		var n int
+		for rows.Next() {
+			assert.NoError(rows.Scan(&n))
+		}
+		assert.Equal(2, n)
+		assert.Equal(context.Canceled, q.ctx.Err())
+		assert.NoError(rows.Err())
+		assert.NoError(rows.Close())
+	}
+}
+
+func TestWithPrepared(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithPrepared())
+	sel := sqlf.Queryf("select %s from %s where id = %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder(), sqlf.WithPrepared())
+	for i := 1; i <= 3; i++ {
+		row := Row1{GivenName: "John"}
+		assert.NoError(insert.Exec(db, &row))
+		assert.Equal(int64(i), row.Id)
+
+		var rows []Row1
+		assert.NoError(sel.Select(db, &rows, i))
+		assert.Len(rows, 1)
+		var got Row1
+		assert.NoError(sel.QueryRow(db, i).StructScan(&got))
+		assert.Equal(int64(i), got.Id)
+	}
+
+	// the statement is not prepared when executed in a transaction
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	assert.NoError(insert.Exec(tx, &Row1{GivenName: "Jane"}))
+	var count int
+	assert.NoError(tx.QueryRow("select count(*) from table1").Scan(&count))
+	assert.Equal(4, count)
+}
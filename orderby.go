@@ -0,0 +1,150 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// SortList is the list of columns in an ORDER BY clause, built from a sort
+// order supplied by the user of an application. It is created using OrderBy,
+// and passed as an argument to Queryf.
+type SortList struct {
+	table *TableInfo
+	keys  []sortKey
+}
+
+// sortKey is a column in a sort list.
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+// OrderBy builds the list of columns for an ORDER BY clause from sort, which
+// is typically a query parameter of a list endpoint, such as "?sort=-created,name".
+// Each column in sort is separated by a comma, and is the name of one of
+// the columns, either the column name or the field name, ignoring case.
+// A column is sorted in descending order if it has a "-" prefix or a "desc"
+// suffix, otherwise in ascending order. The columns are validated against
+// the column list, so that only those columns can be sorted on, and the
+// user cannot inject SQL:
+//
+//	sortList, err := sqlf.OrderBy(users.Select.Columns.Exclude("Password"), r.FormValue("sort"))
+//	if err != nil {
+//		// respond with status 400
+//	}
+//	cmd := sqlf.Queryf("select %s from %s order by %s",
+//		users.Select.Columns, users.Select.TableName, sortList)
+//
+// Column names are quoted in the same way as Select.OrderBy, using the
+// dialect of the command. If sort is blank, the list contains the columns
+// of Select.OrderBy, which are the primary key columns, so that the list is
+// always valid in an ORDER BY clause. OrderBy returns an error if a column
+// is not in the list, or is sorted more than once.
+func OrderBy(columns ColumnList, sort string) (*SortList, error) {
+	sl := &SortList{table: columns.table}
+	if strings.TrimSpace(sort) == "" {
+		return sl, nil
+	}
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(sort, ",") {
+		var key sortKey
+		name := strings.TrimSpace(item)
+		if strings.HasPrefix(name, "-") {
+			key.desc = true
+			name = name[1:]
+		} else {
+			name = strings.TrimPrefix(name, "+")
+		}
+		if fields := strings.Fields(name); len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				key.desc = true
+			default:
+				return nil, fmt.Errorf("OrderBy: invalid sort direction %q", fields[1])
+			}
+			name = fields[0]
+		}
+		ci := columns.named(name)
+		if ci == nil {
+			return nil, fmt.Errorf("OrderBy: cannot sort by %q", name)
+		}
+		if seen[ci.columnName] {
+			return nil, fmt.Errorf("OrderBy: cannot sort by %q more than once", name)
+		}
+		seen[ci.columnName] = true
+		key.column = ci.columnName
+		sl.keys = append(sl.keys, key)
+	}
+	return sl, nil
+}
+
+// named returns the column in the list with the column
+// name or field name, ignoring case, or nil if there is none.
+func (cil ColumnList) named(name string) *columnInfo {
+	for _, ci := range cil.filtered() {
+		if strings.EqualFold(ci.columnName, name) || strings.EqualFold(ci.fieldName, name) {
+			return ci
+		}
+	}
+	return nil
+}
+
+// clone makes a copy of the sort list that is associated with a different
+// TableInfo, which must have been cloned from the original.
+func (sl *SortList) clone(ti *TableInfo) *SortList {
+	return &SortList{table: ti, keys: sl.keys}
+}
+
+// String renders the sort list, so that it can be formatted
+// using "%s" in an ORDER BY clause.
+func (sl *SortList) String() string {
+	if len(sl.keys) == 0 {
+		return sl.table.Select.OrderBy.String()
+	}
+	var buf bytes.Buffer
+	for i, key := range sl.keys {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		if sl.table.alias != "" {
+			buf.WriteString(sl.table.alias)
+			buf.WriteRune('.')
+		}
+		buf.WriteString(sl.table.Dialect().Quote(key.column))
+		if key.desc {
+			buf.WriteString(" desc")
+		}
+	}
+	return buf.String()
+}
+
+// Unique returns a copy of the sort list with the primary key columns that
+// are not in the list appended in ascending order, so that the list sorts
+// the rows in a unique order, as required for pagination using PageTokens.
+func (sl *SortList) Unique() *SortList {
+	unique := &SortList{table: sl.table, keys: append([]sortKey(nil), sl.keys...)}
+	for _, ci := range sl.table.columns {
+		if !ci.primaryKey {
+			continue
+		}
+		found := false
+		for _, key := range sl.keys {
+			found = found || key.column == ci.columnName
+		}
+		if !found {
+			unique.keys = append(unique.keys, sortKey{column: ci.columnName})
+		}
+	}
+	return unique
+}
+
+// sortKeys returns the keys of the sort list, which are the
+// primary key columns in ascending order if the list is empty.
+func (sl *SortList) sortKeys() []sortKey {
+	if len(sl.keys) > 0 {
+		return sl.keys
+	}
+	return sl.Unique().keys
+}
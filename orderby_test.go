@@ -0,0 +1,93 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBy(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Row1Table.WithDialect(sqlf.DialectPG)
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{
+			sort: "-given_name,FamilyName",
+			want: `select "id" from "table1" order by "given_name" desc,"family_name"`,
+		},
+		{
+			sort: " family_name desc , +Date_of_Birth ASC",
+			want: `select "id" from "table1" order by "family_name" desc,"Date_of_Birth"`,
+		},
+		{
+			// the primary key columns
+			sort: "",
+			want: `select "id" from "table1" order by "id"`,
+		},
+	}
+	for _, tt := range tests {
+		sortList, err := sqlf.OrderBy(tbl.Select.Columns, tt.sort)
+		if !assert.NoError(err, tt.sort) {
+			continue
+		}
+		query := sqlf.Queryf("select %s from %s order by %s", `"id"`, tbl.Select.TableName, sortList)
+		assert.Equal(tt.want, query.Command(), tt.sort)
+	}
+
+	// the command's dialect and the table's alias are used
+	sortList, err := sqlf.OrderBy(Row1Table.WithAlias("r").Select.Columns, "given_name")
+	assert.NoError(err)
+	query := sqlf.Queryf("select %s from %s order by %s",
+		Row1Table.WithAlias("r").Select.Columns, Row1Table.WithAlias("r").Select.TableName, sortList,
+		sqlf.WithDialect(sqlf.DialectMySQL))
+	assert.Contains(query.Command(), " order by r.`given_name`")
+
+	for _, sort := range []string{
+		"password",
+		"given_name; drop table table1",
+		"given_name desc nulls first",
+		"given_name sideways",
+		"given_name,-given_name",
+		"given_name,",
+	} {
+		_, err := sqlf.OrderBy(tbl.Select.Columns, sort)
+		assert.Error(err, sort)
+	}
+	_, err = sqlf.OrderBy(tbl.Select.Columns.Exclude("GivenName"), "given_name")
+	assert.Error(err)
+}
+
+func TestOrderByQuery(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	type Product struct {
+		ID    int64 `sql:"primary_key"`
+		Name  string
+		Price int
+	}
+	products := sqlf.Table("products", Product{}).WithDialect(sqlf.DialectSQLite)
+	if _, err := db.Exec(`create table products(id integer primary key, name text, price integer);
+		insert into products values(1, 'b', 20), (2, 'a', 20), (3, 'c', 10)`); err != nil {
+		t.Fatal(err)
+	}
+
+	sortList, err := sqlf.OrderBy(products.Select.Columns, "-price,name")
+	assert.NoError(err)
+	var rows []Product
+	assert.NoError(sqlf.Queryf("select %s from %s order by %s",
+		products.Select.Columns, products.Select.TableName, sortList).Select(db, &rows))
+	var ids []int64
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	assert.Equal([]int64{2, 1, 3}, ids)
+}
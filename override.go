@@ -0,0 +1,75 @@
+package sqlf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// overrideKey identifies the SQL registered for
+// a named command and a dialect.
+type overrideKey struct {
+	name    string
+	dialect string
+}
+
+var overrides = struct {
+	mutex sync.RWMutex
+	m     map[overrideKey]string
+}{m: make(map[overrideKey]string)}
+
+// RegisterOverride registers alternate SQL for the command with the name
+// (see WithName), which is used instead of the command's own SQL when it is
+// executed using a database handle for dialect d. The handle must report its
+// driver name, as *sqlx.DB and *sqlx.Tx do. This makes it possible to write
+// an optimized version of a performance-critical query for one database,
+// such as one with PostgreSQL-specific hints or operators, while the command
+// built in the usual way is the generic fallback for all other databases:
+//
+//	var searchProducts = sqlf.Queryf("select %s from %s where lower(name) like lower(?)",
+//		products.Select.Columns, products.Select.TableName,
+//		sqlf.WithName("SearchProducts"))
+//
+//	func init() {
+//		sqlf.RegisterOverride("SearchProducts", sqlf.DialectPG,
+//			`select id, name, price from products where name ilike $1`)
+//	}
+//
+// The alternate SQL is written for dialect d, and is used exactly as
+// written. It must accept the same args as the command, in the same order,
+// and a query must return columns that can be scanned in the same way as
+// the command's own result. RegisterOverride panics if the name or the
+// SQL is blank, or if d is nil.
+func RegisterOverride(name string, d Dialect, query string) {
+	if name == "" {
+		panic("sqlf.RegisterOverride: command name is blank")
+	}
+	if d == nil {
+		panic(fmt.Sprintf("sqlf.RegisterOverride: dialect for command %q is nil", name))
+	}
+	if query == "" {
+		panic(fmt.Sprintf("sqlf.RegisterOverride: SQL for command %q is blank", name))
+	}
+	overrides.mutex.Lock()
+	defer overrides.mutex.Unlock()
+	overrides.m[overrideKey{name: name, dialect: DialectName(d)}] = query
+}
+
+// lookupOverride returns the alternate SQL registered for the command
+// with the name when executed using db, if there is any.
+func lookupOverride(name string, db interface{}) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	dn, ok := db.(driverNamer)
+	if !ok {
+		return "", false
+	}
+	d := dialectForDriver(dn.DriverName())
+	if d == nil {
+		return "", false
+	}
+	overrides.mutex.RLock()
+	defer overrides.mutex.RUnlock()
+	query, ok := overrides.m[overrideKey{name: name, dialect: DialectName(d)}]
+	return query, ok
+}
@@ -0,0 +1,60 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterOverride(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table products(id integer primary key, name text);
+		insert into products values(1, 'Widget'), (2, 'Gadget')`); err != nil {
+		t.Fatal(err)
+	}
+
+	search := sqlf.Queryf("select id from products where lower(name) like lower(?) order by id",
+		sqlf.WithName("OverrideSearchProducts"), sqlf.WithDialect(sqlf.DialectSQLite))
+	sqlf.RegisterOverride("OverrideSearchProducts", sqlf.DialectPG, `select id from products where name ilike $1 order by id`)
+
+	// generic fallback
+	var ids []int64
+	assert.NoError(search.Select(db, &ids, "%ADG%"))
+	assert.Equal([]int64{2}, ids)
+
+	// override for the dialect
+	rec := sqlftest.NewWithDriverName("postgres")
+	rows, err := search.Query(rec, "%ADG%")
+	assert.NoError(err)
+	rows.Close()
+	assert.Equal("select id from products where name ilike $1 order by id", rec.Commands()[0].Query)
+	rec = sqlftest.NewWithDriverName("mysql")
+	rows, err = search.Query(rec, "%ADG%")
+	assert.NoError(err)
+	rows.Close()
+	assert.Equal("select id from products where lower(name) like lower(?) order by id", rec.Commands()[0].Query)
+
+	// exec commands
+	sqlf.RegisterOverride("OverrideDeleteProduct", sqlf.DialectSQLite, "delete from products where id = ? and 1 = 1")
+	del := sqlf.Execf("delete from products where id = $1", sqlf.WithName("OverrideDeleteProduct"), sqlf.WithDialect(sqlf.DialectPG))
+	rec = sqlftest.NewWithDriverName("sqlite3")
+	_, err = del.Exec(rec, 1)
+	assert.NoError(err)
+	assert.Equal("delete from products where id = ? and 1 = 1", rec.Commands()[0].Query)
+	result, err := del.Exec(db, 1)
+	assert.NoError(err)
+	n, _ := result.RowsAffected()
+	assert.Equal(int64(1), n)
+
+	assert.Panics(func() { sqlf.RegisterOverride("", sqlf.DialectPG, "select 1") })
+	assert.Panics(func() { sqlf.RegisterOverride("x", nil, "select 1") })
+	assert.Panics(func() { sqlf.RegisterOverride("x", sqlf.DialectPG, "") })
+}
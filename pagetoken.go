@@ -0,0 +1,250 @@
+package sqlf
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// ErrInvalidPageToken is returned by PageTokens.After when the page token
+// has been altered, was signed with a different key, or was created for a
+// different sort order.
+var ErrInvalidPageToken = errors.New("sqlf: invalid page token")
+
+// PageTokens encodes and decodes the page tokens of keyset pagination,
+// where each page of a list is selected using the values of the sort
+// columns in the last row of the previous page, instead of an offset.
+// Keyset pagination is not affected by rows inserted and deleted between
+// pages, and is fast for any page.
+//
+// A page token contains the values of the sort columns and the sort order,
+// and is signed, so that a client cannot alter it to read rows that it
+// should not see. It is opaque to the client, which passes it back to
+// get the next page:
+//
+//	var pageTokens = sqlf.NewPageTokens(secretKey)
+//
+//	func listProducts(db *sqlx.DB, sort, token string) (rows []Product, next string, err error) {
+//		sortList, err := sqlf.OrderBy(products.Select.Columns, sort)
+//		if err != nil {
+//			return nil, "", err
+//		}
+//		sortList = sortList.Unique()
+//		after, err := pageTokens.After(sortList, token)
+//		if err != nil {
+//			return nil, "", err
+//		}
+//		cmd := sqlf.Queryf("select %s from %s where %s order by %s limit 50",
+//			products.Select.Columns, products.Select.TableName, after, sortList)
+//		if err := cmd.Select(db, &rows, after.Args()...); err != nil {
+//			return nil, "", err
+//		}
+//		if len(rows) == 50 {
+//			next, err = pageTokens.Encode(sortList, &rows[len(rows)-1])
+//		}
+//		return rows, next, err
+//	}
+//
+// The sort order must select the rows in a unique order, so it must
+// include the primary key columns, see SortList.Unique. The sort
+// columns cannot be null.
+type PageTokens struct {
+	key []byte
+}
+
+// NewPageTokens returns page tokens signed using HMAC-SHA256 with the key,
+// which should be at least 32 random bytes, and kept secret. NewPageTokens
+// panics if the key is empty.
+func NewPageTokens(key []byte) *PageTokens {
+	if len(key) == 0 {
+		panic("sqlf.NewPageTokens: key is empty")
+	}
+	return &PageTokens{key: append([]byte(nil), key...)}
+}
+
+// pageState is the content of a page token.
+type pageState struct {
+	Order  string            `json:"o"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// Encode returns the token for the page that follows row, which is the
+// last row of the current page, for the sort order. The row is a struct,
+// or a pointer to a struct, of the row type of the sort list's table.
+func (pt *PageTokens) Encode(sort *SortList, row interface{}) (string, error) {
+	keys, cols, err := sort.pageKeys()
+	if err != nil {
+		return "", err
+	}
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() == reflect.Ptr {
+		return "", fmt.Errorf("PageTokens: expected type %s, got nil", sort.table.rowType)
+	}
+	if v.Type() != sort.table.rowType {
+		return "", fmt.Errorf("PageTokens: expected type %s, got %T", sort.table.rowType, row)
+	}
+	state := pageState{Order: sort.pageOrder(keys)}
+	for i, ci := range cols {
+		value := reflectx.FieldByIndexesReadOnly(v, ci.fields)
+		if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil() {
+			return "", fmt.Errorf("PageTokens: cannot page on the null value of %s", keys[i].column)
+		}
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return "", fmt.Errorf("PageTokens: %s: %v", keys[i].column, err)
+		}
+		state.Values = append(state.Values, data)
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(pt.sign(payload)), nil
+}
+
+// After returns a condition for a WHERE clause that selects the rows that
+// follow the last row of the previous page, using the values in the token,
+// for the sort order. If the token is blank, the condition selects all rows.
+// The values of the condition are returned by its Args method, and must be
+// passed to the command in the position where the condition appears. After
+// returns ErrInvalidPageToken if the token is not valid for the sort order.
+func (pt *PageTokens) After(sort *SortList, token string) (*Fragment, error) {
+	keys, cols, err := sort.pageKeys()
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return NewFragment("1=1"), nil
+	}
+
+	enc := base64.RawURLEncoding
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidPageToken
+	}
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	mac, err := enc.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(mac, pt.sign(payload)) {
+		return nil, ErrInvalidPageToken
+	}
+	var state pageState
+	if err := json.Unmarshal(payload, &state); err != nil || state.Order != sort.pageOrder(keys) || len(state.Values) != len(keys) {
+		return nil, ErrInvalidPageToken
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, ci := range cols {
+		field := reflect.New(sort.table.rowType.FieldByIndex(ci.fields).Type)
+		if err := json.Unmarshal(state.Values[i], field.Interface()); err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		if values[i], err = ci.encodeArg(context.Background(), field.Elem().Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	// (a > ?) or (a = ? and b > ?) or ..., with "<" for a descending column
+	var buf bytes.Buffer
+	var args []interface{}
+	buf.WriteRune('(')
+	for i := range keys {
+		if i > 0 {
+			buf.WriteString(" or ")
+		}
+		buf.WriteRune('(')
+		for j := 0; j <= i; j++ {
+			if j > 0 {
+				buf.WriteString(" and ")
+			}
+			buf.WriteString(sort.quotedColumn(keys[j].column))
+			switch {
+			case j < i:
+				buf.WriteString(" = ?")
+			case keys[j].desc:
+				buf.WriteString(" < ?")
+			default:
+				buf.WriteString(" > ?")
+			}
+			args = append(args, values[j])
+		}
+		buf.WriteRune(')')
+	}
+	buf.WriteRune(')')
+	return NewFragment(buf.String(), args...), nil
+}
+
+// sign returns the signature of the payload of a page token.
+func (pt *PageTokens) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, pt.key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// pageKeys returns the keys of the sort list and their columns. It returns
+// an error if the keys do not include the primary key columns, because the
+// rows would not be in a unique order.
+func (sl *SortList) pageKeys() ([]sortKey, []*columnInfo, error) {
+	keys := sl.sortKeys()
+	cols := make([]*columnInfo, len(keys))
+	for i, key := range keys {
+		cols[i] = sl.table.columnNamed(key.column)
+	}
+	for _, ci := range sl.table.columns {
+		if !ci.primaryKey {
+			continue
+		}
+		found := false
+		for _, key := range keys {
+			found = found || key.column == ci.columnName
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("PageTokens: sort order does not include primary key column %s", ci.columnName)
+		}
+	}
+	return keys, cols, nil
+}
+
+// pageOrder returns the sort order of the keys for a page token,
+// eg "orders:-created,id", so that a token cannot be used with
+// a different table or sort order.
+func (sl *SortList) pageOrder(keys []sortKey) string {
+	var buf bytes.Buffer
+	buf.WriteString(sl.table.Name)
+	buf.WriteRune(':')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		if key.desc {
+			buf.WriteRune('-')
+		}
+		buf.WriteString(key.column)
+	}
+	return buf.String()
+}
+
+// quotedColumn returns the quoted name of the column,
+// with the table alias if there is one.
+func (sl *SortList) quotedColumn(column string) string {
+	name := sl.table.Dialect().Quote(column)
+	if sl.table.alias != "" {
+		name = sl.table.alias + "." + name
+	}
+	return name
+}
@@ -0,0 +1,112 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageTokens(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table products(id integer primary key, name text, price integer)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Product struct {
+		ID    int64 `sql:"primary_key;auto_increment"`
+		Name  string
+		Price int64
+	}
+	products := sqlf.Table("products", Product{}).WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		products.Insert.TableName, products.Insert.Columns, products.Insert.Values)
+	for _, p := range []Product{
+		{Name: "apple", Price: 3},
+		{Name: "pear", Price: 5},
+		{Name: "banana", Price: 3},
+		{Name: "cherry", Price: 9},
+		{Name: "apple", Price: 5},
+		{Name: "fig", Price: 3},
+		{Name: "grape", Price: 5},
+	} {
+		assert.NoError(insert.Exec(db, &p))
+	}
+
+	pageTokens := sqlf.NewPageTokens([]byte("0123456789abcdef0123456789abcdef"))
+	list := func(sort string, limit int) []int64 {
+		sortList, err := sqlf.OrderBy(products.Select.Columns, sort)
+		if !assert.NoError(err) {
+			return nil
+		}
+		sortList = sortList.Unique()
+		var ids []int64
+		var token string
+		for pages := 0; pages < 10; pages++ {
+			after, err := pageTokens.After(sortList, token)
+			if !assert.NoError(err) {
+				return nil
+			}
+			cmd := sqlf.Queryf("select %s from %s where %s order by %s limit ?",
+				products.Select.Columns, products.Select.TableName, after, sortList)
+			var rows []Product
+			if !assert.NoError(cmd.Select(db, &rows, append(after.Args(), limit)...)) {
+				return nil
+			}
+			for _, row := range rows {
+				ids = append(ids, row.ID)
+			}
+			if len(rows) < limit {
+				return ids
+			}
+			token, err = pageTokens.Encode(sortList, &rows[len(rows)-1])
+			assert.NoError(err)
+		}
+		t.Fatal("too many pages")
+		return nil
+	}
+
+	assert.Equal([]int64{1, 2, 3, 4, 5, 6, 7}, list("", 2))
+	assert.Equal([]int64{4, 2, 5, 7, 1, 3, 6}, list("-price", 3))
+	assert.Equal([]int64{1, 5, 3, 4, 6, 7, 2}, list("name", 2))
+	assert.Equal([]int64{6, 3, 1, 2, 7, 5, 4}, list("price,-name", 2))
+
+	// tokens cannot be altered, or used with a different sort order or key
+	byName, _ := sqlf.OrderBy(products.Select.Columns, "name")
+	byName = byName.Unique()
+	token, err := pageTokens.Encode(byName, Product{ID: 3, Name: "banana"})
+	assert.NoError(err)
+	after, err := pageTokens.After(byName, token)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"banana", "banana", int64(3)}, after.Args())
+
+	tampered := []byte(token)
+	tampered[2] ^= 1
+	_, err = pageTokens.After(byName, string(tampered))
+	assert.Equal(sqlf.ErrInvalidPageToken, err)
+	_, err = pageTokens.After(byName, "not a token")
+	assert.Equal(sqlf.ErrInvalidPageToken, err)
+	byNameDesc, _ := sqlf.OrderBy(products.Select.Columns, "-name")
+	_, err = pageTokens.After(byNameDesc.Unique(), token)
+	assert.Equal(sqlf.ErrInvalidPageToken, err)
+	_, err = sqlf.NewPageTokens([]byte("another key")).After(byName, token)
+	assert.Equal(sqlf.ErrInvalidPageToken, err)
+
+	// the sort order must be unique
+	notUnique, _ := sqlf.OrderBy(products.Select.Columns, "name")
+	_, err = pageTokens.After(notUnique, "")
+	assert.Error(err)
+	_, err = pageTokens.Encode(byName, struct{ ID int64 }{})
+	assert.Error(err)
+	_, err = pageTokens.Encode(byName, nil)
+	assert.Error(err)
+	_, err = pageTokens.Encode(byName, (*Product)(nil))
+	assert.Error(err)
+	assert.Panics(func() { sqlf.NewPageTokens(nil) })
+}
@@ -0,0 +1,129 @@
+package sqlf
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (cmd *queryCommand) SelectParallel(db sqlx.Queryer, dest interface{}, shards int, shardColumn string, args ...interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("must pass a non-nil pointer to SelectParallel")
+	}
+	direct := value.Elem()
+	if direct.Kind() != reflect.Slice {
+		return fmt.Errorf("expected slice but got %s", direct.Type())
+	}
+	if shards <= 0 {
+		return fmt.Errorf("SelectParallel: invalid number of shards %d", shards)
+	}
+
+	// the key range of the rows selected by the query
+	var min, max sql.NullInt64
+	rangeQuery := cmd.derived(fmt.Sprintf("select min(%s), max(%s) from (%s) sqlf_shard",
+		shardColumn, shardColumn, cmd.command))
+	if err := rangeQuery.QueryRow(db, args...).Scan(&min, &max); err != nil {
+		return err
+	}
+	if !min.Valid {
+		// no rows
+		return nil
+	}
+
+	// the query for each shard has two additional placeholders
+	// for the lower (inclusive) and upper (exclusive) key values,
+	// which follow any placeholders for the tenant and fixed args
+	d := commandDialect(cmd.dialect)
+	n := len(args) + cmd.opts.binding.numFixed() + cmd.opts.tenant.numInserted()
+	shardQuery := cmd.derived(fmt.Sprintf("select * from (%s) sqlf_shard where %s >= %s and %s < %s",
+		cmd.command, shardColumn, d.Placeholder(n+1), shardColumn, d.Placeholder(n+2)))
+	ranges := shardRanges(min.Int64, max.Int64, shards)
+
+	results := make([]reflect.Value, len(ranges))
+	errs := make([]error, len(ranges))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(ranges) {
+		workers = len(ranges)
+	}
+	next := make(chan int)
+	var failed sync.Once
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = reflect.New(direct.Type())
+				shardArgs := append(append([]interface{}(nil), args...), ranges[i][0], ranges[i][1])
+				if err := shardQuery.Select(db, results[i].Interface(), shardArgs...); err != nil {
+					errs[i] = err
+					failed.Do(func() { close(done) })
+				}
+			}
+		}()
+	}
+feed:
+	for i := range ranges {
+		select {
+		case next <- i:
+		case <-done:
+			// do not start any more shards after an error
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	for _, result := range results {
+		direct.Set(reflect.AppendSlice(direct, result.Elem()))
+	}
+	return nil
+}
+
+// derived returns a query command for a query that is derived from cmd,
+// and selects the same columns.
+func (cmd *queryCommand) derived(query string) *queryCommand {
+	return &queryCommand{
+		command: query,
+		dialect: cmd.dialect,
+		columns: cmd.columns,
+		opts:    cmd.opts,
+		format:  strings.Replace(query, "%", "%%", -1),
+	}
+}
+
+// shardRanges divides the keys from min to max inclusive into at most n
+// ranges of approximately equal size. Each range is the lower (inclusive)
+// and upper (exclusive) key value.
+func shardRanges(min, max int64, n int) [][2]int64 {
+	span := uint64(max-min) + 1
+	size := span / uint64(n)
+	if span%uint64(n) != 0 || size == 0 {
+		size++
+	}
+	var ranges [][2]int64
+	for lo := min; ; {
+		hi := lo + int64(size)
+		if hi > max || hi <= lo {
+			// the last range, which includes max
+			ranges = append(ranges, [2]int64{lo, max + 1})
+			break
+		}
+		ranges = append(ranges, [2]int64{lo, hi})
+		lo = hi
+	}
+	return ranges
+}
@@ -0,0 +1,51 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectParallel(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", "file:parallel?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	for i := 0; i < 100; i++ {
+		family := "Citizen"
+		if i%10 == 0 {
+			family = "Other"
+		}
+		if err := ins.Exec(db, &Row1{GivenName: "John", FamilyName: family}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sel := sqlf.Queryf("select %s from %s where family_name = ? order by id", tbl.Select.Columns, tbl.Select.TableName)
+	for _, shards := range []int{1, 3, 7, 200} {
+		var rows []*Row1
+		assert.NoError(sel.SelectParallel(db, &rows, shards, "id", "Citizen"))
+		if assert.Len(rows, 90, "shards=%d", shards) {
+			var want []*Row1
+			assert.NoError(sel.Select(db, &want, "Citizen"))
+			assert.Equal(want, rows, "shards=%d", shards)
+		}
+	}
+
+	// no rows
+	var rows []Row1
+	assert.NoError(sel.SelectParallel(db, &rows, 4, "id", "Nobody"))
+	assert.Len(rows, 0)
+
+	assert.Error(sel.SelectParallel(db, &rows, 0, "id", "Citizen"))
+	assert.Error(sel.SelectParallel(db, &rows, 4, "no_such_column", "Citizen"))
+}
@@ -0,0 +1,281 @@
+package sqlf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// PartitionPeriod is the period of time covered by each child
+// table of a table partitioned by time, see TimePartitions.
+type PartitionPeriod int
+
+// Partition periods for TimePartitions.
+const (
+	PartitionDaily PartitionPeriod = iota + 1
+	PartitionMonthly
+	PartitionYearly
+)
+
+// PartitionScheme describes how the rows of a partitioned table are
+// divided between its child tables. It is created using TimePartitions
+// or ListPartitions, and applied to a table using WithPartitions.
+type PartitionScheme struct {
+	column string
+	period PartitionPeriod // zero for ListPartitions
+}
+
+// TimePartitions returns a scheme for a table partitioned by the time in
+// the column, where each child table holds the rows for one period, in UTC.
+// The name of a child table is the name of the table with a suffix for
+// the period: "orders_2024_10_05" (daily), "orders_2024_10" (monthly) or
+// "orders_2024" (yearly).
+func TimePartitions(column string, period PartitionPeriod) PartitionScheme {
+	return PartitionScheme{column: column, period: period}
+}
+
+// ListPartitions returns a scheme for a table partitioned by the value in
+// the column, where each child table holds the rows for one value. The
+// name of a child table is the name of the table with the value as a
+// suffix, eg "orders_emea". Values must contain only letters, digits and
+// underscores.
+func ListPartitions(column string) PartitionScheme {
+	return PartitionScheme{column: column}
+}
+
+// WithPartitions returns a copy of the table that uses the partition
+// scheme, so that rows can be inserted into the child table that holds
+// them (see PartitionedInsertRowf), and queries can use predicates that
+// allow the database to skip the other child tables (see PartitionFilter).
+//
+//	var orders = sqlf.Table("orders", Order{}).
+//		WithPartitions(sqlf.TimePartitions("created_at", sqlf.PartitionMonthly))
+//
+// WithPartitions panics if the table does not have the partition column,
+// or if the column of a time partition is not a time.Time.
+func (ti *TableInfo) WithPartitions(scheme PartitionScheme) *TableInfo {
+	ci := ti.columnByName("WithPartitions", scheme.column)
+	if scheme.period != 0 {
+		t := ti.rowType.FieldByIndex(ci.fields).Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t != reflect.TypeOf(time.Time{}) {
+			panic(fmt.Sprintf("sqlf.WithPartitions: column %s of table %s is not a time.Time", scheme.column, ti.Name))
+		}
+	}
+	ti2 := ti.clone()
+	ti2.partitions = &scheme
+	return ti2
+}
+
+// Partition returns the name of the child table that holds the row,
+// which is of the table's row type, or a pointer to it.
+func (ti *TableInfo) Partition(row interface{}) (string, error) {
+	key, err := ti.partitionKey(row)
+	if err != nil {
+		return "", err
+	}
+	return ti.PartitionFor(key)
+}
+
+// PartitionFor returns the name of the child table that
+// holds the rows with the value of the partition column.
+func (ti *TableInfo) PartitionFor(key interface{}) (string, error) {
+	p, err := ti.partitionScheme("PartitionFor")
+	if err != nil {
+		return "", err
+	}
+	if p.period == 0 {
+		s := fmt.Sprint(key)
+		if !partitionValueRE.MatchString(s) {
+			return "", fmt.Errorf("PartitionFor: invalid partition value %q for table %s", s, ti.Name)
+		}
+		return ti.Name + "_" + strings.ToLower(s), nil
+	}
+	t, err := partitionTime(key)
+	if err != nil {
+		return "", fmt.Errorf("PartitionFor: %v", err)
+	}
+	switch p.period {
+	case PartitionDaily:
+		return ti.Name + t.Format("_2006_01_02"), nil
+	case PartitionMonthly:
+		return ti.Name + t.Format("_2006_01"), nil
+	}
+	return ti.Name + t.Format("_2006"), nil
+}
+
+// PartitionFilter returns a fragment for a WHERE clause that compares the
+// partition column directly with its args, so that the database can skip
+// the child tables that cannot hold any matching rows. For a time partition
+// the fragment is "created_at >= ? and created_at < ?", and for a list
+// partition it is "region = ?". The args to select all of the rows in one
+// child table are returned by PartitionArgs:
+//
+//	var listOrders = sqlf.Queryf("select %s from %s where %s",
+//		orders.Select.Columns, orders.Select.TableName, orders.PartitionFilter())
+//
+//	args, err := orders.PartitionArgs(time.Now())
+//	err = listOrders.Select(db, &rows, args...)
+//
+// Avoid wrapping the column in a function, such as date_trunc, which
+// prevents the database from pruning child tables. PartitionFilter panics
+// if the table does not have a partition scheme.
+func (ti *TableInfo) PartitionFilter() *Fragment {
+	p, err := ti.partitionScheme("PartitionFilter")
+	if err != nil {
+		panic("sqlf." + err.Error())
+	}
+	column := ti.Dialect().Quote(p.column)
+	if p.period == 0 {
+		return NewFragment(column + " = ?")
+	}
+	return NewFragment(column + " >= ? and " + column + " < ?")
+}
+
+// PartitionArgs returns the args for PartitionFilter that select all of the
+// rows in the child table that holds the value of the partition column.
+// For a time partition these are the start and end times of the period.
+func (ti *TableInfo) PartitionArgs(key interface{}) ([]interface{}, error) {
+	p, err := ti.partitionScheme("PartitionArgs")
+	if err != nil {
+		return nil, err
+	}
+	if p.period == 0 {
+		return []interface{}{key}, nil
+	}
+	t, err := partitionTime(key)
+	if err != nil {
+		return nil, fmt.Errorf("PartitionArgs: %v", err)
+	}
+	var start, end time.Time
+	switch p.period {
+	case PartitionDaily:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 0, 1)
+	case PartitionMonthly:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 1, 0)
+	default:
+		start = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(1, 0, 0)
+	}
+	return []interface{}{start, end}, nil
+}
+
+// partitionValueRE matches the values of a list partition.
+var partitionValueRE = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func (ti *TableInfo) partitionScheme(funcName string) (*PartitionScheme, error) {
+	if ti.partitions == nil {
+		return nil, fmt.Errorf("%s: table %s does not have a partition scheme", funcName, ti.Name)
+	}
+	return ti.partitions, nil
+}
+
+// partitionKey returns the value of the partition column of the row.
+func (ti *TableInfo) partitionKey(row interface{}) (interface{}, error) {
+	p, err := ti.partitionScheme("Partition")
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.New("Partition: cannot obtain partition key from a nil pointer")
+		}
+		v = v.Elem()
+	}
+	if v.Type() != ti.rowType {
+		return nil, fmt.Errorf("Partition: expected type %s.%s or pointer", ti.rowType.PkgPath(), ti.rowType.Name())
+	}
+	ci := ti.columnNamed(p.column)
+	return reflectx.FieldByIndexesReadOnly(v, ci.fields).Interface(), nil
+}
+
+// partitionTime returns the key of a time partition in UTC.
+func partitionTime(key interface{}) (time.Time, error) {
+	switch t := key.(type) {
+	case time.Time:
+		return t.UTC(), nil
+	case *time.Time:
+		if t != nil {
+			return t.UTC(), nil
+		}
+		return time.Time{}, errors.New("partition time is nil")
+	}
+	return time.Time{}, fmt.Errorf("expected partition time, got %T", key)
+}
+
+// PartitionedInsert inserts each row into the child table of a partitioned
+// table that holds the row. It is created using PartitionedInsertRowf.
+type PartitionedInsert struct {
+	format string
+	args   []interface{}
+	table  *TableInfo
+
+	mutex sync.Mutex
+	cmds  map[string]InsertRowCommand
+}
+
+// PartitionedInsertRowf is like InsertRowf, but the row is inserted into the
+// child table that holds it, as determined by the partition scheme of the
+// table (see WithPartitions). This is useful for databases that do not
+// route rows to child tables, and for inserting directly into a child table,
+// which avoids the cost of routing the row in the database.
+//
+//	var insertOrder = sqlf.PartitionedInsertRowf("insert into %s(%s) values(%s)",
+//		orders.Insert.TableName,
+//		orders.Insert.Columns,
+//		orders.Insert.Values)
+//
+//	err := insertOrder.Exec(db, &order)
+//
+// The command for each child table is built using InsertRowf with the
+// WithTableName option when it is first needed, and is kept for reuse.
+func PartitionedInsertRowf(format string, args ...interface{}) *PartitionedInsert {
+	return &PartitionedInsert{
+		format: format,
+		args:   args,
+		table:  firstTable(args),
+		cmds:   make(map[string]InsertRowCommand),
+	}
+}
+
+// For returns the command that inserts the row into the
+// child table that holds it.
+func (p *PartitionedInsert) For(row interface{}) (InsertRowCommand, error) {
+	if p.table == nil {
+		return nil, errors.New("PartitionedInsert: command does not refer to a table")
+	}
+	child, err := p.table.Partition(row)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	cmd, ok := p.cmds[child]
+	if !ok {
+		args := append(append([]interface{}(nil), p.args...), WithTableName(child))
+		cmd = InsertRowf(p.format, args...)
+		p.cmds[child] = cmd
+	}
+	return cmd, nil
+}
+
+// Exec inserts the row into the child table that holds it.
+func (p *PartitionedInsert) Exec(db sqlx.Execer, row interface{}) error {
+	cmd, err := p.For(row)
+	if err != nil {
+		return err
+	}
+	return cmd.Exec(db, row)
+}
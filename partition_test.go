@@ -0,0 +1,81 @@
+package sqlf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitions(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, name := range []string{"events_2024_10", "events_2024_11"} {
+		if _, err := db.Exec(`create table ` + name + `(id integer primary key, region text, created_at datetime)`); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type Event struct {
+		ID        int64
+		Region    string
+		CreatedAt time.Time
+	}
+	events := sqlf.Table("events", Event{}).WithDialect(sqlf.DialectSQLite).
+		WithPartitions(sqlf.TimePartitions("created_at", sqlf.PartitionMonthly))
+
+	oct := time.Date(2024, 10, 31, 23, 0, 0, 0, time.UTC)
+	nov := time.Date(2024, 11, 1, 9, 0, 0, 0, time.FixedZone("AEST", 10*3600))
+	child, err := events.Partition(&Event{CreatedAt: oct})
+	assert.NoError(err)
+	assert.Equal("events_2024_10", child)
+	child, err = events.PartitionFor(nov)
+	assert.NoError(err)
+	assert.Equal("events_2024_10", child, "partitions are in UTC")
+
+	insert := sqlf.PartitionedInsertRowf("insert into %s(%s) values(%s)",
+		events.Insert.TableName, events.Insert.Columns, events.Insert.Values)
+	assert.NoError(insert.Exec(db, &Event{ID: 1, CreatedAt: oct}))
+	assert.NoError(insert.Exec(db, &Event{ID: 2, CreatedAt: oct.Add(time.Hour)}))
+	assert.NoError(insert.Exec(db, &Event{ID: 3, CreatedAt: oct.AddDate(0, 0, 2)}))
+	cmd, err := insert.For(Event{CreatedAt: oct})
+	assert.NoError(err)
+	assert.Equal("insert into `events_2024_10`(`id`,`region`,`created_at`) values(?,?,?)", cmd.Command())
+	for name, want := range map[string]int{"events_2024_10": 1, "events_2024_11": 2} {
+		var count int
+		assert.NoError(db.Get(&count, "select count(*) from "+name))
+		assert.Equal(want, count, name)
+	}
+
+	query := sqlf.Queryf("select %s from %s where %s", events.Select.Columns, events.Select.TableName, events.PartitionFilter())
+	assert.Equal("select `id`,`region`,`created_at` from `events` where `created_at` >= ? and `created_at` < ?", query.Command())
+	args, err := events.PartitionArgs(oct)
+	assert.NoError(err)
+	assert.Equal([]interface{}{
+		time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+	}, args)
+
+	regions := sqlf.Table("events", Event{}).WithPartitions(sqlf.ListPartitions("region"))
+	child, err = regions.PartitionFor("EMEA")
+	assert.NoError(err)
+	assert.Equal("events_emea", child)
+	_, err = regions.Partition(&Event{Region: "x; drop table events"})
+	assert.Error(err)
+	args, err = regions.PartitionArgs("emea")
+	assert.NoError(err)
+	assert.Equal([]interface{}{"emea"}, args)
+
+	_, err = sqlf.Table("events", Event{}).PartitionFor(oct)
+	assert.Error(err)
+	assert.Panics(func() {
+		sqlf.Table("events", Event{}).WithPartitions(sqlf.TimePartitions("region", sqlf.PartitionDaily))
+	})
+	assert.Panics(func() { sqlf.Table("events", Event{}).WithPartitions(sqlf.ListPartitions("missing")) })
+}
@@ -0,0 +1,144 @@
+package sqlf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// placeholderOp is the operator rendered with a placeholder.
+type placeholderOp int
+
+const (
+	placeholderValue placeholderOp = iota
+	placeholderBetween
+	placeholderLikePrefix
+	placeholderLikeSuffix
+	placeholderLikeContains
+)
+
+// likeEscape is the escape character for LIKE patterns. It is not
+// a backslash, because backslashes in string literals are treated
+// differently by MySQL and PostgreSQL.
+const likeEscape = '!'
+
+// Between returns a placeholder that renders a BETWEEN operator with two
+// placeholders, for the lower and upper bounds, which are supplied as
+// consecutive args when the command is executed:
+//
+//	listUsers := sqlf.Queryf("select %s from %s where dob %s",
+//		users.Select.Columns, users.Select.TableName,
+//		users.Select.Placeholder().Between())
+//	err := listUsers.Select(db, &rows, from, to)
+//
+// For PostgreSQL it renders "between $1 and $2".
+func (p *Placeholder) Between() *Placeholder {
+	return p.withOp(placeholderBetween)
+}
+
+// LikePrefix returns a placeholder that renders a LIKE operator matching
+// values that start with the arg supplied when the command is executed.
+// Any wildcard characters ("%" and "_") in the arg are escaped, so that
+// user input can be passed unchanged:
+//
+//	searchUsers := sqlf.Queryf("select %s from %s where name %s",
+//		users.Select.Columns, users.Select.TableName,
+//		users.Select.Placeholder().LikePrefix())
+//	err := searchUsers.Select(db, &rows, "50%") // name like '50!%%' escape '!'
+//
+// Args that are not strings are passed unchanged.
+func (p *Placeholder) LikePrefix() *Placeholder {
+	return p.withOp(placeholderLikePrefix)
+}
+
+// LikeSuffix returns a placeholder that renders a LIKE operator matching
+// values that end with the arg supplied when the command is executed.
+// Wildcard characters in the arg are escaped in the same way as LikePrefix.
+func (p *Placeholder) LikeSuffix() *Placeholder {
+	return p.withOp(placeholderLikeSuffix)
+}
+
+// LikeContains returns a placeholder that renders a LIKE operator
+// matching values that contain the arg supplied when the command is
+// executed. Wildcard characters in the arg are escaped in the same
+// way as LikePrefix.
+func (p *Placeholder) LikeContains() *Placeholder {
+	return p.withOp(placeholderLikeContains)
+}
+
+func (p *Placeholder) withOp(op placeholderOp) *Placeholder {
+	if p.op != placeholderValue {
+		panic("sqlf.Placeholder: operator already specified")
+	}
+	p2 := p.clone(p.table)
+	p2.op = op
+	return p2
+}
+
+// numInputs returns the number of placeholders rendered.
+func (p *Placeholder) numInputs() int {
+	if p.op == placeholderBetween {
+		return 2
+	}
+	return 1
+}
+
+// placeholderInput is a positioner for the second placeholder rendered
+// by a Placeholder, whose position follows the first.
+type placeholderInput struct {
+	ph *Placeholder
+}
+
+func (pi *placeholderInput) setPosition(n int) {}
+
+// convertedInput returns the function that converts the arg for the
+// input when the command is executed, or nil if it is not converted.
+func convertedInput(input positioner) func(interface{}) interface{} {
+	ph, ok := input.(*Placeholder)
+	if !ok {
+		return nil
+	}
+	var format string
+	switch ph.op {
+	case placeholderLikePrefix:
+		format = "%s%%"
+	case placeholderLikeSuffix:
+		format = "%%%s"
+	case placeholderLikeContains:
+		format = "%%%s%%"
+	default:
+		return nil
+	}
+	return func(arg interface{}) interface{} {
+		// string types, such as "type Search string", and pointers
+		// to strings are sent as strings by database/sql
+		v := reflect.ValueOf(arg)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		var s string
+		switch {
+		case v.Kind() == reflect.String:
+			s = v.String()
+		case v.Kind() == reflect.Ptr:
+			// a nil pointer is sent as NULL
+			return arg
+		default:
+			str, ok := arg.(fmt.Stringer)
+			if !ok {
+				return arg
+			}
+			s = str.String()
+		}
+		return fmt.Sprintf(format, likeEscaper.Replace(s))
+	}
+}
+
+// likeEscaper escapes the characters that have a special meaning in
+// LIKE patterns, including "[" which is a wildcard for SQL Server.
+var likeEscaper = strings.NewReplacer(
+	string(likeEscape), string(likeEscape)+string(likeEscape),
+	"%", string(likeEscape)+"%",
+	"_", string(likeEscape)+"_",
+	"[", string(likeEscape)+"[",
+)
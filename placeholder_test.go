@@ -0,0 +1,107 @@
+package sqlf_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceholderOperators(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table products(id integer primary key, name text)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`insert into products(id, name) values
+		(1, '50% off'), (2, '500 items'), (3, 'a_b'), (4, 'axb'), (5, 'save 50%')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Product struct {
+		ID   int64 `sql:"primary_key"`
+		Name string
+	}
+	products := sqlf.Table("products", Product{}).WithDialect(sqlf.DialectSQLite)
+	search := func(ph *sqlf.Placeholder) sqlf.QueryCommand {
+		return sqlf.Queryf("select %s from %s where name %s order by %s",
+			products.Select.Columns, products.Select.TableName, ph, products.Select.OrderBy)
+	}
+	ids := func(cmd sqlf.QueryCommand, args ...interface{}) []int64 {
+		var rows []Product
+		assert.NoError(cmd.Select(db, &rows, args...))
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+
+	prefix := search(products.Select.Placeholder().LikePrefix())
+	assert.Equal("select `id`,`name` from `products` where name like ? escape '!' order by `id`", prefix.Command())
+	assert.Equal([]int64{1}, ids(prefix, "50%"))
+	assert.Equal([]int64{1, 2}, ids(prefix, "50"))
+	assert.Equal([]int64{3}, ids(prefix, "a_"))
+	s, err := prefix.Interpolate("50%")
+	assert.NoError(err)
+	assert.Equal("select `id`,`name` from `products` where name like '50!%%' escape '!' order by `id`", s)
+
+	suffix := search(products.Select.Placeholder().LikeSuffix())
+	assert.Equal([]int64{5}, ids(suffix, "50%"))
+	contains := search(products.Select.Placeholder().LikeContains())
+	assert.Equal([]int64{1, 5}, ids(contains, "0%"))
+	assert.Equal([]int64{3}, ids(contains, "_"))
+
+	// string types and pointers to strings are escaped too
+	type Search string
+	assert.Equal([]int64{1, 5}, ids(contains, Search("0%")))
+	pattern := "_"
+	assert.Equal([]int64{3}, ids(contains, &pattern))
+	assert.Equal([]int64(nil), ids(contains, (*string)(nil)))
+
+	between := sqlf.Queryf("select %s from %s where id %s and name %s",
+		products.Select.Columns, products.Select.TableName,
+		products.Select.Placeholder().Between(), products.Select.Placeholder().LikePrefix())
+	assert.Equal("select `id`,`name` from `products` where id between ? and ? and name like ? escape '!'", between.Command())
+	assert.Equal([]int64{2, 3, 4}, ids(between, 2, 4, ""))
+
+	pg := sqlf.Queryf("select * from %s where id %s and name %s",
+		products.Select.TableName, products.Select.Placeholder().Between(),
+		products.Select.Placeholder().LikeContains(), sqlf.WithDialect(sqlf.DialectPG))
+	assert.Equal(`select * from "products" where id between $1 and $2 and name like $3 escape '!'`, pg.Command())
+
+	assert.Panics(func() { products.Select.Placeholder().Between().LikePrefix() })
+}
+
+func TestPlaceholderOperatorsTenant(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table items(id integer primary key, tenant_id integer, name text);
+		insert into items(id, tenant_id, name) values (1, 1, '50% off'), (2, 1, '500 items'), (3, 2, '50% off')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Item struct {
+		ID       int64 `sql:"primary_key"`
+		TenantID int64 `sql:"tenant"`
+		Name     string
+	}
+	items := sqlf.Table("items", Item{}).WithDialect(sqlf.DialectSQLite)
+	search := sqlf.Queryf("select %s from %s where name %s order by %s",
+		items.Select.Columns, items.Select.TableName, items.Select.Placeholder().LikePrefix(), items.Select.OrderBy)
+
+	// the tenant is inserted before the converted arg
+	var rows []Item
+	assert.NoError(search.Select(sqlf.ForTenant(sqlf.ContextWithTenant(context.Background(), int64(1)), db), &rows, "50%"))
+	assert.Equal([]Item{{1, 1, "50% off"}}, rows)
+}
@@ -0,0 +1,122 @@
+package sqlf
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ProcCommand calls a stored procedure, which can have output parameters.
+type ProcCommand interface {
+	// Command returns the SQL statement that calls the procedure.
+	Command() string
+
+	// Call calls the procedure with the arguments given. Output parameters
+	// are passed as named arguments whose value is sql.Out, for example:
+	//
+	//	var id int64
+	//	_, err := addUser.Call(db,
+	//		sql.Named("Email", email),
+	//		sql.Named("ID", sql.Out{Dest: &id}))
+	Call(db sqlx.Execer, args ...interface{}) (sql.Result, error)
+
+	// CallStruct calls the procedure with a named argument for each
+	// exported field of the struct pointed to by arg. Output parameters
+	// are scanned into their fields. The field tags are:
+	//
+	//	param:name    the name of the parameter, the field name by default
+	//	out           an output parameter
+	//	inout         an input/output parameter
+	//	-             the field is not a parameter
+	//
+	// For example:
+	//
+	//	type AddUserParams struct {
+	//		Email string
+	//		ID    int64 `sql:"out"`
+	//	}
+	CallStruct(db sqlx.Execer, arg interface{}) (sql.Result, error)
+}
+
+type procCommand struct {
+	cmd execCommand
+}
+
+// CallProcf formats a command that calls a stored procedure. The form of
+// the command depends on the database driver. For example, for SQL Server
+// the command is the name of the procedure ("dbo.AddUser"), and for Oracle
+// it is a PL/SQL block ("begin add_user(:Email, :ID); end;"). The parameters
+// of the procedure are passed as named arguments (see sql.Named), and output
+// parameters use sql.Out. The database driver must support named arguments
+// and sql.Out.
+func CallProcf(format string, args ...interface{}) ProcCommand {
+	return &procCommand{cmd: newExecCommand(format, args)}
+}
+
+func (p *procCommand) Command() string {
+	return p.cmd.command
+}
+
+func (p *procCommand) Call(db sqlx.Execer, args ...interface{}) (sql.Result, error) {
+	cmd := p.cmd
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	cmd.opts.debugBindings(OpCall, cmd.command, cmd.inputs, args)
+	start := time.Now()
+	result, err := cmd.opts.exec(db, cmd.queryFor(db), args)
+	cmd.opts.report(contextOf(db), OpCall, cmd.command, start, rowsAffected(result), err)
+	return result, cmd.opts.wrapError(OpCall, cmd.command, args, err)
+}
+
+func (p *procCommand) CallStruct(db sqlx.Execer, arg interface{}) (sql.Result, error) {
+	args, err := procArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	return p.Call(db, args...)
+}
+
+// procArgs returns a named argument for each parameter field of the
+// struct pointed to by arg.
+func procArgs(arg interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("CallStruct: expected pointer to struct, got %T", arg)
+	}
+	v = v.Elem()
+	var args []interface{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tagSettings := parseTagSetting(field.Tag)
+		if _, ok := tagSettings["-"]; ok {
+			continue
+		}
+		name := field.Name
+		if param := strings.TrimSpace(tagSettings["PARAM"]); param != "" {
+			name = param
+		}
+		fv := v.Field(i)
+		_, out := tagSettings["OUT"]
+		_, inout := tagSettings["INOUT"]
+		switch {
+		case out && inout:
+			return nil, fmt.Errorf("CallStruct: field %s cannot be both out and inout", field.Name)
+		case out:
+			args = append(args, sql.Named(name, sql.Out{Dest: fv.Addr().Interface()}))
+		case inout:
+			args = append(args, sql.Named(name, sql.Out{Dest: fv.Addr().Interface(), In: true}))
+		default:
+			args = append(args, sql.Named(name, fv.Interface()))
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,90 @@
+package sqlf_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// procDriver is a database driver that implements a stored procedure
+// that sets the "ID" output parameter to 42 and increments the "Count"
+// input/output parameter.
+type procDriver struct{}
+
+func (procDriver) Open(name string) (driver.Conn, error) { return procConn{}, nil }
+
+type procConn struct{}
+
+func (procConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (procConn) Close() error                              { return nil }
+func (procConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (procConn) CheckNamedValue(nv *driver.NamedValue) error { return nil }
+
+func (procConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query != "dbo.AddUser" {
+		return nil, errors.New("unknown procedure " + query)
+	}
+	for _, arg := range args {
+		out, ok := arg.Value.(sql.Out)
+		if !ok {
+			continue
+		}
+		dest := reflect.ValueOf(out.Dest).Elem()
+		switch arg.Name {
+		case "ID":
+			dest.SetInt(42)
+		case "Count":
+			dest.SetInt(dest.Int() + 1)
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func init() {
+	sql.Register("sqlfproc", procDriver{})
+}
+
+func TestCallProcf(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlfproc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	addUser := sqlf.CallProcf("dbo.AddUser")
+	assert.Equal("dbo.AddUser", addUser.Command())
+
+	var id int64
+	_, err = addUser.Call(db, sql.Named("Email", "john@example.com"), sql.Named("ID", sql.Out{Dest: &id}))
+	assert.NoError(err)
+	assert.Equal(int64(42), id)
+
+	type AddUserParams struct {
+		EmailAddress string `sql:"param:Email"`
+		UserID       int64  `sql:"out;param:ID"`
+		Count        int    `sql:"inout"`
+		Ignored      string `sql:"-"`
+	}
+	params := AddUserParams{EmailAddress: "jane@example.com", Count: 5}
+	_, err = addUser.CallStruct(db, &params)
+	assert.NoError(err)
+	assert.Equal(int64(42), params.UserID)
+	assert.Equal(6, params.Count)
+
+	_, err = addUser.CallStruct(db, params)
+	assert.Error(err)
+
+	_, err = sqlf.CallProcf("dbo.NoSuchProc").Call(db)
+	var sqlfErr *sqlf.Error
+	if assert.True(errors.As(err, &sqlfErr)) {
+		assert.Equal(sqlf.OpCall, sqlfErr.Op)
+	}
+}
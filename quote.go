@@ -0,0 +1,128 @@
+package sqlf
+
+import "strings"
+
+// QuoteIfNeeded returns a dialect that is the same as d, except that it
+// only quotes the table and column names that need to be quoted: names
+// that are reserved words (eg "order", "user"), and names that contain
+// characters other than letters, digits and underscores. For PostgreSQL,
+// names that contain upper case letters are also quoted, so that they are
+// not folded to lower case. This produces SQL that is easier to read in
+// logs and query plans:
+//
+//	sqlf.DefaultDialect = sqlf.QuoteIfNeeded(sqlf.DialectPG)
+//	// select id,name,"order" from orders where id=$1
+//
+// The dialects DialectMySQL, DialectMSSQL, DialectPG and DialectSQLite
+// quote every name, which is always safe. The reserved words are the same
+// for every dialect, so that SQL built for one dialect can be translated
+// for another (see DialectForDriver) without clashing with its reserved
+// words.
+func QuoteIfNeeded(d Dialect) Dialect {
+	if q, ok := d.(quoteIfNeeded); ok {
+		return q
+	}
+	return quoteIfNeeded{Dialect: d}
+}
+
+// quoteIfNeeded is a dialect that only quotes names that need quoting.
+type quoteIfNeeded struct {
+	Dialect
+}
+
+func (d quoteIfNeeded) name() string {
+	return DialectName(d.Dialect)
+}
+
+func (d quoteIfNeeded) Quote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		part = strings.Trim(part, "\"`[] \t")
+		if needsQuote(DialectName(d), part) {
+			part = d.Dialect.Quote(part)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ".")
+}
+
+// needsQuote reports whether the name must be quoted
+// for the dialect with the name dialectName.
+func needsQuote(dialectName string, name string) bool {
+	if name == "" || isReservedWord(name) {
+		return true
+	}
+	for i, ch := range name {
+		switch {
+		case ch == '_' || ch >= 'a' && ch <= 'z':
+		case ch >= 'A' && ch <= 'Z':
+			if dialectName == "postgres" {
+				return true
+			}
+		case ch >= '0' && ch <= '9':
+			if i == 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// isReservedWord reports whether the name is a reserved word in SQL,
+// in any of the supported dialects.
+func isReservedWord(name string) bool {
+	return reservedWords[strings.ToLower(name)]
+}
+
+var reservedWords = make(map[string]bool)
+
+func init() {
+	// reserved words of SQL:2016, MySQL, PostgreSQL, SQL Server and
+	// SQLite that are likely to be used as table and column names
+	for _, word := range strings.Fields(`
+		add all alter analyze and any array as asc asymmetric authorization
+		backup begin between binary both break browse bulk by
+		call cascade case cast change check checkpoint close clustered
+		collate column commit constraint contains continue convert create
+		cross current current_date current_role current_time
+		current_timestamp current_user cursor
+		database databases day deallocate dec decimal declare default
+		deferrable delete deny desc describe distinct distributed div do
+		double drop dump
+		each else elseif end escape except exclusive exec execute exists
+		exit explain external
+		false fetch file fillfactor float for force foreign freetext from
+		full fulltext function
+		get glob goto grant group
+		having high_priority holdlock hour
+		identity if ignore ilike immediate in index inner inout insert int
+		integer intersect interval into is isnull
+		join
+		key keys kill
+		lateral leading left like limit lineno lines load localtime
+		localtimestamp lock long loop
+		match merge minute mod modifies month
+		natural no not notnull null nullif numeric
+		of off offset offsets on only open option optionally or order out
+		outer over overlaps
+		partition percent pivot placing plan precision primary print proc
+		procedure public
+		raiserror range read reads real recursive references regexp
+		reindex release rename repeat replace require restrict return
+		returning revoke right rlike rollback row rows rowcount rule
+		savepoint schema schemas second select session_user set setuser
+		show similar smallint some sql statistics symmetric system_user
+		table tablesample temporary textsize then to top trailing tran
+		transaction trigger true truncate
+		union unique unlock unpivot unsigned update usage use user using
+		vacuum values varchar varying view
+		waitfor when where while window with within write
+		xor
+		year
+		zerofill
+	`) {
+		reservedWords[word] = true
+	}
+}
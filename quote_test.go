@@ -0,0 +1,72 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIfNeeded(t *testing.T) {
+	tests := []struct {
+		dialect sqlf.Dialect
+		name    string
+		want    string
+	}{
+		{sqlf.DialectPG, "customer_id", "customer_id"},
+		{sqlf.DialectPG, "order", `"order"`},
+		{sqlf.DialectPG, "User", `"User"`},
+		{sqlf.DialectPG, "sales.orders", "sales.orders"},
+		{sqlf.DialectPG, `"orders"."group"`, `orders."group"`},
+		{sqlf.DialectPG, "first name", `"first name"`},
+		{sqlf.DialectPG, "2fa", `"2fa"`},
+		{sqlf.DialectMySQL, "Name", "Name"},
+		{sqlf.DialectMySQL, "KEY", "`KEY`"},
+		{sqlf.DialectMSSQL, "user", "[user]"},
+		{sqlf.DialectSQLite, "id", "id"},
+	}
+	for _, tt := range tests {
+		d := sqlf.QuoteIfNeeded(tt.dialect)
+		if got := d.Quote(tt.name); got != tt.want {
+			t.Errorf("%s: %q: want %q, got %q", sqlf.DialectName(tt.dialect), tt.name, tt.want, got)
+		}
+		if got, want := sqlf.DialectName(d), sqlf.DialectName(tt.dialect); got != want {
+			t.Errorf("name: want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestQuoteIfNeededCommands(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("create table `orders`(id integer primary key, `order` integer, `user` text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	type Order struct {
+		ID    int64 `sql:"primary_key"`
+		Order int
+		User  string
+	}
+	orders := sqlf.Table("orders", Order{}).WithDialect(sqlf.QuoteIfNeeded(sqlf.DialectSQLite))
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		orders.Insert.TableName, orders.Insert.Columns, orders.Insert.Values)
+	assert.Equal("insert into orders(id,`order`,`user`) values(?,?,?)", insert.Command())
+	assert.NoError(insert.Exec(db, &Order{ID: 1, Order: 2, User: "john"}))
+
+	get := sqlf.Queryf("select %s from %s where %s",
+		orders.Select.Columns, orders.Select.TableName, orders.Update.WhereColumns)
+	assert.Equal("select id,`order`,`user` from orders where id=?", get.Command())
+	var order Order
+	assert.NoError(get.Get(db, &order, 1))
+	assert.Equal(Order{ID: 1, Order: 2, User: "john"}, order)
+
+	s, err := get.Interpolate(1)
+	assert.NoError(err)
+	assert.Equal("select id,`order`,`user` from orders where id=1", s)
+}
@@ -0,0 +1,127 @@
+package sqlf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// driverNamer is implemented by database handles that know the name of
+// their database driver. Both *sqlx.DB and *sqlx.Tx implement this interface.
+// A *sql.DB can be wrapped using sqlx.NewDb to supply the driver name.
+type driverNamer interface {
+	DriverName() string
+}
+
+// DialectForDriver returns the dialect for the named database driver,
+// or nil if the driver is not recognized.
+func DialectForDriver(driverName string) Dialect {
+	return dialectForDriver(driverName)
+}
+
+// dialectForDriver returns the dialect for the named database driver,
+// or nil if the driver is not recognized.
+func dialectForDriver(driverName string) Dialect {
+	d := strings.ToLower(driverName)
+	switch {
+	case strings.Contains(d, "mysql"):
+		return DialectMySQL
+	case d == "mssql":
+		return DialectMSSQL
+	case d == "sqlserver":
+		return dialectSQLServer
+	case d == "sqlite3":
+		return DialectSQLite
+	case d == "postgres" || d == "pgx":
+		return DialectPG
+	}
+	return nil
+}
+
+// rebindCache holds the SQL for a command that has been translated
+// for use with dialects other than the one it was built for.
+// It is shared by all copies of a command.
+type rebindCache struct {
+	mutex sync.Mutex
+	m     map[string]string
+}
+
+// queryFor returns the SQL for a command built for dialect d, translated
+// if necessary for the dialect of the database driver used by db.
+func (c *rebindCache) queryFor(db interface{}, d Dialect, query string) string {
+	dn, ok := db.(driverNamer)
+	if !ok || c == nil {
+		return query
+	}
+	target := dialectForDriver(dn.DriverName())
+	if target == nil || DialectName(target) == DialectName(d) {
+		return query
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if q, ok := c.m[DialectName(target)]; ok {
+		return q
+	}
+	q := rebind(target, query)
+	if c.m == nil {
+		c.m = make(map[string]string)
+	}
+	c.m[DialectName(target)] = q
+	return q
+}
+
+// rebind translates the query so that its placeholders and quoted
+// identifiers suit dialect d. Positional placeholders ("?") are numbered
+// in order of appearance. Numbered placeholders (eg "$1") are only
+// translated to positional placeholders if they appear in order.
+func rebind(d Dialect, query string) string {
+	var tokens []string
+	var inOrder = true
+	var next int
+
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		switch tok {
+		case scan.PLACEHOLDER:
+			next++
+			n := next
+			if len(lit) > 1 {
+				if i, err := strconv.Atoi(lit[1:]); err == nil {
+					n = i
+				}
+			}
+			if n != next {
+				inOrder = false
+			}
+			tokens = append(tokens, d.Placeholder(n))
+		case scan.IDENT:
+			if scan.IsQuoted(lit) {
+				lit = d.Quote(scan.Unquote(lit))
+			}
+			tokens = append(tokens, lit)
+		default:
+			tokens = append(tokens, lit)
+		}
+	}
+
+	if !inOrder && d.Placeholder(1) == d.Placeholder(2) {
+		// cannot translate numbered placeholders that are not in order
+		// to positional placeholders, because the args would need to be
+		// re-ordered
+		return query
+	}
+
+	var buf bytes.Buffer
+	for _, tok := range tokens {
+		buf.WriteString(tok)
+	}
+	return buf.String()
+}
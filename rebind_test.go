@@ -0,0 +1,59 @@
+package sqlf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDriverNamer string
+
+func (d fakeDriverNamer) DriverName() string {
+	return string(d)
+}
+
+func TestRebind(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{DialectPG, "select `a`, 'x?' from `t` where a = ? and b = ?", `select "a", 'x?' from "t" where a = $1 and b = $2`},
+		{DialectMySQL, `select "a" from "t" where a = $1 and b = $2`, "select `a` from `t` where a = ? and b = ?"},
+		{DialectMySQL, `select a from t where a = $2 and b = $1`, `select a from t where a = $2 and b = $1`},
+		{dialectSQLServer, "select `a` from t where a = ?", "select [a] from t where a = @p1"},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.want, rebind(tt.dialect, tt.query))
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		query  string
+		offset int
+		want   string
+	}{
+		{`insert into t(a, b) values($1, $2)`, 0, `insert into t(a, b) values($1, $2)`},
+		{`insert into t(a, b) values($1, $2)`, 4, `insert into t(a, b) values($5, $6)`},
+		{`insert into t(a, b) values(?, ?)`, 2, `insert into t(a, b) values(?, ?)`},
+		{`insert into [t](a, b) values(@p1, @p2)`, 2, `insert into [t](a, b) values(@p3, @p4)`},
+		{`update t set b = '$1', p1 = $1`, 1, `update t set b = '$1', p1 = $2`},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.want, renumber(tt.query, tt.offset))
+	}
+}
+
+func TestQueryForDriver(t *testing.T) {
+	assert := assert.New(t)
+	tbl := Table("users", User{}).WithDialect(DialectMySQL)
+	cmd := Queryf("select %s from %s where %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Update.WhereColumns).(*queryCommand)
+
+	assert.Equal("select `id`,`given_name`,`family_name` from `users` where `id`=?", cmd.queryFor(fakeDriverNamer("mysql")))
+	assert.Equal(`select "id","given_name","family_name" from "users" where "id"=$1`, cmd.queryFor(fakeDriverNamer("postgres")))
+	assert.Equal(`select "id","given_name","family_name" from "users" where "id"=$1`, cmd.queryFor(fakeDriverNamer("postgres")))
+	assert.Equal("select `id`,`given_name`,`family_name` from `users` where `id`=?", cmd.queryFor(nil))
+}
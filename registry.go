@@ -0,0 +1,72 @@
+package sqlf
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Commander is implemented by all of the command types:
+// InsertRowCommand, UpdateRowCommand, ExecCommand and QueryCommand.
+type Commander interface {
+	// Command returns the SQL statement with placeholders for arguments.
+	Command() string
+}
+
+// RegisteredCommand is a command that has been added to the registry
+// using Register.
+type RegisteredCommand struct {
+	Name string
+	Cmd  Commander
+}
+
+var registry = struct {
+	mutex sync.RWMutex
+	m     map[string]Commander
+}{m: make(map[string]Commander)}
+
+// Register adds a command to the registry of named commands. Because
+// commands are usually built once during program initialization, the
+// registry provides an inventory of all the SQL that the program can run.
+// This is useful for logging the SQL at startup, for checking the SQL using
+// a linter, or for review by a DBA. For example:
+//
+//	var getOrders = sqlf.Queryf("select %s from %s where customer_id = ?",
+//		orders.Select.Columns,
+//		orders.Select.TableName)
+//
+//	func init() {
+//		sqlf.Register("GetOrders", getOrders)
+//	}
+//
+// Register panics if the name is blank or if a command has already been
+// registered with the same name.
+func Register(name string, cmd Commander) {
+	if name == "" {
+		panic("sqlf.Register: command name is blank")
+	}
+	if cmd == nil {
+		panic(fmt.Sprintf("sqlf.Register: command %q is nil", name))
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, ok := registry.m[name]; ok {
+		panic(fmt.Sprintf("sqlf.Register: command %q registered more than once", name))
+	}
+	registry.m[name] = cmd
+}
+
+// Commands returns all of the commands added to the registry using
+// Register, sorted by name.
+func Commands() []RegisteredCommand {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	commands := make([]RegisteredCommand, 0, len(registry.m))
+	for name, cmd := range registry.m {
+		commands = append(commands, RegisteredCommand{Name: name, Cmd: cmd})
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
@@ -0,0 +1,32 @@
+package sqlf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+	defer func() {
+		registry.m = make(map[string]Commander)
+	}()
+
+	tbl := Table("users", User{}).WithDialect(DialectPG)
+	getUsers := Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName)
+	updateUser := UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	Register("GetUsers", getUsers)
+	Register("UpdateUser", updateUser)
+
+	commands := Commands()
+	if assert.Len(commands, 2) {
+		assert.Equal("GetUsers", commands[0].Name)
+		assert.Equal(getUsers.Command(), commands[0].Cmd.Command())
+		assert.Equal("UpdateUser", commands[1].Name)
+		assert.Equal(`update "users" set "given_name"=$1,"family_name"=$2 where "id"=$3`, commands[1].Cmd.Command())
+	}
+
+	assert.Panics(func() { Register("GetUsers", getUsers) })
+	assert.Panics(func() { Register("", getUsers) })
+	assert.Panics(func() { Register("Nil", nil) })
+}
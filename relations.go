@@ -0,0 +1,287 @@
+package sqlf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// maxRelatedKeys is the maximum number of key values included in
+// the "in" clause of a single query issued by LoadRelated. Fewer are
+// included if the database does not accept this many parameters.
+const maxRelatedKeys = 1000
+
+// relation describes a relationship declared using HasMany or BelongsTo.
+type relation struct {
+	hasMany bool
+	field   []int       // index of the field in the owner row struct
+	key     *columnInfo // key column in the owner table
+	target  *TableInfo  // table containing the related rows
+	ref     *columnInfo // column in the target table that matches key
+}
+
+type relationKey struct {
+	rowType reflect.Type
+	field   string
+}
+
+var relations = struct {
+	mutex sync.RWMutex
+	m     map[relationKey]*relation
+}{m: make(map[relationKey]*relation)}
+
+// HasMany declares that each row in the table has many related rows in
+// the child table, which are loaded into the named field using
+// LoadRelated. The field must be a slice of the child row type, or
+// pointers to the child row type. The foreign key is the name of the
+// column in the child table that refers to the primary key of this table.
+// The field should be tagged `sql:"-"`, so that it is not mapped to a column.
+//
+//	users.HasMany("Posts", posts, "user_id")
+//
+// HasMany panics if the table does not have a single primary key column,
+// or if the field or foreign key column do not exist.
+func (ti *TableInfo) HasMany(fieldName string, child *TableInfo, foreignKey string) {
+	key := ti.singlePrimaryKey("HasMany")
+	ref := child.columnByName("HasMany", foreignKey)
+	field := ti.relationField("HasMany", fieldName, reflect.Slice, child.rowType)
+	registerRelation(ti.rowType, fieldName, &relation{
+		hasMany: true,
+		field:   field,
+		key:     key,
+		target:  child,
+		ref:     ref,
+	})
+}
+
+// BelongsTo declares that each row in the table refers to a row in the
+// parent table, which is loaded into the named field using LoadRelated.
+// The field must be of the parent row type, or a pointer to the parent row
+// type. The foreign key is the name of the column in this table that refers
+// to the primary key of the parent table. The field should be tagged
+// `sql:"-"`, so that it is not mapped to columns.
+//
+//	posts.BelongsTo("User", users, "user_id")
+//
+// BelongsTo panics if the parent table does not have a single primary key
+// column, or if the field or foreign key column do not exist.
+func (ti *TableInfo) BelongsTo(fieldName string, parent *TableInfo, foreignKey string) {
+	ref := parent.singlePrimaryKey("BelongsTo")
+	key := ti.columnByName("BelongsTo", foreignKey)
+	field := ti.relationField("BelongsTo", fieldName, reflect.Struct, parent.rowType)
+	registerRelation(ti.rowType, fieldName, &relation{
+		field:  field,
+		key:    key,
+		target: parent,
+		ref:    ref,
+	})
+}
+
+func registerRelation(rowType reflect.Type, fieldName string, rel *relation) {
+	relations.mutex.Lock()
+	defer relations.mutex.Unlock()
+	relations.m[relationKey{rowType: rowType, field: fieldName}] = rel
+}
+
+func (ti *TableInfo) singlePrimaryKey(funcName string) *columnInfo {
+	var key *columnInfo
+	for _, ci := range ti.columns {
+		if ci.primaryKey {
+			if key != nil {
+				panic(fmt.Sprintf("sqlf.%s: table %s has more than one primary key column", funcName, ti.Name))
+			}
+			key = ci
+		}
+	}
+	if key == nil {
+		panic(fmt.Sprintf("sqlf.%s: table %s has no primary key", funcName, ti.Name))
+	}
+	return key
+}
+
+func (ti *TableInfo) columnByName(funcName string, columnName string) *columnInfo {
+	if ci := ti.columnNamed(columnName); ci != nil {
+		return ci
+	}
+	panic(fmt.Sprintf("sqlf.%s: table %s has no column %s", funcName, ti.Name, columnName))
+}
+
+// relationField returns the index of the named field, which must be of the
+// kind (slice or struct) with elements of the row type, or pointers to it.
+func (ti *TableInfo) relationField(funcName string, fieldName string, kind reflect.Kind, rowType reflect.Type) []int {
+	field, ok := ti.rowType.FieldByName(fieldName)
+	if !ok {
+		panic(fmt.Sprintf("sqlf.%s: %s has no field %s", funcName, ti.rowType, fieldName))
+	}
+	t := field.Type
+	if kind == reflect.Slice {
+		if t.Kind() != reflect.Slice {
+			panic(fmt.Sprintf("sqlf.%s: field %s must be a slice", funcName, fieldName))
+		}
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != rowType {
+		panic(fmt.Sprintf("sqlf.%s: field %s has type %s, expected %s", funcName, fieldName, field.Type, rowType))
+	}
+	return field.Index
+}
+
+// LoadRelated loads the related rows into the named field of each row in
+// rows, which is a slice of rows (or pointers to rows), or a pointer to a
+// single row. The relationship must have been declared using HasMany or
+// BelongsTo. The related rows for all of the rows are loaded using one query
+// (or for very large numbers of rows, one query for each 1000 rows, or fewer
+// if the database limits the number of parameters, see MaxParams), which
+// avoids querying the database once for each row.
+func LoadRelated(db sqlx.Queryer, rows interface{}, fieldName string) error {
+	owners, err := relatedOwners(rows)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	rowType := owners[0].Type()
+	relations.mutex.RLock()
+	rel := relations.m[relationKey{rowType: rowType, field: fieldName}]
+	relations.mutex.RUnlock()
+	if rel == nil {
+		return fmt.Errorf("LoadRelated: no relationship declared for %s.%s", rowType, fieldName)
+	}
+
+	// distinct key values, ignoring nil pointers
+	var keys []interface{}
+	seen := make(map[string]bool)
+	for _, owner := range owners {
+		v := reflectx.FieldByIndexesReadOnly(owner, rel.key.fields)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		k := keyString(v.Interface())
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, v.Interface())
+		}
+	}
+
+	// related rows grouped by the value of the ref column, leaving
+	// a parameter for the tenant, if the target table has one
+	related := make(map[string][]reflect.Value)
+	chunkSize := maxRelatedKeys
+	if max := maxParamsFor(db, rel.target.Dialect()) - 1; chunkSize > max {
+		chunkSize = max
+	}
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > chunkSize {
+			n = chunkSize
+		}
+		if err := rel.load(db, keys[:n], related); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+
+	for _, owner := range owners {
+		field := reflectx.FieldByIndexes(owner, rel.field)
+		key := reflectx.FieldByIndexesReadOnly(owner, rel.key.fields)
+		var matches []reflect.Value
+		if key.Kind() != reflect.Ptr || !key.IsNil() {
+			matches = related[keyString(reflect.Indirect(key).Interface())]
+		}
+		if rel.hasMany {
+			slice := reflect.Zero(field.Type())
+			for _, m := range matches {
+				slice = reflect.Append(slice, elemValue(field.Type().Elem(), m))
+			}
+			field.Set(slice)
+		} else if len(matches) > 0 {
+			field.Set(elemValue(field.Type(), matches[0]))
+		} else {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	return nil
+}
+
+// load selects the target rows whose ref column matches one of the keys.
+func (rel *relation) load(db sqlx.Queryer, keys []interface{}, related map[string][]reflect.Value) error {
+	target := rel.target
+	format := "select %s from %s where %s in (" + strings.TrimSuffix(strings.Repeat("%s,", len(keys)), ",") + ")"
+	args := []interface{}{
+		target.Select.Columns,
+		target.Select.TableName,
+		target.Dialect().Quote(rel.ref.columnName),
+	}
+	for range keys {
+		args = append(args, target.Select.Placeholder())
+	}
+	dest := reflect.New(reflect.SliceOf(reflect.PtrTo(target.rowType)))
+	if err := Queryf(format, args...).Select(db, dest.Interface(), keys...); err != nil {
+		return err
+	}
+	for i := 0; i < dest.Elem().Len(); i++ {
+		row := dest.Elem().Index(i)
+		ref := reflectx.FieldByIndexesReadOnly(row.Elem(), rel.ref.fields)
+		if ref.Kind() == reflect.Ptr && ref.IsNil() {
+			continue
+		}
+		k := keyString(reflect.Indirect(ref).Interface())
+		related[k] = append(related[k], row)
+	}
+	return nil
+}
+
+// elemValue converts row, which is a pointer to a row, into
+// a value of type t, which is the row type or a pointer to it.
+func elemValue(t reflect.Type, row reflect.Value) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return row
+	}
+	return row.Elem()
+}
+
+// relatedOwners returns the addressable row values in rows.
+func relatedOwners(rows interface{}) ([]reflect.Value, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		if !v.CanAddr() {
+			return nil, fmt.Errorf("LoadRelated: must pass a pointer to %s", v.Type())
+		}
+		return []reflect.Value{v}, nil
+	}
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("LoadRelated: expected slice or pointer, got %T", rows)
+	}
+	var owners []reflect.Value
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("LoadRelated: expected slice of structs, got %T", rows)
+		}
+		owners = append(owners, elem)
+	}
+	return owners, nil
+}
@@ -0,0 +1,116 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type Author struct {
+	ID    int64
+	Name  string
+	Books []*Book `sql:"-"`
+}
+
+type Book struct {
+	ID       int64
+	AuthorID *int64 `sql:"column:author_id"`
+	Title    string
+	Author   Author `sql:"-"`
+}
+
+var (
+	authorTable = sqlf.Table("authors", Author{}).WithDialect(sqlf.DialectSQLite)
+	bookTable   = sqlf.Table("books", Book{}).WithDialect(sqlf.DialectSQLite)
+)
+
+func init() {
+	authorTable.HasMany("Books", bookTable, "author_id")
+	bookTable.BelongsTo("Author", authorTable, "author_id")
+}
+
+func TestLoadRelated(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`create table authors(id integer primary key, name text);
+		create table books(id integer primary key, author_id integer, title text);
+		insert into authors values(1, 'Austen'), (2, 'Bronte'), (3, 'Carroll');
+		insert into books values(10, 1, 'Emma'), (11, 2, 'Villette'), (12, 1, 'Persuasion'), (13, null, 'Anonymous');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var authors []Author
+	assert.NoError(sqlf.Queryf("select %s from %s order by id",
+		authorTable.Select.Columns, authorTable.Select.TableName).Select(db, &authors))
+	assert.NoError(sqlf.LoadRelated(db, authors, "Books"))
+	if assert.Len(authors, 3) {
+		if assert.Len(authors[0].Books, 2) {
+			assert.Equal("Emma", authors[0].Books[0].Title)
+			assert.Equal("Persuasion", authors[0].Books[1].Title)
+		}
+		if assert.Len(authors[1].Books, 1) {
+			assert.Equal("Villette", authors[1].Books[0].Title)
+		}
+		assert.Nil(authors[2].Books)
+	}
+
+	var books []*Book
+	assert.NoError(sqlf.Queryf("select %s from %s order by id",
+		bookTable.Select.Columns, bookTable.Select.TableName).Select(db, &books))
+	assert.NoError(sqlf.LoadRelated(db, &books, "Author"))
+	if assert.Len(books, 4) {
+		assert.Equal("Austen", books[0].Author.Name)
+		assert.Equal("Bronte", books[1].Author.Name)
+		assert.Equal("Austen", books[2].Author.Name)
+		assert.Equal(Author{}, books[3].Author)
+	}
+
+	// a single row
+	book := Book{ID: 99, AuthorID: books[1].AuthorID}
+	assert.NoError(sqlf.LoadRelated(db, &book, "Author"))
+	assert.Equal("Bronte", book.Author.Name)
+
+	assert.EqualError(sqlf.LoadRelated(db, &book, "Title"), "LoadRelated: no relationship declared for sqlf_test.Book.Title")
+	assert.Error(sqlf.LoadRelated(db, book, "Author"))
+	assert.NoError(sqlf.LoadRelated(db, []Book{}, "Author"))
+}
+
+func TestRelationPanics(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() { authorTable.HasMany("Missing", bookTable, "author_id") })
+	assert.Panics(func() { authorTable.HasMany("Books", bookTable, "missing_id") })
+	assert.Panics(func() { authorTable.HasMany("Name", bookTable, "author_id") })
+	assert.Panics(func() { bookTable.BelongsTo("Author", Row2Table, "author_id") })
+}
+
+func TestLoadRelatedMaxParams(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(65535, sqlf.MaxParams(sqlf.DialectPG))
+	assert.Equal(2100, sqlf.MaxParams(sqlf.DialectMSSQL))
+	assert.Equal(999, sqlf.MaxParams(sqlf.DialectSQLite))
+
+	books := make([]Book, 2500)
+	for i := range books {
+		id := int64(i + 1)
+		books[i].AuthorID = &id
+	}
+	rec := sqlftest.NewWithDriverName("sqlite3")
+	assert.NoError(sqlf.LoadRelated(rec, books, "Author"))
+	var counts []int
+	for _, cmd := range rec.Commands() {
+		counts = append(counts, len(cmd.Args))
+	}
+	assert.Equal([]int{998, 998, 504}, counts)
+
+	rec = sqlftest.NewWithDriverName("postgres")
+	assert.NoError(sqlf.LoadRelated(rec, books, "Author"))
+	assert.Len(rec.Commands(), 3, "no more than 1000 keys per query")
+}
@@ -0,0 +1,47 @@
+package sqlf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ResultColumn describes a column in the result set of a query,
+// which was selected using the Select.Columns of a table.
+type ResultColumn struct {
+	Name      string       // Name of the column in the result set
+	Column    string       // Column name in the table
+	Table     string       // Table name
+	Alias     string       // Table alias, if any
+	FieldPath string       // Path of the field in the row struct, eg "Address.Street"
+	FieldType reflect.Type // Type of the field in the row struct
+	RowType   reflect.Type // Type of the row struct
+}
+
+func (cmd *queryCommand) Columns() []ResultColumn {
+	columns := make([]ResultColumn, 0, len(cmd.columns))
+	for _, ci := range cmd.columns {
+		field := ci.table.rowType.FieldByIndex(ci.fields)
+		columns = append(columns, ResultColumn{
+			Name:      ci.outputName(),
+			Column:    ci.columnName,
+			Table:     ci.table.Name,
+			Alias:     ci.table.alias,
+			FieldPath: fieldPath(ci.table.rowType, ci.fields),
+			FieldType: field.Type,
+			RowType:   ci.table.rowType,
+		})
+	}
+	return columns
+}
+
+// fieldPath returns the names of the fields traversed by the
+// index sequence, separated by periods.
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, 0, len(index))
+	for _, i := range index {
+		field := t.Field(i)
+		names = append(names, field.Name)
+		t = field.Type
+	}
+	return strings.Join(names, ".")
+}
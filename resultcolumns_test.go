@@ -0,0 +1,36 @@
+package sqlf_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryColumns(t *testing.T) {
+	assert := assert.New(t)
+
+	type Address struct {
+		Street string
+	}
+	type Customer struct {
+		ID      int64
+		Name    string
+		Address Address
+	}
+	customers := sqlf.Table("customers", Customer{}).WithAlias("c")
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+
+	q := sqlf.Queryf("select %s, %s, count(*) from %s join %s on c.id = table1.id",
+		tbl.Select.Columns.Include("Id", "DOB"), customers.Select.Columns.Exclude("ID"),
+		tbl.Select.TableName, customers.Select.TableName)
+
+	assert.Equal([]sqlf.ResultColumn{
+		{Name: "id", Column: "id", Table: "table1", FieldPath: "Id", FieldType: reflect.TypeOf(int64(0)), RowType: reflect.TypeOf(Row1{})},
+		{Name: "Date_of_Birth", Column: "Date_of_Birth", Table: "table1", FieldPath: "DOB", FieldType: reflect.TypeOf(time.Time{}), RowType: reflect.TypeOf(Row1{})},
+		{Name: "c_name", Column: "name", Table: "customers", Alias: "c", FieldPath: "Name", FieldType: reflect.TypeOf(""), RowType: reflect.TypeOf(Customer{})},
+		{Name: "c_address_street", Column: "address_street", Table: "customers", Alias: "c", FieldPath: "Address.Street", FieldType: reflect.TypeOf(""), RowType: reflect.TypeOf(Customer{})},
+	}, q.Columns())
+}
@@ -0,0 +1,117 @@
+package sqlf
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryRows is the result of calling Query to select rows. It is a thin
+// wrapper around *sqlx.Rows, and has the same methods. The context of the
+// query, such as the timeout of a command built using WithTimeout, is
+// released when the rows are closed, or when Next reports that there are
+// no more rows, so the rows must always be closed.
+type QueryRows struct {
+	*sqlx.Rows
+	cancel context.CancelFunc // releases the query context, if any
+}
+
+// Next prepares the next row for reading, see sql.Rows.Next. The context
+// of the query is released once there are no more rows.
+func (r *QueryRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.done()
+	return false
+}
+
+// Close closes the rows and releases the context of the query.
+func (r *QueryRows) Close() error {
+	err := r.Rows.Close()
+	r.done()
+	return err
+}
+
+// done releases the context of the query once the rows have been read.
+func (r *QueryRows) done() {
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// Row is the result of calling QueryRow to select a single row.
+// It is a thin wrapper around *sqlx.Row. Any error that occurs while
+// preparing the query is deferred until one of the scan methods is
+// called, in the same way as errors returned by the database.
+type Row struct {
+	row    *sqlx.Row
+	cmd    *queryCommand
+	ctx    context.Context    // passed to codecs and AfterScan
+	cancel context.CancelFunc // releases the query context, if any
+	err    error
+}
+
+// done releases the context of the query once the row has been read.
+func (r *Row) done() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// Err returns the error, if any, that was encountered while running the query.
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
+
+// Scan copies the columns in the current row into the values pointed at by dest.
+// If more than one row matches the query, Scan uses the first row and discards
+// the rest. If no row matches the query, Scan returns sql.ErrNoRows.
+func (r *Row) Scan(dest ...interface{}) error {
+	defer r.done()
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// StructScan scans the row into the struct pointed to by dest, using
+// the column mapping of the query command. See QueryCommand.Select for
+// how columns from more than one table are scanned.
+func (r *Row) StructScan(dest interface{}) error {
+	defer r.done()
+	if r.err != nil {
+		return r.err
+	}
+	return r.cmd.structScan(r.ctx, r.row, dest)
+}
+
+// MapScan scans the row into a map of column name to value.
+func (r *Row) MapScan(dest map[string]interface{}) error {
+	defer r.done()
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.MapScan(dest)
+}
+
+// SliceScan scans the row into a slice of values, one for each column.
+func (r *Row) SliceScan() ([]interface{}, error) {
+	defer r.done()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.row.SliceScan()
+}
+
+// Columns returns the column names of the row.
+func (r *Row) Columns() ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.row.Columns()
+}
@@ -0,0 +1,154 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// rowArg is an arg of a command built using InsertRowf or UpdateRowf. The
+// args are worked out when the command is built, so that the args for each
+// row are obtained without searching for the fields of the columns, which
+// dominates the time taken to insert rows.
+type rowArg struct {
+	ci     *columnInfo // input column, or nil for a fixed arg
+	index  int         // index of the field, if direct
+	direct bool        // the field is in the row struct, not an embedded struct
+	encode bool        // the value is encoded or replaced, see encodeArg
+	value  interface{} // fixed arg supplied by a fragment, see Set
+}
+
+// newRowArgs returns the args for the input columns, with the fixed args
+// inserted at their positions.
+func newRowArgs(inputs []*columnInfo, fixed map[int]interface{}) []rowArg {
+	n := len(inputs) + len(fixed)
+	rowArgs := make([]rowArg, 0, n)
+	next := 0
+	for i := 0; i < n; i++ {
+		if value, ok := fixed[i]; ok {
+			rowArgs = append(rowArgs, rowArg{value: value})
+			continue
+		}
+		if next >= len(inputs) {
+			continue
+		}
+		ci := inputs[next]
+		next++
+		ra := rowArg{
+			ci: ci,
+			encode: ci.times != nil || ci.codec != nil || ci.array ||
+				ci.audit != auditNone || ci.discriminator,
+		}
+		if len(ci.fields) == 1 {
+			ra.index, ra.direct = ci.fields[0], true
+		}
+		rowArgs = append(rowArgs, ra)
+	}
+	return rowArgs
+}
+
+// rowArgs returns the args of the command, which are worked out when it
+// is built, or now if it was not built using InsertRowf or UpdateRowf.
+func (cmd execRowCommand) rowArgs() []rowArg {
+	if cmd.argPlan != nil {
+		return cmd.argPlan
+	}
+	return newRowArgs(cmd.inputs, cmd.fixed)
+}
+
+// appendArgs appends the args for the row to dst, encoded using ctx.
+// If withFixed is not set, only the args of the input columns are
+// appended.
+func (cmd execRowCommand) appendArgs(ctx context.Context, dst []interface{}, row interface{}, withFixed bool) ([]interface{}, error) {
+	if cmd.table == nil {
+		return nil, errTableNotSpecified
+	}
+	rowVal, err := cmd.getRowValue(row)
+	if err != nil {
+		return nil, err
+	}
+
+	av := auditValue{ctx: ctx}
+	for _, ra := range cmd.rowArgs() {
+		ci := ra.ci
+		if ci == nil {
+			if withFixed {
+				dst = append(dst, ra.value)
+			}
+			continue
+		}
+		var value interface{}
+		if ra.direct {
+			value = rowVal.Field(ra.index).Interface()
+		} else {
+			value = reflectx.FieldByIndexesReadOnly(rowVal, ci.fields).Interface()
+		}
+		if ra.encode {
+			if ci.discriminator && ci.table.variant != "" {
+				value = ci.table.variant
+			}
+			if ci.audit != auditNone {
+				if value, err = av.get(ci); err != nil {
+					return nil, err
+				}
+			}
+			if value, err = ci.encodeArg(ctx, value); err != nil {
+				return nil, err
+			}
+		}
+		dst = append(dst, value)
+	}
+	return dst, nil
+}
+
+// argsPool contains the arg slices used to execute row commands, which
+// are not needed once the command has executed.
+var argsPool = sync.Pool{
+	New: func() interface{} {
+		return new([]interface{})
+	},
+}
+
+// poolArgs reports whether the arg slice used to execute a command using
+// db can be reused once the command has executed. This is only the case
+// for the database/sql and sqlx handles, which convert the args and do
+// not keep the slice. Other handles, such as wrappers, recorders and
+// mocks, can keep the slice, which must not be overwritten by the args
+// of the next row.
+func poolArgs(db interface{}) bool {
+	switch unwrapHandle(db).(type) {
+	case *sqlx.DB, *sqlx.Tx, *sql.DB, *sql.Tx:
+		return true
+	}
+	return false
+}
+
+// getArgs returns an empty arg slice for executing a command using db,
+// from the pool if the slice can be reused.
+func getArgs(db interface{}) *[]interface{} {
+	if !poolArgs(db) {
+		return new([]interface{})
+	}
+	return argsPool.Get().(*[]interface{})
+}
+
+// putArgs returns the arg slice used to execute a command using db to
+// the pool, if it can be reused, after clearing it so that the pool does
+// not keep the values of the args.
+func putArgs(db interface{}, p *[]interface{}, args []interface{}) {
+	if !poolArgs(db) {
+		return
+	}
+	if cap(args) > 1024 {
+		// do not keep very large slices
+		return
+	}
+	for i := range args {
+		args[i] = nil
+	}
+	*p = args[:0]
+	argsPool.Put(p)
+}
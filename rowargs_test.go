@@ -0,0 +1,131 @@
+package sqlf_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type ArgsAudit struct {
+	CreatedAt time.Time
+	Note      string
+}
+
+type argsRow struct {
+	ID     int64 `sql:"primary_key;auto_increment"`
+	Name   string
+	Active bool `sql:"codec:boolint"`
+	ArgsAudit
+}
+
+var argsRows = sqlf.Table("args_rows", argsRow{}).WithDialect(sqlf.DialectSQLite)
+
+func TestRowArgs(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table args_rows(id integer primary key, name text, active integer, created_at datetime, note text)`); err != nil {
+		t.Fatal(err)
+	}
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		argsRows.Insert.TableName, argsRows.Insert.Columns, argsRows.Insert.Values)
+
+	args, err := insert.Args(&argsRow{Name: "a", Active: true})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"a", int64(1), time.Time{}, ""}, args)
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	args, err = insert.Args(argsRow{Name: "b", ArgsAudit: ArgsAudit{CreatedAt: created, Note: "x"}})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"b", int64(0), created, "x"}, args)
+
+	_, err = insert.Args(&ArgsAudit{})
+	assert.Error(err)
+
+	// the arg slices used to execute are reused, so the args
+	// returned previously must not change
+	for i := 0; i < 10; i++ {
+		assert.NoError(insert.Exec(db, &argsRow{Name: "c", ArgsAudit: ArgsAudit{Note: "y"}}))
+	}
+	assert.Equal([]interface{}{"b", int64(0), created, "x"}, args)
+	var n int
+	assert.NoError(db.Get(&n, "select count(*) from args_rows where name = 'c' and note = 'y'"))
+	assert.Equal(10, n)
+}
+
+// keepArgs is an Execer that keeps the args of each command.
+type keepArgs struct {
+	sqlx.Execer
+	args [][]interface{}
+}
+
+func (k *keepArgs) Exec(query string, args ...interface{}) (sql.Result, error) {
+	k.args = append(k.args, args)
+	return k.Execer.Exec(query, args...)
+}
+
+func TestRowArgsKept(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table args_rows(id integer primary key, name text, active integer, created_at datetime, note text)`); err != nil {
+		t.Fatal(err)
+	}
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		argsRows.Insert.TableName, argsRows.Insert.Columns, argsRows.Insert.Values)
+
+	// the arg slices are not reused for handles that can keep them
+	keep := &keepArgs{Execer: db}
+	assert.NoError(insert.Exec(keep, &argsRow{Name: "a"}))
+	assert.NoError(insert.Exec(keep, &argsRow{Name: "b"}))
+	if assert.Len(keep.args, 2) {
+		assert.Equal("a", keep.args[0][0])
+		assert.Equal("b", keep.args[1][0])
+	}
+}
+
+func BenchmarkInsertRowArgs(b *testing.B) {
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		argsRows.Insert.TableName, argsRows.Insert.Columns, argsRows.Insert.Values)
+	row := &argsRow{Name: "name", Active: true, ArgsAudit: ArgsAudit{CreatedAt: time.Now(), Note: "note"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := insert.Args(row); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertRowExec(b *testing.B) {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`create table args_rows(id integer primary key, name text, active integer, created_at datetime, note text)`); err != nil {
+		b.Fatal(err)
+	}
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		argsRows.Insert.TableName, argsRows.Insert.Columns, argsRows.Insert.Values,
+		sqlf.WithPrepared())
+	row := &argsRow{Name: "name", Active: true, ArgsAudit: ArgsAudit{CreatedAt: time.Now(), Note: "note"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		row.ID = 0
+		if err := insert.Exec(db, row); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package sqlf
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// HashRow returns a hash of the column values of the row, which is the
+// row type of the table or a pointer to it. Rows with the same values
+// for every column have the same hash, which does not change between
+// program runs, so it can be stored and compared later. This is useful
+// for sync and ETL pipelines, which can skip updating a row whose hash
+// has not changed.
+//
+// The values are hashed as they are written to the database, so codecs,
+// array columns and time settings (eg "utc") are applied, and times that
+// are the same instant in different locations have the same hash.
+func (ti *TableInfo) HashRow(row interface{}) (string, error) {
+	values, err := ti.columnValues(row)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for i, ci := range ti.columns {
+		h.Write([]byte(ci.columnName))
+		h.Write([]byte{0})
+		h.Write([]byte(values[i]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DiffRows compares two rows of the table column by column, and returns
+// the names of the columns whose values differ, in the order of the
+// fields in the row struct. Each row is the row type of the table or a
+// pointer to it. The values are compared as they are written to the
+// database, in the same way as HashRow.
+func (ti *TableInfo) DiffRows(a, b interface{}) ([]string, error) {
+	av, err := ti.columnValues(a)
+	if err != nil {
+		return nil, err
+	}
+	bv, err := ti.columnValues(b)
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+	for i, ci := range ti.columns {
+		if av[i] != bv[i] {
+			columns = append(columns, ci.columnName)
+		}
+	}
+	return columns, nil
+}
+
+// columnValues returns the canonical representation of
+// the value of each column of the row.
+func (ti *TableInfo) columnValues(row interface{}) ([]string, error) {
+	rowVal := reflect.ValueOf(row)
+	for rowVal.IsValid() && rowVal.Kind() == reflect.Ptr && !rowVal.IsNil() {
+		rowVal = rowVal.Elem()
+	}
+	if !rowVal.IsValid() || rowVal.Type() != ti.rowType {
+		return nil, fmt.Errorf("expected %s or pointer, got %T", ti.rowType, row)
+	}
+	values := make([]string, 0, len(ti.columns))
+	for _, ci := range ti.columns {
+		value := reflectx.FieldByIndexesReadOnly(rowVal, ci.fields).Interface()
+		arg, err := ci.encodeArg(context.Background(), value)
+		if err != nil {
+			return nil, err
+		}
+		dv, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", ci.columnName, err)
+		}
+		values = append(values, canonicalValue(dv))
+	}
+	return values, nil
+}
+
+// canonicalValue returns a representation of the driver value
+// that includes its type, so that values of different types,
+// such as nil and the empty string, are different.
+func canonicalValue(v driver.Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "n"
+	case int64:
+		return "i" + strconv.FormatInt(v, 10)
+	case float64:
+		return "f" + strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return "b" + strconv.FormatBool(v)
+	case []byte:
+		return "x" + hex.EncodeToString(v)
+	case string:
+		return "s" + v
+	case time.Time:
+		return "t" + v.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
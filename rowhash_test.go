@@ -0,0 +1,60 @@
+package sqlf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRow(t *testing.T) {
+	assert := assert.New(t)
+	type Customer struct {
+		ID       int64
+		Name     string
+		Email    *string
+		Tags     []string
+		JoinedAt time.Time
+	}
+	customers := sqlf.Table("customers", Customer{})
+
+	email := "john@example.com"
+	joined := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := Customer{ID: 1, Name: "John", Email: &email, Tags: []string{"x"}, JoinedAt: joined}
+	b := a
+	b.JoinedAt = joined.In(time.FixedZone("AEST", 10*3600))
+
+	ha, err := customers.HashRow(&a)
+	assert.NoError(err)
+	assert.Len(ha, 64)
+	hb, err := customers.HashRow(b)
+	assert.NoError(err)
+	assert.Equal(ha, hb)
+	diff, err := customers.DiffRows(&a, &b)
+	assert.NoError(err)
+	assert.Empty(diff)
+
+	b.Name = "Jane"
+	b.Email = nil
+	b.Tags = []string{"x", "y"}
+	hb, err = customers.HashRow(&b)
+	assert.NoError(err)
+	assert.NotEqual(ha, hb)
+	diff, err = customers.DiffRows(a, &b)
+	assert.NoError(err)
+	assert.Equal([]string{"name", "email", "tags"}, diff)
+
+	// nil and the empty string are different
+	empty := ""
+	b = a
+	b.Email = &empty
+	diff, err = customers.DiffRows(a, b)
+	assert.NoError(err)
+	assert.Equal([]string{"email"}, diff)
+
+	_, err = customers.HashRow(&Row1{})
+	assert.Error(err)
+	_, err = customers.DiffRows(a, nil)
+	assert.Error(err)
+}
@@ -0,0 +1,80 @@
+package sqlf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldScanning returns the codec used to write and read the field,
+// if any, and whether the field is stored in an array column.
+//
+// By default a field with a "codec" tag uses its codec, a field whose
+// type has a registered converter (see RegisterConverter) uses the
+// converter, and a slice field uses an array column. Otherwise the
+// field is passed to the database driver, which uses its Value and
+// Scan methods if it has them. The "scan" tag selects one of these
+// explicitly for a column, so that adding a converter or a method to
+// a type does not silently change how existing columns are stored:
+//
+//	type Payment struct {
+//		Amount   decimal.Decimal `sql:"scan:scanner"`   // Value and Scan methods
+//		Discount decimal.Decimal `sql:"scan:converter"` // registered converter
+//		Card     string          `sql:"scan:codec;codec:encrypt"`
+//	}
+//
+// A field with a "decimal" tag uses the decimal codec (see Decimal).
+// Table panics if the choice is not available for the field, or if it
+// conflicts with a "codec" or "decimal" tag.
+func fieldScanning(field reflect.StructField, tagSettings map[string]string, c constraints) (codec Codec, array bool) {
+	codecName, hasCodec := tagSettings["CODEC"]
+	value, ok := tagSettings["SCAN"]
+	if scale, isDecimal := tagSettings["DECIMAL"]; isDecimal {
+		if hasCodec || ok {
+			panic(fmt.Sprintf("sqlf.Table: field %s: decimal tag conflicts with codec and scan tags", field.Name))
+		}
+		return fieldDecimal(field, scale), false
+	}
+	if !ok {
+		if hasCodec {
+			return fieldCodec(field, strings.TrimSpace(codecName), c), false
+		}
+		if codec := lookupConverter(field.Type); codec != nil {
+			return codec, false
+		}
+		return nil, isArrayType(field.Type)
+	}
+
+	scan := strings.ToLower(strings.TrimSpace(value))
+	if hasCodec && scan != "codec" {
+		panic(fmt.Sprintf("sqlf.Table: field %s: scan:%s conflicts with codec tag", field.Name, scan))
+	}
+	switch scan {
+	case "codec":
+		if !hasCodec {
+			panic(fmt.Sprintf("sqlf.Table: field %s: scan:codec requires the codec tag", field.Name))
+		}
+		return fieldCodec(field, strings.TrimSpace(codecName), c), false
+	case "converter":
+		codec := lookupConverter(field.Type)
+		if codec == nil {
+			panic(fmt.Sprintf("sqlf.Table: field %s: scan:converter requires a converter for %s", field.Name, field.Type))
+		}
+		return codec, false
+	case "scanner":
+		if !isScannerType(field.Type) {
+			panic(fmt.Sprintf("sqlf.Table: field %s: scan:scanner requires %s to implement sql.Scanner", field.Name, field.Type))
+		}
+		return nil, false
+	}
+	panic(fmt.Sprintf("sqlf.Table: field %s: invalid scan tag %q", field.Name, value))
+}
+
+// isScannerType reports whether a field of type t can scan
+// itself, directly or via the type it points to.
+func isScannerType(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(sqlScanType) {
+		return true
+	}
+	return t.Kind() == reflect.Ptr && t.Implements(sqlScanType)
+}
@@ -0,0 +1,132 @@
+package sqlf_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// amount has Value and Scan methods that store it as text, eg "1.50",
+// and a registered converter that stores it as an integer number of cents.
+type amount struct {
+	cents int64
+}
+
+func (a amount) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d.%02d", a.cents/100, a.cents%100), nil
+}
+
+func (a *amount) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("amount: unexpected %T", src)
+	}
+	var dollars, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%d", &dollars, &cents); err != nil {
+		return err
+	}
+	a.cents = dollars*100 + cents
+	return nil
+}
+
+func init() {
+	sqlf.RegisterConverter(reflect.TypeOf(amount{}),
+		func(v interface{}) (driver.Value, error) {
+			return v.(amount).cents, nil
+		},
+		func(v interface{}) (interface{}, error) {
+			cents, ok := v.(int64)
+			if !ok {
+				return nil, fmt.Errorf("amount: unexpected %T", v)
+			}
+			return amount{cents: cents}, nil
+		})
+}
+
+func TestScanTag(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table payments(id integer primary key, price, discount, total)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Payment struct {
+		ID       int64
+		Price    amount  `sql:"scan:scanner"`
+		Discount amount  `sql:"scan:converter"`
+		Total    *amount `sql:"scan:scanner"`
+	}
+	payments := sqlf.Table("payments", Payment{}).WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		payments.Insert.TableName, payments.Insert.Columns, payments.Insert.Values)
+	assert.NoError(insert.Exec(db, &Payment{ID: 1, Price: amount{150}, Discount: amount{25}, Total: &amount{125}}))
+
+	var raw struct {
+		Price    string
+		Discount int64
+		Total    string
+	}
+	assert.NoError(db.QueryRowx("select price, discount, total from payments").Scan(&raw.Price, &raw.Discount, &raw.Total))
+	assert.Equal("1.50", raw.Price)
+	assert.Equal(int64(25), raw.Discount)
+	assert.Equal("1.25", raw.Total)
+
+	var payment Payment
+	assert.NoError(sqlf.Queryf("select %s from %s", payments.Select.Columns, payments.Select.TableName).Get(db, &payment))
+	assert.Equal(Payment{ID: 1, Price: amount{150}, Discount: amount{25}, Total: &amount{125}}, payment)
+}
+
+func TestScanTagPanics(t *testing.T) {
+	tests := []struct {
+		row  interface{}
+		want string
+	}{
+		{
+			row: struct {
+				Count int `sql:"scan:scanner"`
+			}{},
+			want: "sqlf.Table: field Count: scan:scanner requires int to implement sql.Scanner",
+		},
+		{
+			row: struct {
+				Name string `sql:"scan:converter"`
+			}{},
+			want: "sqlf.Table: field Name: scan:converter requires a converter for string",
+		},
+		{
+			row: struct {
+				Active bool `sql:"scan:codec"`
+			}{},
+			want: "sqlf.Table: field Active: scan:codec requires the codec tag",
+		},
+		{
+			row: struct {
+				Price amount `sql:"scan:scanner;codec:boolint"`
+			}{},
+			want: "sqlf.Table: field Price: scan:scanner conflicts with codec tag",
+		},
+		{
+			row: struct {
+				Price amount `sql:"scan:value"`
+			}{},
+			want: `sqlf.Table: field Price: invalid scan tag "value"`,
+		},
+	}
+	for _, tt := range tests {
+		assert.PanicsWithValue(t, tt.want, func() { sqlf.Table("t", tt.row) })
+	}
+}
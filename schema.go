@@ -0,0 +1,354 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// tables contains every table created using Table, so that
+// ValidateSchema can check them all.
+var tables = struct {
+	mutex sync.Mutex
+	list  []*TableInfo
+}{}
+
+func registerTable(ti *TableInfo) {
+	tables.mutex.Lock()
+	defer tables.mutex.Unlock()
+	tables.list = append(tables.list, ti)
+}
+
+func registeredTables() []*TableInfo {
+	tables.mutex.Lock()
+	defer tables.mutex.Unlock()
+	return append([]*TableInfo(nil), tables.list...)
+}
+
+// Tables returns every table created using Table, in the
+// order in which they were created.
+func Tables() []*TableInfo {
+	return registeredTables()
+}
+
+// SchemaError describes a difference between a table as described
+// by its row struct, and the table in the database.
+type SchemaError struct {
+	Table   string // Table name
+	Column  string // Column name, blank if the error applies to the table
+	Field   string // Field name in the Go struct, if any
+	Message string // Description of the difference
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("%s: %s", e.Table, e.Message)
+	}
+	return fmt.Sprintf("%s.%s: %s", e.Table, e.Column, e.Message)
+}
+
+// SchemaErrors is the error returned by ValidateSchema when one or more
+// tables do not match the database. There is one entry for each difference.
+type SchemaErrors []*SchemaError
+
+// Error implements the error interface.
+func (errs SchemaErrors) Error() string {
+	var buf bytes.Buffer
+	for i, err := range errs {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// ValidateSchema compares tables against the database, and returns
+// a SchemaErrors describing any differences. If no tables are specified,
+// all tables created using Table are compared. Calling ValidateSchema
+// during program startup detects a renamed or missing column before
+// the first command that refers to it fails.
+//
+// The following differences are reported:
+//
+//   - a table that does not exist
+//   - a column that does not exist
+//   - a primary key that does not match
+//   - a field whose type cannot hold the values of the column, for example
+//     an int64 field for a text column
+//
+// Columns in the database that have no corresponding field are not
+// reported. Types are only compared where the column type is known,
+// and fields with a codec or that implement sql.Scanner are not compared.
+//
+// The table information is read using "pragma table_info" for SQLite,
+// and information_schema for other databases. If db implements
+// DriverName (as *sqlx.DB does), the queries suit the dialect of the
+// driver, otherwise they suit the default dialect.
+func ValidateSchema(db sqlx.Queryer, tables ...*TableInfo) error {
+	if len(tables) == 0 {
+		tables = registeredTables()
+	}
+	d := Default.dialect()
+	if dn, ok := db.(driverNamer); ok {
+		if dd := dialectForDriver(dn.DriverName()); dd != nil {
+			d = dd
+		}
+	}
+
+	var errs SchemaErrors
+	for _, ti := range tables {
+		columns, err := loadDBColumns(db, d, ti.Name)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, compareTable(d, ti, columns)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// dbColumn describes a column read from the database.
+type dbColumn struct {
+	name       string
+	typ        string
+	primaryKey bool
+}
+
+// loadDBColumns reads the columns for the table from the database.
+// If the table does not exist, there are no columns.
+func loadDBColumns(db sqlx.Queryer, d Dialect, tableName string) ([]*dbColumn, error) {
+	var columns []*dbColumn
+	if DialectName(d) == "sqlite3" {
+		rows, err := db.Query(fmt.Sprintf("pragma table_info(%s)", d.Quote(tableName)))
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				col     dbColumn
+				cid     int
+				notNull bool
+				dflt    interface{}
+				pk      int
+			)
+			if err := rows.Scan(&cid, &col.name, &col.typ, &notNull, &dflt, &pk); err != nil {
+				return nil, err
+			}
+			col.primaryKey = pk > 0
+			columns = append(columns, &col)
+		}
+		return columns, rows.Err()
+	}
+
+	schema := "current_schema()"
+	switch DialectName(d) {
+	case "mysql":
+		schema = "database()"
+	case "mssql", "sqlserver":
+		schema = "schema_name()"
+	}
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		schema = quoteString(d, tableName[:i])
+		tableName = tableName[i+1:]
+	}
+
+	query := rebind(d, fmt.Sprintf(`select column_name, data_type
+		from information_schema.columns
+		where table_schema = %s and table_name = ?
+		order by ordinal_position`, schema))
+	rows, err := db.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byName := make(map[string]*dbColumn)
+	for rows.Next() {
+		var col dbColumn
+		if err := rows.Scan(&col.name, &col.typ); err != nil {
+			return nil, err
+		}
+		columns = append(columns, &col)
+		byName[col.name] = &col
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = rebind(d, fmt.Sprintf(`select kcu.column_name
+		from information_schema.table_constraints tc
+		join information_schema.key_column_usage kcu
+		on kcu.constraint_name = tc.constraint_name
+		and kcu.table_schema = tc.table_schema
+		and kcu.table_name = tc.table_name
+		where tc.constraint_type = 'PRIMARY KEY'
+		and tc.table_schema = %s and tc.table_name = ?`, schema))
+	pkRows, err := db.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer pkRows.Close()
+	for pkRows.Next() {
+		var name string
+		if err := pkRows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if col := byName[name]; col != nil {
+			col.primaryKey = true
+		}
+	}
+	return columns, pkRows.Err()
+}
+
+// compareTable returns the differences between the table and
+// the columns read from the database.
+func compareTable(d Dialect, ti *TableInfo, columns []*dbColumn) SchemaErrors {
+	if len(columns) == 0 {
+		return SchemaErrors{{Table: ti.Name, Message: "table not found"}}
+	}
+
+	// identifiers are case sensitive in PostgreSQL, because sqlf quotes them
+	findColumn := func(name string) *dbColumn {
+		for _, col := range columns {
+			if col.name == name || DialectName(d) != "postgres" && strings.EqualFold(col.name, name) {
+				return col
+			}
+		}
+		return nil
+	}
+
+	var errs SchemaErrors
+	matched := make(map[*dbColumn]bool)
+	for _, ci := range ti.columns {
+		newError := func(format string, args ...interface{}) {
+			errs = append(errs, &SchemaError{
+				Table:   ti.Name,
+				Column:  ci.columnName,
+				Field:   ci.fieldName,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+		col := findColumn(ci.columnName)
+		if col == nil {
+			newError("column not found")
+			continue
+		}
+		matched[col] = true
+		if ci.primaryKey && !col.primaryKey {
+			newError("column is not part of the primary key")
+		} else if !ci.primaryKey && col.primaryKey {
+			newError("primary key column is not tagged as a primary key")
+		}
+		if ci.codec == nil {
+			fieldType := ti.rowType.FieldByIndex(ci.fields).Type
+			if !typeCompatible(fieldType, col.typ) {
+				newError("field type %s is not compatible with column type %s", fieldType, col.typ)
+			}
+		}
+	}
+	for _, col := range columns {
+		if col.primaryKey && !matched[col] {
+			errs = append(errs, &SchemaError{
+				Table:   ti.Name,
+				Column:  col.name,
+				Message: "primary key column has no field",
+			})
+		}
+	}
+	return errs
+}
+
+// typeClasses classifies database column types by the first
+// matching substring of the type name.
+var typeClasses = []struct {
+	substr string
+	class  string
+}{
+	{"int", "int"},
+	{"serial", "int"},
+	{"bool", "bool"},
+	{"bit", "bool"},
+	{"date", "time"},
+	{"time", "time"},
+	{"real", "float"},
+	{"float", "float"},
+	{"double", "float"},
+	{"numeric", "float"},
+	{"decimal", "float"},
+	{"money", "float"},
+	{"char", "text"},
+	{"text", "text"},
+	{"clob", "text"},
+	{"uuid", "text"},
+	{"json", "text"},
+	{"xml", "text"},
+	{"enum", "text"},
+	{"blob", "bytes"},
+	{"binary", "bytes"},
+	{"bytea", "bytes"},
+	{"image", "bytes"},
+}
+
+// compatibleClasses lists the column type classes that can be
+// scanned into a field of each kind. Fields of other kinds, including
+// string and []byte, can hold the values of any column.
+var compatibleClasses = map[reflect.Kind][]string{
+	reflect.Int:     {"int", "float", "bool"},
+	reflect.Int8:    {"int", "float", "bool"},
+	reflect.Int16:   {"int", "float", "bool"},
+	reflect.Int32:   {"int", "float", "bool"},
+	reflect.Int64:   {"int", "float", "bool"},
+	reflect.Uint:    {"int", "float", "bool"},
+	reflect.Uint8:   {"int", "float", "bool"},
+	reflect.Uint16:  {"int", "float", "bool"},
+	reflect.Uint32:  {"int", "float", "bool"},
+	reflect.Uint64:  {"int", "float", "bool"},
+	reflect.Float32: {"int", "float"},
+	reflect.Float64: {"int", "float"},
+	reflect.Bool:    {"bool", "int"},
+}
+
+// typeCompatible reports whether a field of type t can hold the values
+// of a column with the database type. It returns true if this cannot be
+// determined.
+func typeCompatible(t reflect.Type, dbType string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(sqlScanType) {
+		return true
+	}
+	var class string
+	lower := strings.ToLower(dbType)
+	for _, tc := range typeClasses {
+		if strings.Contains(lower, tc.substr) {
+			class = tc.class
+			break
+		}
+	}
+	if class == "" {
+		return true
+	}
+	if t == timeType {
+		// SQLite stores dates and times as text
+		return class == "time" || class == "text"
+	}
+	classes, ok := compatibleClasses[t.Kind()]
+	if !ok {
+		return true
+	}
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
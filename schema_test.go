@@ -0,0 +1,90 @@
+package sqlf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSchema(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	defer db.Close()
+	if _, err := db.Exec(`create table orders(
+		order_no integer primary key,
+		customer text,
+		amount numeric,
+		created_at datetime
+	)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Order struct {
+		OrderNo   int64 `sql:"primary_key"`
+		Customer  string
+		Amount    float64
+		CreatedAt time.Time
+	}
+	users := Table("users", User{})
+	orders := Table("orders", Order{})
+	assert.NoError(ValidateSchema(db, users, orders))
+
+	type BadOrder struct {
+		OrderNo  int64
+		Customer int64
+		Amount   float64
+		Total    float64
+	}
+	type Missing struct {
+		ID int
+	}
+	err := ValidateSchema(db,
+		Table("orders", BadOrder{}),
+		Table("missing", Missing{}),
+	)
+	errs, ok := err.(SchemaErrors)
+	if !assert.True(ok, "expected SchemaErrors, got %v", err) {
+		return
+	}
+	assert.Equal(SchemaErrors{
+		{Table: "orders", Column: "order_no", Field: "OrderNo", Message: "primary key column is not tagged as a primary key"},
+		{Table: "orders", Column: "customer", Field: "Customer", Message: "field type int64 is not compatible with column type TEXT"},
+		{Table: "orders", Column: "total", Field: "Total", Message: "column not found"},
+		{Table: "missing", Message: "table not found"},
+	}, errs)
+	assert.Equal("orders.order_no: primary key column is not tagged as a primary key; "+
+		"orders.customer: field type int64 is not compatible with column type TEXT; "+
+		"orders.total: column not found; "+
+		"missing: table not found", err.Error())
+}
+
+func TestTypeCompatible(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		dbType string
+		want   bool
+	}{
+		{int64(0), "integer", true},
+		{int64(0), "numeric(10,2)", true},
+		{int64(0), "varchar(10)", false},
+		{int64(0), "timestamp", false},
+		{float64(0), "double precision", true},
+		{float64(0), "boolean", false},
+		{true, "tinyint(1)", true},
+		{true, "text", false},
+		{"", "integer", true},
+		{[]byte(nil), "text", true},
+		{time.Time{}, "timestamp with time zone", true},
+		{time.Time{}, "text", true},
+		{time.Time{}, "integer", false},
+		{int64(0), "geometry", true},
+	}
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt.value)
+		if got := typeCompatible(typ, tt.dbType); got != tt.want {
+			t.Errorf("typeCompatible(%s, %q): got %v, want %v", typ, tt.dbType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package sqlf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ScriptCommand contains a script of SQL statements separated by
+// semicolons, which are executed in order. Scripts are useful for
+// creating the tables of a test database, or for small migrations
+// driven from Go code.
+type ScriptCommand interface {
+	// Command returns the script.
+	Command() string
+
+	// Statements returns the statements in the script.
+	Statements() []string
+
+	// Exec executes the statements in order. If db can begin a
+	// transaction (eg *sqlx.DB), the statements are executed in a
+	// transaction, which is rolled back if any statement fails. Otherwise
+	// the statements are executed using db, which is typically a *sqlx.Tx.
+	// Note that some databases, including MySQL, commit any DDL statement
+	// (eg "create table") immediately.
+	Exec(db sqlx.Execer) error
+}
+
+type scriptCommand struct {
+	command    string
+	statements []execCommand
+}
+
+// Scriptf formats a script of SQL statements separated by semicolons. The
+// arguments are formatted in the same way as for Execf, except that
+// scripts cannot contain placeholders.
+//
+// The script is split into statements according to the SQL dialect, so
+// that semicolons in string literals, quoted identifiers and comments are
+// not treated as separators. Semicolons in the body of a trigger or stored
+// procedure between "begin" and "end" are not separators either, and for
+// PostgreSQL, neither are semicolons in dollar-quoted strings (eg the body
+// of a function between "$$" and "$$").
+//
+//	setup := sqlf.Scriptf(`
+//		create table %s(id integer primary key, name text);
+//		insert into %s(id, name) values(1, 'admin');
+//	`, users.Insert.TableName, users.Insert.TableName)
+func Scriptf(format string, args ...interface{}) ScriptCommand {
+	args, opts := splitOptions(args)
+	args = cloneArgs(args, opts)
+	d := opts.dialectFor(args)
+	cmd := &scriptCommand{
+		command: fmt.Sprintf(format, args...),
+	}
+	var err error
+	if len(inputPositioners(args, d)) > 0 {
+		err = fmt.Errorf("Scriptf: scripts cannot have placeholders")
+	}
+	for _, stmt := range splitStatements(d, cmd.command) {
+		cmd.statements = append(cmd.statements, execCommand{
+			command: stmt,
+			dialect: d,
+			rebind:  &rebindCache{},
+			opts:    opts,
+			err:     err,
+		})
+	}
+	return cmd
+}
+
+func (cmd *scriptCommand) Command() string {
+	return cmd.command
+}
+
+func (cmd *scriptCommand) Statements() []string {
+	stmts := make([]string, 0, len(cmd.statements))
+	for _, stmt := range cmd.statements {
+		stmts = append(stmts, stmt.command)
+	}
+	return stmts
+}
+
+func (cmd *scriptCommand) Exec(db sqlx.Execer) error {
+	if b, ok := db.(txBeginner); ok {
+		tx, err := b.Beginx()
+		if err != nil {
+			return err
+		}
+		if err := cmd.exec(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	}
+	return cmd.exec(db)
+}
+
+func (cmd *scriptCommand) exec(db sqlx.Execer) error {
+	for _, stmt := range cmd.statements {
+		if _, err := stmt.Exec(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits the script into statements separated by
+// semicolons, according to the rules of dialect d, which may be nil.
+// Statements that contain only white space and comments are omitted.
+func splitStatements(d Dialect, script string) []string {
+	name := DialectName(d)
+	var (
+		stmts   []string
+		start   int  // start of the current statement
+		hasCode bool // the current statement has more than comments
+		isBlock bool // the current statement can have begin...end blocks
+		depth   int  // depth of begin...end blocks
+		words   int  // number of words in the current statement
+	)
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case ch == '-' && strings.HasPrefix(script[i:], "--"):
+			i = skipTo(script, i, "\n") - 1
+			continue
+		case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+			i = skipTo(script, i+2, "*/") - 1
+			continue
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			continue
+		case ch == ';' && depth == 0:
+			if hasCode {
+				stmts = append(stmts, strings.TrimSpace(script[start:i]))
+			}
+			start = i + 1
+			hasCode, isBlock, words = false, false, 0
+			continue
+		}
+		hasCode = true
+		switch {
+		case ch == '\'':
+			i = skipQuoted(script, i, '\'', name == "mysql") - 1
+		case ch == '"' || ch == '`':
+			i = skipQuoted(script, i, ch, false) - 1
+		case ch == '[' && name == "mssql":
+			i = skipQuoted(script, i, ']', false) - 1
+		case ch == '$' && name == "postgres":
+			if tag, ok := dollarTag(script, i); ok {
+				i = skipTo(script, i+len(tag), tag) - 1
+			}
+		case isIdentByte(ch):
+			j := i
+			for j < len(script) && isIdentByte(script[j]) {
+				j++
+			}
+			word := strings.ToLower(script[i:j])
+			words++
+			if words == 1 {
+				isBlock = word == "create"
+			} else if isBlock {
+				switch word {
+				case "begin", "case":
+					depth++
+				case "end":
+					// "end if", "end loop" etc close blocks that are not
+					// counted, and "end case" closes a counted case
+					switch nextWord(script, j) {
+					case "if", "loop", "while", "repeat":
+					case "case":
+						j = skipWord(script, j)
+						fallthrough
+					default:
+						if depth > 0 {
+							depth--
+						}
+					}
+				}
+			}
+			i = j - 1
+		}
+	}
+	if hasCode {
+		stmts = append(stmts, strings.TrimSpace(script[start:]))
+	}
+	return stmts
+}
+
+// skipTo returns the index of the first byte after the next occurrence
+// of s in script at or after index i, or the length of the script.
+func skipTo(script string, i int, s string) int {
+	if n := strings.Index(script[i:], s); n >= 0 {
+		return i + n + len(s)
+	}
+	return len(script)
+}
+
+// skipQuoted returns the index of the first byte after the quoted string
+// or identifier starting at index i. The quote is escaped by doubling it,
+// or if backslash is true, by a preceding backslash.
+func skipQuoted(script string, i int, quote byte, backslash bool) int {
+	for i++; i < len(script); i++ {
+		switch script[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(script)
+}
+
+// dollarTag returns the PostgreSQL dollar quote tag (eg "$$" or "$body$")
+// that starts at index i, if any. Placeholders (eg "$1") are not tags.
+func dollarTag(script string, i int) (string, bool) {
+	if i > 0 && isIdentByte(script[i-1]) {
+		return "", false
+	}
+	for j := i + 1; j < len(script); j++ {
+		ch := script[j]
+		if ch == '$' {
+			return script[i : j+1], true
+		}
+		if !isIdentByte(ch) || (j == i+1 && ch >= '0' && ch <= '9') {
+			break
+		}
+	}
+	return "", false
+}
+
+// nextWord returns the word, in lower case, that follows any
+// white space at index i.
+func nextWord(script string, i int) string {
+	i = skipSpace(script, i)
+	j := i
+	for j < len(script) && isIdentByte(script[j]) {
+		j++
+	}
+	return strings.ToLower(script[i:j])
+}
+
+// skipWord returns the index of the first byte after the word
+// that follows any white space at index i.
+func skipWord(script string, i int) int {
+	i = skipSpace(script, i)
+	for i < len(script) && isIdentByte(script[i]) {
+		i++
+	}
+	return i
+}
+
+func skipSpace(script string, i int) int {
+	for i < len(script) && strings.IndexByte(" \t\r\n", script[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch >= 0x80
+}
@@ -0,0 +1,112 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptfStatements(t *testing.T) {
+	tests := []struct {
+		dialect sqlf.Dialect
+		script  string
+		want    []string
+	}{
+		{
+			dialect: sqlf.DialectSQLite,
+			script:  "create table t(a text);\n\ninsert into t values('a;b');  \n",
+			want:    []string{"create table t(a text)", "insert into t values('a;b')"},
+		},
+		{
+			dialect: sqlf.DialectSQLite,
+			script:  "-- comment; with a semicolon\nselect 1; /* another; */ select \"x;y\" from t;;\n-- trailing comment",
+			want:    []string{"-- comment; with a semicolon\nselect 1", "/* another; */ select \"x;y\" from t"},
+		},
+		{
+			dialect: sqlf.DialectSQLite,
+			script: `create trigger tr after insert on t begin
+				update t set a = case when a is null then 'x' else a end;
+				delete from u;
+			end;
+			select 1`,
+			want: []string{`create trigger tr after insert on t begin
+				update t set a = case when a is null then 'x' else a end;
+				delete from u;
+			end`, "select 1"},
+		},
+		{
+			dialect: sqlf.DialectPG,
+			script: `create function f() returns int as $body$
+				begin return 1; end;
+			$body$ language plpgsql;
+			select f(), $$a;b$$, 'it''s;'`,
+			want: []string{`create function f() returns int as $body$
+				begin return 1; end;
+			$body$ language plpgsql`, `select f(), $$a;b$$, 'it''s;'`},
+		},
+		{
+			dialect: sqlf.DialectMySQL,
+			script: `insert into t values('it\'s;');
+			create procedure p() begin
+				if 1 then select 1; end if;
+				case when 1 then select 2; else select 3; end case;
+			end;
+			select 4`,
+			want: []string{`insert into t values('it\'s;')`, `create procedure p() begin
+				if 1 then select 1; end if;
+				case when 1 then select 2; else select 3; end case;
+			end`, "select 4"},
+		},
+	}
+	for i, tt := range tests {
+		script := sqlf.Scriptf(tt.script, sqlf.WithDialect(tt.dialect))
+		assert.Equal(t, tt.want, script.Statements(), "test %d", i)
+	}
+}
+
+func TestScriptfExec(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+
+	setup := sqlf.Scriptf(`
+		create table %s(id integer primary key, given_name text, family_name text, Date_of_Birth datetime);
+		insert into %s(given_name, family_name) values('John', 'Citizen');
+		insert into %s(given_name, family_name) values('Jane', 'Citizen');
+	`, tbl.Insert.TableName, tbl.Insert.TableName, tbl.Insert.TableName)
+	assert.Len(setup.Statements(), 3)
+	assert.NoError(setup.Exec(db))
+
+	var count int
+	assert.NoError(db.Get(&count, "select count(*) from table1"))
+	assert.Equal(2, count)
+
+	// the script is rolled back if a statement fails
+	bad := sqlf.Scriptf(`
+		delete from table1;
+		insert into no_such_table values(1);
+	`)
+	assert.Error(bad.Exec(db))
+	assert.NoError(db.Get(&count, "select count(*) from table1"))
+	assert.Equal(2, count)
+
+	// with a transaction, the statements are executed in the transaction
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(sqlf.Scriptf("delete from table1 where given_name = 'Jane'").Exec(tx))
+	assert.NoError(tx.Rollback())
+	assert.NoError(db.Get(&count, "select count(*) from table1"))
+	assert.Equal(2, count)
+
+	// scripts cannot have placeholders
+	assert.Error(sqlf.Scriptf("delete from table1 where id = %s", tbl.Delete.Placeholder()).Exec(db))
+}
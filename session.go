@@ -0,0 +1,239 @@
+package sqlf
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Session caches the rows loaded from the database, so that loading the
+// same row more than once, which often happens while handling a single
+// request, only queries the database the first time. Rows are cached by
+// table name and primary key.
+//
+// Rows inserted or updated using the session are written through to the
+// database and then cached. Changes made to the database other than by
+// the session are not visible to it, so a session should be short-lived:
+// typically one session is created for each request.
+//
+// The session stores a copy of each row, so that changes made by the
+// caller to a row do not change the cached copy. A session is safe for
+// concurrent use.
+type Session struct {
+	mutex sync.Mutex
+	rows  map[sessionKey]reflect.Value
+}
+
+// sessionKey identifies a row in the session cache.
+type sessionKey struct {
+	table string
+	pk    string
+}
+
+// NewSession returns a new, empty session.
+func NewSession() *Session {
+	return &Session{rows: make(map[sessionKey]reflect.Value)}
+}
+
+// sessionCommands contains the commands used by sessions for each table.
+type sessionCommands struct {
+	get    QueryCommand
+	insert InsertRowCommand
+	update UpdateRowCommand
+	delete ExecCommand
+}
+
+var sessionCmds = struct {
+	mutex sync.Mutex
+	m     map[*TableInfo]*sessionCommands
+}{m: make(map[*TableInfo]*sessionCommands)}
+
+func commandsForSession(ti *TableInfo) (*sessionCommands, error) {
+	if len(ti.Update.WhereColumns.filtered()) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", ti.Name)
+	}
+	sessionCmds.mutex.Lock()
+	defer sessionCmds.mutex.Unlock()
+	cmds := sessionCmds.m[ti]
+	if cmds == nil {
+		cmds = &sessionCommands{
+			get: Queryf("select %s from %s where %s",
+				ti.Select.Columns, ti.Select.TableName, ti.Update.WhereColumns),
+			insert: InsertRowf("insert into %s(%s) values(%s)",
+				ti.Insert.TableName, ti.Insert.Columns, ti.Insert.Values),
+			update: UpdateRowf("update %s set %s where %s",
+				ti.Update.TableName, ti.Update.SetColumns, ti.Update.WhereColumns),
+			delete: Execf("delete from %s where %s",
+				ti.Delete.TableName, ti.Update.WhereColumns),
+		}
+		sessionCmds.m[ti] = cmds
+	}
+	return cmds, nil
+}
+
+// key returns the cache key for the primary key values.
+func (s *Session) key(ti *TableInfo, pk []interface{}) sessionKey {
+	var buf bytes.Buffer
+	for i, v := range pk {
+		if i > 0 {
+			buf.WriteByte(0)
+		}
+		buf.WriteString(keyString(v))
+	}
+	return sessionKey{table: ti.Name, pk: buf.String()}
+}
+
+// keyString returns a string representation of a key value, so that
+// values of different types that compare equal in the database (eg
+// int and int64) are treated as the same key.
+func keyString(v interface{}) string {
+	for rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr; rv = reflect.ValueOf(v) {
+		if rv.IsNil() {
+			break
+		}
+		v = rv.Elem().Interface()
+	}
+	return fmt.Sprint(v)
+}
+
+// rowKey returns the cache key for the row.
+func (s *Session) rowKey(ti *TableInfo, rowVal reflect.Value) sessionKey {
+	var pk []interface{}
+	for _, ci := range ti.Update.WhereColumns.filtered() {
+		pk = append(pk, reflectx.FieldByIndexesReadOnly(rowVal, ci.fields).Interface())
+	}
+	return s.key(ti, pk)
+}
+
+// rowValue returns the struct value pointed to by row, which
+// must be a pointer to the row type of the table.
+func rowValue(ti *TableInfo, row interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(row)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Type() != ti.rowType {
+		return reflect.Value{}, fmt.Errorf("expected pointer to %s, got %T", ti.rowType, row)
+	}
+	return v.Elem(), nil
+}
+
+// store saves a copy of the row in the cache.
+func (s *Session) store(ti *TableInfo, rowVal reflect.Value) {
+	c := reflect.New(ti.rowType).Elem()
+	c.Set(rowVal)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rows[s.rowKey(ti, rowVal)] = c
+}
+
+// Get loads the row from the table with the primary key values into dest,
+// which must be a pointer to the row type of the table. The primary key
+// values are in the same order as the primary key fields in the row
+// struct. If the row is not in the session cache, it is selected from the
+// database. If the row does not exist, Get returns sql.ErrNoRows.
+func (s *Session) Get(db sqlx.Queryer, ti *TableInfo, dest interface{}, pk ...interface{}) error {
+	destVal, err := rowValue(ti, dest)
+	if err != nil {
+		return err
+	}
+	key := s.key(ti, pk)
+	s.mutex.Lock()
+	cached, ok := s.rows[key]
+	s.mutex.Unlock()
+	if ok {
+		destVal.Set(cached)
+		return nil
+	}
+
+	cmds, err := commandsForSession(ti)
+	if err != nil {
+		return err
+	}
+	if err := cmds.get.QueryRow(db, pk...).StructScan(dest); err != nil {
+		return err
+	}
+	s.store(ti, destVal)
+	return nil
+}
+
+// Insert inserts the row into the database, and then caches it.
+// Row must be a pointer to the row type of the table.
+func (s *Session) Insert(db sqlx.Execer, ti *TableInfo, row interface{}) error {
+	rowVal, err := rowValue(ti, row)
+	if err != nil {
+		return err
+	}
+	cmds, err := commandsForSession(ti)
+	if err != nil {
+		return err
+	}
+	if err := cmds.insert.Exec(db, row); err != nil {
+		return err
+	}
+	s.store(ti, rowVal)
+	return nil
+}
+
+// Update updates the row in the database, and then caches it. Row must be
+// a pointer to the row type of the table. If the row does not exist in the
+// database, Update returns sql.ErrNoRows, and the row is removed from
+// the cache.
+func (s *Session) Update(db sqlx.Execer, ti *TableInfo, row interface{}) error {
+	rowVal, err := rowValue(ti, row)
+	if err != nil {
+		return err
+	}
+	cmds, err := commandsForSession(ti)
+	if err != nil {
+		return err
+	}
+	n, err := cmds.update.Exec(db, row)
+	if err != nil {
+		s.evict(s.rowKey(ti, rowVal))
+		return err
+	}
+	if n == 0 {
+		s.evict(s.rowKey(ti, rowVal))
+		return sql.ErrNoRows
+	}
+	s.store(ti, rowVal)
+	return nil
+}
+
+// Delete deletes the row from the database, and removes it
+// from the cache. Row must be a pointer to the row type of the table.
+func (s *Session) Delete(db sqlx.Execer, ti *TableInfo, row interface{}) error {
+	rowVal, err := rowValue(ti, row)
+	if err != nil {
+		return err
+	}
+	cmds, err := commandsForSession(ti)
+	if err != nil {
+		return err
+	}
+	s.evict(s.rowKey(ti, rowVal))
+	_, err = cmds.delete.ExecStruct(db, row)
+	return err
+}
+
+// Evict removes the row with the primary key values from the cache,
+// so that it is selected from the database the next time it is loaded.
+func (s *Session) Evict(ti *TableInfo, pk ...interface{}) {
+	s.evict(s.key(ti, pk))
+}
+
+func (s *Session) evict(key sessionKey) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.rows, key)
+}
+
+// Clear removes all rows from the cache.
+func (s *Session) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rows = make(map[sessionKey]reflect.Value)
+}
@@ -0,0 +1,65 @@
+package sqlf_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	session := sqlf.NewSession()
+
+	row := Row1{GivenName: "John", FamilyName: "Citizen"}
+	assert.NoError(session.Insert(db, tbl, &row))
+	assert.Equal(int64(1), row.Id)
+
+	// changes to the row do not change the cached copy
+	row.GivenName = "Changed"
+
+	// the row is loaded from the cache, even though it has
+	// been removed from the database
+	_, err = db.Exec("delete from table1")
+	assert.NoError(err)
+	var loaded Row1
+	assert.NoError(session.Get(db, tbl, &loaded, 1))
+	assert.Equal("John", loaded.GivenName)
+
+	// update writes through to the database
+	_, err = db.Exec("insert into table1(id, given_name, family_name) values(1, 'John', 'Citizen')")
+	assert.NoError(err)
+	loaded.FamilyName = "Smith"
+	assert.NoError(session.Update(db, tbl, &loaded))
+	var familyName string
+	assert.NoError(db.Get(&familyName, "select family_name from table1 where id = 1"))
+	assert.Equal("Smith", familyName)
+
+	// evicted rows are loaded from the database
+	_, err = db.Exec("update table1 set given_name = 'Jack'")
+	assert.NoError(err)
+	assert.NoError(session.Get(db, tbl, &loaded, int64(1)))
+	assert.Equal("John", loaded.GivenName)
+	session.Evict(tbl, 1)
+	assert.NoError(session.Get(db, tbl, &loaded, 1))
+	assert.Equal("Jack", loaded.GivenName)
+
+	assert.NoError(session.Delete(db, tbl, &loaded))
+	assert.Equal(sql.ErrNoRows, session.Get(db, tbl, &loaded, 1))
+	assert.Equal(sql.ErrNoRows, session.Update(db, tbl, &loaded))
+
+	session.Clear()
+	assert.Error(session.Get(db, tbl, loaded, 1))
+	assert.Error(session.Get(db, tbl, &Row2{}, 1))
+}
@@ -0,0 +1,291 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// setting is a session setting applied by WithSetting.
+type setting struct {
+	name  string
+	value string
+}
+
+var (
+	// settingNameRE matches valid setting names, eg "search_path",
+	// "sql_mode" and the "app.tenant" form of PostgreSQL.
+	settingNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+	// pragmaValueRE matches the values that can be given to an SQLite
+	// pragma, which cannot be passed as an argument.
+	pragmaValueRE = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+)
+
+// WithSetting causes a session setting of the database connection, such as
+// statement_timeout for PostgreSQL or sql_mode for MySQL, to be set to the
+// value while the command is executed. The option can be given more than
+// once for different settings. Settings that vary for each request, such as
+// the search_path of a multi-schema application, are stored in the context
+// using ContextWithSetting instead.
+//
+// Settings belong to a connection, and a *sqlx.DB is a pool of connections,
+// so the command takes a connection from the pool, applies the settings,
+// executes the command, and then resets the settings to their defaults
+// before returning the connection to the pool. If the settings cannot be
+// reset, the connection is discarded, so that the settings never leak into
+// other commands. For a transaction in PostgreSQL, the settings are local
+// to the transaction, and are reset when it ends. For other handles, such
+// as a transaction in MySQL, the settings are reset after the command.
+//
+// Settings are applied using set_config for PostgreSQL, "set session" for
+// MySQL, and "pragma" for SQLite, for which the previous value is restored
+// instead of the default. Other dialects are not supported, and commands
+// with settings return an error. For QueryCommand.Query, the settings are
+// reset when the rows are closed (see QueryRows), and until then the
+// connection is not returned to the pool.
+func WithSetting(name, value string) Option {
+	return func(o *options) {
+		o.settings = append(o.settings, setting{name: name, value: value})
+	}
+}
+
+type settingKey struct{}
+
+// ContextWithSetting returns a copy of ctx that contains a session setting,
+// which is applied to the connection that executes each command using
+// ForContext with the context, in the same way as WithSetting. A setting
+// in the context replaces a setting with the same name given using
+// WithSetting. For example, to set the schema for each request:
+//
+//	ctx := sqlf.ContextWithSetting(r.Context(), "search_path", tenant.Schema)
+//	err := listOrders.Select(sqlf.ForContext(ctx, db), &orders)
+func ContextWithSetting(ctx context.Context, name, value string) context.Context {
+	prev, _ := ctx.Value(settingKey{}).([]setting)
+	settings := make([]setting, 0, len(prev)+1)
+	for _, s := range prev {
+		if s.name != name {
+			settings = append(settings, s)
+		}
+	}
+	settings = append(settings, setting{name: name, value: value})
+	return context.WithValue(ctx, settingKey{}, settings)
+}
+
+// sessionSettings returns the session settings for executing
+// the command with the context.
+func (o options) sessionSettings(ctx context.Context) []setting {
+	fromContext, _ := ctx.Value(settingKey{}).([]setting)
+	if len(fromContext) == 0 {
+		return o.settings
+	}
+	var settings []setting
+	for _, s := range o.settings {
+		replaced := false
+		for _, cs := range fromContext {
+			replaced = replaced || cs.name == s.name
+		}
+		if !replaced {
+			settings = append(settings, s)
+		}
+	}
+	return append(settings, fromContext...)
+}
+
+// withSettings applies the session settings to a connection of db, which
+// is used to execute the command. It returns the handle for executing the
+// command, and a function that must be called once the command has
+// completed, which resets the settings.
+func (o options) withSettings(ctx context.Context, db interface{}, settings []setting) (handle interface{}, release func(), err error) {
+	var name string
+	if dn, ok := db.(driverNamer); ok {
+		name = dn.DriverName()
+	}
+	d := dialectForDriver(name)
+	if d == nil {
+		d = commandDialect(o.dialect)
+	}
+	for _, s := range settings {
+		if !settingNameRE.MatchString(s.name) {
+			return nil, nil, fmt.Errorf("WithSetting: invalid setting name %q", s.name)
+		}
+	}
+
+	switch h := unwrapHandle(db).(type) {
+	case *sqlx.DB:
+		conn, err := h.Connx(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		handle := &connHandle{conn: conn, ctx: ctx, driverName: h.DriverName()}
+		reset, err := applySettings(ctx, handle, d, settings, false)
+		if err != nil {
+			// the connection may have some of the settings
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			conn.Close()
+			return nil, nil, err
+		}
+		return handle, func() {
+			if err := reset(); err != nil {
+				// discard the connection, which has the settings
+				conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+			conn.Close()
+		}, nil
+	case *sqlx.Tx:
+		reset, err := applySettings(ctx, h, d, settings, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, func() { reset() }, nil
+	default:
+		reset, err := applySettings(ctx, h, d, settings, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, func() { reset() }, nil
+	}
+}
+
+// applySettings applies the settings using db, in the SQL for dialect
+// d, and returns a function that resets them. If inTx is set, db is a
+// transaction. If a setting cannot be applied, the settings that have
+// been applied are reset.
+func applySettings(ctx context.Context, db interface{}, d Dialect, settings []setting, inTx bool) (reset func() error, err error) {
+	exec := func(ctx context.Context, query string, args ...interface{}) error {
+		var err error
+		switch h := db.(type) {
+		case sqlx.ExecerContext:
+			_, err = h.ExecContext(ctx, query, args...)
+		case sqlx.Execer:
+			_, err = h.Exec(query, args...)
+		default:
+			err = fmt.Errorf("WithSetting: cannot apply settings using %T", db)
+		}
+		return err
+	}
+	queryRow := func(ctx context.Context, query string) *sqlx.Row {
+		if q, ok := db.(sqlx.QueryerContext); ok {
+			return q.QueryRowxContext(ctx, query)
+		}
+		if q, ok := db.(sqlx.Queryer); ok {
+			return q.QueryRowx(query)
+		}
+		return nil
+	}
+
+	// reset with a new context, so that the settings are
+	// reset even if the command's context has expired
+	bg := context.Background()
+	var resets []func() error
+	resetAll := func() error {
+		var firstErr error
+		for i := len(resets) - 1; i >= 0; i-- {
+			if err := resets[i](); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+	defer func() {
+		if err != nil {
+			resetAll()
+		}
+	}()
+
+	for _, s := range settings {
+		s := s
+		switch DialectName(d) {
+		case "postgres":
+			if err := exec(ctx, "select set_config($1, $2, $3)", s.name, s.value, inTx); err != nil {
+				return nil, err
+			}
+			if !inTx {
+				resets = append(resets, func() error {
+					return exec(bg, "reset "+s.name)
+				})
+			}
+		case "mysql":
+			if err := exec(ctx, "set session "+s.name+" = ?", s.value); err != nil {
+				return nil, err
+			}
+			resets = append(resets, func() error {
+				return exec(bg, "set session "+s.name+" = default")
+			})
+		case "sqlite3":
+			if !pragmaValueRE.MatchString(s.value) {
+				return nil, fmt.Errorf("WithSetting: invalid value for pragma %s: %q", s.name, s.value)
+			}
+			var prev sql.NullString
+			row := queryRow(ctx, "pragma "+s.name)
+			if row == nil || row.Scan(&prev) != nil || !pragmaValueRE.MatchString(prev.String) {
+				return nil, fmt.Errorf("WithSetting: cannot read the value of pragma %s", s.name)
+			}
+			if err := exec(ctx, "pragma "+s.name+" = "+s.value); err != nil {
+				return nil, err
+			}
+			resets = append(resets, func() error {
+				return exec(bg, "pragma "+s.name+" = "+prev.String)
+			})
+		default:
+			return nil, fmt.Errorf("WithSetting: not supported for dialect %q", DialectName(d))
+		}
+	}
+	return resetAll, nil
+}
+
+// connHandle is a database handle for a connection taken from the pool
+// to execute a command with session settings, see WithSetting.
+type connHandle struct {
+	conn       *sqlx.Conn
+	ctx        context.Context
+	driverName string
+}
+
+func (h *connHandle) DriverName() string {
+	return h.driverName
+}
+
+func (h *connHandle) Rebind(query string) string {
+	return sqlx.Rebind(sqlx.BindType(h.driverName), query)
+}
+
+func (h *connHandle) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return sqlx.BindNamed(sqlx.BindType(h.driverName), query, arg)
+}
+
+func (h *connHandle) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return h.conn.ExecContext(h.ctx, query, args...)
+}
+
+func (h *connHandle) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return h.conn.ExecContext(ctx, query, args...)
+}
+
+func (h *connHandle) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return h.conn.QueryContext(h.ctx, query, args...)
+}
+
+func (h *connHandle) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return h.conn.QueryContext(ctx, query, args...)
+}
+
+func (h *connHandle) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return h.conn.QueryxContext(h.ctx, query, args...)
+}
+
+func (h *connHandle) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return h.conn.QueryxContext(ctx, query, args...)
+}
+
+func (h *connHandle) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	return h.conn.QueryRowxContext(h.ctx, query, args...)
+}
+
+func (h *connHandle) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return h.conn.QueryRowxContext(ctx, query, args...)
+}
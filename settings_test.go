@@ -0,0 +1,98 @@
+package sqlf_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSetting(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	cacheSize := func() int {
+		var n int
+		assert.NoError(db.Get(&n, "pragma cache_size"))
+		return n
+	}
+	before := cacheSize()
+
+	// the setting applies to the command, and is then restored
+	getCacheSize := sqlf.Queryf("pragma cache_size", sqlf.WithSetting("cache_size", "1234"))
+	var n int
+	assert.NoError(getCacheSize.Get(db, &n))
+	assert.Equal(1234, n)
+	assert.Equal(before, cacheSize())
+	assert.NoError(getCacheSize.QueryRow(db).Scan(&n))
+	assert.Equal(1234, n)
+	assert.Equal(before, cacheSize())
+
+	// a setting in the context replaces the setting of the command
+	ctx := sqlf.ContextWithSetting(context.Background(), "cache_size", "4321")
+	assert.NoError(getCacheSize.Get(sqlf.ForContext(ctx, db), &n))
+	assert.Equal(4321, n)
+	assert.Equal(before, cacheSize())
+	assert.NoError(sqlf.Queryf("pragma cache_size").Get(sqlf.ForContext(ctx, db), &n))
+	assert.Equal(4321, n)
+
+	// the settings of Query are reset when the rows are closed
+	rows, err := getCacheSize.Query(db)
+	if assert.NoError(err) {
+		assert.True(rows.Next())
+		assert.NoError(rows.Scan(&n))
+		assert.Equal(1234, n)
+		assert.NoError(rows.Close())
+	}
+	assert.Equal(before, cacheSize())
+	tx, err := db.Beginx()
+	assert.NoError(err)
+	rows, err = getCacheSize.Query(tx)
+	if assert.NoError(err) {
+		assert.NoError(rows.Close())
+	}
+	assert.NoError(tx.Get(&n, "pragma cache_size"))
+	assert.Equal(before, n)
+	assert.NoError(tx.Rollback())
+
+	_, err = sqlf.Execf("select 1", sqlf.WithSetting("cache_size", "1; drop table x")).Exec(db)
+	assert.Error(err)
+	_, err = sqlf.Execf("select 1", sqlf.WithSetting("cache size", "1")).Exec(db)
+	assert.Error(err)
+}
+
+func TestWithSettingDialects(t *testing.T) {
+	assert := assert.New(t)
+	update := sqlf.Execf("update accounts set balance = 0", sqlf.WithSetting("statement_timeout", "5s"))
+
+	rec := sqlftest.NewWithDriverName("postgres")
+	_, err := update.Exec(rec)
+	assert.NoError(err)
+	assert.Equal([]sqlftest.Command{
+		{Query: "select set_config($1, $2, $3)", Args: []interface{}{"statement_timeout", "5s", false}},
+		{Query: "update accounts set balance = 0"},
+		{Query: "reset statement_timeout"},
+	}, rec.Commands())
+
+	rec = sqlftest.NewWithDriverName("mysql")
+	ctx := sqlf.ContextWithSetting(context.Background(), "time_zone", "+00:00")
+	_, err = sqlf.Execf("update accounts set balance = 0").Exec(sqlf.ForContext(ctx, rec))
+	assert.NoError(err)
+	assert.Equal([]sqlftest.Command{
+		{Query: "set session time_zone = ?", Args: []interface{}{"+00:00"}},
+		{Query: "update accounts set balance = 0"},
+		{Query: "set session time_zone = default"},
+	}, rec.Commands())
+
+	rec = sqlftest.NewWithDriverName("mssql")
+	_, err = update.Exec(rec)
+	assert.Error(err)
+	assert.Len(rec.Commands(), 0)
+}
@@ -0,0 +1,145 @@
+package sqlf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Sharder routes the rows of tables that are partitioned horizontally
+// across several databases (shards). Each row belongs to the shard chosen
+// by the value of its shard key (see TableInfo.ShardKey).
+type Sharder interface {
+	// ShardFor returns the shard that holds the rows with the shard key.
+	ShardFor(key interface{}) *sqlx.DB
+
+	// Shards returns all of the shards, in a consistent order.
+	Shards() []*sqlx.DB
+}
+
+// ShardKey returns the value of the shard key of the row, which must be
+// of the table's row type, or a pointer to it. The shard key is the column
+// whose field is tagged "shard_key", or if there is none, the primary key:
+//
+//	type Order struct {
+//		ID         int64
+//		CustomerID int64 `sql:"shard_key"`
+//	}
+//
+// Note that the value of an auto-increment primary key is not known before
+// the row is inserted, so it is not suitable as a shard key.
+func (ti *TableInfo) ShardKey(row interface{}) (interface{}, error) {
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.New("ShardKey: cannot obtain shard key from a nil pointer")
+		}
+		v = v.Elem()
+	}
+	if v.Type() != ti.rowType {
+		return nil, fmt.Errorf("ShardKey: expected type %s.%s or pointer", ti.rowType.PkgPath(), ti.rowType.Name())
+	}
+	var key *columnInfo
+	for _, ci := range ti.columns {
+		if ci.shardKey {
+			key = ci
+			break
+		}
+		if ci.primaryKey {
+			if key != nil {
+				return nil, fmt.Errorf("ShardKey: table %s has more than one primary key column", ti.Name)
+			}
+			key = ci
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("ShardKey: table %s has no shard key", ti.Name)
+	}
+	return reflectx.FieldByIndexesReadOnly(v, key.fields).Interface(), nil
+}
+
+// ShardedExec executes a row command built using InsertRowf or UpdateRowf
+// on the shard that holds the row, as determined by the row's shard key.
+// It returns the number of rows affected.
+func ShardedExec(s Sharder, cmd interface{}, row interface{}) (rowCount int, err error) {
+	var table *TableInfo
+	switch cmd := cmd.(type) {
+	case insertRowCommand:
+		table = cmd.table
+	case updateRowCommand:
+		table = cmd.table
+	default:
+		return 0, fmt.Errorf("ShardedExec: expected InsertRowCommand or UpdateRowCommand, got %T", cmd)
+	}
+	if table == nil {
+		return 0, errors.New("ShardedExec: command does not refer to a table")
+	}
+	key, err := table.ShardKey(row)
+	if err != nil {
+		return 0, err
+	}
+	db := s.ShardFor(key)
+	if db == nil {
+		return 0, fmt.Errorf("ShardedExec: no shard for key %v", key)
+	}
+	switch cmd := cmd.(type) {
+	case insertRowCommand:
+		result, err := cmd.ExecResult(db, row)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		return int(n), err
+	case updateRowCommand:
+		return cmd.Exec(db, row)
+	}
+	panic("not reached")
+}
+
+// ShardedSelect executes the query on all of the shards concurrently,
+// and appends the rows returned to the slice pointed to by dest. The rows
+// from each shard are appended in the order of the shards, so any "order by"
+// clause in the query applies within each shard only. If the query fails on
+// any shard, the first error is returned and dest is unchanged.
+//
+// To query a single shard, pass the result of ShardFor to the command:
+//
+//	err := getOrder.Get(sharder.ShardFor(customerID), &order, customerID, orderID)
+func ShardedSelect(s Sharder, cmd QueryCommand, dest interface{}, args ...interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("must pass a non-nil pointer to ShardedSelect")
+	}
+	direct := value.Elem()
+	if direct.Kind() != reflect.Slice {
+		return fmt.Errorf("expected slice but got %s", direct.Type())
+	}
+
+	shards := s.Shards()
+	results := make([]reflect.Value, len(shards))
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i, db := range shards {
+		wg.Add(1)
+		go func(i int, db *sqlx.DB) {
+			defer wg.Done()
+			results[i] = reflect.New(direct.Type())
+			errs[i] = cmd.Select(db, results[i].Interface(), args...)
+		}(i, db)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	for _, result := range results {
+		direct.Set(reflect.AppendSlice(direct, result.Elem()))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package sqlf_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// modSharder assigns rows to shards using the shard key modulo
+// the number of shards
+type modSharder []*sqlx.DB
+
+func (s modSharder) ShardFor(key interface{}) *sqlx.DB {
+	return s[key.(int64)%int64(len(s))]
+}
+
+func (s modSharder) Shards() []*sqlx.DB {
+	return s
+}
+
+func TestSharded(t *testing.T) {
+	assert := assert.New(t)
+
+	type Order struct {
+		ID         int64
+		CustomerID int64 `sql:"shard_key"`
+		Item       string
+	}
+
+	var sharder modSharder
+	for i := 0; i < 3; i++ {
+		db, err := sqlx.Open("sqlite3", fmt.Sprintf("file:shard%d?mode=memory&cache=shared", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if _, err := db.Exec("create table orders(id integer primary key, customer_id integer, item text)"); err != nil {
+			t.Fatal(err)
+		}
+		sharder = append(sharder, db)
+	}
+
+	tbl := sqlf.Table("orders", Order{}).WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	for i := int64(1); i <= 6; i++ {
+		n, err := sqlf.ShardedExec(sharder, ins, &Order{ID: i, CustomerID: i + 10, Item: "widget"})
+		assert.NoError(err)
+		assert.Equal(1, n)
+	}
+
+	// each row is stored in the shard for its customer
+	for i, db := range sharder {
+		var customers []int64
+		assert.NoError(db.Select(&customers, "select customer_id from orders order by id"))
+		assert.Len(customers, 2)
+		for _, c := range customers {
+			assert.Equal(int64(i), c%3)
+		}
+	}
+
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	n, err := sqlf.ShardedExec(sharder, upd, &Order{ID: 2, CustomerID: 12, Item: "gadget"})
+	assert.NoError(err)
+	assert.Equal(1, n)
+
+	sel := sqlf.Queryf("select %s from %s where item = ? order by id", tbl.Select.Columns, tbl.Select.TableName)
+	var orders []Order
+	assert.NoError(sqlf.ShardedSelect(sharder, sel, &orders, "widget"))
+	assert.Len(orders, 5)
+	for _, o := range orders {
+		assert.NotEqual(int64(2), o.ID)
+	}
+
+	assert.NoError(sqlf.ShardedSelect(sharder, sel, &orders, "gadget"))
+	if assert.Len(orders, 6) {
+		assert.Equal(int64(2), orders[5].ID)
+	}
+
+	key, err := tbl.ShardKey(Order{ID: 1, CustomerID: 42})
+	assert.NoError(err)
+	assert.Equal(int64(42), key)
+	key, err = Row1Table.ShardKey(&Row1{Id: 7})
+	assert.NoError(err)
+	assert.Equal(int64(7), key)
+	_, err = tbl.ShardKey(&Row1{})
+	assert.Error(err)
+
+	bad := sqlf.Queryf("select no_such_column from %s", tbl.Select.TableName)
+	assert.Error(sqlf.ShardedSelect(sharder, bad, &orders))
+	_, err = sqlf.ShardedExec(sharder, sel, &Order{})
+	assert.Error(err)
+}
@@ -0,0 +1,82 @@
+package sqlf
+
+import "fmt"
+
+// SlotArg is an argument of a query that can be replaced
+// using QueryCommand.With. It is created using Slot.
+type SlotArg struct {
+	name  string
+	value interface{}
+}
+
+// Slot returns an argument for Queryf that is formatted in the same way as
+// value, and which can be replaced by another value using QueryCommand.With.
+// A base query can then be specialized for each request, with a different
+// condition, column list or sort order, without repeating its format string
+// and arguments:
+//
+//	listUsers := sqlf.Queryf("select %s from %s where %s order by %s",
+//		sqlf.Slot("columns", users.Select.Columns),
+//		users.Select.TableName,
+//		sqlf.Slot("where", sqlf.NewFragment("1=1")),
+//		sqlf.Slot("order", users.Select.OrderBy))
+//
+//	cmd := listUsers.With(map[string]interface{}{
+//		"columns": users.Select.Columns.Exclude("Password"),
+//		"where":   sqlf.NewFragment("tenant_id = ?", tenantID),
+//	})
+//
+// The value can be any argument of Queryf, including a column list,
+// a fragment, a filter list, a sort list and a subquery.
+func Slot(name string, value interface{}) SlotArg {
+	return SlotArg{name: name, value: value}
+}
+
+// fillSlots returns args with each slot replaced by its value in values,
+// or by its own value if it is not in values.
+func fillSlots(args []interface{}, values map[string]interface{}) []interface{} {
+	var filled []interface{}
+	for i, arg := range args {
+		slot, ok := arg.(SlotArg)
+		if !ok {
+			continue
+		}
+		if filled == nil {
+			filled = append([]interface{}(nil), args...)
+		}
+		if value, ok := values[slot.name]; ok {
+			filled[i] = value
+		} else {
+			filled[i] = slot.value
+		}
+	}
+	if filled == nil {
+		return args
+	}
+	return filled
+}
+
+// With returns a copy of the query built with the values of the slots that
+// are keys of args replaced by the corresponding values, see Slot. The values
+// of any slots replaced previously using With are kept unless replaced.
+// With panics if a key is not the name of a slot of the query.
+func (cmd *queryCommand) With(args map[string]interface{}) QueryCommand {
+	values := make(map[string]interface{}, len(cmd.slotValues)+len(args))
+	for name, value := range cmd.slotValues {
+		values[name] = value
+	}
+	for name, value := range args {
+		found := false
+		for _, arg := range cmd.template {
+			if slot, ok := arg.(SlotArg); ok && slot.name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic(fmt.Sprintf("sqlf.With: query has no slot named %q", name))
+		}
+		values[name] = value
+	}
+	return newQuery(cmd.format, cmd.template, values, cmd.opts)
+}
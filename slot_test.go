@@ -0,0 +1,62 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlot(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table staff(id integer primary key, name text, team text, password text)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`insert into staff(name, team, password) values('ann', 'red', 'x'), ('bob', 'blue', 'y'), ('cat', 'red', 'z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Staff struct {
+		ID       int64 `sql:"primary_key"`
+		Name     string
+		Team     string
+		Password string
+	}
+	staff := sqlf.Table("staff", Staff{}).WithDialect(sqlf.DialectSQLite)
+	list := sqlf.Queryf("select %s from %s where team = ? and %s order by %s",
+		sqlf.Slot("columns", staff.Select.Columns),
+		staff.Select.TableName,
+		sqlf.Slot("where", sqlf.NewFragment("1=1")),
+		sqlf.Slot("order", staff.Select.OrderBy))
+	assert.Equal("select `id`,`name`,`team`,`password` from `staff` where team = ? and 1=1 order by `id`", list.Command())
+
+	var rows []Staff
+	assert.NoError(list.Select(db, &rows, "red"))
+	assert.Equal([]Staff{{1, "ann", "red", "x"}, {3, "cat", "red", "z"}}, rows)
+
+	// the placeholders of the replacement follow the command's own
+	byName := list.With(map[string]interface{}{
+		"columns": staff.Select.Columns.Exclude("Password"),
+		"where":   sqlf.NewFragment("name <> ?", "ann"),
+	})
+	assert.Equal("select `id`,`name`,`team` from `staff` where team = ? and name <> ? order by `id`", byName.Command())
+	rows = nil
+	assert.NoError(byName.Select(db, &rows, "red", "ann"))
+	assert.Equal([]Staff{{ID: 3, Name: "cat", Team: "red"}}, rows)
+
+	// slots replaced previously are kept
+	sortList, err := sqlf.OrderBy(staff.Select.Columns, "-name")
+	assert.NoError(err)
+	desc := byName.With(map[string]interface{}{"order": sortList})
+	assert.Equal("select `id`,`name`,`team` from `staff` where team = ? and name <> ? order by `name` desc", desc.Command())
+
+	// the base query is not changed
+	assert.Equal("select `id`,`name`,`team`,`password` from `staff` where team = ? and 1=1 order by `id`", list.Command())
+	assert.Panics(func() { list.With(map[string]interface{}{"limit": 10}) })
+}
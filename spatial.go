@@ -0,0 +1,179 @@
+package sqlf
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// WKT is a spatial value in well-known text, eg "POINT(30 10)".
+//
+// A field of type WKT, WKB or GeoJSON, or a pointer to one of them, is
+// stored in a spatial column using the "geometry" or "geography" field
+// tag, which can specify the SRID of the column:
+//
+//	type Store struct {
+//		ID       int64
+//		Location sqlf.WKT      `sql:"geometry:4326"`
+//		Area     *sqlf.GeoJSON `sql:"geography"`
+//	}
+//
+// The value of a spatial column is converted from the type of the field by
+// the database: the placeholder for the column in the VALUES clause of an
+// insert and the SET clause of an update is ST_GeomFromText(?, srid) for WKT,
+// ST_GeomFromWKB for WKB and ST_GeomFromGeoJSON for GeoJSON. In the same
+// way, Select.Columns selects ST_AsText, ST_AsBinary or ST_AsGeoJSON of the
+// column, with the column name as its alias, so that it scans into the field.
+// A field with a pointer type is NULL when it is nil.
+//
+// For PostgreSQL, the functions are those of PostGIS, and the value of a
+// geography column is cast to geography, with an SRID of 4326 unless another
+// is specified. MySQL (8.0 or later) does not have a separate geography type,
+// so a geography column is a geometry column with an SRID of 4326 unless
+// another is specified. Other dialects use the same function names, which
+// are supported by SpatiaLite for SQLite, and not by SQL Server.
+type WKT string
+
+// WKB is a spatial value in well-known binary. See WKT.
+type WKB []byte
+
+// GeoJSON is a spatial value encoded as a GeoJSON geometry, eg
+// {"type":"Point","coordinates":[30,10]}. It is passed to the database
+// as text, and is encoded as JSON without change, so that it can be
+// returned in the body of a response. See WKT.
+type GeoJSON []byte
+
+// Value implements the driver.Valuer interface.
+func (g GeoJSON) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	return string(g), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (g GeoJSON) MarshalJSON() ([]byte, error) {
+	if len(g) == 0 {
+		return []byte("null"), nil
+	}
+	return g, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (g *GeoJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*g = nil
+		return nil
+	}
+	*g = append((*g)[:0], data...)
+	return nil
+}
+
+var (
+	wktType     = reflect.TypeOf(WKT(""))
+	wkbType     = reflect.TypeOf(WKB(nil))
+	geoJSONType = reflect.TypeOf(GeoJSON(nil))
+)
+
+// spatialSettings are the settings of a column with the "geometry"
+// or "geography" tag.
+type spatialSettings struct {
+	geography bool
+	srid      int          // 0 if not specified
+	format    reflect.Type // WKT, WKB or GeoJSON
+}
+
+// parseSpatial returns the spatial settings for a field with the "geometry"
+// or "geography" tag, or nil if it has neither. It panics if the field's
+// type is not a spatial type, or the SRID is invalid.
+func parseSpatial(field reflect.StructField, tagSettings map[string]string) *spatialSettings {
+	tag := "geometry"
+	value, ok := tagSettings["GEOMETRY"]
+	if !ok {
+		tag = "geography"
+		if value, ok = tagSettings["GEOGRAPHY"]; !ok {
+			return nil
+		}
+	}
+	ss := &spatialSettings{geography: tag == "geography", format: field.Type}
+	if ss.format.Kind() == reflect.Ptr {
+		ss.format = ss.format.Elem()
+	}
+	if ss.format != wktType && ss.format != wkbType && ss.format != geoJSONType {
+		panic(fmt.Sprintf("sqlf.Table: field %s: %s tag requires type sqlf.WKT, sqlf.WKB or sqlf.GeoJSON", field.Name, tag))
+	}
+	if value = strings.TrimSpace(value); value != "" && value != strings.ToUpper(tag) {
+		srid, err := strconv.Atoi(value)
+		if err != nil || srid < 0 {
+			panic(fmt.Sprintf("sqlf.Table: field %s: invalid SRID %q", field.Name, value))
+		}
+		ss.srid = srid
+	}
+	if ss.geography && ss.srid == 0 {
+		ss.srid = 4326
+	}
+	return ss
+}
+
+// dbType returns the database type of the column for DDL.
+func (ss *spatialSettings) dbType() string {
+	if ss.geography {
+		return "geography"
+	}
+	return "geometry"
+}
+
+// selectExpr returns the expression that selects the spatial column
+// in the format of its field.
+func (ss *spatialSettings) selectExpr(column string) string {
+	switch ss.format {
+	case wkbType:
+		return "ST_AsBinary(" + column + ")"
+	case geoJSONType:
+		return "ST_AsGeoJSON(" + column + ")"
+	}
+	return "ST_AsText(" + column + ")"
+}
+
+// inputExpr returns the expression that converts the value of the
+// placeholder, in the format of the field, to the spatial value.
+func (ss *spatialSettings) inputExpr(d Dialect, placeholder string) string {
+	postgres := DialectName(d) == "postgres"
+	var expr string
+	switch {
+	case ss.format == geoJSONType && ss.srid == 0:
+		expr = "ST_GeomFromGeoJSON(" + placeholder + ")"
+	case ss.format == geoJSONType && postgres:
+		expr = "ST_SetSRID(ST_GeomFromGeoJSON(" + placeholder + ")," + strconv.Itoa(ss.srid) + ")"
+	case ss.format == geoJSONType:
+		// the options argument of 1 rejects geometries with more
+		// than two dimensions, which is the MySQL default
+		expr = "ST_GeomFromGeoJSON(" + placeholder + ",1," + strconv.Itoa(ss.srid) + ")"
+	default:
+		fn := "ST_GeomFromText("
+		if ss.format == wkbType {
+			fn = "ST_GeomFromWKB("
+		}
+		expr = fn + placeholder
+		if ss.srid != 0 {
+			expr += "," + strconv.Itoa(ss.srid)
+		}
+		expr += ")"
+	}
+	if ss.geography && postgres {
+		expr += "::geography"
+	}
+	return expr
+}
+
+// inputExpr returns the placeholder for the value of the column,
+// which converts the value if it is a spatial column.
+func (ci *columnInfo) inputExpr() string {
+	d := ci.table.Dialect()
+	if ci.spatial != nil {
+		return ci.spatial.inputExpr(d, d.Placeholder(ci.inputPosition))
+	}
+	return d.Placeholder(ci.inputPosition)
+}
@@ -0,0 +1,85 @@
+package sqlf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/stretchr/testify/assert"
+)
+
+type Store struct {
+	ID       int64 `sql:"primary_key;auto_increment"`
+	Name     string
+	Location sqlf.WKT      `sql:"geometry:4326"`
+	Outline  sqlf.WKB      `sql:"geometry"`
+	Area     *sqlf.GeoJSON `sql:"geography"`
+}
+
+func TestSpatial(t *testing.T) {
+	assert := assert.New(t)
+	stores := sqlf.Table("stores", Store{})
+
+	pg := stores.WithDialect(sqlf.DialectPG)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		pg.Insert.TableName, pg.Insert.Columns, pg.Insert.Values)
+	assert.Equal(`insert into "stores"("name","location","outline","area") values($1,ST_GeomFromText($2,4326),ST_GeomFromWKB($3),ST_SetSRID(ST_GeomFromGeoJSON($4),4326)::geography)`,
+		insert.Command())
+	update := sqlf.UpdateRowf("update %s set %s where %s",
+		pg.Update.TableName, pg.Update.SetColumns.Include("Location"), pg.Update.WhereColumns)
+	assert.Equal(`update "stores" set "location"=ST_GeomFromText($1,4326) where "id"=$2`, update.Command())
+	get := sqlf.Queryf("select %s from %s where id = ?", pg.Select.Columns, pg.Select.TableName)
+	assert.Equal(`select "id","name",ST_AsText("location") as "location",ST_AsBinary("outline") as "outline",ST_AsGeoJSON("area") as "area" from "stores" where id = ?`,
+		get.Command())
+	s := pg.WithAlias("s")
+	assert.Equal(`s."id" as s_id,s."name" as s_name,ST_AsText(s."location") as s_location,ST_AsBinary(s."outline") as s_outline,ST_AsGeoJSON(s."area") as s_area`,
+		s.Select.Columns.String())
+
+	my := stores.WithDialect(sqlf.DialectMySQL)
+	insert = sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		my.Insert.TableName, my.Insert.Columns, my.Insert.Values)
+	assert.Equal("insert into `stores`(`name`,`location`,`outline`,`area`) values(?,ST_GeomFromText(?,4326),ST_GeomFromWKB(?),ST_GeomFromGeoJSON(?,1,4326))",
+		insert.Command())
+
+	// values are passed in the format of the field
+	area := sqlf.GeoJSON(`{"type":"Point","coordinates":[30,10]}`)
+	store := Store{Name: "central", Location: "POINT(30 10)", Outline: sqlf.WKB{1, 2}, Area: &area}
+	args, err := insert.Args(&store)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"central", sqlf.WKT("POINT(30 10)"), sqlf.WKB{1, 2}, &area}, args)
+	value, err := area.Value()
+	assert.NoError(err)
+	assert.Equal(`{"type":"Point","coordinates":[30,10]}`, value)
+
+	// and are scanned from the database in the same format
+	rec := sqlftest.NewWithDriverName("postgres")
+	rec.ExpectCommand(get).WillReturnRows([]string{"id", "name", "location", "outline", "area"},
+		[]interface{}{int64(1), "central", "POINT(30 10)", []byte{1, 2}, []byte(area)})
+	var got Store
+	assert.NoError(get.Get(rec, &got, 1))
+	assert.Equal(store.Location, got.Location)
+	assert.Equal(store.Outline, got.Outline)
+	if assert.NotNil(got.Area) {
+		assert.Equal(area, *got.Area)
+	}
+
+	// GeoJSON is encoded as JSON without change
+	data, err := json.Marshal(store)
+	assert.NoError(err)
+	assert.Contains(string(data), `"Area":{"type":"Point","coordinates":[30,10]}`)
+	var decoded Store
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.Equal(store.Area, decoded.Area)
+
+	assert.Panics(func() {
+		sqlf.Table("stores", struct {
+			Location string `sql:"geometry"`
+		}{})
+	})
+	assert.Panics(func() {
+		sqlf.Table("stores", struct {
+			Location sqlf.WKT `sql:"geography:x"`
+		}{})
+	})
+}
@@ -3,6 +3,10 @@
 // writing SQL, but would like assistance with the tedious
 // process of preparing SELECT, INSERT and UPDATE statements
 // for tables that have a large number of columns.
+//
+// Commands are intended to be built once, typically when initializing
+// package-level variables, and then used many times. Once built, a command
+// is immutable and is safe for concurrent use by multiple goroutines.
 package sqlf
 
 import (
@@ -10,6 +14,7 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -25,7 +30,7 @@ var Default Settings
 // by row. The dialect and column name mapping functions
 // are defined in the default settings.
 //
-//This function wil panic if row is not a struct
+// This function wil panic if row is not a struct
 // or a pointer to a struct. The contents of row
 // are ignored, only the structure fields and field tags
 // are used.
@@ -46,17 +51,43 @@ type TableInfo struct {
 	columns  []*columnInfo
 	settings Settings
 	alias    string
+
+	// withoutTenant is set on the clones of tables used to build
+	// a command with the WithoutTenant option
+	withoutTenant bool
+
+	// masked is set on the clones of tables used to build
+	// a command with the WithMasking option
+	masked bool
+
+	// groups contains the field names of each column group,
+	// see WithColumnGroup
+	groups map[string][]string
+
+	// variant is the discriminator value of a variant table, and
+	// variantOf is its base table, see Variant
+	variant   string
+	variantOf *TableInfo
+
+	// partitions is the partition scheme, see WithPartitions
+	partitions *PartitionScheme
 }
 
 // clone makes a complete, deep copy of the table.
 // This is important for taking a copy that can be modified.
 func (ti *TableInfo) clone() *TableInfo {
 	ti2 := &TableInfo{
-		Name:     ti.Name,
-		rowType:  ti.rowType,
-		columns:  make([]*columnInfo, len(ti.columns)),
-		settings: ti.settings,
-		alias:    ti.alias,
+		Name:          ti.Name,
+		rowType:       ti.rowType,
+		columns:       make([]*columnInfo, len(ti.columns)),
+		settings:      ti.settings,
+		alias:         ti.alias,
+		withoutTenant: ti.withoutTenant,
+		masked:        ti.masked,
+		groups:        ti.groups,
+		variant:       ti.variant,
+		variantOf:     ti.variantOf,
+		partitions:    ti.partitions,
 	}
 	// create a clone of all of the columns before cloning
 	// anything else.
@@ -107,7 +138,7 @@ func (s Settings) Merge(settings Settings) Settings {
 // and schema as defined by the struct that is pointed to
 // by row.
 //
-//This function wil panic if row is not a struct
+// This function wil panic if row is not a struct
 // or a pointer to a struct. The contents of row
 // are ignored, only the structure fields and field tags
 // are used.
@@ -124,8 +155,9 @@ func (settings Settings) Table(name string, row interface{}) *TableInfo {
 	}
 
 	ti.addColumns(ti.rowType, nil, nil)
+	ti.checkTenantColumns()
 	ti.Select.TableName = TableName{clause: clauseSelectFrom, table: ti}
-	ti.Select.Columns = ColumnList{clause: clauseSelectColumns, table: ti}.All()
+	ti.Select.Columns = ColumnList{clause: clauseSelectColumns, table: ti}.Selectable()
 	ti.Select.OrderBy = ColumnList{clause: clauseSelectOrderBy, table: ti}.PrimaryKey()
 	ti.Insert.TableName = TableName{clause: clauseInsertInto, table: ti}
 	ti.Insert.Columns = ColumnList{clause: clauseInsertColumns, table: ti}.Insertable()
@@ -136,6 +168,7 @@ func (settings Settings) Table(name string, row interface{}) *TableInfo {
 	ti.Delete.TableName = TableName{clause: clauseDeleteTable, table: ti}
 	ti.Delete.WhereColumns = ColumnList{clause: clauseDeleteWhere, table: ti}.PrimaryKey()
 
+	registerTable(ti)
 	return ti
 }
 
@@ -184,9 +217,13 @@ func (ti *TableInfo) addColumns(rowType reflect.Type, fields []int, prefixes []s
 			// * it is time.Time (special case)
 			// * it implements sql.Scan (unlikely)
 			// * its pointer type implements sql.Scan (more likely)
+			// * it has a registered converter (see RegisterConverter)
+			// * it has a decimal tag (see Decimal)
+			_, isDecimal := tagSettings["DECIMAL"]
 			if fieldType != timeType &&
 				!fieldType.Implements(sqlScanType) &&
-				!reflect.PtrTo(fieldType).Implements(sqlScanType) {
+				!reflect.PtrTo(fieldType).Implements(sqlScanType) &&
+				lookupConverter(fieldType) == nil && !isDecimal {
 				var prefix string
 				if value, ok := tagSettings["PREFIX"]; ok {
 					prefix = value
@@ -229,6 +266,41 @@ func (ti *TableInfo) addColumns(rowType reflect.Type, fields []int, prefixes []s
 		if _, ok := tagSettings["AUTO_INCREMENT"]; ok {
 			ci.autoIncrement = true
 		}
+		if _, ok := tagSettings["SHARD_KEY"]; ok {
+			ci.shardKey = true
+		}
+		if _, ok := tagSettings["TENANT"]; ok {
+			ci.tenant = true
+		}
+		if _, ok := tagSettings["READONLY"]; ok {
+			ci.readOnly = true
+		}
+		if _, ok := tagSettings["WRITEONLY"]; ok {
+			ci.writeOnly = true
+		}
+		if _, ok := tagSettings["SENSITIVE"]; ok {
+			ci.sensitive = true
+		}
+		if _, ok := tagSettings["IDEMPOTENCY"]; ok {
+			ci.idempotency = true
+		}
+		if _, ok := tagSettings["DISCRIMINATOR"]; ok {
+			ci.discriminator = true
+		}
+		ci.indexes = parseIndexes(ci.columnName, tagSettings)
+		ci.constraints = parseConstraints(field, tagSettings)
+		ci.codec, ci.array = fieldScanning(field, tagSettings, ci.constraints)
+		ci.times = parseTimeSettings(field, tagSettings)
+		ci.spatial = parseSpatial(field, tagSettings)
+		ci.audit = parseAudit(field, tagSettings)
+		if value, ok := tagSettings["DBTYPE"]; ok {
+			ci.dbType = strings.TrimSpace(value)
+		} else if ci.spatial != nil {
+			ci.dbType = ci.spatial.dbType()
+		}
+		if value, ok := tagSettings["COMMENT"]; ok {
+			ci.comment = strings.TrimSpace(value)
+		}
 		ti.columns = append(ti.columns, ci)
 	}
 }
@@ -252,11 +324,129 @@ func (ti *TableInfo) WithAlias(alias string) *TableInfo {
 	return ti2
 }
 
+// WithName creates a clone of the table with a different name, and the
+// same row struct and column information. It is useful for an updatable
+// view of the table, or a partition whose name has a date suffix.
+// See also WithTableName, which renames a table for a single command.
+func (ti *TableInfo) WithName(name string) *TableInfo {
+	ti2 := ti.clone()
+	ti2.Name = name
+	return ti2
+}
+
+// WithSortedColumns creates a clone of the table whose columns are listed
+// in order of column name, instead of the order that their fields are
+// declared in the row struct. The order applies to every column list of
+// the table, so the columns and values of an insert statement correspond.
+// It is useful when the row struct is generated, or embeds other structs,
+// and the order of its fields is not meaningful.
+func (ti *TableInfo) WithSortedColumns() *TableInfo {
+	ti2 := ti.clone()
+	sort.SliceStable(ti2.columns, func(i, j int) bool {
+		return ti2.columns[i].columnName < ti2.columns[j].columnName
+	})
+	return ti2
+}
+
+// WithColumnGroup creates a clone of the table with a named group of
+// columns, which can be selected using ColumnList.Group. Column groups are
+// useful for wide tables, so that routine queries do not select large text
+// or blob columns:
+//
+//	var users = sqlf.Table("users", User{}).
+//		WithColumnGroup("summary", "ID", "Name", "Status")
+//
+//	listUsers := sqlf.Queryf("select %s from %s order by %s",
+//		users.Select.Columns.Group("summary"),
+//		users.Select.TableName,
+//		users.Select.OrderBy)
+//
+// When specifying columns, use the name of field in the Go struct, not the
+// column name in the database table. WithColumnGroup panics if a field does
+// not have a column, in the same way that Table panics for an invalid row
+// type.
+func (ti *TableInfo) WithColumnGroup(name string, fields ...string) *TableInfo {
+	for _, field := range fields {
+		if ti.columnForField(field) == nil {
+			panic(fmt.Sprintf("sqlf.WithColumnGroup: table %s has no column for field %s", ti.Name, field))
+		}
+	}
+	ti2 := ti.clone()
+	ti2.groups = make(map[string][]string, len(ti.groups)+1)
+	for k, v := range ti.groups {
+		ti2.groups[k] = v
+	}
+	ti2.groups[name] = append([]string(nil), fields...)
+	return ti2
+}
+
+// columnForField returns the column for the field, or nil if there is none.
+func (ti *TableInfo) columnForField(fieldName string) *columnInfo {
+	for _, ci := range ti.columns {
+		if ci.fieldName == fieldName {
+			return ci
+		}
+	}
+	return nil
+}
+
 // Dialect returns the SQL dialect to use with this table.
 func (ti *TableInfo) Dialect() Dialect {
 	return ti.settings.dialect()
 }
 
+// RowType returns the type of the row struct for the table.
+func (ti *TableInfo) RowType() reflect.Type {
+	return ti.rowType
+}
+
+// ColumnField returns the name of the field in the row struct that holds
+// the named column, and its index sequence for use with
+// reflect.Value.FieldByIndex. It returns false if the table does not
+// have a column with that name.
+func (ti *TableInfo) ColumnField(columnName string) (fieldName string, index []int, ok bool) {
+	for _, ci := range ti.columns {
+		if ci.columnName == columnName {
+			return ci.fieldName, append([]int(nil), ci.fields...), true
+		}
+	}
+	return "", nil, false
+}
+
+// Column describes a column of a table, as defined by the
+// field of the row struct and its field tags.
+type Column struct {
+	Name          string       // Column name
+	FieldName     string       // Name of the field in the row struct
+	FieldType     reflect.Type // Type of the field in the row struct
+	DBType        string       // Database type from the "dbtype" tag, if any
+	PrimaryKey    bool         // Part of the primary key
+	AutoIncrement bool         // Value assigned by the database on insert
+	NotNull       bool         // Tagged "not null"
+	MaxLen        int          // Maximum length from the "maxlen" tag, if any
+	Comment       string       // Description from the "comment" tag, if any
+}
+
+// Columns returns a description of each column in the table, in
+// the order of the fields in the row struct.
+func (ti *TableInfo) Columns() []Column {
+	columns := make([]Column, 0, len(ti.columns))
+	for _, ci := range ti.columns {
+		columns = append(columns, Column{
+			Name:          ci.columnName,
+			FieldName:     ci.fieldName,
+			FieldType:     ti.rowType.FieldByIndex(ci.fields).Type,
+			DBType:        ci.dbType,
+			PrimaryKey:    ci.primaryKey,
+			AutoIncrement: ci.autoIncrement,
+			NotNull:       ci.constraints.notNull,
+			MaxLen:        ci.constraints.maxLen,
+			Comment:       ci.comment,
+		})
+	}
+	return columns
+}
+
 // SelectInfo contains information about a table that can
 // be formatted for a SELECT statement or a select clause
 // in an INSERT statement.
@@ -363,8 +553,25 @@ type columnInfo struct {
 	table         *TableInfo
 	primaryKey    bool
 	autoIncrement bool
+	shardKey      bool
+	tenant        bool
 	version       bool
+	readOnly      bool
+	writeOnly     bool
+	sensitive     bool     // see WithMasking
+	idempotency   bool     // see InsertRowCommand.ExecInserted
+	discriminator bool     // see TableInfo.Variant
+	indexes       []string // see TableInfo.Finder
+	constraints   constraints
+	codec         Codec
+	array         bool // slice stored in an array (or JSON) column
+	times         *timeSettings
+	audit         auditKind // see SetAuditFunc
+	dbType        string    // database type for DDL, see Column.DBType
+	comment       string    // see Column.Comment
 	fields        []int
+	spatial       *spatialSettings // see WKT
+	outputAlias   string           // generated when selected from more than one table
 
 	// modified on copies during SQL statement preparation
 	inputPosition int
@@ -388,13 +595,30 @@ func (ci *columnInfo) tableAlias() string {
 }
 
 func (ci *columnInfo) hasColumnAlias() bool {
-	return ci.table.alias != ""
+	return ci.table.alias != "" || ci.outputAlias != ""
 }
 
 func (ci *columnInfo) columnAlias() string {
+	if ci.table.alias == "" {
+		return ci.outputAlias
+	}
 	return ci.table.alias + "_" + ci.columnName
 }
 
+// qualifiedName returns the column name, qualified by the table alias
+// if the table has one, or by the table name if the column has a
+// generated alias.
+func (ci *columnInfo) qualifiedName() string {
+	column := ci.table.Dialect().Quote(ci.columnName)
+	if ci.hasTableAlias() {
+		return ci.tableAlias() + "." + column
+	}
+	if ci.outputAlias != "" {
+		return ci.table.Dialect().Quote(ci.table.Name) + "." + column
+	}
+	return column
+}
+
 func (ci *columnInfo) setPosition(n int) {
 	ci.inputPosition = n
 }
@@ -437,6 +661,7 @@ func (c sqlClause) isInput() bool {
 type TableName struct {
 	table  *TableInfo
 	clause sqlClause
+	tenant *tenantInput // set for a table with a tenant column, see TenantScope
 }
 
 // clone makes a copy of the table name that is associated with
@@ -447,6 +672,14 @@ func (tn TableName) clone(ti *TableInfo) TableName {
 	return tn2
 }
 
+// As returns a table name for a clone of the table with the
+// specified alias. It is a convenient alternative to TableInfo.WithAlias
+// when the alias is only needed for a single query. Note that the
+// column lists for the query must also refer to the alias, see ColumnList.As.
+func (tn TableName) As(alias string) TableName {
+	return tn.clone(tn.table.WithAlias(alias))
+}
+
 // String prints the table name in the appropriate
 // form for the part of the SQL statement that this TableName
 // applies to. Because TableName implements the Stringer
@@ -455,6 +688,20 @@ func (tn TableName) String() string {
 	dialect := tn.table.Dialect()
 	switch tn.clause {
 	case clauseSelectFrom:
+		if tn.tenant != nil {
+			// only the rows of the tenant are visible to the command
+			name := dialect.Quote(tn.table.Name)
+			alias := tn.table.alias
+			if alias == "" {
+				alias = name
+			}
+			return fmt.Sprintf("(select * from %s where %s = %s) as %s",
+				name,
+				dialect.Quote(tn.table.tenantColumn().columnName),
+				dialect.Placeholder(tn.tenant.position),
+				alias,
+			)
+		}
 		if tn.table.alias != "" {
 			return fmt.Sprintf("%s as %s",
 				dialect.Quote(tn.table.Name),
@@ -478,6 +725,11 @@ func (tn TableName) String() string {
 // subset of the columns in the table. For example calling the All
 // method on any ColumnList will return a ColumnList with all of the
 // columns in the table.
+//
+// The columns are always listed in the order that their fields are
+// declared in the row struct, so the SQL text of a command is the same
+// each time it is built, which is important for prepared statement caches
+// and golden-file tests. See TableInfo.WithSortedColumns for an alternative.
 type ColumnList struct {
 	table  *TableInfo
 	filter func(ci *columnInfo) bool
@@ -496,18 +748,40 @@ func (cil ColumnList) clone(ti *TableInfo) ColumnList {
 }
 
 func (cil ColumnList) filtered() []*columnInfo {
-	if cil.filter == nil {
+	// sensitive columns are not selected by a command built using WithMasking
+	masked := cil.table.masked && cil.clause == clauseSelectColumns
+	if cil.filter == nil && !masked {
 		return cil.table.columns
 	}
 	var list []*columnInfo
+	var hasTenant bool
 	for _, ci := range cil.table.columns {
-		if cil.filter(ci) {
+		if masked && ci.sensitive {
+			continue
+		}
+		if cil.filter == nil || cil.filter(ci) {
 			list = append(list, ci)
+			hasTenant = hasTenant || ci.tenant
+		}
+	}
+	if cil.clause == clauseUpdateWhere && !hasTenant {
+		// updates and deletes only apply to the rows of the tenant
+		if tc := cil.table.tenantColumn(); tc != nil {
+			list = append(list, tc)
 		}
 	}
 	return list
 }
 
+// As returns a column list for a clone of the table with the specified
+// alias. In a SELECT clause, each column is qualified with the alias
+// (eg "o.id") and given an output alias (eg "o_id"), so that columns from
+// more than one table, including the same table joined to itself, can be
+// selected in the one query.
+func (cil ColumnList) As(alias string) ColumnList {
+	return cil.clone(cil.table.WithAlias(alias))
+}
+
 // All returns a column list of all of the columns in the associated table.
 func (cil ColumnList) All() ColumnList {
 	return ColumnList{clause: cil.clause, table: cil.table}
@@ -531,7 +805,7 @@ func (cil ColumnList) Include(names ...string) ColumnList {
 // Exclude returns a column list that excludes the nominated columns.
 // This method can be appended to another method. For example:
 //
-//  table.Update.Columns.Updateable().Except("Name", "Age")
+//	table.Update.Columns.Updateable().Except("Name", "Age")
 //
 // will specify all updateable columns (ie non-primary key and
 // non-auto-increment) except for the columns corresponding to the
@@ -554,12 +828,45 @@ func (cil ColumnList) Exclude(names ...string) ColumnList {
 	})
 }
 
+// Group returns a column list that includes only the columns in the
+// named group (see TableInfo.WithColumnGroup). This method can be appended
+// to another method, and the columns it excludes remain excluded. Group
+// panics if the table has no group with the name.
+func (cil ColumnList) Group(name string) ColumnList {
+	fields, ok := cil.table.groups[name]
+	if !ok {
+		panic(fmt.Sprintf("sqlf: table %s has no column group %q", cil.table.Name, name))
+	}
+	prevFilter := cil.filter
+	return cil.applyFilter(func(ci *columnInfo) bool {
+		if prevFilter != nil && !prevFilter(ci) {
+			return false
+		}
+		for _, name := range fields {
+			if name == ci.fieldName {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 // Insertable returns a column list of all columns in the associated
 // table that can be inserted. This list includes all columns except
-// an auto-increment column, if the table has one.
+// an auto-increment column, if the table has one, and any columns
+// tagged as read-only.
 func (cil ColumnList) Insertable() ColumnList {
 	return cil.applyFilter(func(ci *columnInfo) bool {
-		return !ci.autoIncrement
+		return !ci.autoIncrement && !ci.readOnly
+	})
+}
+
+// Selectable returns a column list of all columns in the associated
+// table that can be selected. This list includes all columns except
+// for any columns tagged as write-only.
+func (cil ColumnList) Selectable() ColumnList {
+	return cil.applyFilter(func(ci *columnInfo) bool {
+		return !ci.writeOnly
 	})
 }
 
@@ -586,30 +893,37 @@ func (cil ColumnList) String() string {
 			}
 		}
 		switch cil.clause {
-		case clauseSelectColumns, clauseSelectOrderBy:
-			if ci.hasTableAlias() {
-				buf.WriteString(ci.tableAlias())
-				buf.WriteRune('.')
+		case clauseSelectColumns:
+			column := ci.qualifiedName()
+			if ci.spatial != nil {
+				buf.WriteString(ci.spatial.selectExpr(column))
+				buf.WriteString(" as ")
+				if ci.hasColumnAlias() {
+					buf.WriteString(ci.columnAlias())
+				} else {
+					buf.WriteString(ci.table.Dialect().Quote(ci.columnName))
+				}
+				break
 			}
-			buf.WriteString(ci.table.Dialect().Quote(ci.columnName))
+			buf.WriteString(column)
 			if ci.hasColumnAlias() {
 				buf.WriteString(" as ")
 				buf.WriteString(ci.columnAlias())
 			}
+		case clauseSelectOrderBy:
+			// the column alias is not used in the ORDER BY clause
+			buf.WriteString(ci.qualifiedName())
 		case clauseDeleteWhere:
-			if ci.hasTableAlias() {
-				buf.WriteString(ci.tableAlias())
-				buf.WriteRune('.')
-			}
+			// the table name in a DELETE statement does not have an alias
 			buf.WriteString(ci.table.Dialect().Quote(ci.columnName))
 		case clauseInsertColumns:
 			buf.WriteString(ci.table.Dialect().Quote(ci.columnName))
 		case clauseInsertValues:
-			buf.WriteString(ci.table.Dialect().Placeholder(ci.inputPosition))
+			buf.WriteString(ci.inputExpr())
 		case clauseUpdateSet, clauseUpdateWhere:
 			buf.WriteString(ci.table.Dialect().Quote(ci.columnName))
 			buf.WriteRune('=')
-			buf.WriteString(ci.table.Dialect().Placeholder(ci.inputPosition))
+			buf.WriteString(ci.inputExpr())
 		}
 	}
 	return buf.String()
@@ -617,10 +931,13 @@ func (cil ColumnList) String() string {
 
 // Updateable returns a column list of all columns that can be
 // updated in the associated table. This list excludes any
-// primary key columns and any auto-increment column.
+// primary key columns, any auto-increment column, any columns
+// tagged as read-only, the tenant column (see TenantScope), and
+// any "createdby" audit column (see SetAuditFunc).
 func (cil ColumnList) Updateable() ColumnList {
 	return cil.applyFilter(func(ci *columnInfo) bool {
-		return !ci.primaryKey && !ci.autoIncrement
+		return !ci.primaryKey && !ci.autoIncrement && !ci.readOnly &&
+			(!ci.tenant || ci.table.withoutTenant) && ci.audit != auditCreatedBy
 	})
 }
 
@@ -643,17 +960,26 @@ func (cil ColumnList) applyFilter(f func(ci *columnInfo) bool) ColumnList {
 type Placeholder struct {
 	table    *TableInfo
 	position int
+	op       placeholderOp // see Between and LikePrefix
 }
 
 func (p *Placeholder) clone(ti *TableInfo) *Placeholder {
 	return &Placeholder{
 		table:    ti,
 		position: p.position,
+		op:       p.op,
 	}
 }
 
 func (p *Placeholder) String() string {
-	return p.table.Dialect().Placeholder(p.position)
+	d := p.table.Dialect()
+	switch p.op {
+	case placeholderBetween:
+		return fmt.Sprintf("between %s and %s", d.Placeholder(p.position), d.Placeholder(p.position+1))
+	case placeholderLikePrefix, placeholderLikeSuffix, placeholderLikeContains:
+		return fmt.Sprintf("like %s escape '%c'", d.Placeholder(p.position), likeEscape)
+	}
+	return d.Placeholder(p.position)
 }
 
 func (p *Placeholder) setPosition(n int) {
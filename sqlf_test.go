@@ -1,6 +1,8 @@
 package sqlf
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
@@ -104,3 +106,321 @@ func Test1(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal(int64(2), rowsAffected)
 }
+
+func TestReadOnlyWriteOnly(t *testing.T) {
+	assert := assert.New(t)
+	type Row struct {
+		ID        int `sql:"primary_key;auto_increment"`
+		Name      string
+		FullText  string `sql:"readonly"`
+		Password  string `sql:"writeonly"`
+		Transient string `sql:"-"`
+	}
+	tbl := Table("rows", Row{}).WithDialect(DialectMySQL)
+	assert.Equal("`id`,`name`,`full_text`", tbl.Select.Columns.String())
+	assert.Equal("`name`,`password`", tbl.Insert.Columns.String())
+	assert.Equal("`name`=?,`password`=?", tbl.Update.SetColumns.String())
+	assert.Equal("`id`,`name`,`full_text`,`password`", tbl.Select.Columns.All().String())
+}
+
+func TestInsertExecResult(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	tbl := Table("users", User{})
+
+	ins := InsertRowf("insert or ignore into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns.All(), tbl.Insert.Values.All())
+	user := User{ID: 5, GivenName: "John", FamilyName: "Citizen"}
+	result, err := ins.ExecResult(db, &user)
+	assert.NoError(err)
+	n, err := result.RowsAffected()
+	assert.NoError(err)
+	assert.Equal(int64(1), n)
+
+	result, err = ins.ExecResult(db, &user)
+	assert.NoError(err)
+	n, err = result.RowsAffected()
+	assert.NoError(err)
+	assert.Equal(int64(0), n)
+}
+
+func TestQueryRowMapperError(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	if _, err := db.Exec("create table teams(id integer primary key, name text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	type Team struct {
+		ID   int `sql:"primary_key"`
+		Name string
+	}
+	type UserTeam struct {
+		User User
+		Team Team
+	}
+	users := Table("users", User{})
+	teams := Table("teams", Team{})
+
+	// errors preparing the query are deferred until the row is scanned
+	sel := Queryf("select %s from %s, missing", users.Select.Columns, users.Select.TableName)
+	row := sel.QueryRow(db)
+	assert.NotNil(row)
+	var u User
+	err := row.StructScan(&u)
+	assert.Error(err)
+	assert.Equal(err, row.Err())
+
+	// both tables have an "id" column, which is selected using generated aliases
+	if _, err := db.Exec("insert into users(id, given_name, family_name) values(1, 'John', 'Citizen');" +
+		"insert into teams(id, name) values(1, 'Red')"); err != nil {
+		t.Fatal(err)
+	}
+	sel = Queryf("select %s, %s from %s join %s on users.id = teams.id", users.Select.Columns, teams.Select.Columns, users.Select.TableName, teams.Select.TableName)
+	assert.Contains(sel.Command(), "`users`.`id` as users_id")
+	assert.Contains(sel.Command(), "`teams`.`id` as teams_id")
+	var ut UserTeam
+	assert.NoError(sel.QueryRow(db).StructScan(&ut))
+	assert.Equal(UserTeam{
+		User: User{ID: 1, GivenName: "John", FamilyName: "Citizen"},
+		Team: Team{ID: 1, Name: "Red"},
+	}, ut)
+}
+
+func TestQueryJoinDuplicateFields(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+
+	tbl := Table("users", User{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &User{GivenName: "John", FamilyName: "Citizen"}))
+	assert.NoError(ins.Exec(db, &User{GivenName: "Jane", FamilyName: "Doe"}))
+
+	u1 := tbl.WithAlias("u1")
+	u2 := tbl.WithAlias("u2")
+
+	// both tables have fields with the same names
+	sel := Queryf("select %s, %s from %s, %s where u1.id < u2.id",
+		u1.Select.Columns, u2.Select.Columns,
+		u1.Select.TableName, u2.Select.TableName)
+
+	type Pair struct {
+		U1 User
+		U2 *User
+	}
+
+	var pairs []Pair
+	assert.NoError(sel.Select(db, &pairs))
+	if assert.Equal(1, len(pairs)) {
+		assert.Equal(1, pairs[0].U1.ID)
+		assert.Equal("John", pairs[0].U1.GivenName)
+		assert.Equal(2, pairs[0].U2.ID)
+		assert.Equal("Jane", pairs[0].U2.GivenName)
+	}
+
+	var pair Pair
+	assert.NoError(sel.QueryRow(db).StructScan(&pair))
+	assert.Equal("Citizen", pair.U1.FamilyName)
+	assert.Equal("Doe", pair.U2.FamilyName)
+
+	// the field matching the table alias is preferred
+	type Reversed struct {
+		U2 User
+		U1 User
+	}
+	var reversed Reversed
+	assert.NoError(sel.QueryRow(db).StructScan(&reversed))
+	assert.Equal(1, reversed.U1.ID)
+	assert.Equal(2, reversed.U2.ID)
+
+	// aliased table into the row struct directly
+	sel = Queryf("select %s from %s order by u1.id", u1.Select.Columns, u1.Select.TableName)
+	var users []*User
+	assert.NoError(sel.Select(db, &users))
+	assert.Equal(2, len(users))
+
+	var ids []int
+	sel = Queryf("select id from %s order by id", tbl.Select.TableName)
+	assert.NoError(sel.Select(db, &ids))
+	assert.Equal([]int{1, 2}, ids)
+}
+
+func TestTableAlias(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	tbl := Table("users", User{}).WithDialect(DialectSQLite)
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &User{GivenName: "John", FamilyName: "Citizen"}))
+	assert.NoError(ins.Exec(db, &User{GivenName: "Jane", FamilyName: "Citizen"}))
+
+	sel := Queryf("select %s from %s order by %s",
+		tbl.Select.Columns.As("u"),
+		tbl.Select.TableName.As("u"),
+		tbl.Select.OrderBy.As("u"))
+	assert.Equal("select u.`id` as u_id,u.`given_name` as u_given_name,u.`family_name` as u_family_name from `users` as u order by u.`id`", sel.Command())
+
+	var users []User
+	assert.NoError(sel.Select(db, &users))
+	assert.Equal(2, len(users))
+	assert.Equal("John", users[0].GivenName)
+
+	// self join
+	type Siblings struct {
+		A User
+		B User
+	}
+	sel = Queryf("select %s, %s from %s inner join %s on a.family_name = b.family_name and a.id < b.id order by %s",
+		tbl.Select.Columns.As("a"), tbl.Select.Columns.As("b"),
+		tbl.Select.TableName.As("a"), tbl.Select.TableName.As("b"),
+		tbl.Select.OrderBy.As("a"))
+	var siblings []Siblings
+	assert.NoError(sel.Select(db, &siblings))
+	if assert.Equal(1, len(siblings)) {
+		assert.Equal("John", siblings[0].A.GivenName)
+		assert.Equal("Jane", siblings[0].B.GivenName)
+	}
+
+	del := Execf("delete from %s where %s = %s", tbl.Delete.TableName, tbl.WithAlias("u").Delete.WhereColumns, tbl.Delete.Placeholder())
+	assert.Equal("delete from `users` where `id` = ?", del.Command())
+}
+
+type userWithFullName struct {
+	User
+	FullName string `sql:"-"`
+}
+
+func (u *userWithFullName) AfterScan(ctx context.Context) error {
+	u.FullName = u.GivenName + " " + u.FamilyName
+	return nil
+}
+
+func TestAfterScan(t *testing.T) {
+	assert := assert.New(t)
+	db := createDatabase(t, "")
+	tbl := Table("users", userWithFullName{})
+	ins := InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &userWithFullName{User: User{GivenName: "John", FamilyName: "Citizen"}}))
+
+	sel := Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName)
+	var users []userWithFullName
+	assert.NoError(sel.Select(db, &users))
+	if assert.Equal(1, len(users)) {
+		assert.Equal("John Citizen", users[0].FullName)
+	}
+
+	var user userWithFullName
+	assert.NoError(sel.QueryRow(db).StructScan(&user))
+	assert.Equal("John Citizen", user.FullName)
+}
+
+func TestColumnField(t *testing.T) {
+	assert := assert.New(t)
+	type Name struct {
+		Given  string
+		Family string
+	}
+	type Person struct {
+		ID   int64
+		Name Name
+	}
+	tbl := Table("people", Person{})
+	assert.Equal(reflect.TypeOf(Person{}), tbl.RowType())
+
+	fieldName, index, ok := tbl.ColumnField("name_family")
+	assert.True(ok)
+	assert.Equal("Family", fieldName)
+	assert.Equal([]int{1, 1}, index)
+
+	_, _, ok = tbl.ColumnField("family")
+	assert.False(ok)
+}
+
+func TestColumns(t *testing.T) {
+	assert := assert.New(t)
+	type Product struct {
+		ID    int64  `sql:"primary_key;auto_increment"`
+		Code  string `sql:"maxlen:20;not null"`
+		Price *int64 `sql:"dbtype:numeric(10,2)"`
+	}
+	tbl := Table("products", Product{})
+	assert.Equal([]Column{
+		{Name: "id", FieldName: "ID", FieldType: reflect.TypeOf(int64(0)), PrimaryKey: true, AutoIncrement: true},
+		{Name: "code", FieldName: "Code", FieldType: reflect.TypeOf(""), NotNull: true, MaxLen: 20},
+		{Name: "price", FieldName: "Price", FieldType: reflect.TypeOf((*int64)(nil)), DBType: "numeric(10,2)"},
+	}, tbl.Columns())
+}
+
+func TestColumnOrder(t *testing.T) {
+	assert := assert.New(t)
+	type Address struct {
+		Street string
+		City   string
+	}
+	type Customer struct {
+		ID      int64 `sql:"primary_key;auto_increment"`
+		Name    string
+		Address Address
+		Email   string
+	}
+
+	// the SQL text is the same each time the commands are built
+	build := func(tbl *TableInfo) []string {
+		return []string{
+			Queryf("select %s from %s order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy).Command(),
+			InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values).Command(),
+			UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns).Command(),
+		}
+	}
+	want := build(Table("customers", Customer{}).WithDialect(DialectMySQL))
+	for i := 0; i < 20; i++ {
+		assert.Equal(want, build(Table("customers", Customer{}).WithDialect(DialectMySQL)))
+	}
+
+	// declaration order by default
+	tbl := Table("customers", Customer{}).WithDialect(DialectMySQL)
+	assert.Equal("`id`,`name`,`address_street`,`address_city`,`email`", tbl.Select.Columns.String())
+
+	sorted := tbl.WithSortedColumns()
+	assert.Equal("`address_city`,`address_street`,`email`,`id`,`name`", sorted.Select.Columns.String())
+	assert.Equal("`address_city`,`address_street`,`email`,`name`", sorted.Insert.Columns.String())
+	assert.Equal("`address_city`=?,`address_street`=?,`email`=?,`name`=?", sorted.Update.SetColumns.String())
+	ins := InsertRowf("insert into %s(%s) values(%s)", sorted.Insert.TableName, sorted.Insert.Columns, sorted.Insert.Values)
+	args, err := ins.Args(&Customer{Name: "n", Email: "e", Address: Address{Street: "s", City: "c"}})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"c", "s", "e", "n"}, args)
+
+	// the original table is unchanged
+	assert.Equal("`id`,`name`,`address_street`,`address_city`,`email`", tbl.Select.Columns.String())
+}
+
+func TestColumnGroup(t *testing.T) {
+	assert := assert.New(t)
+	type Article struct {
+		ID       int64 `sql:"primary_key;auto_increment"`
+		Title    string
+		Status   string
+		Body     string
+		Password string `sql:"writeonly"`
+	}
+	tbl := Table("articles", Article{}).WithDialect(DialectMySQL).
+		WithColumnGroup("summary", "ID", "Title", "Status").
+		WithColumnGroup("secret", "ID", "Password")
+
+	assert.Equal("`id`,`title`,`status`", tbl.Select.Columns.Group("summary").String())
+	assert.Equal("`title`,`status`", tbl.Insert.Columns.Group("summary").String())
+	cmd := Queryf("select %s from %s order by %s",
+		tbl.Select.Columns.Group("summary"), tbl.Select.TableName, tbl.Select.OrderBy)
+	assert.Equal("select `id`,`title`,`status` from `articles` order by `id`", cmd.Command())
+
+	// columns excluded by the column list remain excluded
+	assert.Equal("`id`", tbl.Select.Columns.Group("secret").String())
+
+	// groups are kept by clones of the table
+	assert.Equal(`"id","title","status"`, tbl.WithDialect(DialectPG).Select.Columns.Group("summary").String())
+
+	assert.Panics(func() {
+		tbl.Select.Columns.Group("full")
+	})
+	assert.Panics(func() {
+		tbl.WithColumnGroup("bad", "Missing")
+	})
+}
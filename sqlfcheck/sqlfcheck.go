@@ -0,0 +1,431 @@
+// Package sqlfcheck defines an analyzer that checks calls to the sqlf
+// command functions for common mistakes. The sqlf API uses "printf"-style
+// format strings, which are convenient but easy to get wrong in ways
+// that are only discovered when the command is built or executed.
+//
+// The analyzer reports:
+//
+//   - a format string whose number of verbs does not match the number of
+//     arguments (Option arguments are not counted);
+//   - an InsertRowf call without a table name and insert values, or an
+//     UpdateRowf call without a table name and where columns;
+//   - an InsertRowf or UpdateRowf call whose arguments refer to more than
+//     one table (eg users.Insert.TableName, orders.Insert.Values);
+//   - a call to QueryCommand.Select whose destination struct has no
+//     field for the rows of a table whose columns are selected.
+//
+// Checks are only made where the values can be determined statically,
+// for example a constant format string, or a query command assigned to
+// a variable from a call to Queryf. See the cmd/sqlfcheck command for
+// running the analyzer.
+package sqlfcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const sqlfPath = "github.com/jjeffery/sqlf"
+
+// Analyzer checks calls to the sqlf package.
+var Analyzer = &analysis.Analyzer{
+	Name:     "sqlfcheck",
+	Doc:      "check calls to sqlf command functions for format and argument errors",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// formatFuncs are the sqlf functions that accept a format string.
+var formatFuncs = map[string]bool{
+	"Execf":         true,
+	"InsertRowf":    true,
+	"InsertSelectf": true,
+	"Queryf":        true,
+	"UpdateRowf":    true,
+}
+
+type checker struct {
+	pass *analysis.Pass
+
+	// inits maps variables to the expression they are assigned, for
+	// variables that are assigned exactly once in the package
+	inits map[types.Object]ast.Expr
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	c := &checker{
+		pass:  pass,
+		inits: make(map[types.Object]ast.Expr),
+	}
+
+	assigned := make(map[types.Object]int)
+	record := func(lhs ast.Expr, rhs ast.Expr) {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
+			return
+		}
+		obj := pass.TypesInfo.ObjectOf(ident)
+		if obj == nil {
+			return
+		}
+		assigned[obj]++
+		c.inits[obj] = rhs
+	}
+	nodeFilter := []ast.Node{(*ast.ValueSpec)(nil), (*ast.AssignStmt)(nil)}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i := range n.Names {
+					record(n.Names[i], n.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i := range n.Lhs {
+					record(n.Lhs[i], n.Rhs[i])
+				}
+			}
+		}
+	})
+	for obj, n := range assigned {
+		if n > 1 {
+			delete(c.inits, obj)
+		}
+	}
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if name := c.sqlfFunc(call); formatFuncs[name] {
+			c.checkFormat(call, name)
+			if name == "InsertRowf" || name == "UpdateRowf" {
+				c.checkRowCommand(call, name)
+			}
+			return
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Select" {
+			if isSqlfType(pass.TypesInfo.TypeOf(sel.X), "QueryCommand") {
+				c.checkSelect(call, sel.X)
+			}
+		}
+	})
+	return nil, nil
+}
+
+// sqlfFunc returns the name of the sqlf package-level function
+// called, or the empty string if call is not a call to one.
+func (c *checker) sqlfFunc(call *ast.CallExpr) string {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != sqlfPath {
+		return ""
+	}
+	if fn.Type().(*types.Signature).Recv() != nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// isSqlfType reports whether t is the named type in the sqlf package.
+func isSqlfType(t types.Type, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == sqlfPath && obj.Name() == name
+}
+
+// checkFormat checks that the number of verbs in a constant format
+// string matches the number of arguments.
+func (c *checker) checkFormat(call *ast.CallExpr, name string) {
+	if len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return
+	}
+	tv, ok := c.pass.TypesInfo.Types[call.Args[0]]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	verbs := countVerbs(constant.StringVal(tv.Value))
+	var args int
+	for _, arg := range call.Args[1:] {
+		if !isSqlfType(c.pass.TypesInfo.TypeOf(arg), "Option") {
+			args++
+		}
+	}
+	if verbs != args {
+		c.pass.Reportf(call.Pos(), "sqlf.%s format has %d verbs but call has %d args", name, verbs, args)
+	}
+}
+
+// countVerbs returns the number of arguments consumed by the format string.
+func countVerbs(format string) int {
+	var count int
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+		// skip flags, width and precision
+		for i < len(format) && strings.IndexByte("+-# 0123456789.*", format[i]) >= 0 {
+			if format[i] == '*' {
+				count++
+			}
+			i++
+		}
+		count++
+	}
+	return count
+}
+
+// clause describes an argument of the form tbl.Section.Field, for
+// example users.Insert.TableName or users.Select.Columns.All().
+type clause struct {
+	arg     ast.Expr
+	table   ast.Expr
+	section string
+	field   string
+}
+
+// clauseOf returns the clause for the argument, if it has that form.
+func clauseOf(arg ast.Expr) (clause, bool) {
+	expr := ast.Unparen(arg)
+	for {
+		// remove method calls such as All(), PrimaryKey() and As("x")
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		expr = ast.Unparen(sel.X)
+	}
+	field, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return clause{}, false
+	}
+	section, ok := ast.Unparen(field.X).(*ast.SelectorExpr)
+	if !ok {
+		return clause{}, false
+	}
+	return clause{
+		arg:     arg,
+		table:   section.X,
+		section: section.Sel.Name,
+		field:   field.Sel.Name,
+	}, true
+}
+
+// checkRowCommand checks that the arguments of InsertRowf and UpdateRowf
+// include the table name and input columns, and that they all refer to
+// the same table.
+func (c *checker) checkRowCommand(call *ast.CallExpr, name string) {
+	if call.Ellipsis.IsValid() {
+		return
+	}
+	section, input := "Insert", "Values"
+	if name == "UpdateRowf" {
+		section, input = "Update", "WhereColumns"
+	}
+
+	var clauses []clause
+	var table ast.Expr
+	var hasInput bool
+	for _, arg := range call.Args[1:] {
+		cl, ok := clauseOf(arg)
+		if !ok || cl.section != section {
+			continue
+		}
+		clauses = append(clauses, cl)
+		switch cl.field {
+		case "TableName":
+			if table == nil {
+				table = cl.table
+			}
+		case input:
+			hasInput = true
+		}
+	}
+
+	if table == nil {
+		c.pass.Reportf(call.Pos(), "sqlf.%s call has no table name (eg tbl.%s.TableName)", name, section)
+		return
+	}
+	if !hasInput {
+		c.pass.Reportf(call.Pos(), "sqlf.%s call has no input columns (eg tbl.%s.%s)", name, section, input)
+	}
+	tableName := types.ExprString(table)
+	for _, cl := range clauses {
+		if s := types.ExprString(cl.table); s != tableName {
+			c.pass.Reportf(cl.arg.Pos(), "sqlf.%s argument %s refers to table %s, but the table name is from %s",
+				name, types.ExprString(cl.arg), s, tableName)
+		}
+	}
+}
+
+// checkSelect checks that the destination of a call to QueryCommand.Select
+// has a field for each table whose columns are selected by the query.
+func (c *checker) checkSelect(call *ast.CallExpr, cmd ast.Expr) {
+	if len(call.Args) < 2 {
+		return
+	}
+	dest := elemType(c.pass.TypesInfo.TypeOf(call.Args[1]))
+	if dest == nil {
+		return
+	}
+	st, ok := dest.Underlying().(*types.Struct)
+	if !ok || isScanner(dest) {
+		return
+	}
+	for _, rowType := range c.selectedRowTypes(cmd) {
+		if types.Identical(dest, rowType) || containsType(st, rowType) {
+			continue
+		}
+		c.pass.Reportf(call.Args[1].Pos(), "sqlf QueryCommand.Select destination %s has no field for the columns of %s",
+			dest, rowType)
+	}
+}
+
+// elemType returns the element type of dest, which should be a
+// pointer to a slice, with any pointer removed.
+func elemType(t types.Type) types.Type {
+	ptr, ok := t.(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	slice, ok := ptr.Elem().Underlying().(*types.Slice)
+	if !ok {
+		return nil
+	}
+	elem := slice.Elem()
+	if ptr, ok := elem.(*types.Pointer); ok {
+		elem = ptr.Elem()
+	}
+	return elem
+}
+
+// isScanner reports whether values of type t are scanned directly,
+// such as time.Time and sql.NullString.
+func isScanner(t types.Type) bool {
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return true
+		}
+	}
+	mset := types.NewMethodSet(types.NewPointer(t))
+	return mset.Lookup(nil, "Scan") != nil
+}
+
+// containsType reports whether the struct has a field (at any depth)
+// whose type is t, or a pointer to t.
+func containsType(st *types.Struct, t types.Type) bool {
+	seen := make(map[*types.Struct]bool)
+	queue := []*types.Struct{st}
+	for len(queue) > 0 {
+		st := queue[0]
+		queue = queue[1:]
+		if seen[st] {
+			continue
+		}
+		seen[st] = true
+		for i := 0; i < st.NumFields(); i++ {
+			ft := st.Field(i).Type()
+			if ptr, ok := ft.(*types.Pointer); ok {
+				ft = ptr.Elem()
+			}
+			if types.Identical(ft, t) {
+				return true
+			}
+			if fst, ok := ft.Underlying().(*types.Struct); ok {
+				queue = append(queue, fst)
+			}
+		}
+	}
+	return false
+}
+
+// selectedRowTypes returns the row types of the tables whose columns
+// are selected by the query command, where they can be determined.
+func (c *checker) selectedRowTypes(cmd ast.Expr) []types.Type {
+	call, ok := c.resolve(cmd).(*ast.CallExpr)
+	if !ok || c.sqlfFunc(call) != "Queryf" {
+		return nil
+	}
+	var rowTypes []types.Type
+	for _, arg := range call.Args[1:] {
+		cl, ok := clauseOf(arg)
+		if !ok || cl.section != "Select" || cl.field != "Columns" {
+			continue
+		}
+		if t := c.tableRowType(cl.table); t != nil {
+			rowTypes = append(rowTypes, t)
+		}
+	}
+	return rowTypes
+}
+
+// tableRowType returns the row type for an expression that evaluates
+// to a *sqlf.TableInfo, or nil if it cannot be determined.
+func (c *checker) tableRowType(expr ast.Expr) types.Type {
+	for depth := 0; depth < 10; depth++ {
+		call, ok := c.resolve(expr).(*ast.CallExpr)
+		if !ok {
+			return nil
+		}
+		if c.sqlfFunc(call) == "Table" {
+			if len(call.Args) != 2 {
+				return nil
+			}
+			t := c.pass.TypesInfo.TypeOf(call.Args[1])
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			return t
+		}
+		// methods such as WithDialect and WithAlias return a copy of the table
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !isTableInfo(c.pass.TypesInfo.TypeOf(sel.X)) {
+			return nil
+		}
+		expr = sel.X
+	}
+	return nil
+}
+
+func isTableInfo(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return isSqlfType(t, "TableInfo")
+}
+
+// resolve returns the expression assigned to a variable,
+// or the expression itself if it is not a variable.
+func (c *checker) resolve(expr ast.Expr) ast.Expr {
+	expr = ast.Unparen(expr)
+	for depth := 0; depth < 10; depth++ {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return expr
+		}
+		init, ok := c.inits[c.pass.TypesInfo.ObjectOf(ident)]
+		if !ok {
+			return expr
+		}
+		expr = ast.Unparen(init)
+	}
+	return expr
+}
@@ -0,0 +1,30 @@
+package sqlfcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestCountVerbs(t *testing.T) {
+	tests := []struct {
+		format string
+		want   int
+	}{
+		{"", 0},
+		{"select * from t", 0},
+		{"select %s from %s", 2},
+		{"select %s from t where name like 'x%%'", 1},
+		{"%-10s %*d", 3},
+		{"%", 1},
+	}
+	for _, tt := range tests {
+		if got := countVerbs(tt.format); got != tt.want {
+			t.Errorf("countVerbs(%q): got %d, want %d", tt.format, got, tt.want)
+		}
+	}
+}
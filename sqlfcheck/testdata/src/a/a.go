@@ -0,0 +1,66 @@
+package a
+
+import (
+	"time"
+
+	"github.com/jjeffery/sqlf"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+
+type Order struct {
+	ID     int
+	UserID int
+}
+
+var users = sqlf.Table("users", User{})
+var orders = sqlf.Table("orders", &Order{}).WithDialect(nil)
+
+var getUsers = sqlf.Queryf("select %s from %s", users.Select.Columns, users.Select.TableName, sqlf.WithName("GetUsers"))
+
+var getUserOrders = sqlf.Queryf("select %s, %s from %s join %s on ...",
+	users.Select.Columns, orders.Select.Columns, users.Select.TableName, orders.Select.TableName)
+
+func formats(args []interface{}) {
+	sqlf.Execf("delete from %s", users.Select.TableName)
+	sqlf.Execf("delete from users where name like '%%x'")
+	sqlf.Execf("delete from %s where id = ?")              // want `sqlf.Execf format has 1 verbs but call has 0 args`
+	sqlf.Queryf("select %s from %s", users.Select.Columns) // want `sqlf.Queryf format has 2 verbs but call has 1 args`
+	sqlf.Queryf("select %s from %s", args...)
+}
+
+func rowCommands() {
+	sqlf.InsertRowf("insert into %s(%s) values(%s)", users.Insert.TableName, users.Insert.Columns, users.Insert.Values)
+	sqlf.InsertRowf("insert into %s(%s) values(%s)", users.Insert.TableName, users.Insert.Columns.All(), users.Insert.Values.All())
+	sqlf.InsertRowf("insert into users(%s) values(%s)", users.Insert.Columns, users.Insert.Values)                       // want `sqlf.InsertRowf call has no table name`
+	sqlf.InsertRowf("insert into %s(%s) values(%s)", users.Insert.TableName, users.Insert.Columns, "?")                  // want `sqlf.InsertRowf call has no input columns`
+	sqlf.InsertRowf("insert into %s(%s) values(%s)", users.Insert.TableName, orders.Insert.Columns, users.Insert.Values) // want `argument orders.Insert.Columns refers to table orders, but the table name is from users`
+	sqlf.UpdateRowf("update %s set %s where %s", users.Update.TableName, users.Update.SetColumns, users.Update.WhereColumns)
+	sqlf.UpdateRowf("update %s set %s where id = ?", users.Update.TableName, users.Update.SetColumns) // want `sqlf.UpdateRowf call has no input columns`
+}
+
+type UserOrder struct {
+	User  User
+	Order *Order
+}
+
+func selects(db interface{}) {
+	var u []User
+	var up []*User
+	var o []Order
+	var uo []UserOrder
+	var times []time.Time
+	getUsers.Select(db, &u)
+	getUsers.Select(db, &up)
+	getUsers.Select(db, &times)
+	getUsers.Select(db, &o) // want `destination a.Order has no field for the columns of a.User`
+	getUserOrders.Select(db, &uo)
+	getUserOrders.Select(db, &u) // want `destination a.User has no field for the columns of a.Order`
+
+	local := sqlf.Queryf("select %s from %s", orders.Select.Columns, orders.Select.TableName)
+	local.Select(db, &o)
+	local.Select(db, &u) // want `destination a.User has no field for the columns of a.Order`
+}
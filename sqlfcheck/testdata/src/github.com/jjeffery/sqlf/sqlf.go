@@ -0,0 +1,59 @@
+// Package sqlf is a minimal copy of the sqlf API for testing the analyzer.
+package sqlf
+
+type Dialect interface{}
+
+type Option func()
+
+func WithName(name string) Option { return nil }
+
+type TableName struct{}
+
+type ColumnList struct{}
+
+func (cl ColumnList) All() ColumnList { return cl }
+
+type SelectInfo struct {
+	TableName TableName
+	Columns   ColumnList
+}
+
+type InsertInfo struct {
+	TableName TableName
+	Columns   ColumnList
+	Values    ColumnList
+}
+
+type UpdateInfo struct {
+	TableName    TableName
+	SetColumns   ColumnList
+	WhereColumns ColumnList
+}
+
+type TableInfo struct {
+	Select SelectInfo
+	Insert InsertInfo
+	Update UpdateInfo
+}
+
+func Table(name string, row interface{}) *TableInfo { return nil }
+
+func (ti *TableInfo) WithDialect(d Dialect) *TableInfo { return ti }
+
+type InsertRowCommand interface{}
+
+type UpdateRowCommand interface{}
+
+type ExecCommand interface{}
+
+type QueryCommand interface {
+	Select(db interface{}, dest interface{}, args ...interface{}) error
+}
+
+func InsertRowf(format string, args ...interface{}) InsertRowCommand { return nil }
+
+func UpdateRowf(format string, args ...interface{}) UpdateRowCommand { return nil }
+
+func Execf(format string, args ...interface{}) ExecCommand { return nil }
+
+func Queryf(format string, args ...interface{}) QueryCommand { return nil }
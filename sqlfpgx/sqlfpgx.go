@@ -0,0 +1,137 @@
+// Package sqlfpgx executes sqlf commands using the native pgx interface
+// to PostgreSQL, rather than going through database/sql. This preserves
+// the performance benefits of pgx, including its use of the binary protocol.
+//
+// Commands executed using this package should be built for the PostgreSQL
+// dialect, so that the placeholders are numbered (eg "$1"). The args are
+// bound in the same way as when commands are executed using database/sql
+// (see sqlf.ExecCommand.BindArgs). In particular, commands that refer to a table
+// with a tenant column are restricted to the tenant stored in the context
+// (see sqlf.TenantScope and sqlf.ContextWithTenant).
+package sqlfpgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jjeffery/sqlf"
+)
+
+// Querier is the interface used to execute commands. It is implemented
+// by *pgx.Conn, *pgxpool.Pool, *pgxpool.Conn and pgx.Tx.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// Exec executes a command that does not return rows.
+func Exec(ctx context.Context, db Querier, cmd sqlf.ExecCommand, args ...interface{}) (pgconn.CommandTag, error) {
+	args, err := cmd.BindArgs(ctx, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return db.Exec(ctx, cmd.Command(), args...)
+}
+
+// InsertRow inserts a row using an insert row command.
+//
+// Unlike database/sql drivers for MySQL and SQLite, PostgreSQL does not
+// report the last inserted ID, so any auto-increment field in the row
+// is not updated. Use an "insert ... returning" query to obtain the value
+// generated by the database server.
+func InsertRow(ctx context.Context, db Querier, cmd sqlf.InsertRowCommand, row interface{}) error {
+	args, err := cmd.BindArgs(ctx, row)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, cmd.Command(), args...)
+	return err
+}
+
+// UpdateRow updates (or deletes) a row using an update row command,
+// returning the number of rows affected.
+func UpdateRow(ctx context.Context, db Querier, cmd sqlf.UpdateRowCommand, row interface{}) (int, error) {
+	args, err := cmd.BindArgs(ctx, row)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := db.Exec(ctx, cmd.Command(), args...)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// Batcher is the interface used to execute a batch of commands. It is
+// implemented by *pgx.Conn, *pgxpool.Pool, *pgxpool.Conn and pgx.Tx.
+type Batcher interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// ExecBatch executes the command once for each of the argument sets,
+// sending all of the statements to the server in a single batch. It
+// returns the total number of rows affected. Unless db is a transaction,
+// the batch is executed in an implicit transaction, so if any statement
+// fails, none of the statements take effect.
+func ExecBatch(ctx context.Context, db Batcher, cmd sqlf.ExecCommand, argSets [][]interface{}) (int64, error) {
+	if len(argSets) == 0 {
+		return 0, nil
+	}
+	b := &pgx.Batch{}
+	for _, args := range argSets {
+		args, err := cmd.BindArgs(ctx, args...)
+		if err != nil {
+			return 0, err
+		}
+		b.Queue(cmd.Command(), args...)
+	}
+	br := db.SendBatch(ctx, b)
+	var total int64
+	for range argSets {
+		tag, err := br.Exec()
+		if err != nil {
+			br.Close()
+			return 0, err
+		}
+		total += tag.RowsAffected()
+	}
+	if err := br.Close(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// Select executes the query and scans each row into dest, which
+// must be a pointer to a slice. Rows are scanned in the same way as
+// QueryCommand.Select.
+func Select(ctx context.Context, db Querier, cmd sqlf.QueryCommand, dest interface{}, args ...interface{}) error {
+	args, err := cmd.BindArgs(ctx, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query(ctx, cmd.Command(), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return cmd.SelectRows(Rows(rows), dest)
+}
+
+// Rows adapts pgx.Rows so that it implements the sqlf.Rows interface.
+func Rows(rows pgx.Rows) sqlf.Rows {
+	return pgxRows{rows}
+}
+
+type pgxRows struct {
+	pgx.Rows
+}
+
+func (r pgxRows) Columns() ([]string, error) {
+	fields := r.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, fd := range fields {
+		columns[i] = fd.Name
+	}
+	return columns, nil
+}
@@ -0,0 +1,238 @@
+package sqlfpgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRows struct {
+	pgx.Rows
+	columns []string
+	values  [][]interface{}
+	index   int
+}
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	var fields []pgconn.FieldDescription
+	for _, c := range r.columns {
+		fields = append(fields, pgconn.FieldDescription{Name: c})
+	}
+	return fields
+}
+
+func (r *fakeRows) Next() bool {
+	r.index++
+	return r.index <= len(r.values)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, v := range r.values[r.index-1] {
+		switch d := dest[i].(type) {
+		case *int64:
+			*d = v.(int64)
+		case *string:
+			*d = v.(string)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error { return nil }
+func (r *fakeRows) Close()     {}
+
+type fakeQuerier struct {
+	sql  string
+	args []interface{}
+	rows *fakeRows
+}
+
+func (q *fakeQuerier) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	q.sql, q.args = sql, args
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	q.sql, q.args = sql, args
+	return q.rows, nil
+}
+
+type User struct {
+	ID   int64
+	Name string
+}
+
+func TestSelectAndUpdate(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	tbl := sqlf.Table("users", User{}).WithDialect(sqlf.DialectPG)
+
+	q := &fakeQuerier{
+		rows: &fakeRows{
+			columns: []string{"id", "name"},
+			values:  [][]interface{}{{int64(1), "one"}, {int64(2), "two"}},
+		},
+	}
+	sel := sqlf.Queryf("select %s from %s where id > %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder())
+	var users []User
+	assert.NoError(Select(ctx, q, sel, &users, 0))
+	assert.Equal([]User{{1, "one"}, {2, "two"}}, users)
+	assert.Equal(`select "id","name" from "users" where id > $1`, q.sql)
+
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	n, err := UpdateRow(ctx, q, upd, &User{ID: 1, Name: "uno"})
+	assert.NoError(err)
+	assert.Equal(1, n)
+	assert.Equal([]interface{}{"uno", int64(1)}, q.args)
+}
+
+func TestLikePlaceholder(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	tbl := sqlf.Table("users", User{}).WithDialect(sqlf.DialectPG)
+
+	// the pattern is escaped and the wildcard appended, as for database/sql
+	q := &fakeQuerier{rows: &fakeRows{columns: []string{"id", "name"}}}
+	sel := sqlf.Queryf("select %s from %s where name %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder().LikePrefix())
+	var users []User
+	assert.NoError(Select(ctx, q, sel, &users, "50%"))
+	assert.Equal(`select "id","name" from "users" where name like $1 escape '!'`, q.sql)
+	assert.Equal([]interface{}{"50!%%"}, q.args)
+
+	del := sqlf.Execf("delete from %s where name %s", tbl.Delete.TableName, tbl.Delete.Placeholder().LikeContains())
+	_, err := Exec(ctx, q, del, "a_b")
+	assert.NoError(err)
+	assert.Equal([]interface{}{"%a!_b%"}, q.args)
+}
+
+// sqlizer is a minimal squirrel-style query builder.
+type sqlizer struct {
+	sql  string
+	args []interface{}
+	err  error
+}
+
+func (s sqlizer) ToSql() (string, []interface{}, error) {
+	return s.sql, s.args, s.err
+}
+
+func TestFixedArgs(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	type Page struct {
+		ID     int64
+		Visits int
+	}
+	tbl := sqlf.Table("pages", Page{}).WithDialect(sqlf.DialectPG)
+	q := &fakeQuerier{rows: &fakeRows{columns: []string{"id", "visits"}}}
+
+	// the args of Set are inserted by the command
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, sqlf.Set("visits", "visits + ?", 1), tbl.Update.WhereColumns)
+	_, err := UpdateRow(ctx, q, upd, &Page{ID: 2})
+	assert.NoError(err)
+	assert.Equal(`update "pages" set "visits"=visits + $1 where "id"=$2`, q.sql)
+	assert.Equal([]interface{}{1, int64(2)}, q.args)
+
+	// and so are the args of a Sqlizer
+	popular := sqlizer{sql: "visits > ?", args: []interface{}{100}}
+	sel := sqlf.Queryf("select %s from %s where id > %s and %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder(), popular)
+	var pages []Page
+	assert.NoError(Select(ctx, q, sel, &pages, 0))
+	assert.Equal(`select "id","visits" from "pages" where id > $1 and visits > $2`, q.sql)
+	assert.Equal([]interface{}{0, 100}, q.args)
+
+	// an error building the command is returned without executing it
+	q.sql, q.args = "", nil
+	errSqlizer := errors.New("cannot build")
+	del := sqlf.Execf("delete from %s where %s", tbl.Delete.TableName, sqlizer{err: errSqlizer})
+	_, err = Exec(ctx, q, del)
+	assert.Equal(errSqlizer, err)
+	assert.Equal("", q.sql)
+}
+
+func TestTenant(t *testing.T) {
+	assert := assert.New(t)
+	type Order struct {
+		ID       int64
+		TenantID int64 `sql:"tenant"`
+		Item     string
+	}
+	tbl := sqlf.Table("orders", Order{}).WithDialect(sqlf.DialectPG)
+	ctx := sqlf.ContextWithTenant(context.Background(), int64(7))
+	q := &fakeQuerier{rows: &fakeRows{columns: []string{"id", "tenant_id", "item"}}}
+
+	sel := sqlf.Queryf("select %s from %s where id > %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.Placeholder())
+	var orders []Order
+	assert.NoError(Select(ctx, q, sel, &orders, 0))
+	assert.Equal(`select "id","tenant_id","item" from (select * from "orders" where "tenant_id" = $1) as "orders" where id > $2`, q.sql)
+	assert.Equal([]interface{}{int64(7), 0}, q.args)
+
+	// the tenant field of the row is replaced
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	_, err := UpdateRow(ctx, q, upd, &Order{ID: 1, TenantID: 8, Item: "x"})
+	assert.NoError(err)
+	assert.Equal([]interface{}{"x", int64(1), int64(7)}, q.args)
+
+	// commands fail without a tenant
+	q.sql, q.args = "", nil
+	assert.Equal(sqlf.ErrNoTenant, Select(context.Background(), q, sel, &orders, 0))
+	_, err = UpdateRow(context.Background(), q, upd, &Order{ID: 1})
+	assert.Equal(sqlf.ErrNoTenant, err)
+	assert.Equal("", q.sql)
+}
+
+type fakeBatchResults struct {
+	pgx.BatchResults
+	n      int
+	closed bool
+}
+
+func (br *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	if br.n == 0 {
+		return pgconn.CommandTag{}, errors.New("no more results")
+	}
+	br.n--
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (br *fakeBatchResults) Close() error {
+	br.closed = true
+	return nil
+}
+
+type fakeBatcher struct {
+	batch   *pgx.Batch
+	results *fakeBatchResults
+}
+
+func (b *fakeBatcher) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	b.batch = batch
+	b.results = &fakeBatchResults{n: batch.Len()}
+	return b.results
+}
+
+func TestExecBatch(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	tbl := sqlf.Table("users", User{}).WithDialect(sqlf.DialectPG)
+	ins := sqlf.Execf("insert into %s(id, name) values(%s, %s)", tbl.Insert.TableName, tbl.Insert.Placeholder(), tbl.Insert.Placeholder())
+
+	b := &fakeBatcher{}
+	n, err := ExecBatch(ctx, b, ins, [][]interface{}{{1, "one"}, {2, "two"}, {3, "three"}})
+	assert.NoError(err)
+	assert.Equal(int64(3), n)
+	if assert.Equal(3, b.batch.Len()) {
+		assert.Equal(`insert into "users"(id, name) values($1, $2)`, b.batch.QueuedQueries[2].SQL)
+		assert.Equal([]interface{}{3, "three"}, b.batch.QueuedQueries[2].Arguments)
+	}
+	assert.True(b.results.closed)
+
+	n, err = ExecBatch(ctx, b, ins, nil)
+	assert.NoError(err)
+	assert.Equal(int64(0), n)
+}
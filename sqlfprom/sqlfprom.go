@@ -0,0 +1,81 @@
+// Package sqlfprom reports the execution of sqlf commands to Prometheus.
+//
+// Create a collector, register it with Prometheus, and set it as the
+// sqlf metrics sink:
+//
+//	collector := sqlfprom.NewCollector("myapp")
+//	prometheus.MustRegister(collector)
+//	sqlf.SetMetricsSink(collector)
+//
+// Metrics are labelled with the command name set using sqlf.WithName,
+// and the operation used to execute the command.
+package sqlfprom
+
+import (
+	"github.com/jjeffery/sqlf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector that implements sqlf.MetricsSink.
+type Collector struct {
+	duration *prometheus.HistogramVec
+	rows     *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+}
+
+// NewCollector returns a collector whose metrics are in the namespace,
+// which can be blank. The metrics are:
+//
+//	<namespace>_sqlf_command_duration_seconds{name,operation}
+//	<namespace>_sqlf_command_rows_total{name,operation}
+//	<namespace>_sqlf_command_errors_total{name,operation,class}
+//
+// The class label is the value returned by sqlf.ErrorClass.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "sqlf",
+			Name:      "command_duration_seconds",
+			Help:      "Time taken to execute SQL commands.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"name", "operation"}),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sqlf",
+			Name:      "command_rows_total",
+			Help:      "Number of rows affected or selected by SQL commands.",
+		}, []string{"name", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sqlf",
+			Name:      "command_errors_total",
+			Help:      "Number of SQL commands that returned an error.",
+		}, []string{"name", "operation", "class"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.rows.Describe(ch)
+	c.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.rows.Collect(ch)
+	c.errors.Collect(ch)
+}
+
+// CommandExecuted implements sqlf.MetricsSink.
+func (c *Collector) CommandExecuted(event *sqlf.CommandEvent) {
+	c.duration.WithLabelValues(event.Name, event.Operation).Observe(event.Duration.Seconds())
+	if event.Rows > 0 {
+		c.rows.WithLabelValues(event.Name, event.Operation).Add(float64(event.Rows))
+	}
+	if event.Err != nil {
+		c.errors.WithLabelValues(event.Name, event.Operation, sqlf.ErrorClass(event.Err)).Inc()
+	}
+}
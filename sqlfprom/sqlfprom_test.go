@@ -0,0 +1,61 @@
+package sqlfprom
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector("test")
+	c.CommandExecuted(&sqlf.CommandEvent{
+		Name:      "GetUserByEmail",
+		Operation: sqlf.OpSelect,
+		Duration:  10 * time.Millisecond,
+		Rows:      3,
+	})
+	c.CommandExecuted(&sqlf.CommandEvent{
+		Name:      "GetUserByEmail",
+		Operation: sqlf.OpSelect,
+		Duration:  time.Millisecond,
+		Rows:      2,
+	})
+	c.CommandExecuted(&sqlf.CommandEvent{
+		Name:      "GetUserByEmail",
+		Operation: sqlf.OpQueryRow,
+		Duration:  time.Millisecond,
+		Rows:      -1,
+		Err:       sql.ErrNoRows,
+	})
+	c.CommandExecuted(&sqlf.CommandEvent{
+		Name:      "DeleteUser",
+		Operation: sqlf.OpExec,
+		Duration:  time.Millisecond,
+		Rows:      -1,
+		Err:       errors.New("constraint violation"),
+	})
+
+	expected := `
+# HELP test_sqlf_command_errors_total Number of SQL commands that returned an error.
+# TYPE test_sqlf_command_errors_total counter
+test_sqlf_command_errors_total{class="no_rows",name="GetUserByEmail",operation="queryrow"} 1
+test_sqlf_command_errors_total{class="other",name="DeleteUser",operation="exec"} 1
+# HELP test_sqlf_command_rows_total Number of rows affected or selected by SQL commands.
+# TYPE test_sqlf_command_rows_total counter
+test_sqlf_command_rows_total{name="GetUserByEmail",operation="select"} 5
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"test_sqlf_command_errors_total", "test_sqlf_command_rows_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if got, want := testutil.CollectAndCount(c, "test_sqlf_command_duration_seconds"), 3; got != want {
+		t.Errorf("got %d duration series, want %d", got, want)
+	}
+}
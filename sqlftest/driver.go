@@ -0,0 +1,99 @@
+package sqlftest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// rowsQuery is the query passed to the driver to obtain the rows (or the
+// error) of an expectation, which is passed as the only argument.
+const rowsQuery = "sqlftest rows"
+
+// connector is a driver.Connector for the database used by a recorder.
+// The database is only used to construct the *sql.Rows and *sqlx.Row
+// values returned by queries.
+type connector struct{}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return conn{}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("sqlftest: use sqlftest.New")
+}
+
+type conn struct{}
+
+func (conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("sqlftest: prepared statements are not supported")
+}
+
+func (conn) Close() error {
+	return nil
+}
+
+func (conn) Begin() (driver.Tx, error) {
+	return nil, errors.New("sqlftest: transactions are not supported")
+}
+
+// CheckNamedValue accepts any argument, so that the
+// expectation can be passed to QueryContext.
+func (conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query != rowsQuery || len(args) != 1 {
+		return nil, errors.New("sqlftest: unexpected query")
+	}
+	e, ok := args[0].Value.(*Expectation)
+	if !ok {
+		return nil, errors.New("sqlftest: unexpected query")
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &rows{columns: e.columns, values: e.rows}, nil
+}
+
+// rows returns the rows of an expectation.
+type rows struct {
+	columns []string
+	values  [][]interface{}
+	pos     int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	row := r.values[r.pos]
+	r.pos++
+	if len(row) != len(dest) {
+		return errors.New("sqlftest: row has the wrong number of values")
+	}
+	for i, v := range row {
+		dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return err
+		}
+		dest[i] = dv
+	}
+	return nil
+}
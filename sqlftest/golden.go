@@ -0,0 +1,245 @@
+package sqlftest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// UpdateEnv is the environment variable that causes AssertGolden to write
+// the golden files instead of comparing against them. For example:
+//
+//	SQLFTEST_UPDATE=1 go test ./...
+const UpdateEnv = "SQLFTEST_UPDATE"
+
+// AssertGolden compares the SQL of each command with its golden file,
+// and reports an error with a diff for each command whose SQL has changed.
+// The golden file for a command is "<dir>/<name>.sql", and contains the
+// SQL formatted using FormatSQL. If no commands are specified, all commands
+// added to the registry using sqlf.Register are compared.
+//
+// When the environment variable SQLFTEST_UPDATE is set to a non-empty
+// value, the golden files are written instead. Committing the golden files
+// means that any change to the SQL generated by a command shows up in
+// code review.
+func AssertGolden(t TestingT, dir string, commands ...sqlf.RegisteredCommand) bool {
+	if len(commands) == 0 {
+		commands = sqlf.Commands()
+	}
+	update := os.Getenv(UpdateEnv) != ""
+	ok := true
+	for _, rc := range commands {
+		filename := filepath.Join(dir, rc.Name+".sql")
+		got := FormatSQL(rc.Cmd.Command()) + "\n"
+		if update {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Errorf("sqlftest: %v", err)
+				return false
+			}
+			if err := ioutil.WriteFile(filename, []byte(got), 0644); err != nil {
+				t.Errorf("sqlftest: %v", err)
+				ok = false
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				t.Errorf("sqlftest: %s: golden file not found: set %s=1 to create it", rc.Name, UpdateEnv)
+			} else {
+				t.Errorf("sqlftest: %v", err)
+			}
+			ok = false
+			continue
+		}
+		if string(want) != got {
+			t.Errorf("sqlftest: %s: SQL does not match %s:\n%s", rc.Name, filename, diff(string(want), got))
+			ok = false
+		}
+	}
+	return ok
+}
+
+// clauseKeywords are the keywords that start a new line when formatted.
+var clauseKeywords = map[string]bool{
+	"select":    true,
+	"from":      true,
+	"where":     true,
+	"group":     true,
+	"order":     true,
+	"having":    true,
+	"limit":     true,
+	"offset":    true,
+	"insert":    true,
+	"values":    true,
+	"update":    true,
+	"set":       true,
+	"delete":    true,
+	"returning": true,
+	"union":     true,
+	"except":    true,
+	"intersect": true,
+	"with":      true,
+	"join":      true,
+	"inner":     true,
+	"left":      true,
+	"right":     true,
+	"full":      true,
+	"cross":     true,
+	"on":        true,
+}
+
+// listClauses are the clauses whose comma-separated items
+// are each formatted on a separate line.
+var listClauses = map[string]bool{
+	"select": true,
+	"set":    true,
+	"group":  true,
+	"order":  true,
+}
+
+// FormatSQL returns the SQL statement in a canonical format, with each
+// clause on a new line, and each item of a select list on a new line.
+// Subqueries are indented. White space is otherwise collapsed. The format
+// is intended for comparing SQL statements, and for displaying the
+// differences between them.
+func FormatSQL(sql string) string {
+	var (
+		buf     bytes.Buffer
+		depth   int
+		clauses = []string{""}
+		space   bool   // white space is pending
+		start   bool   // at the start of a line
+		prev    string // previous keyword, lower case
+	)
+	newline := func(indent int) {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat("  ", indent))
+		}
+		start = true
+	}
+	write := func(s string) {
+		if space && buf.Len() > 0 && !start {
+			buf.WriteByte(' ')
+		}
+		space = false
+		start = false
+		buf.WriteString(s)
+	}
+
+	scanner := scan.NewScanner(strings.NewReader(sql))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		switch tok {
+		case scan.WS:
+			space = true
+			continue
+		case scan.IDENT:
+			kw := strings.ToLower(lit)
+			if clauseKeywords[kw] && !isContinuation(prev, kw) {
+				newline(depth)
+				clauses[depth] = kw
+			}
+			write(lit)
+			prev = kw
+			continue
+		case scan.OP:
+			switch lit {
+			case "(":
+				write(lit)
+				depth++
+				clauses = append(clauses, "")
+			case ")":
+				if depth > 0 {
+					depth--
+					clauses = clauses[:depth+1]
+				}
+				write(lit)
+			case ",":
+				write(lit)
+				if listClauses[clauses[depth]] {
+					newline(depth + 1)
+				}
+			default:
+				write(lit)
+			}
+		default:
+			write(lit)
+		}
+		prev = ""
+	}
+	return buf.String()
+}
+
+// isContinuation reports whether the keyword continues the clause
+// started by the previous keyword, eg "left join" or "delete from".
+func isContinuation(prev, kw string) bool {
+	switch kw {
+	case "join":
+		switch prev {
+		case "inner", "outer", "left", "right", "full", "cross":
+			return true
+		}
+	case "from":
+		return prev == "delete"
+	case "select":
+		return prev == "all" || prev == "union"
+	case "set":
+		return prev == "update" || prev == "do"
+	case "inner", "left", "right", "full", "cross":
+		return prev == "natural"
+	}
+	return false
+}
+
+// diff returns a line-by-line description of the differences
+// between want and got, using the longest common subsequence.
+func diff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&buf, "  %s\n", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			fmt.Fprintf(&buf, "+ %s\n", b[j])
+			j++
+		default:
+			fmt.Fprintf(&buf, "- %s\n", a[i])
+			i++
+		}
+	}
+	return buf.String()
+}
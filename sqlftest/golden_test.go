@@ -0,0 +1,92 @@
+package sqlftest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{
+			sql: `select "id","email" from "users" where email = $1 order by id, email`,
+			want: `select "id",
+  "email"
+from "users"
+where email = $1
+order by id,
+  email`,
+		},
+		{
+			sql: "insert into users(id, email)  values(?, ?)",
+			want: `insert into users(id, email)
+values(?, ?)`,
+		},
+		{
+			sql: `update users set email=?, name=? where id=?`,
+			want: `update users
+set email=?,
+  name=?
+where id=?`,
+		},
+		{
+			sql: "delete from users where id in (select user_id from posts p left join comments c on c.post_id = p.id where 'from x' = ?)",
+			want: `delete from users
+where id in (
+  select user_id
+  from posts p
+  left join comments c
+  on c.post_id = p.id
+  where 'from x' = ?)`,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sqlftest.FormatSQL(tt.sql), tt.sql)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "sqlftest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	commands := []sqlf.RegisteredCommand{
+		{Name: "GetUser", Cmd: getUser},
+		{Name: "InsertUser", Cmd: insertUser},
+	}
+
+	// golden files do not exist yet
+	var ft fakeT
+	assert.False(sqlftest.AssertGolden(&ft, dir, commands...))
+	assert.Len(ft.errors, 2)
+	assert.Contains(ft.errors[0], "golden file not found")
+
+	os.Setenv(sqlftest.UpdateEnv, "1")
+	assert.True(sqlftest.AssertGolden(t, dir, commands...))
+	os.Unsetenv(sqlftest.UpdateEnv)
+	assert.True(sqlftest.AssertGolden(t, dir, commands...))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "GetUser.sql"))
+	assert.NoError(err)
+	assert.Equal("select \"id\",\n  \"email\"\nfrom \"users\"\nwhere email = $1\n", string(data))
+
+	// a change to the SQL is reported with a diff
+	changed := sqlf.Queryf("select %s from %s where %s = %s",
+		users.Select.Columns, users.Select.TableName, "id", users.Select.Placeholder())
+	ft = fakeT{}
+	assert.False(sqlftest.AssertGolden(&ft, dir, sqlf.RegisteredCommand{Name: "GetUser", Cmd: changed}))
+	if assert.Len(ft.errors, 1) {
+		assert.Contains(ft.errors[0], "  from \"users\"\n- where email = $1\n+ where id = $1\n")
+	}
+}
@@ -0,0 +1,205 @@
+package sqlftest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+)
+
+// PlanCheck is an assertion about the query plan of a command,
+// for use with AssertPlan.
+type PlanCheck struct {
+	desc  string
+	check func(plan []string) bool
+}
+
+// UsesIndex checks that the query plan uses the named index.
+func UsesIndex(index string) PlanCheck {
+	lower := strings.ToLower(index)
+	return PlanCheck{
+		desc: "use index " + index,
+		check: func(plan []string) bool {
+			for _, line := range plan {
+				for _, word := range planWords(line) {
+					if word == lower {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}
+}
+
+// NoFullScan checks that the query plan does not scan every row of the
+// table, which is shown as "Seq Scan on orders" by PostgreSQL, "Table scan
+// on orders" by MySQL, and "SCAN orders" (without an index) by SQLite.
+func NoFullScan(table string) PlanCheck {
+	lower := strings.ToLower(table)
+	return PlanCheck{
+		desc: "avoid a full scan of " + table,
+		check: func(plan []string) bool {
+			for _, line := range plan {
+				if isFullScan(planWords(line), lower) {
+					return false
+				}
+			}
+			return true
+		},
+	}
+}
+
+// PlanContains checks that a line of the query plan contains the text,
+// ignoring case.
+func PlanContains(text string) PlanCheck {
+	lower := strings.ToLower(text)
+	return PlanCheck{
+		desc: "contain " + text,
+		check: func(plan []string) bool {
+			for _, line := range plan {
+				if strings.Contains(strings.ToLower(line), lower) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// AssertPlan runs EXPLAIN for the command added to the registry with the
+// name using sqlf.Register, and reports an error for each check that its
+// query plan does not satisfy. The args are passed with the command, and
+// are used by the database to choose the plan. For example:
+//
+//	sqlftest.AssertPlan(t, db, "GetOrdersByCustomer", []interface{}{1},
+//		sqlftest.UsesIndex("idx_orders_customer"),
+//		sqlftest.NoFullScan("orders"),
+//	)
+//
+// The database only needs the schema (tables and indexes) of the program,
+// so a test can create it from the migrations. Database statistics affect
+// the plan, so a check that passes with an empty table may fail with a
+// large one, and vice versa. The command must be built for the dialect of
+// the database. PostgreSQL, MySQL (8.0.16 or later) and SQLite are
+// supported.
+func AssertPlan(t TestingT, db sqlx.Queryer, name string, args []interface{}, checks ...PlanCheck) bool {
+	var cmd sqlf.Commander
+	for _, rc := range sqlf.Commands() {
+		if rc.Name == name {
+			cmd = rc.Cmd
+			break
+		}
+	}
+	if cmd == nil {
+		t.Errorf("sqlftest: %s: command is not registered", name)
+		return false
+	}
+	plan, err := Explain(db, cmd.Command(), args...)
+	if err != nil {
+		t.Errorf("sqlftest: %s: %v", name, err)
+		return false
+	}
+	ok := true
+	for _, c := range checks {
+		if !c.check(plan) {
+			t.Errorf("sqlftest: %s: query plan does not %s:\n  %s", name, c.desc, strings.Join(plan, "\n  "))
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Explain returns the lines of the query plan for the query,
+// which is run with EXPLAIN using the args.
+func Explain(db sqlx.Queryer, query string, args ...interface{}) ([]string, error) {
+	var explain string
+	switch driverName := driverNameOf(db); {
+	case driverName == "sqlite3":
+		explain = "explain query plan "
+	case driverName == "postgres" || driverName == "pgx":
+		explain = "explain "
+	case strings.Contains(driverName, "mysql"):
+		explain = "explain format=tree "
+	default:
+		return nil, fmt.Errorf("query plans are not supported for driver %q", driverName)
+	}
+	rows, err := db.Query(explain+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	// The plan is in the last column: SQLite has
+	// other columns with the position in the plan.
+	var plan []string
+	values := make([]interface{}, len(columns))
+	for rows.Next() {
+		var detail interface{}
+		for i := range values {
+			values[i] = new(interface{})
+		}
+		values[len(values)-1] = &detail
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		var text string
+		switch v := detail.(type) {
+		case []byte:
+			text = string(v)
+		default:
+			text = fmt.Sprint(v)
+		}
+		plan = append(plan, strings.Split(strings.TrimRight(text, "\n"), "\n")...)
+	}
+	return plan, rows.Err()
+}
+
+// driverNameOf returns the driver name of db, or
+// the empty string if it does not have one.
+func driverNameOf(db sqlx.Queryer) string {
+	if d, ok := db.(interface{ DriverName() string }); ok {
+		return strings.ToLower(d.DriverName())
+	}
+	return ""
+}
+
+// planWords returns the words of a line of a query plan in lower
+// case, without the quotes and punctuation around names.
+func planWords(line string) []string {
+	words := strings.Fields(strings.ToLower(line))
+	for i, word := range words {
+		words[i] = strings.Trim(word, "\"`[]():,;")
+	}
+	return words
+}
+
+// isFullScan reports whether the words of a line of a query plan
+// describe a full scan of the table.
+func isFullScan(words []string, table string) bool {
+	for i, word := range words {
+		switch word {
+		case "scan":
+			// SQLite: "SCAN orders", "SCAN TABLE orders", but
+			// not "SCAN orders USING COVERING INDEX ..."
+			rest := words[i+1:]
+			if len(rest) > 0 && rest[0] == "table" {
+				rest = rest[1:]
+			}
+			if len(rest) > 0 && rest[0] == table && (len(rest) == 1 || rest[1] != "using") {
+				return true
+			}
+			// PostgreSQL: "Seq Scan on orders", MySQL: "Table scan on orders"
+			if i > 0 && (words[i-1] == "seq" || words[i-1] == "table") &&
+				len(rest) > 1 && rest[0] == "on" && rest[1] == table {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package sqlftest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertPlan(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`create table orders(id integer primary key, customer_id integer, status text)`,
+		`create index idx_orders_customer on orders(customer_id)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sqlf.Register("PlanOrdersByCustomer", sqlf.Queryf("select id from orders where customer_id = ?"))
+	sqlf.Register("PlanOrdersByStatus", sqlf.Queryf("select id from orders where status = ?"))
+
+	assert.True(sqlftest.AssertPlan(t, db, "PlanOrdersByCustomer", []interface{}{1},
+		sqlftest.UsesIndex("idx_orders_customer"),
+		sqlftest.NoFullScan("orders"),
+		sqlftest.PlanContains("customer_id=?"),
+	))
+
+	var ft fakeT
+	assert.False(sqlftest.AssertPlan(&ft, db, "PlanOrdersByStatus", []interface{}{"open"},
+		sqlftest.UsesIndex("idx_orders_customer"),
+		sqlftest.NoFullScan("orders"),
+	))
+	if assert.Len(ft.errors, 2) {
+		assert.True(strings.HasPrefix(ft.errors[0], "sqlftest: PlanOrdersByStatus: query plan does not use index idx_orders_customer:"), ft.errors[0])
+		assert.True(strings.HasPrefix(ft.errors[1], "sqlftest: PlanOrdersByStatus: query plan does not avoid a full scan of orders:"), ft.errors[1])
+	}
+
+	ft = fakeT{}
+	assert.False(sqlftest.AssertPlan(&ft, db, "PlanNotRegistered", nil))
+	assert.Equal([]string{"sqlftest: PlanNotRegistered: command is not registered"}, ft.errors)
+
+	_, err = sqlftest.Explain(sqlftest.New(), "select 1")
+	assert.Error(err)
+}
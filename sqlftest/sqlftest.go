@@ -0,0 +1,256 @@
+// Package sqlftest provides a recording database handle for unit testing
+// code that executes sqlf commands, without a real database.
+//
+// A Recorder implements sqlx.Execer and sqlx.Queryer (and sqlx.Ext). It
+// records the text and arguments of each command executed, and returns
+// results, rows or errors that have been set up by the test:
+//
+//	rec := sqlftest.New()
+//	rec.Expect(getUser.Command()).WillReturnRows(
+//		[]string{"id", "email"},
+//		[]interface{}{1, "john@example.com"},
+//	)
+//	rec.ExpectCommand(updateUser).WillReturnResult(0, 1)
+//
+//	// ... call the code under test, passing rec as the database
+//
+//	rec.AssertExpectations(t)
+//
+// Commands that do not match an expectation are recorded, and succeed with
+// an empty result: no rows are returned, and no rows are affected.
+package sqlftest
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Command is a command executed using a Recorder.
+type Command struct {
+	Query string        // SQL command text
+	Args  []interface{} // Arguments passed with the command
+}
+
+// Expectation describes the response to a command. Each expectation
+// is used for one command with matching text, in the order that the
+// expectations were created.
+type Expectation struct {
+	query        string
+	columns      []string
+	rows         [][]interface{}
+	err          error
+	lastInsertID int64
+	rowsAffected int64
+	used         bool
+}
+
+// WillReturnResult sets the result returned by Exec.
+func (e *Expectation) WillReturnResult(lastInsertID int64, rowsAffected int64) *Expectation {
+	e.lastInsertID = lastInsertID
+	e.rowsAffected = rowsAffected
+	return e
+}
+
+// WillReturnRows sets the rows returned by a query. Each row has one
+// value for each column.
+func (e *Expectation) WillReturnRows(columns []string, rows ...[]interface{}) *Expectation {
+	e.columns = columns
+	e.rows = rows
+	return e
+}
+
+// WillReturnError sets the error returned when the command is executed.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Commander is implemented by all sqlf commands.
+type Commander interface {
+	Command() string
+}
+
+// TestingT is the subset of *testing.T used by AssertExpectations.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Recorder is a database handle that records the commands executed.
+// It is safe for concurrent use.
+type Recorder struct {
+	db         *sqlx.DB
+	driverName string
+
+	mutex        sync.Mutex
+	commands     []Command
+	expectations []*Expectation
+}
+
+// New returns a new recorder. Its DriverName method returns "sqlftest",
+// which is not associated with any dialect, so commands are recorded
+// exactly as they were built.
+func New() *Recorder {
+	return NewWithDriverName("sqlftest")
+}
+
+// NewWithDriverName returns a new recorder whose DriverName method returns
+// driverName. Commands are translated for the dialect of the driver in the
+// same way as for a real database handle.
+func NewWithDriverName(driverName string) *Recorder {
+	r := &Recorder{driverName: driverName}
+	r.db = sqlx.NewDb(sql.OpenDB(connector{}), driverName)
+	return r
+}
+
+// Expect returns an expectation for a command with the query text.
+func (r *Recorder) Expect(query string) *Expectation {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	e := &Expectation{query: query}
+	r.expectations = append(r.expectations, e)
+	return e
+}
+
+// ExpectCommand returns an expectation for the command.
+func (r *Recorder) ExpectCommand(cmd Commander) *Expectation {
+	return r.Expect(cmd.Command())
+}
+
+// Commands returns the commands executed, in order.
+func (r *Recorder) Commands() []Command {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Command(nil), r.commands...)
+}
+
+// Reset discards the commands recorded, and all expectations.
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.commands = nil
+	r.expectations = nil
+}
+
+// AssertExpectations reports an error for each expectation
+// that has not been used.
+func (r *Recorder) AssertExpectations(t TestingT) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ok := true
+	for _, e := range r.expectations {
+		if !e.used {
+			t.Errorf("sqlftest: expected command was not executed: %s", e.query)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// record records the command, and returns the expectation
+// it matches, or nil if it does not match any.
+func (r *Recorder) record(query string, args []interface{}) *Expectation {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.commands = append(r.commands, Command{
+		Query: query,
+		Args:  append([]interface{}(nil), args...),
+	})
+	normalized := normalize(query)
+	for _, e := range r.expectations {
+		if !e.used && normalize(e.query) == normalized {
+			e.used = true
+			return e
+		}
+	}
+	return nil
+}
+
+// normalize removes insignificant differences in white space,
+// so that expectations can be written over more than one line.
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// DriverName returns the driver name of the recorder.
+func (r *Recorder) DriverName() string {
+	return r.driverName
+}
+
+// Rebind transforms a query from QUESTION to the bind type of the driver.
+func (r *Recorder) Rebind(query string) string {
+	return r.db.Rebind(query)
+}
+
+// BindNamed binds a query using the driver's bind type.
+func (r *Recorder) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return r.db.BindNamed(query, arg)
+}
+
+// Exec records the command, and returns the result of the
+// matching expectation.
+func (r *Recorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	e := r.record(query, args)
+	if e == nil {
+		return result{}, nil
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return result{lastInsertID: e.lastInsertID, rowsAffected: e.rowsAffected}, nil
+}
+
+// Query records the command, and returns the rows of
+// the matching expectation.
+func (r *Recorder) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	e := r.record(query, args)
+	if e == nil {
+		e = &Expectation{}
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return r.db.Query(rowsQuery, e)
+}
+
+// Queryx is the same as Query, but returns *sqlx.Rows.
+func (r *Recorder) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	rows, err := r.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlx.Rows{Rows: rows, Mapper: r.db.Mapper}, nil
+}
+
+// QueryRowx is the same as Query, but returns at most one row.
+func (r *Recorder) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	e := r.record(query, args)
+	if e == nil {
+		e = &Expectation{}
+	}
+	// A *sqlx.Row can only be obtained by querying a database,
+	// so the expectation is passed to the driver, which returns
+	// its rows or error.
+	return r.db.QueryRowx(rowsQuery, e)
+}
+
+// String returns a description of the commands recorded, for use in
+// test failure messages.
+func (r *Recorder) String() string {
+	var lines []string
+	for i, cmd := range r.Commands() {
+		lines = append(lines, fmt.Sprintf("%d: %s %v", i+1, cmd.Query, cmd.Args))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
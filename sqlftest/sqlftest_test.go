@@ -0,0 +1,112 @@
+package sqlftest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jjeffery/sqlf/sqlftest"
+	"github.com/stretchr/testify/assert"
+)
+
+type User struct {
+	ID    int64 `sql:"auto_increment"`
+	Email string
+}
+
+var users = sqlf.Table("users", User{}).WithDialect(sqlf.DialectPG)
+
+var (
+	insertUser = sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		users.Insert.TableName, users.Insert.Columns, users.Insert.Values)
+	updateUser = sqlf.UpdateRowf("update %s set %s where %s",
+		users.Update.TableName, users.Update.SetColumns, users.Update.WhereColumns)
+	getUser = sqlf.Queryf("select %s from %s where %s = %s",
+		users.Select.Columns, users.Select.TableName, "email", users.Select.Placeholder())
+)
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	rec := sqlftest.New()
+
+	rec.ExpectCommand(insertUser).WillReturnResult(42, 1)
+	rec.ExpectCommand(getUser).WillReturnRows(
+		[]string{"id", "email"},
+		[]interface{}{42, "john@example.com"},
+	)
+	rec.ExpectCommand(updateUser).WillReturnError(errors.New("update failed"))
+
+	user := User{Email: "john@example.com"}
+	assert.NoError(insertUser.Exec(rec, &user))
+	assert.Equal(int64(42), user.ID)
+
+	var rows []User
+	assert.NoError(getUser.Select(rec, &rows, "john@example.com"))
+	assert.Equal([]User{{42, "john@example.com"}}, rows)
+
+	_, err := updateUser.Exec(rec, &user)
+	assert.EqualError(errors.Unwrap(err), "update failed")
+
+	// commands without an expectation return no rows
+	var user2 User
+	assert.Error(getUser.QueryRow(rec, "nobody@example.com").StructScan(&user2))
+
+	assert.True(rec.AssertExpectations(t))
+	assert.Equal([]sqlftest.Command{
+		{Query: `insert into "users"("email") values($1)`, Args: []interface{}{"john@example.com"}},
+		{Query: `select "id","email" from "users" where email = $1`, Args: []interface{}{"john@example.com"}},
+		{Query: `update "users" set "email"=$1 where "id"=$2`, Args: []interface{}{"john@example.com", int64(42)}},
+		{Query: `select "id","email" from "users" where email = $1`, Args: []interface{}{"nobody@example.com"}},
+	}, rec.Commands())
+}
+
+func TestQueryRow(t *testing.T) {
+	assert := assert.New(t)
+	rec := sqlftest.New()
+	rec.ExpectCommand(getUser).WillReturnRows([]string{"id", "email"}, []interface{}{1, "a@example.com"})
+	rec.ExpectCommand(getUser).WillReturnError(errors.New("query failed"))
+
+	var user User
+	assert.NoError(getUser.QueryRow(rec, "a@example.com").StructScan(&user))
+	assert.Equal(User{1, "a@example.com"}, user)
+	assert.Error(getUser.QueryRow(rec, "a@example.com").StructScan(&user))
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertExpectations(t *testing.T) {
+	assert := assert.New(t)
+	rec := sqlftest.New()
+	rec.Expect(`
+		delete from "users"
+		where id = $1`)
+	var ft fakeT
+	assert.False(rec.AssertExpectations(&ft))
+	assert.Len(ft.errors, 1)
+
+	// white space differences are ignored
+	_, err := sqlf.Execf("delete from %s where id = $1", users.Delete.TableName).Exec(rec, 1)
+	assert.NoError(err)
+	assert.True(rec.AssertExpectations(t))
+
+	rec.Reset()
+	assert.Len(rec.Commands(), 0)
+}
+
+func TestDriverName(t *testing.T) {
+	assert := assert.New(t)
+	rec := sqlftest.NewWithDriverName("sqlite3")
+	assert.Equal("sqlite3", rec.DriverName())
+
+	// commands are translated for the dialect of the driver
+	_, err := getUser.Query(rec, "x")
+	assert.NoError(err)
+	assert.Equal("select `id`,`email` from `users` where email = ?", rec.Commands()[0].Query)
+}
@@ -0,0 +1,41 @@
+package sqlf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqliteJournalModes are the valid SQLite journal modes.
+var sqliteJournalModes = map[string]bool{
+	"delete": true, "truncate": true, "persist": true,
+	"memory": true, "wal": true, "off": true,
+}
+
+// SQLiteForeignKeys returns a command that turns the enforcement of foreign
+// key constraints on or off. SQLite does not enforce foreign keys unless
+// they are turned on, and the setting only applies to the connection that
+// executes the command. For a connection pool (eg *sqlx.DB), it is better
+// to turn foreign keys on for every connection using the driver's data
+// source name, eg "file:app.db?_foreign_keys=on" for go-sqlite3.
+func SQLiteForeignKeys(on bool) ExecCommand {
+	value := "off"
+	if on {
+		value = "on"
+	}
+	return Execf("pragma foreign_keys = "+value, WithDialect(DialectSQLite))
+}
+
+// SQLiteJournalMode returns a command that sets the journal mode of the
+// database, which is one of "delete", "truncate", "persist", "memory",
+// "wal" or "off". The "wal" mode, which allows readers and a writer to
+// access the database concurrently, is recorded in the database file, so
+// it applies to all connections. If the mode is not valid, the command
+// returns an error when it is executed.
+func SQLiteJournalMode(mode string) ExecCommand {
+	mode = strings.ToLower(mode)
+	cmd := newExecCommand("pragma journal_mode = "+mode, []interface{}{WithDialect(DialectSQLite)})
+	if !sqliteJournalModes[mode] {
+		cmd.err = fmt.Errorf("SQLiteJournalMode: invalid journal mode %q", mode)
+	}
+	return cmd
+}
@@ -0,0 +1,34 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLitePragmas(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = sqlf.SQLiteForeignKeys(true).Exec(db)
+	assert.NoError(err)
+	var on int
+	assert.NoError(db.Get(&on, "pragma foreign_keys"))
+	assert.Equal(1, on)
+
+	_, err = sqlf.SQLiteJournalMode("MEMORY").Exec(db)
+	assert.NoError(err)
+	var mode string
+	assert.NoError(db.Get(&mode, "pragma journal_mode"))
+	assert.Equal("memory", mode)
+
+	_, err = sqlf.SQLiteJournalMode("fast").Exec(db)
+	assert.EqualError(err, `SQLiteJournalMode: invalid journal mode "fast"`)
+}
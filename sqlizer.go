@@ -0,0 +1,83 @@
+package sqlf
+
+import "fmt"
+
+// Sqlizer is implemented by query builders that produce SQL and its
+// arguments, such as the builders of the squirrel package. A Sqlizer can
+// be passed as an argument to Queryf or Execf, which makes it easier to
+// migrate from squirrel one query at a time:
+//
+//	active := sq.Eq{"status": "active", "region": region}
+//
+//	listActive := sqlf.Queryf("select %s from %s where %s",
+//		users.Select.Columns, users.Select.TableName, active)
+//
+//	err := listActive.Select(db, &rows)
+//
+// ToSql is called when the command is built. Its SQL is formatted into the
+// command, with the placeholders renumbered to suit their position in the
+// command, and its arguments are supplied when the command is executed, in
+// the position where the Sqlizer appears. They are not passed to the
+// command's methods. The placeholders of the SQL can be either "?" or
+// numbered (eg "$1"), but numbered placeholders must appear in order.
+//
+// If ToSql returns an error, the command returns the error when it is
+// executed.
+type Sqlizer interface {
+	ToSql() (string, []interface{}, error)
+}
+
+// sqlizerFragment returns a fragment for the SQL of the Sqlizer, whose
+// args are supplied when the command is executed.
+func sqlizerFragment(s Sqlizer, dialect Dialect) *Fragment {
+	text, args, err := s.ToSql()
+	if err != nil {
+		return &Fragment{parts: []string{""}, err: err}
+	}
+	f := NewFragment(text)
+	if len(args) != f.NumInputs() {
+		err = fmt.Errorf("sqlf: Sqlizer returned %d placeholders but %d args", f.NumInputs(), len(args))
+		return &Fragment{parts: []string{""}, err: err}
+	}
+	f.args = args
+	f.fixed = true
+	return f.clone(dialect)
+}
+
+// fixedInput returns the value of an input whose arg is supplied by
+// a Sqlizer or a CommandDef, and false if the input is not fixed.
+func fixedInput(input positioner) (interface{}, bool) {
+	switch fi := input.(type) {
+	case *fragmentInput:
+		if fi.frag.fixed {
+			return fi.frag.args[fi.index], true
+		}
+	case *fixedArg:
+		return fi.value, true
+	}
+	return nil, false
+}
+
+// sqlizerError returns the first error from a Sqlizer in args,
+// including any embedded subqueries.
+func sqlizerError(args []interface{}) error {
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *Fragment:
+			if a.err != nil {
+				return a.err
+			}
+		case *subquery:
+			if err := sqlizerError(a.args); err != nil {
+				return err
+			}
+		case *CTE:
+			for _, e := range a.ctes {
+				if err := sqlizerError(e.query.args); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package sqlf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// sqlizer is a minimal squirrel-style query builder.
+type sqlizer struct {
+	sql  string
+	args []interface{}
+	err  error
+}
+
+func (s sqlizer) ToSql() (string, []interface{}, error) {
+	return s.sql, s.args, s.err
+}
+
+func TestSqlizer(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime);
+		insert into table1(id, given_name, family_name) values(1, 'John', 'Citizen'), (2, 'Jane', 'Citizen'), (3, 'John', 'Smith')`); err != nil {
+		t.Fatal(err)
+	}
+
+	johns := sqlizer{sql: "given_name = $1 and id > $2", args: []interface{}{"John", 0}}
+	pg := Row1Table.WithDialect(sqlf.DialectPG)
+	cmd := sqlf.Queryf("select %s from %s where family_name = %s and %s",
+		pg.Select.Columns.Include("Id"), pg.Select.TableName, pg.Select.Placeholder(), johns)
+	assert.Equal(`select "id" from "table1" where family_name = $1 and given_name = $2 and id > $3`, cmd.Command())
+
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	sel := sqlf.Queryf("select %s from %s where family_name = %s and %s order by %s",
+		tbl.Select.Columns.Include("Id"), tbl.Select.TableName, tbl.Select.Placeholder(), johns, tbl.Select.OrderBy)
+	var ids []int64
+	assert.NoError(sel.Select(db, &ids, "Citizen"))
+	assert.Equal([]int64{1}, ids)
+
+	// the args are not supplied by the struct
+	ids = nil
+	byName := sqlf.Queryf("select %s from %s where %s and %s",
+		tbl.Select.Columns.Include("Id"), tbl.Select.TableName, tbl.Update.WhereColumns.Include("FamilyName"), johns)
+	rows, err := byName.QueryStruct(db, &Row1{FamilyName: "Smith"})
+	if assert.NoError(err) {
+		for rows.Next() {
+			var id int64
+			assert.NoError(rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		assert.NoError(rows.Close())
+	}
+	assert.Equal([]int64{3}, ids)
+
+	upd := sqlf.Execf("update %s set family_name = %s where %s",
+		tbl.Update.TableName, tbl.Update.Placeholder(), sqlizer{sql: "id in (?, ?)", args: []interface{}{1, 2}})
+	result, err := upd.Exec(db, "Jones")
+	assert.NoError(err)
+	n, _ := result.RowsAffected()
+	assert.Equal(int64(2), n)
+
+	// errors are returned when the command is executed
+	broken := sqlizer{err: errors.New("no columns")}
+	err = sqlf.Queryf("select * from table1 where %s", broken).Select(db, &ids)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "no columns")
+	}
+	_, err = sqlf.Execf("delete from table1 where %s", broken).Exec(db)
+	assert.EqualError(err, "no columns")
+	err = sqlf.Queryf("select * from table1 where %s", sqlizer{sql: "id = ?"}).Select(db, &ids)
+	assert.Error(err)
+}
+
+func TestSqlizerTenant(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table notes(id integer primary key, tenant_id integer, body text);
+		insert into notes(id, tenant_id, body) values (1, 1, 'a'), (2, 1, 'b'), (3, 2, 'a')`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Note struct {
+		ID       int64 `sql:"primary_key"`
+		TenantID int64 `sql:"tenant"`
+		Body     string
+	}
+	notes := sqlf.Table("notes", Note{}).WithDialect(sqlf.DialectSQLite)
+	sel := sqlf.Queryf("select %s from %s where %s and id > %s order by %s",
+		notes.Select.Columns.Include("ID"), notes.Select.TableName,
+		sqlizer{sql: "body = ?", args: []interface{}{"a"}}, notes.Select.Placeholder(), notes.Select.OrderBy)
+
+	// the tenant and the Sqlizer args are both inserted
+	var ids []int64
+	assert.NoError(sel.Select(sqlf.ForTenant(sqlf.ContextWithTenant(context.Background(), int64(1)), db), &ids, 0))
+	assert.Equal([]int64{1}, ids)
+}
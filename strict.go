@@ -0,0 +1,78 @@
+package sqlf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WithStrictScan causes a query command to check that every field of the
+// destination struct receives a value from a column in the result set.
+// If any field has no column, the scan fails with an error that lists the
+// fields. (A column in the result set that has no destination field is
+// always an error.) Fields tagged `sql:"-"` or `db:"-"`, and fields that
+// hold the rows of related tables, are not checked.
+//
+// Strict scanning is useful for detecting drift between the row structs
+// and the database schema in tests, where a missing column would
+// otherwise leave a field silently set to its zero value.
+func WithStrictScan() Option {
+	return func(o *options) {
+		o.strictScan = true
+	}
+}
+
+// checkUnscanned returns an error if any of the fields of struct type t
+// is not the destination of one of the traversals.
+func checkUnscanned(t reflect.Type, traversals []traversal) error {
+	scanned := make(map[string]bool, len(traversals))
+	for _, tr := range traversals {
+		scanned[fmt.Sprint(tr.index)] = true
+	}
+	var missing []string
+	var walk func(t reflect.Type, index []int, prefix string)
+	walk = func(t reflect.Type, index []int, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			if _, ok := parseTagSetting(field.Tag)["-"]; ok || field.Tag.Get("db") == "-" {
+				continue
+			}
+			fieldIndex := append(append([]int(nil), index...), i)
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isScannable(ft) {
+				walk(ft, fieldIndex, prefix+field.Name+".")
+				continue
+			}
+			if isRelationType(ft) {
+				continue
+			}
+			if !scanned[fmt.Sprint(fieldIndex)] {
+				missing = append(missing, prefix+field.Name)
+			}
+		}
+	}
+	walk(t, nil, t.Name()+".")
+	if len(missing) > 0 {
+		return fmt.Errorf("no column in result set for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// isRelationType reports whether t is a slice of structs (or pointers
+// to structs), which holds the rows of a related table.
+func isRelationType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	elem := t.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct && !isScannable(elem)
+}
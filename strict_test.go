@@ -0,0 +1,61 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStrictScan(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	assert.NoError(ins.Exec(db, &Row1{GivenName: "John", FamilyName: "Citizen"}))
+
+	// all fields scanned
+	all := sqlf.Queryf("select %s from %s", tbl.Select.Columns, tbl.Select.TableName, sqlf.WithStrictScan())
+	var rows []Row1
+	assert.NoError(all.Select(db, &rows))
+	assert.Len(rows, 1)
+
+	// a field with no column
+	some := sqlf.Queryf("select %s from %s", tbl.Select.Columns.Include("Id", "GivenName"), tbl.Select.TableName, sqlf.WithStrictScan())
+	err = some.Select(db, &rows)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "no column in result set for Row1.FamilyName, Row1.DOB")
+	}
+	var row Row1
+	assert.Error(some.Get(db, &row))
+	assert.Error(some.QueryRow(db).StructScan(&row))
+
+	// without strict scanning the missing fields are ignored
+	lax := sqlf.Queryf("select %s from %s", tbl.Select.Columns.Include("Id", "GivenName"), tbl.Select.TableName)
+	assert.NoError(lax.Get(db, &row))
+
+	// a struct that matches the columns selected
+	type Name struct {
+		ID        int64
+		GivenName string
+		Extra     []Row1
+		Ignored   string `db:"-"`
+	}
+	names := sqlf.Queryf("select id, given_name from %s", tbl.Select.TableName,
+		sqlf.WithMapperFunc(sqlf.ToDBName), sqlf.WithStrictScan())
+	var rows2 []Name
+	assert.NoError(names.Select(db, &rows2))
+	assert.Equal("John", rows2[0].GivenName)
+
+	// a column with no field is always an error
+	extra := sqlf.Queryf("select id, given_name, family_name from %s", tbl.Select.TableName, sqlf.WithMapperFunc(sqlf.ToDBName))
+	assert.Error(extra.Select(db, &rows2))
+}
@@ -0,0 +1,92 @@
+package sqlf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// fieldNameMapper finds struct fields by their Go field name.
+var fieldNameMapper = reflectx.NewMapperFunc("", func(name string) string { return name })
+
+// encodeArg converts a field value into the argument
+// value passed to the database driver for the column.
+func (ci *columnInfo) encodeArg(ctx context.Context, value interface{}) (interface{}, error) {
+	if ci.times != nil {
+		value = ci.times.normalizeArg(value)
+	}
+	if ci.codec != nil {
+		arg, err := encode(ctx, ci.codec, value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode %s: %v", ci.columnName, err)
+		}
+		return arg, nil
+	}
+	if ci.array {
+		return arrayValue{dialect: ci.table.Dialect(), value: value}, nil
+	}
+	return value, nil
+}
+
+// structArgs extracts the argument values for the inputs from arg, which
+// is a struct, a pointer to a struct, or a map with string keys.
+//
+// If arg is the row type of the column's table, the value is obtained
+// from the column's field. For any other struct the value is obtained
+// from the field with the same name as the column's field. For a map the
+// value is obtained using the column name as the key, or if not present,
+// the field name. The tenant column is skipped, see TenantScope, as are
+// the args of any Sqlizer. Codecs encode the values using ctx.
+func structArgs(ctx context.Context, inputs []positioner, arg interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.New("cannot obtain args from a nil pointer")
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot obtain args from %s: map key must be a string", v.Type())
+		}
+	default:
+		return nil, fmt.Errorf("cannot obtain args from %s: expected a struct or map", v.Type())
+	}
+
+	args := make([]interface{}, 0, len(inputs))
+	for i, input := range inputs {
+		if _, ok := fixedInput(input); ok || isTenantInput(input) {
+			continue
+		}
+		ci, ok := input.(*columnInfo)
+		if !ok {
+			return nil, fmt.Errorf("cannot obtain arg %d from %s: placeholder has no column", i+1, v.Type())
+		}
+		var value reflect.Value
+		if v.Kind() == reflect.Map {
+			keyType := v.Type().Key()
+			value = v.MapIndex(reflect.ValueOf(ci.columnName).Convert(keyType))
+			if !value.IsValid() {
+				value = v.MapIndex(reflect.ValueOf(ci.fieldName).Convert(keyType))
+			}
+		} else if v.Type() == ci.table.rowType {
+			value = reflectx.FieldByIndexesReadOnly(v, ci.fields)
+		} else if fi := fieldNameMapper.TypeMap(v.Type()).GetByPath(ci.fieldName); fi != nil {
+			value = reflectx.FieldByIndexesReadOnly(v, fi.Index)
+		}
+		if !value.IsValid() {
+			return nil, fmt.Errorf("cannot obtain arg %d from %s: no value for column %s", i+1, v.Type(), ci.columnName)
+		}
+		arg, err := ci.encodeArg(ctx, value.Interface())
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
@@ -0,0 +1,76 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructArgs(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table2(user_id integer, search_term text, primary key(user_id, search_term));
+		insert into table2 values(1, 'one'), (1, 'two'), (2, 'two')`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row2Table.WithDialect(sqlf.DialectSQLite)
+
+	query := sqlf.Queryf("select %s from %s where %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Update.WhereColumns)
+	assert.Equal("select `user_id`,`search_term` from `table2` where `user_id`=? and `search_term`=?", query.Command())
+
+	selectAll := func(arg interface{}) ([]Row2, error) {
+		rows, err := query.QueryStruct(db, arg)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var result []Row2
+		return result, query.SelectRows(rows, &result)
+	}
+
+	// the row type of the table
+	result, err := selectAll(&Row2{UserId: 1, SearchTerm: "two"})
+	assert.NoError(err)
+	assert.Equal([]Row2{{1, "two"}}, result)
+
+	// an ad-hoc struct with fields of the same name
+	result, err = selectAll(struct {
+		SearchTerm string
+		UserId     int64
+	}{"one", 1})
+	assert.NoError(err)
+	assert.Equal([]Row2{{1, "one"}}, result)
+
+	// a map keyed by column name, or field name
+	result, err = selectAll(map[string]interface{}{"user_id": 2, "SearchTerm": "two"})
+	assert.NoError(err)
+	assert.Equal([]Row2{{2, "two"}}, result)
+
+	_, err = selectAll(map[string]interface{}{"user_id": 2})
+	assert.EqualError(err, "cannot obtain arg 2 from map[string]interface {}: no value for column search_term")
+	_, err = selectAll(struct{ UserId int }{1})
+	assert.Error(err)
+	_, err = selectAll(1)
+	assert.EqualError(err, "cannot obtain args from int: expected a struct or map")
+	_, err = selectAll((*Row2)(nil))
+	assert.Error(err)
+
+	del := sqlf.Execf("delete from %s where %s", tbl.Delete.TableName, tbl.Update.WhereColumns)
+	result2, err := del.ExecStruct(db, Row2{UserId: 1, SearchTerm: "one"})
+	if assert.NoError(err) {
+		n, _ := result2.RowsAffected()
+		assert.Equal(int64(1), n)
+	}
+
+	// placeholders without a column cannot be used
+	del = sqlf.Execf("delete from %s where user_id = %s", tbl.Delete.TableName, tbl.Delete.Placeholder())
+	_, err = del.ExecStruct(db, Row2{UserId: 1})
+	assert.EqualError(err, "cannot obtain arg 1 from sqlf_test.Row2: placeholder has no column")
+}
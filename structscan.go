@@ -0,0 +1,461 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// outputName returns the name of the column in the result set.
+func (ci *columnInfo) outputName() string {
+	if ci.hasColumnAlias() {
+		return ci.columnAlias()
+	}
+	return ci.columnName
+}
+
+// isScannable returns true if values of type t are scanned directly,
+// rather than being treated as a struct with one field per column.
+func isScannable(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(sqlScanType) {
+		return true
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	if t == timeType {
+		return true
+	}
+	return false
+}
+
+// traversals returns the index path of the field in a struct of type t
+// for each of the columns in the result set.
+//
+// Columns are matched using the table information for the selected columns,
+// rather than by field name alone. This allows a query to select columns
+// from more than one table where the tables have identically named fields
+// (eg "ID"), which is common for joins. The destination struct can contain
+// one field (or embedded struct) for each table, and the columns for each
+// table are scanned into the field with the matching type. Where more than
+// one field has the matching type, a field whose name matches the table alias
+// is preferred, otherwise the fields are used in order.
+//
+// Any column that does not correspond to a selected ColumnList is matched by
+// name using the command's mapper. Where columns of more than one table have
+// the same name, the command selects them using generated aliases (see
+// aliasDuplicateColumns), so it is only an error if the aliases still clash.
+func (cmd *queryCommand) traversals(t reflect.Type, columns []string) ([]traversal, error) {
+	t = reflectx.Deref(t)
+	byName := make(map[string]*columnInfo)
+	for _, ci := range cmd.columns {
+		name := ci.outputName()
+		if prev, ok := byName[name]; ok && prev != ci {
+			// the columns cannot be told apart in the result set
+			return nil, fmt.Errorf("column %s is selected from tables %s and %s: use a table alias (see TableInfo.WithAlias)",
+				name, prev.table.Name, ci.table.Name)
+		}
+		byName[name] = ci
+	}
+
+	var candidates []structPath
+	if t.Kind() == reflect.Struct {
+		candidates = structPaths(t)
+	}
+	used := make(map[int]bool)
+	prefixes := make(map[*TableInfo][]int)
+	prefixFor := func(ti *TableInfo) ([]int, bool) {
+		if prefix, ok := prefixes[ti]; ok {
+			return prefix, prefix != nil
+		}
+		found := -1
+		for i, c := range candidates {
+			if !used[i] && c.typ == ti.rowType && ti.alias != "" && strings.EqualFold(c.name, ti.alias) {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			for i, c := range candidates {
+				if !used[i] && c.typ == ti.rowType {
+					found = i
+					break
+				}
+			}
+		}
+		if found < 0 {
+			prefixes[ti] = nil
+			return nil, false
+		}
+		used[found] = true
+		prefix := candidates[found].index
+		if prefix == nil {
+			prefix = []int{}
+		}
+		prefixes[ti] = prefix
+		return prefix, true
+	}
+
+	mapper, err := cmd.getMapper()
+	if err != nil {
+		return nil, err
+	}
+
+	var traversals []traversal
+	for _, column := range columns {
+		if ci, ok := byName[column]; ok {
+			if prefix, ok := prefixFor(ci.table); ok {
+				index := make([]int, 0, len(prefix)+len(ci.fields))
+				index = append(index, prefix...)
+				index = append(index, ci.fields...)
+				traversals = append(traversals, traversal{index: index, column: ci})
+				continue
+			}
+		}
+		index := mapper.TraversalsByName(t, []string{column})[0]
+		if len(index) == 0 {
+			return nil, fmt.Errorf("missing destination name %s in %s", column, t)
+		}
+		traversals = append(traversals, traversal{index: index})
+	}
+	if cmd.opts.strictScan && t.Kind() == reflect.Struct {
+		if err := checkUnscanned(t, traversals); err != nil {
+			return nil, err
+		}
+	}
+	return traversals, nil
+}
+
+// traversal describes how to scan a column in the result set into
+// a field of the destination struct.
+type traversal struct {
+	index  []int       // index path of the field
+	column *columnInfo // column information, nil if matched by name only
+}
+
+// structPath is the location of a struct within a destination struct.
+type structPath struct {
+	name   string
+	typ    reflect.Type
+	index  []int
+	parent int // index of parent in list of paths, -1 for none
+}
+
+// structPaths returns the location of every struct within t (including
+// t itself) that could hold the columns of a table, in breadth-first order.
+func structPaths(t reflect.Type) []structPath {
+	paths := []structPath{{typ: t, parent: -1}}
+	isRecursive := func(i int, ft reflect.Type) bool {
+		for ; i >= 0; i = paths[i].parent {
+			if paths[i].typ == ft {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(paths); i++ {
+		p := paths[i]
+		for j := 0; j < p.typ.NumField(); j++ {
+			field := p.typ.Field(j)
+			if len(field.PkgPath) != 0 && !field.Anonymous {
+				continue
+			}
+			ft := reflectx.Deref(field.Type)
+			if ft.Kind() != reflect.Struct || isScannable(ft) || isRecursive(i, ft) {
+				continue
+			}
+			index := make([]int, len(p.index)+1)
+			copy(index, p.index)
+			index[len(p.index)] = j
+			paths = append(paths, structPath{
+				name:   field.Name,
+				typ:    ft,
+				index:  index,
+				parent: i,
+			})
+		}
+	}
+	return paths
+}
+
+// scanStruct scans the current row into the struct pointed to by dest.
+// Codecs decode the column values using ctx.
+func scanStruct(ctx context.Context, scan func(dest ...interface{}) error, v reflect.Value, traversals []traversal) error {
+	v = reflect.Indirect(v)
+	values := make([]interface{}, len(traversals))
+	for i, t := range traversals {
+		field := reflectx.FieldByIndexes(v, t.index)
+		if t.column != nil && t.column.codec != nil {
+			values[i] = &codecScanner{ctx: ctx, codec: t.column.codec, column: t.column, field: field}
+		} else if t.column != nil && t.column.array {
+			values[i] = &arrayScanner{column: t.column, field: field}
+		} else {
+			values[i] = field.Addr().Interface()
+		}
+	}
+	if err := scan(values...); err != nil {
+		return err
+	}
+	for _, t := range traversals {
+		if t.column != nil && t.column.times != nil {
+			t.column.times.normalizeField(reflectx.FieldByIndexesReadOnly(v, t.index))
+		}
+	}
+	return nil
+}
+
+// AfterScanner is implemented by row types that need to perform some
+// processing after they have been scanned from the database, such as
+// calculating derived fields or decrypting sensitive fields. The AfterScan
+// method is called by Select and Row.StructScan for each row scanned,
+// with the context of the handle used to execute the query (see
+// ForContext), or the background context if it has none.
+// If AfterScan returns an error, no more rows are scanned and the error
+// is returned.
+type AfterScanner interface {
+	AfterScan(ctx context.Context) error
+}
+
+// afterScan calls the AfterScan method if v, which is a pointer,
+// implements AfterScanner.
+func afterScan(ctx context.Context, v reflect.Value) error {
+	if as, ok := v.Interface().(AfterScanner); ok {
+		return as.AfterScan(ctx)
+	}
+	return nil
+}
+
+// Rows is the interface used to read the rows of a result set.
+// The *sql.Rows and *sqlx.Rows types implement Rows, and adapters
+// for other database interfaces can implement it too.
+type Rows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+}
+
+func (cmd *queryCommand) Select(db sqlx.Queryer, dest interface{}, args ...interface{}) (err error) {
+	m, entry := cmd.memoEntry(db, OpSelect, dest, args)
+	if m != nil {
+		if m.load(entry, dest) {
+			return nil
+		}
+		n := reflect.Indirect(reflect.ValueOf(dest)).Len()
+		defer func() {
+			if err == nil {
+				m.store(entry, dest, n)
+			}
+		}()
+	}
+	start := time.Now()
+	rowCount := int64(-1)
+	defer func() {
+		cmd.opts.report(contextOf(db), OpSelect, cmd.command, start, rowCount, err)
+	}()
+	cmd.opts.debugBindings(OpSelect, cmd.command, cmd.inputs, args)
+	rows, cancel, err := cmd.opts.query(db, cmd.queryFor(db), args)
+	if err != nil {
+		return cmd.opts.wrapError(OpSelect, cmd.command, args, err)
+	}
+	defer cancel()
+	defer rows.Close()
+	counter := &countingRows{Rows: rows}
+	err = cmd.selectRows(contextOf(db), counter, dest)
+	if err == nil {
+		rowCount = counter.count
+	}
+	return err
+}
+
+// ErrTooManyRows is returned by QueryCommand.Get when the
+// query returns more than one row.
+var ErrTooManyRows = errors.New("sqlf: query returned more than one row")
+
+func (cmd *queryCommand) Get(db sqlx.Queryer, dest interface{}, args ...interface{}) (err error) {
+	m, entry := cmd.memoEntry(db, OpGet, dest, args)
+	if m != nil {
+		if m.load(entry, dest) {
+			return nil
+		}
+		defer func() {
+			if err == nil {
+				m.store(entry, dest, 0)
+			}
+		}()
+	}
+	start := time.Now()
+	rowCount := int64(-1)
+	defer func() {
+		cmd.opts.report(contextOf(db), OpGet, cmd.command, start, rowCount, err)
+	}()
+	cmd.opts.debugBindings(OpGet, cmd.command, cmd.inputs, args)
+	rows, cancel, err := cmd.opts.query(db, cmd.queryFor(db), args)
+	if err != nil {
+		return cmd.opts.wrapError(OpGet, cmd.command, args, err)
+	}
+	defer cancel()
+	defer rows.Close()
+	err = cmd.getRow(contextOf(db), rows, dest)
+	if err == nil {
+		rowCount = 1
+	}
+	return err
+}
+
+// getRow scans the only row in rows into dest.
+func (cmd *queryCommand) getRow(ctx context.Context, rows Rows, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("must pass a non-nil pointer to Get")
+	}
+	base := value.Type().Elem()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	scannable := isScannable(base)
+	var traversals []traversal
+	if scannable {
+		if len(columns) != 1 {
+			return fmt.Errorf("non-struct dest type %s with >1 columns (%d)", base, len(columns))
+		}
+	} else {
+		traversals, err = cmd.traversals(base, columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if scannable {
+		err = rows.Scan(dest)
+	} else {
+		err = scanStruct(ctx, rows.Scan, value, traversals)
+	}
+	if err != nil {
+		return err
+	}
+	if err := afterScan(ctx, value); err != nil {
+		return err
+	}
+	if rows.Next() {
+		return ErrTooManyRows
+	}
+	return rows.Err()
+}
+
+// countingRows counts the number of rows read from a result set.
+type countingRows struct {
+	Rows
+	count int64
+}
+
+func (r *countingRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	return false
+}
+
+func (cmd *queryCommand) SelectRows(rows Rows, dest interface{}) error {
+	return cmd.selectRows(context.Background(), rows, dest)
+}
+
+func (cmd *queryCommand) selectRows(ctx context.Context, rows Rows, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("must pass a non-nil pointer to Select")
+	}
+	direct := reflect.Indirect(value)
+	if direct.Kind() != reflect.Slice {
+		return fmt.Errorf("expected slice but got %s", direct.Type())
+	}
+	slice := direct.Type()
+	isPtr := slice.Elem().Kind() == reflect.Ptr
+	base := reflectx.Deref(slice.Elem())
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if slice.Elem().Kind() == reflect.Interface {
+		if ti := cmd.variantTable(); ti != nil {
+			return cmd.selectVariants(ctx, rows, columns, ti, direct)
+		}
+	}
+
+	var traversals []traversal
+	scannable := isScannable(base)
+	if scannable {
+		if len(columns) != 1 {
+			return fmt.Errorf("non-struct dest type %s with >1 columns (%d)", base, len(columns))
+		}
+	} else {
+		traversals, err = cmd.traversals(base, columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	for rows.Next() {
+		vp := reflect.New(base)
+		if scannable {
+			err = rows.Scan(vp.Interface())
+		} else {
+			err = scanStruct(ctx, rows.Scan, vp, traversals)
+		}
+		if err != nil {
+			return err
+		}
+		if err := afterScan(ctx, vp); err != nil {
+			return err
+		}
+		if isPtr {
+			direct.Set(reflect.Append(direct, vp))
+		} else {
+			direct.Set(reflect.Append(direct, reflect.Indirect(vp)))
+		}
+	}
+	return rows.Err()
+}
+
+// structScan scans a single row into dest using the column information
+// of the query command, passing ctx to codecs and AfterScan.
+func (cmd *queryCommand) structScan(ctx context.Context, row *sqlx.Row, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("must pass a non-nil pointer to StructScan")
+	}
+	if err := row.Err(); err != nil {
+		return err
+	}
+	columns, err := row.Columns()
+	if err != nil {
+		return err
+	}
+	traversals, err := cmd.traversals(v.Type(), columns)
+	if err != nil {
+		// the row must be closed, which Scan will do
+		row.Scan()
+		return err
+	}
+	if err := scanStruct(ctx, row.Scan, v, traversals); err != nil {
+		return err
+	}
+	return afterScan(ctx, v)
+}
@@ -0,0 +1,290 @@
+package sqlf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoTenant is returned when a command that refers to a table with a
+// tenant column is executed without a tenant. See TenantScope.
+var ErrNoTenant = errors.New("sqlf: command requires a tenant")
+
+// TenantScope is a database handle that executes commands on behalf of
+// a single tenant, in a database shared by many tenants. Each table that
+// holds rows for more than one tenant has a column tagged "tenant":
+//
+//	type Order struct {
+//		ID       int64
+//		TenantID int64 `sql:"tenant"`
+//		Item     string
+//	}
+//
+// Commands that refer to the table are restricted to the rows of the
+// tenant, without any change to the SQL written by the programmer:
+//
+//   - In the FROM clause of a query, Select.TableName refers only to the
+//     rows of the tenant, for example "(select * from orders where
+//     tenant_id = ?) as orders".
+//   - Update.WhereColumns includes the tenant column, so that updates only
+//     apply to the rows of the tenant.
+//   - When inserting or updating a row, the value of the tenant column
+//     is always the tenant, regardless of the value of the field in the row.
+//
+// The tenant is bound to these placeholders when the command is executed
+// using a TenantScope. The arguments for the tenant placeholders are not
+// passed to commands built using Execf or Queryf, and the tenant field is
+// ignored by the ExecStruct and QueryStruct methods. Executing a command
+// with any other handle fails with ErrNoTenant, so that forgetting to
+// restrict a command to the tenant is an error that is detected rather
+// than a security problem. Use WithoutTenant for commands that must
+// access the rows of all tenants.
+//
+// A TenantScope is usually created for each request, using the tenant
+// stored in the request's context with ContextWithTenant:
+//
+//	db := sqlf.ForTenant(ctx, pool)
+//	err := listOrders.Select(db, &orders)
+//
+// A TenantScope executes commands using ctx in the same way as a ContextDB.
+// It cannot begin a transaction. To use a transaction, begin it using the
+// underlying handle and then call ForTenant with the transaction.
+type TenantScope struct {
+	ContextDB
+	tenant interface{}
+	ok     bool
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx that contains the tenant,
+// for use with ForTenant.
+func ContextWithTenant(ctx context.Context, tenant interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored in ctx using
+// ContextWithTenant, and false if there is none.
+func TenantFromContext(ctx context.Context) (interface{}, bool) {
+	tenant := ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// ForTenant returns a handle that executes commands using db for the
+// tenant stored in ctx. If ctx does not contain a tenant, commands that
+// refer to a table with a tenant column fail with ErrNoTenant.
+func ForTenant(ctx context.Context, db sqlx.Ext) *TenantScope {
+	tenant, ok := TenantFromContext(ctx)
+	return &TenantScope{ContextDB: ContextDB{Ext: db, ctx: ctx}, tenant: tenant, ok: ok}
+}
+
+// Tenant returns the tenant of the scope, and false if there is none.
+func (ts *TenantScope) Tenant() (interface{}, bool) {
+	return ts.tenant, ts.ok
+}
+
+// WithoutTenant causes a command to ignore the tenant column of the tables
+// it refers to, so that it can access the rows of all tenants. It is the
+// escape hatch for administrative commands, and should be used sparingly.
+// See TenantScope.
+func WithoutTenant() Option {
+	return func(o *options) {
+		o.withoutTenant = true
+	}
+}
+
+// tenantInput is the placeholder for the tenant in the FROM clause
+// of a query.
+type tenantInput struct {
+	position int
+}
+
+func (ti *tenantInput) setPosition(n int) {
+	ti.position = n
+}
+
+// tenantBinding records the arguments of a command that are bound
+// to the tenant. The args for the placeholders of commands built using
+// Execf and Queryf do not include the tenant, so it is inserted. The args
+// for a row include the tenant field, which is replaced. The args of any
+// Sqlizer embedded in the command have already been inserted, see
+// argBinding.
+type tenantBinding struct {
+	inserted map[int]bool // arg index of each inserted tenant arg
+	replaced map[int]bool // arg index of each replaced tenant arg
+}
+
+// isTenantInput reports whether the input is a placeholder for the
+// tenant whose arg is inserted when the command is executed.
+func isTenantInput(input positioner) bool {
+	switch input := input.(type) {
+	case *tenantInput:
+		return true
+	case *columnInfo:
+		return input.tenant && !input.table.withoutTenant
+	}
+	return false
+}
+
+// newTenantBinding returns the tenant binding for the inputs of a command,
+// or nil if none of the inputs are bound to the tenant. The inputs are
+// []positioner or []*columnInfo, in placeholder order.
+func newTenantBinding(inputs interface{}) *tenantBinding {
+	tb := &tenantBinding{
+		inserted: map[int]bool{},
+		replaced: map[int]bool{},
+	}
+	switch inputs := inputs.(type) {
+	case []positioner:
+		for i, input := range inputs {
+			if isTenantInput(input) {
+				tb.inserted[i] = true
+			}
+		}
+	case []*columnInfo:
+		for i, ci := range inputs {
+			if ci.tenant && !ci.table.withoutTenant {
+				tb.replaced[i] = true
+			}
+		}
+	}
+	if len(tb.inserted) == 0 && len(tb.replaced) == 0 {
+		return nil
+	}
+	return tb
+}
+
+// bind returns the args with the tenant inserted and replaced.
+func (tb *tenantBinding) bind(tenant interface{}, args []interface{}) []interface{} {
+	bound := make([]interface{}, 0, len(args)+tb.numInserted())
+	next := 0
+	for i := 0; next < len(args) || tb.inserted[i]; i++ {
+		switch {
+		case tb.inserted[i]:
+			bound = append(bound, tenant)
+		case tb.replaced[i]:
+			bound = append(bound, tenant)
+			next++
+		default:
+			bound = append(bound, args[next])
+			next++
+		}
+	}
+	return bound
+}
+
+// numInserted returns the number of args inserted by bind.
+func (tb *tenantBinding) numInserted() int {
+	if tb == nil {
+		return 0
+	}
+	return len(tb.inserted)
+}
+
+// needsTenant reports whether any args are bound to the tenant.
+func (tb *tenantBinding) needsTenant() bool {
+	return len(tb.inserted) > 0 || len(tb.replaced) > 0
+}
+
+// bindTenant returns the handle and args for executing a command. If db
+// is a TenantScope or a ContextDB, the underlying handle is returned, and
+// for a TenantScope the tenant is bound to the args. It is an error if the
+// command has arguments bound to the tenant and there is no tenant.
+func (o options) bindTenant(db interface{}, args []interface{}) (interface{}, []interface{}, error) {
+	var tenant interface{}
+	var ok bool
+	if ts, isScope := db.(*TenantScope); isScope {
+		tenant, ok = ts.tenant, ts.ok
+	}
+	args, err := o.bindTenantValue(tenant, ok, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return unwrapHandle(db), args, nil
+}
+
+// bindTenantValue returns the args with the tenant bound, where ok reports
+// whether there is a tenant. It is an error if the command has arguments
+// bound to the tenant and there is no tenant.
+func (o options) bindTenantValue(tenant interface{}, ok bool, args []interface{}) ([]interface{}, error) {
+	if o.tenant == nil {
+		return args, nil
+	}
+	if o.tenant.needsTenant() && !ok {
+		return nil, ErrNoTenant
+	}
+	return o.tenant.bind(tenant, args), nil
+}
+
+// tenantColumn returns the tenant column of the table, or nil if it has
+// none, or if the table ignores the tenant column.
+func (ti *TableInfo) tenantColumn() *columnInfo {
+	if ti.withoutTenant {
+		return nil
+	}
+	for _, ci := range ti.columns {
+		if ci.tenant {
+			return ci
+		}
+	}
+	return nil
+}
+
+// checkTenantColumns panics if the table has more than one tenant
+// column, in the same way that Table panics for an invalid row type.
+func (ti *TableInfo) checkTenantColumns() {
+	var found *columnInfo
+	for _, ci := range ti.columns {
+		if ci.tenant {
+			if found != nil {
+				panic(fmt.Sprintf("sqlf.Table: table %s has more than one tenant column", ti.Name))
+			}
+			found = ci
+		}
+	}
+}
+
+// checkTenantInputs returns an error if the args of a command update or
+// delete the rows of a table with a tenant column, or insert rows using
+// the table's Insert.Values, without binding the tenant column.
+func checkTenantInputs(args []interface{}) error {
+	bound := make(map[*TableInfo]bool)
+	values := make(map[*TableInfo]bool)
+	for _, arg := range args {
+		if cil, ok := arg.(ColumnList); ok && cil.clause.isInput() {
+			if cil.clause == clauseInsertValues {
+				values[cil.table] = true
+			}
+			for _, ci := range cil.filtered() {
+				if ci.tenant {
+					bound[cil.table] = true
+				}
+			}
+		}
+	}
+	for _, arg := range args {
+		tn, ok := arg.(TableName)
+		if !ok || bound[tn.table] {
+			continue
+		}
+		tc := tn.table.tenantColumn()
+		if tc == nil {
+			continue
+		}
+		switch tn.clause {
+		case clauseUpdateTable, clauseDeleteTable:
+		case clauseInsertInto:
+			if !values[tn.table] {
+				// eg insert ... select, where the query supplies the tenant
+				continue
+			}
+		default:
+			continue
+		}
+		return fmt.Errorf("table %s: tenant column %s is not bound to the tenant (see TenantScope)",
+			tn.table.Name, tc.columnName)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package sqlf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantScope(t *testing.T) {
+	assert := assert.New(t)
+
+	type Order struct {
+		ID       int64 `sql:"primary key"`
+		TenantID int64 `sql:"tenant"`
+		Item     string
+	}
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("create table orders(id integer primary key, tenant_id integer, item text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl := sqlf.Table("orders", Order{}).WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	del := sqlf.Execf("delete from %s where %s", tbl.Delete.TableName, tbl.Update.WhereColumns)
+	sel := sqlf.Queryf("select %s from %s where id > ? order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy)
+	all := sqlf.Queryf("select %s from %s order by %s", tbl.Select.Columns, tbl.Select.TableName, tbl.Select.OrderBy, sqlf.WithoutTenant())
+
+	assert.Equal("update `orders` set `item`=? where `id`=? and `tenant_id`=?", upd.Command())
+	assert.Equal("select `id`,`tenant_id`,`item` from (select * from `orders` where `tenant_id` = ?) as `orders` where id > ? order by `id`", sel.Command())
+	assert.Equal("select `id`,`tenant_id`,`item` from `orders` order by `id`", all.Command())
+
+	db1 := sqlf.ForTenant(sqlf.ContextWithTenant(context.Background(), int64(1)), db)
+	db2 := sqlf.ForTenant(sqlf.ContextWithTenant(context.Background(), int64(2)), db)
+
+	// the tenant column is set from the scope, not the row
+	assert.NoError(ins.Exec(db1, &Order{ID: 1, TenantID: 2, Item: "one"}))
+	assert.NoError(ins.Exec(db1, &Order{ID: 2, Item: "two"}))
+	assert.NoError(ins.Exec(db2, &Order{ID: 3, Item: "three"}))
+
+	var orders []Order
+	assert.NoError(sel.Select(db1, &orders, 0))
+	assert.Equal([]Order{{1, 1, "one"}, {2, 1, "two"}}, orders)
+
+	// rows of other tenants are not updated or deleted
+	n, err := upd.Exec(db2, &Order{ID: 1, Item: "changed"})
+	assert.NoError(err)
+	assert.Equal(0, n)
+	result, err := del.Exec(db2, 2)
+	assert.NoError(err)
+	count, _ := result.RowsAffected()
+	assert.Equal(int64(0), count)
+	result, err = del.Exec(db1, 2)
+	assert.NoError(err)
+	count, _ = result.RowsAffected()
+	assert.Equal(int64(1), count)
+
+	// admin queries see all tenants
+	orders = nil
+	assert.NoError(all.Select(db, &orders))
+	assert.Equal([]Order{{1, 1, "one"}, {3, 2, "three"}}, orders)
+
+	// commands are not executed without a tenant
+	err = sel.Select(db, &orders, 0)
+	assert.True(errors.Is(err, sqlf.ErrNoTenant), "%v", err)
+	err = sel.Select(sqlf.ForTenant(context.Background(), db), &orders, 0)
+	assert.True(errors.Is(err, sqlf.ErrNoTenant), "%v", err)
+	err = ins.Exec(db, &Order{ID: 4})
+	assert.True(errors.Is(err, sqlf.ErrNoTenant), "%v", err)
+}
+
+func TestTenantNotBound(t *testing.T) {
+	type Order struct {
+		ID       int64
+		TenantID string `sql:"tenant"`
+	}
+	tbl := sqlf.Table("orders", Order{}).WithDialect(sqlf.DialectSQLite)
+
+	del := sqlf.Execf("delete from %s where id = ?", tbl.Delete.TableName)
+	_, err := del.Exec(nil, 1)
+	assert.EqualError(t, err, "table orders: tenant column tenant_id is not bound to the tenant (see TenantScope)")
+
+	del = sqlf.Execf("delete from %s where id = ?", tbl.Delete.TableName, sqlf.WithoutTenant())
+	assert.Equal(t, "delete from `orders` where id = ?", del.Command())
+
+	assert.Panics(t, func() {
+		type Twice struct {
+			ID int64
+			A  string `sql:"tenant"`
+			B  string `sql:"tenant"`
+		}
+		sqlf.Table("twice", Twice{})
+	})
+}
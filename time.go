@@ -0,0 +1,98 @@
+package sqlf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// timeSettings describes how the values of a time.Time column are
+// normalized. The settings are specified using the "utc" and "truncate"
+// field tags. For example:
+//
+//	type Event struct {
+//		ID       int64
+//		StartsAt time.Time  `sql:"utc;truncate:ms"`
+//		EndsAt   *time.Time `sql:"utc"`
+//	}
+//
+// With "utc", times are converted to UTC. With "truncate", times are
+// truncated to the stated precision, which is one of "s", "ms", "us" or
+// "ns", or any duration accepted by time.ParseDuration. Times are
+// normalized before they are passed to the database as arguments of row
+// commands, and after they are scanned from the database using
+// QueryCommand.Select and Row.StructScan. This means that a time read back
+// from the database compares equal to the time that was written, regardless
+// of the time zone of the database session, or the precision of the column.
+type timeSettings struct {
+	utc       bool
+	precision time.Duration
+}
+
+// parseTimeSettings returns the time settings for the field, or nil if the
+// field has none. It panics if the settings are invalid, in the same way
+// that Table panics for an invalid row type.
+func parseTimeSettings(field reflect.StructField, tagSettings map[string]string) *timeSettings {
+	_, utc := tagSettings["UTC"]
+	truncate, hasTruncate := tagSettings["TRUNCATE"]
+	if !utc && !hasTruncate {
+		return nil
+	}
+	if field.Type != timeType && field.Type != reflect.PtrTo(timeType) {
+		panic(fmt.Sprintf("sqlf.Table: field %s: utc and truncate apply only to time.Time fields", field.Name))
+	}
+	ts := &timeSettings{utc: utc}
+	if hasTruncate {
+		truncate = strings.TrimSpace(truncate)
+		precision, err := time.ParseDuration(truncate)
+		if err != nil {
+			// a unit without a number, eg "ms"
+			precision, err = time.ParseDuration("1" + truncate)
+		}
+		if err != nil || precision <= 0 {
+			panic(fmt.Sprintf("sqlf.Table: invalid truncate for field %s: %q", field.Name, truncate))
+		}
+		ts.precision = precision
+	}
+	return ts
+}
+
+// normalize returns t converted to UTC and truncated, as per the settings.
+func (ts *timeSettings) normalize(t time.Time) time.Time {
+	if ts.utc {
+		t = t.UTC()
+	}
+	if ts.precision > 0 {
+		t = t.Truncate(ts.precision)
+	}
+	return t
+}
+
+// normalizeArg normalizes the value of a time.Time or *time.Time field.
+func (ts *timeSettings) normalizeArg(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return ts.normalize(v)
+	case *time.Time:
+		if v == nil {
+			return v
+		}
+		t := ts.normalize(*v)
+		return &t
+	}
+	return value
+}
+
+// normalizeField normalizes the time.Time or *time.Time field in place.
+func (ts *timeSettings) normalizeField(field reflect.Value) {
+	switch v := field.Addr().Interface().(type) {
+	case *time.Time:
+		*v = ts.normalize(*v)
+	case **time.Time:
+		if *v != nil {
+			t := ts.normalize(**v)
+			*v = &t
+		}
+	}
+}
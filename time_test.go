@@ -0,0 +1,85 @@
+package sqlf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type Event struct {
+	ID       int64      `sql:"primary_key"`
+	StartsAt time.Time  `sql:"utc;truncate:ms"`
+	EndsAt   *time.Time `sql:"utc"`
+	Created  time.Time  `sql:"truncate:1s"`
+}
+
+func TestTimeNormalization(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table events(id integer primary key, starts_at datetime, ends_at datetime, created datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	events := sqlf.Table("events", Event{}).WithDialect(sqlf.DialectSQLite)
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		events.Insert.TableName, events.Insert.Columns, events.Insert.Values)
+	get := sqlf.Queryf("select %s from %s where id = ?", events.Select.Columns, events.Select.TableName)
+
+	zone := time.FixedZone("AEST", 10*60*60)
+	startsAt := time.Date(2020, 6, 1, 9, 30, 0, 123456789, zone)
+	endsAt := startsAt.Add(time.Hour)
+	created := time.Date(2020, 5, 1, 8, 0, 0, 999999999, time.UTC)
+	assert.NoError(insert.Exec(db, &Event{ID: 1, StartsAt: startsAt, EndsAt: &endsAt, Created: created}))
+
+	// the arguments are normalized before they are passed to the database
+	var s1, s2, s3 time.Time
+	assert.NoError(db.QueryRow("select starts_at, ends_at, created from events where id = 1").Scan(&s1, &s2, &s3))
+	assert.Equal(time.UTC, s1.Location())
+	assert.Equal(123000000, s1.Nanosecond())
+	assert.Equal(time.UTC, s2.Location())
+	assert.Equal(123456789, s2.Nanosecond())
+	assert.Equal(0, s3.Nanosecond())
+
+	// the time read back is equal to the normalized time that was written
+	var event Event
+	assert.NoError(get.QueryRow(db, 1).StructScan(&event))
+	assert.True(event.StartsAt.Equal(startsAt.Truncate(time.Millisecond)))
+	assert.Equal(time.UTC, event.StartsAt.Location())
+
+	// values are normalized when scanned
+	if _, err := db.Exec(`insert into events(id, starts_at, ends_at, created) values(2, ?, null, ?)`, startsAt, created); err != nil {
+		t.Fatal(err)
+	}
+	var events2 []Event
+	assert.NoError(get.Select(db, &events2, 2))
+	if assert.Len(events2, 1) {
+		assert.Equal(time.UTC, events2[0].StartsAt.Location())
+		assert.Equal(123000000, events2[0].StartsAt.Nanosecond())
+		assert.Nil(events2[0].EndsAt)
+		assert.Equal(0, events2[0].Created.Nanosecond())
+	}
+}
+
+func TestTimeSettingsInvalid(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() {
+		type Row struct {
+			ID   int64
+			Name string `sql:"utc"`
+		}
+		sqlf.Table("rows", Row{})
+	})
+	assert.Panics(func() {
+		type Row struct {
+			ID      int64
+			Created time.Time `sql:"truncate:fortnight"`
+		}
+		sqlf.Table("rows", Row{})
+	})
+}
@@ -0,0 +1,160 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TxOption is an option for Transact.
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	isolation sql.IsolationLevel
+	readOnly  bool
+	retries   int
+}
+
+// TxIsolation sets the isolation level of the transaction, such as
+// sql.LevelSerializable or sql.LevelRepeatableRead. The default is the
+// default isolation level of the database.
+func TxIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *txOptions) {
+		o.isolation = level
+	}
+}
+
+// TxReadOnly begins a read-only transaction.
+func TxReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.readOnly = true
+	}
+}
+
+// TxRetry causes the transaction to be retried up to n times if it fails
+// because of a serialization failure or a deadlock (see
+// IsSerializationFailure). The function passed to Transact is called
+// again for each attempt, so it must not have side effects outside of
+// the transaction. Each retry waits a little longer than the last.
+func TxRetry(n int) TxOption {
+	return func(o *txOptions) {
+		o.retries = n
+	}
+}
+
+// txOptionsBeginner is implemented by *sqlx.DB.
+type txOptionsBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// retryDelay is the wait before the first retry of a transaction.
+var retryDelay = 10 * time.Millisecond
+
+// Transact calls fn with a transaction begun using db, which is a *sqlx.DB
+// or a ClusterSession, or a ContextDB or a TenantScope for one. The handle
+// passed to fn has the same context, and for a TenantScope the same tenant,
+// as db. If fn returns nil the transaction is committed, otherwise it is
+// rolled back and the error from fn is returned.
+//
+// Money paths that need serializable transactions use:
+//
+//	err := sqlf.Transact(sqlf.ForContext(ctx, db), func(tx sqlx.Ext) error {
+//		// ... read and update balances using tx
+//	}, sqlf.TxIsolation(sql.LevelSerializable), sqlf.TxRetry(3))
+//
+// If db is a *sqlx.Tx, fn is called with db and the options are ignored,
+// so that functions using Transact can be called in a transaction.
+func Transact(db sqlx.Ext, fn func(tx sqlx.Ext) error, opts ...TxOption) error {
+	var o txOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch inner := unwrapHandle(db).(type) {
+	case *sqlx.Tx:
+		return fn(db)
+	case txOptionsBeginner:
+		ctx := contextOf(db)
+		txOpts := &sql.TxOptions{Isolation: o.isolation, ReadOnly: o.readOnly}
+		delay := retryDelay
+		for attempt := 0; ; attempt++ {
+			err := transact(ctx, inner, txOpts, db, fn)
+			if err == nil || attempt >= o.retries || !IsSerializationFailure(err) {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+	default:
+		return fmt.Errorf("Transact: cannot begin a transaction using %T", inner)
+	}
+}
+
+// transact makes one attempt at the transaction for Transact.
+func transact(ctx context.Context, b txOptionsBeginner, txOpts *sql.TxOptions, db sqlx.Ext, fn func(tx sqlx.Ext) error) error {
+	tx, err := b.BeginTxx(ctx, txOpts)
+	if err != nil {
+		return err
+	}
+	if err := fn(rewrapHandle(db, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if s, ok := b.(*ClusterSession); ok && !txOpts.ReadOnly {
+		s.MarkWrite()
+	}
+	return nil
+}
+
+// IsSerializationFailure reports whether err, or an error it wraps, is a
+// database driver error indicating that the transaction can be retried,
+// identified by the fields of its error type:
+//
+//	PostgreSQL  Code "40001" (serialization failure) or "40P01" (deadlock)
+//	MySQL       Number 1213 (deadlock) or 1205 (lock wait timeout)
+//	SQLite      Code 5 (busy) or 6 (locked)
+//	SQL Server  Number 1205 (deadlock victim)
+func IsSerializationFailure(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if driverSerializationFailure(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func driverSerializationFailure(err error) bool {
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	if code, ok := stringField(v, "Code"); ok {
+		return code == "40001" || code == "40P01"
+	}
+	if _, ok := intField(v, "ExtendedCode"); ok {
+		code, _ := intField(v, "Code")
+		return code == 5 || code == 6
+	}
+	if number, ok := intField(v, "Number"); ok {
+		return number == 1213 || number == 1205
+	}
+	return false
+}
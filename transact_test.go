@@ -0,0 +1,116 @@
+package sqlf_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// serializationError has the fields of a PostgreSQL driver error.
+type serializationError struct {
+	Code string
+}
+
+func (e *serializationError) Error() string {
+	return "could not serialize access due to concurrent update"
+}
+
+func TestTransact(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`create table accounts(id integer primary key, balance integer)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`insert into accounts values(1, 100), (2, 0)`); err != nil {
+		t.Fatal(err)
+	}
+	transfer := sqlf.Execf("update accounts set balance = balance + ? where id = ?")
+	balance := func(id int) int {
+		var n int
+		assert.NoError(sqlf.Queryf("select balance from accounts where id = ?").Get(db, &n, id))
+		return n
+	}
+
+	// committed
+	ctx := context.Background()
+	err = sqlf.Transact(sqlf.ForContext(ctx, db), func(tx sqlx.Ext) error {
+		if _, err := transfer.Exec(tx, -10, 1); err != nil {
+			return err
+		}
+		_, err := transfer.Exec(tx, 10, 2)
+		return err
+	}, sqlf.TxIsolation(sql.LevelSerializable))
+	assert.NoError(err)
+	assert.Equal(90, balance(1))
+	assert.Equal(10, balance(2))
+
+	// rolled back
+	errFailed := errors.New("failed")
+	err = sqlf.Transact(db, func(tx sqlx.Ext) error {
+		if _, err := transfer.Exec(tx, -10, 1); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.Equal(errFailed, err)
+	assert.Equal(90, balance(1))
+
+	// retried after a serialization failure
+	var attempts int
+	err = sqlf.Transact(db, func(tx sqlx.Ext) error {
+		attempts++
+		if _, err := transfer.Exec(tx, -10, 1); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return fmt.Errorf("transfer: %w", &serializationError{Code: "40001"})
+		}
+		return nil
+	}, sqlf.TxRetry(3))
+	assert.NoError(err)
+	assert.Equal(3, attempts)
+	assert.Equal(80, balance(1))
+
+	// retries exhausted
+	attempts = 0
+	err = sqlf.Transact(db, func(tx sqlx.Ext) error {
+		attempts++
+		return &serializationError{Code: "40P01"}
+	}, sqlf.TxRetry(1))
+	assert.True(sqlf.IsSerializationFailure(err))
+	assert.Equal(2, attempts)
+
+	// other errors are not retried
+	attempts = 0
+	err = sqlf.Transact(db, func(tx sqlx.Ext) error {
+		attempts++
+		return &serializationError{Code: "23505"}
+	}, sqlf.TxRetry(3))
+	assert.False(sqlf.IsSerializationFailure(err))
+	assert.Equal(1, attempts)
+
+	// in an existing transaction
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sqlf.Transact(tx, func(inner sqlx.Ext) error {
+		assert.True(inner == tx)
+		_, err := transfer.Exec(inner, -10, 1)
+		return err
+	}, sqlf.TxReadOnly())
+	assert.NoError(err)
+	assert.NoError(tx.Rollback())
+	assert.Equal(80, balance(1))
+}
@@ -0,0 +1,301 @@
+package sqlf
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// UnitOfWork records the rows to be inserted, updated and deleted while
+// handling a request, and writes them to the database in one transaction
+// when Commit is called. This keeps the business logic free of database
+// access until the end of the request:
+//
+//	uow := sqlf.NewUnitOfWork()
+//	uow.Insert(orders, &order)
+//	for i := range lines {
+//		uow.Insert(orderLines, &lines[i])
+//	}
+//	uow.Delete(carts, &cart)
+//	if err := uow.Commit(db); err != nil {
+//		return err
+//	}
+//
+// The rows are written in dependency order, using the relationships
+// declared with HasMany and BelongsTo: rows are inserted and updated in
+// parent tables before child tables, and deleted from child tables before
+// parent tables. Otherwise the rows are written in the order recorded.
+//
+// The rows are recorded by reference, and their values are written when
+// Commit is called. A UnitOfWork is safe for concurrent use.
+type UnitOfWork struct {
+	mutex sync.Mutex
+	ops   []workOp
+}
+
+// workOp is a row recorded by a unit of work.
+type workOp struct {
+	kind  workKind
+	table *TableInfo
+	row   interface{}
+}
+
+type workKind int
+
+const (
+	workInsert workKind = iota
+	workUpdate
+	workDelete
+)
+
+// NewUnitOfWork returns a new, empty unit of work.
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{}
+}
+
+// Insert records a row to be inserted into the table. Row must be
+// a pointer to the row type of the table.
+func (u *UnitOfWork) Insert(ti *TableInfo, row interface{}) error {
+	return u.record(workInsert, ti, row)
+}
+
+// Update records a row to be updated in the table. Row must be a pointer
+// to the row type of the table. If the row does not exist when Commit is
+// called, Commit returns sql.ErrNoRows.
+func (u *UnitOfWork) Update(ti *TableInfo, row interface{}) error {
+	return u.record(workUpdate, ti, row)
+}
+
+// Delete records a row to be deleted from the table. Row must be
+// a pointer to the row type of the table.
+func (u *UnitOfWork) Delete(ti *TableInfo, row interface{}) error {
+	return u.record(workDelete, ti, row)
+}
+
+func (u *UnitOfWork) record(kind workKind, ti *TableInfo, row interface{}) error {
+	if _, err := rowValue(ti, row); err != nil {
+		return err
+	}
+	if _, err := commandsForSession(ti); err != nil {
+		return err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.ops = append(u.ops, workOp{kind: kind, table: ti, row: row})
+	return nil
+}
+
+// Len returns the number of rows recorded.
+func (u *UnitOfWork) Len() int {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return len(u.ops)
+}
+
+// Discard removes all of the recorded rows without writing them.
+func (u *UnitOfWork) Discard() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.ops = nil
+}
+
+// savepointCount is used to name the savepoints created by Commit.
+var savepointCount int64
+
+// Commit writes the recorded rows to the database. If db is a *sqlx.DB,
+// the rows are written in a transaction. If db is a *sqlx.Tx, they are
+// written inside a savepoint, so that if a row cannot be written, the
+// changes made by the unit of work are rolled back and the transaction
+// can continue. The handle can be a ContextDB or a TenantScope for either.
+//
+// When the rows have been written, the unit of work is empty. If an error
+// occurs, none of the rows are written, and they remain recorded.
+func (u *UnitOfWork) Commit(db sqlx.Ext) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	switch inner := unwrapHandle(db).(type) {
+	case txBeginner:
+		tx, err := inner.Beginx()
+		if err != nil {
+			return err
+		}
+		if err := u.flush(rewrapHandle(db, tx)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	case *sqlx.Tx:
+		sp := newSavepoint(inner)
+		if _, err := db.Exec(sp.save); err != nil {
+			return err
+		}
+		if err := u.flush(db); err != nil {
+			db.Exec(sp.rollback)
+			return err
+		}
+		if sp.release != "" {
+			if _, err := db.Exec(sp.release); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := u.flush(db); err != nil {
+			return err
+		}
+	}
+	u.ops = nil
+	return nil
+}
+
+// savepoint contains the statements for a savepoint.
+type savepoint struct {
+	save     string
+	rollback string
+	release  string // blank if savepoints are not released
+}
+
+// newSavepoint returns a uniquely named savepoint for the
+// dialect of the transaction.
+func newSavepoint(tx *sqlx.Tx) savepoint {
+	name := fmt.Sprintf("sqlf_uow_%d", atomic.AddInt64(&savepointCount, 1))
+	d := dialectForDriver(tx.DriverName())
+	if d == nil {
+		d = Default.dialect()
+	}
+	switch DialectName(d) {
+	case "mssql", "sqlserver":
+		return savepoint{
+			save:     "save transaction " + name,
+			rollback: "rollback transaction " + name,
+		}
+	}
+	return savepoint{
+		save:     "savepoint " + name,
+		rollback: "rollback to savepoint " + name,
+		release:  "release savepoint " + name,
+	}
+}
+
+// flush writes the recorded rows using db, in dependency order.
+func (u *UnitOfWork) flush(db sqlx.Ext) error {
+	for _, op := range u.ordered() {
+		cmds, err := commandsForSession(op.table)
+		if err != nil {
+			return err
+		}
+		switch op.kind {
+		case workInsert:
+			err = cmds.insert.Exec(db, op.row)
+		case workUpdate:
+			var n int
+			if n, err = cmds.update.Exec(db, op.row); err == nil && n == 0 {
+				err = sql.ErrNoRows
+			}
+		case workDelete:
+			_, err = cmds.delete.ExecStruct(db, op.row)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op.table.Name, err)
+		}
+	}
+	return nil
+}
+
+// ordered returns the recorded rows in the order that they are written:
+// inserts and updates with parent tables first, followed by deletes
+// with child tables first.
+func (u *UnitOfWork) ordered() []workOp {
+	var rowTypes []reflect.Type
+	seen := make(map[reflect.Type]bool)
+	for _, op := range u.ops {
+		if !seen[op.table.rowType] {
+			seen[op.table.rowType] = true
+			rowTypes = append(rowTypes, op.table.rowType)
+		}
+	}
+	rank := dependencyRank(rowTypes)
+
+	var writes, deletes []workOp
+	for _, op := range u.ops {
+		if op.kind == workDelete {
+			deletes = append(deletes, op)
+		} else {
+			writes = append(writes, op)
+		}
+	}
+	sort.SliceStable(writes, func(i, j int) bool {
+		return rank[writes[i].table.rowType] < rank[writes[j].table.rowType]
+	})
+	sort.SliceStable(deletes, func(i, j int) bool {
+		return rank[deletes[i].table.rowType] > rank[deletes[j].table.rowType]
+	})
+	return append(writes, deletes...)
+}
+
+// dependencyRank returns the rank of each of the row types, such that the
+// row type of a parent table has a lower rank than its child tables. Row
+// types that do not depend on each other keep their order. Row types in a
+// cycle of relationships are ranked after the others.
+func dependencyRank(rowTypes []reflect.Type) map[reflect.Type]int {
+	parents := make(map[reflect.Type]map[reflect.Type]bool)
+	addParent := func(child, parent reflect.Type) {
+		if child == parent {
+			return
+		}
+		if parents[child] == nil {
+			parents[child] = make(map[reflect.Type]bool)
+		}
+		parents[child][parent] = true
+	}
+	relations.mutex.RLock()
+	for key, rel := range relations.m {
+		if rel.hasMany {
+			addParent(rel.target.rowType, key.rowType)
+		} else {
+			addParent(key.rowType, rel.target.rowType)
+		}
+	}
+	relations.mutex.RUnlock()
+
+	rank := make(map[reflect.Type]int, len(rowTypes))
+	ranked := func(t reflect.Type) bool {
+		_, ok := rank[t]
+		return ok
+	}
+	for len(rank) < len(rowTypes) {
+		progress := false
+		for _, t := range rowTypes {
+			if ranked(t) {
+				continue
+			}
+			ready := true
+			for _, p := range rowTypes {
+				if parents[t][p] && !ranked(p) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				rank[t] = len(rank)
+				progress = true
+			}
+		}
+		if !progress {
+			// a cycle: rank the remaining row types in order
+			for _, t := range rowTypes {
+				if !ranked(t) {
+					rank[t] = len(rank)
+				}
+			}
+		}
+	}
+	return rank
+}
@@ -0,0 +1,103 @@
+package sqlf_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`pragma foreign_keys = on;
+		create table uow_customers(id integer primary key, name text);
+		create table uow_orders(id integer primary key, customer_id integer not null references uow_customers(id), amount integer)`); err != nil {
+		t.Fatal(err)
+	}
+
+	type Customer struct {
+		ID   int64
+		Name string
+	}
+	type Order struct {
+		ID         int64
+		CustomerID int64
+		Amount     int64
+		Customer   *Customer `sql:"-"`
+	}
+	customers := sqlf.Table("uow_customers", Customer{}).WithDialect(sqlf.DialectSQLite)
+	orders := sqlf.Table("uow_orders", Order{}).WithDialect(sqlf.DialectSQLite)
+	orders.BelongsTo("Customer", customers, "customer_id")
+	count := func(table string) int {
+		var n int
+		assert.NoError(db.Get(&n, "select count(*) from "+table))
+		return n
+	}
+
+	// the child rows are recorded first, but the parent is inserted first
+	uow := sqlf.NewUnitOfWork()
+	order1 := Order{ID: 1, CustomerID: 10, Amount: 100}
+	order2 := Order{ID: 2, CustomerID: 10, Amount: 200}
+	customer := Customer{ID: 10, Name: "John"}
+	assert.NoError(uow.Insert(orders, &order1))
+	assert.NoError(uow.Insert(orders, &order2))
+	assert.NoError(uow.Insert(customers, &customer))
+	assert.Equal(3, uow.Len())
+	assert.Equal(0, count("uow_orders"))
+
+	// rows are written as they are when committed
+	order2.Amount = 250
+	assert.NoError(uow.Commit(db))
+	assert.Equal(0, uow.Len())
+	var amount int64
+	assert.NoError(db.Get(&amount, "select amount from uow_orders where id = 2"))
+	assert.Equal(int64(250), amount)
+
+	// the parent is deleted after the children
+	assert.NoError(uow.Delete(customers, &customer))
+	assert.NoError(uow.Delete(orders, &order1))
+	assert.NoError(uow.Delete(orders, &order2))
+	assert.NoError(uow.Commit(db))
+	assert.Equal(0, count("uow_customers"))
+	assert.Equal(0, count("uow_orders"))
+
+	// nothing is written if a row cannot be written
+	assert.NoError(uow.Insert(customers, &customer))
+	assert.NoError(uow.Update(orders, &order1))
+	err = uow.Commit(db)
+	assert.True(errors.Is(err, sql.ErrNoRows))
+	assert.Equal(0, count("uow_customers"))
+	assert.Equal(2, uow.Len())
+	uow.Discard()
+	assert.Equal(0, uow.Len())
+
+	// inside a transaction, a savepoint is used
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Exec("insert into uow_customers(id, name) values(20, 'Jane')")
+	assert.NoError(err)
+	assert.NoError(uow.Insert(customers, &customer))
+	assert.NoError(uow.Insert(orders, &Order{ID: 3, CustomerID: 99}))
+	assert.Error(uow.Commit(tx))
+	uow.Discard()
+	assert.NoError(uow.Insert(customers, &customer))
+	assert.NoError(uow.Commit(sqlf.ForContext(context.Background(), tx)))
+	assert.NoError(tx.Commit())
+	var names []string
+	assert.NoError(db.Select(&names, "select name from uow_customers order by id"))
+	assert.Equal([]string{"John", "Jane"}, names)
+
+	assert.Error(uow.Insert(orders, &customer))
+}
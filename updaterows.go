@@ -0,0 +1,201 @@
+package sqlf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// UpdateRows executes an update (or delete) command built using UpdateRowf
+// for each of the rows, which is a slice of the table's row type, or of
+// pointers to it. It returns the total number of rows affected.
+//
+// The rows are updated in order of their primary key, regardless of their
+// order in the slice, which is not modified. When concurrent transactions
+// update overlapping sets of rows, updating them in a consistent order
+// means that the transactions acquire their row locks in the same order,
+// which avoids lock-order deadlocks.
+//
+// If db can begin a transaction (eg *sqlx.DB), the rows are updated in
+// transactions of at most chunkSize rows each, or in a single transaction
+// if chunkSize is zero. Smaller transactions hold fewer locks at a time,
+// but if an update fails, only the rows in its transaction are rolled back,
+// and rowCount is the number of rows affected by the transactions that were
+// committed. If db is a transaction (eg *sqlx.Tx), all of the rows are
+// updated using it, and chunkSize is ignored.
+//
+// See WithProgress for reporting the progress of the updates. If the
+// context of db is canceled (see ForContext), no more rows are updated,
+// the current transaction is rolled back, and rowCount is the number of
+// rows affected by the transactions that were committed.
+func UpdateRows(db sqlx.Execer, cmd UpdateRowCommand, rows interface{}, chunkSize int) (rowCount int, err error) {
+	urc, ok := cmd.(updateRowCommand)
+	if !ok {
+		return 0, fmt.Errorf("UpdateRows: expected command built using UpdateRowf, got %T", cmd)
+	}
+	if urc.table == nil {
+		return 0, errors.New("UpdateRows: command does not refer to a table")
+	}
+	if chunkSize < 0 {
+		return 0, fmt.Errorf("UpdateRows: invalid chunk size %d", chunkSize)
+	}
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("UpdateRows: expected slice but got %T", rows)
+	}
+	order, err := primaryKeyOrder(urc.table, v)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateRows: %v", err)
+	}
+
+	ctx := contextOf(db)
+	b, ok := unwrapHandle(db).(txBeginner)
+	if !ok {
+		return updateRows(ctx, db, urc, v, order, 0)
+	}
+	if chunkSize == 0 {
+		chunkSize = len(order)
+	}
+	for start := 0; start < len(order); start += chunkSize {
+		end := start + chunkSize
+		if end > len(order) {
+			end = len(order)
+		}
+		if err := ctx.Err(); err != nil {
+			return rowCount, err
+		}
+		tx, err := b.Beginx()
+		if err != nil {
+			return rowCount, err
+		}
+		n, err := updateRows(ctx, rewrapHandle(db, tx), urc, v, order[start:end], start)
+		if err != nil {
+			tx.Rollback()
+			return rowCount, err
+		}
+		if err := tx.Commit(); err != nil {
+			return rowCount, err
+		}
+		rowCount += n
+	}
+	return rowCount, nil
+}
+
+// updateRows executes the command for the rows of v with the indexes
+// in order, and returns the number of rows affected. The done count is
+// the number of rows updated before these, for reporting progress.
+func updateRows(ctx context.Context, db sqlx.Execer, cmd updateRowCommand, v reflect.Value, order []int, done int) (int, error) {
+	var total int
+	for j, i := range order {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := cmd.Exec(db, v.Index(i).Interface())
+		if err != nil {
+			return total, fmt.Errorf("row %d: %w", i, err)
+		}
+		total += n
+		cmd.opts.reportProgress(done+j+1, v.Len())
+	}
+	return total, nil
+}
+
+// primaryKeyOrder returns the indexes of the rows in v sorted
+// by the primary key of the table.
+func primaryKeyOrder(ti *TableInfo, v reflect.Value) ([]int, error) {
+	var pk []*columnInfo
+	for _, ci := range ti.columns {
+		if ci.primaryKey {
+			pk = append(pk, ci)
+		}
+	}
+	if len(pk) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", ti.Name)
+	}
+
+	keys := make([][]interface{}, v.Len())
+	for i := range keys {
+		row := v.Index(i)
+		for row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				return nil, fmt.Errorf("row %d is a nil pointer", i)
+			}
+			row = row.Elem()
+		}
+		if row.Type() != ti.rowType {
+			return nil, fmt.Errorf("expected type %s.%s or pointer", ti.rowType.PkgPath(), ti.rowType.Name())
+		}
+		for _, ci := range pk {
+			keys[i] = append(keys[i], reflectx.FieldByIndexesReadOnly(row, ci.fields).Interface())
+		}
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		for k := range a {
+			if c := compareKey(a[k], b[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return order, nil
+}
+
+// compareKey compares two primary key values of the same column,
+// returning -1, 0 or +1. Values of types that are not ordered
+// are compared using their string representation.
+func compareKey(a, b interface{}) int {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		}
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for va.Kind() == reflect.Ptr && !va.IsNil() {
+		va = va.Elem()
+	}
+	for vb.Kind() == reflect.Ptr && !vb.IsNil() {
+		vb = vb.Elem()
+	}
+	if va.Kind() == vb.Kind() {
+		switch va.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compareOrdered(va.Int() < vb.Int(), va.Int() > vb.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return compareOrdered(va.Uint() < vb.Uint(), va.Uint() > vb.Uint())
+		case reflect.Float32, reflect.Float64:
+			return compareOrdered(va.Float() < vb.Float(), va.Float() > vb.Float())
+		case reflect.String:
+			return strings.Compare(va.String(), vb.String())
+		}
+	}
+	return strings.Compare(keyString(a), keyString(b))
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
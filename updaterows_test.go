@@ -0,0 +1,117 @@
+package sqlf_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// execRecorder records the last argument of each statement executed.
+type execRecorder struct {
+	sqlx.Execer
+	ids []interface{}
+}
+
+func (r *execRecorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	r.ids = append(r.ids, args[len(args)-1])
+	return r.Execer.Exec(query, args...)
+}
+
+func TestUpdateRows(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", "file:updaterows?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	for _, id := range []int64{1, 2, 3, 4, 5} {
+		assert.NoError(ins.Exec(db, &Row1{Id: id, GivenName: "before"}))
+	}
+
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	rows := []*Row1{{Id: 4}, {Id: 1}, {Id: 5}, {Id: 3}, {Id: 2}}
+	for _, row := range rows {
+		row.GivenName = "after"
+	}
+
+	// rows are updated in primary key order, and the slice is unchanged
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &execRecorder{Execer: tx}
+	n, err := sqlf.UpdateRows(rec, upd, rows, 2)
+	assert.NoError(err)
+	assert.NoError(tx.Commit())
+	assert.Equal(5, n)
+	assert.Equal([]interface{}{int64(1), int64(2), int64(3), int64(4), int64(5)}, rec.ids)
+	assert.Equal(int64(4), rows[0].Id)
+
+	var names []string
+	assert.NoError(db.Select(&names, "select given_name from table1 where given_name = 'after'"))
+	assert.Len(names, 5)
+
+	// chunked transactions
+	del := sqlf.UpdateRowf("delete from %s where %s", tbl.Delete.TableName, tbl.Update.WhereColumns)
+	n, err = sqlf.UpdateRows(db, del, []Row1{{Id: 5}, {Id: 2}, {Id: 3}}, 2)
+	assert.NoError(err)
+	assert.Equal(3, n)
+	var ids []int64
+	assert.NoError(db.Select(&ids, "select id from table1 order by id"))
+	assert.Equal([]int64{1, 4}, ids)
+
+	_, err = sqlf.UpdateRows(db, upd, []Row2{{}}, 0)
+	assert.Error(err)
+}
+
+func TestUpdateRowsProgress(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", "file:updaterowsprogress?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	ins := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+	rows := []Row1{{Id: 5}, {Id: 4}, {Id: 3}, {Id: 2}, {Id: 1}}
+	for i := range rows {
+		assert.NoError(ins.Exec(db, &rows[i]))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var progress []int
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns,
+		sqlf.WithProgress(func(done, total int) {
+			assert.Equal(5, total)
+			progress = append(progress, done)
+			if done == 3 {
+				cancel()
+			}
+		}))
+	for i := range rows {
+		rows[i].GivenName = "after"
+	}
+
+	// the first chunk is committed, and the second is rolled back
+	n, err := sqlf.UpdateRows(sqlf.ForContext(ctx, db), upd, rows, 2)
+	assert.True(errors.Is(err, context.Canceled), "%v", err)
+	assert.Equal(2, n)
+	assert.Equal([]int{1, 2, 3}, progress)
+	var ids []int64
+	assert.NoError(db.Select(&ids, "select id from table1 where given_name = 'after' order by id"))
+	assert.Equal([]int64{1, 2}, ids)
+}
@@ -0,0 +1,192 @@
+package sqlf
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WithUpsert causes a command built using InsertRowf to update the existing
+// row when the row would violate a unique constraint, instead of returning
+// an error. The columns are the column names of the constraint, and default
+// to the primary key. The existing row is updated with the values of all of
+// the inserted columns that are not in the constraint, except for any
+// "createdby" audit column (see SetAuditFunc).
+//
+// The insert statement is modified to suit the dialect of the table:
+// "on conflict(columns) do update set ..." for SQLite and PostgreSQL, and
+// "on duplicate key update ..." for MySQL, which does not need the columns.
+// Other dialects are not supported, and the command returns an error when
+// it is executed.
+//
+// If the table has an auto-increment column that is not inserted, its field
+// is set to the value of the inserted or updated row. For SQLite and
+// PostgreSQL this requires a "returning" clause, which is added to the
+// statement when the database handle is also a sqlx.Queryer, as *sqlx.DB
+// and *sqlx.Tx are.
+func WithUpsert(columns ...string) Option {
+	return func(o *options) {
+		o.upsert = true
+		o.upsertColumns = columns
+	}
+}
+
+// upsert modifies the insert statement for the table so that it updates
+// the existing row when the row would violate a unique constraint. It also
+// returns the statement with a returning clause for the auto-increment
+// column, if required.
+func upsert(d Dialect, ti *TableInfo, inputs []*columnInfo, columns []string, query string) (string, string, error) {
+	if ti == nil {
+		return query, "", errTableNotSpecified
+	}
+	var conflict []*columnInfo
+	if len(columns) == 0 {
+		for _, ci := range ti.columns {
+			if ci.primaryKey {
+				conflict = append(conflict, ci)
+			}
+		}
+		if len(conflict) == 0 {
+			return query, "", fmt.Errorf("WithUpsert: table %s has no primary key", ti.Name)
+		}
+	}
+	for _, column := range columns {
+		ci := ti.columnNamed(column)
+		if ci == nil {
+			return query, "", fmt.Errorf("WithUpsert: table %s has no column %s", ti.Name, column)
+		}
+		conflict = append(conflict, ci)
+	}
+
+	// the auto-increment column is returned unless it is inserted
+	var autoInc *columnInfo
+	for _, ci := range ti.columns {
+		if ci.autoIncrement {
+			autoInc = ci
+		}
+	}
+	for _, ci := range inputs {
+		if ci == autoInc {
+			autoInc = nil
+		}
+	}
+
+	inConflict := func(ci *columnInfo) bool {
+		for _, c := range conflict {
+			if c == ci {
+				return true
+			}
+		}
+		return false
+	}
+	var sets []string
+	for _, ci := range inputs {
+		if inConflict(ci) || ci.audit == auditCreatedBy {
+			// the creator of an existing row is not changed
+			continue
+		}
+		column := d.Quote(ci.columnName)
+		if DialectName(d) == "mysql" {
+			sets = append(sets, fmt.Sprintf("%s=values(%s)", column, column))
+		} else {
+			sets = append(sets, fmt.Sprintf("%s=excluded.%s", column, column))
+		}
+	}
+
+	switch DialectName(d) {
+	case "mysql":
+		if autoInc != nil {
+			// makes LastInsertId return the value of the updated row
+			column := d.Quote(autoInc.columnName)
+			sets = append(sets, fmt.Sprintf("%s=last_insert_id(%s)", column, column))
+		}
+		if len(sets) == 0 {
+			column := d.Quote(conflict[0].columnName)
+			sets = append(sets, fmt.Sprintf("%s=%s", column, column))
+		}
+		if _, _, ok := insertClause(query, ""); !ok {
+			return query, "", fmt.Errorf("WithUpsert: not an insert statement: %q", query)
+		}
+		return query + " on duplicate key update " + strings.Join(sets, ","), "", nil
+	case "sqlite3", "postgres":
+		if len(sets) == 0 {
+			// update rather than do nothing, so that the row is returned
+			column := d.Quote(conflict[0].columnName)
+			sets = append(sets, fmt.Sprintf("%s=excluded.%s", column, column))
+		}
+		var targets []string
+		for _, ci := range conflict {
+			targets = append(targets, d.Quote(ci.columnName))
+		}
+		clause := fmt.Sprintf("on conflict(%s) do update set %s", strings.Join(targets, ","), strings.Join(sets, ","))
+		result, returning, ok := insertClause(query, clause)
+		if !ok {
+			return query, "", fmt.Errorf("WithUpsert: not an insert statement: %q", query)
+		}
+		if autoInc == nil || returning {
+			return result, "", nil
+		}
+		return result, result + " returning " + d.Quote(autoInc.columnName), nil
+	}
+	return query, "", fmt.Errorf("WithUpsert: not supported for dialect %q", DialectName(d))
+}
+
+// columnNamed returns the column with the name, or nil
+// if the table does not have the column.
+func (ti *TableInfo) columnNamed(name string) *columnInfo {
+	for _, ci := range ti.columns {
+		if ci.columnName == name {
+			return ci
+		}
+	}
+	return nil
+}
+
+// execReturning executes the upsert statement with a returning clause,
+// and sets the auto-increment field to the value returned.
+func (cmd insertRowCommand) execReturning(db sqlx.Queryer, row interface{}, field reflect.Value) (sql.Result, error) {
+	ctx := contextOf(db)
+	defer forgetMemo(ctx)
+	pooled := getArgs(db)
+	args, err := cmd.appendArgs(ctx, *pooled, row, true)
+	if err != nil {
+		return nil, err
+	}
+	defer putArgs(db, pooled, args)
+	cmd.opts.debugBindings(OpInsertRow, cmd.returning, cmd.inputs, args)
+	start := time.Now()
+	query := cmd.returningRebind.queryFor(db, cmd.table.Dialect(), cmd.returning)
+	var id int64
+	r, cancel, err := cmd.opts.queryRow(db, query, args)
+	if err == nil {
+		err = r.Scan(&id)
+		cancel()
+	}
+	var result returnedResult
+	if err == nil {
+		result = returnedResult{lastInsertID: id, rowsAffected: 1}
+		field.SetInt(id)
+	} else if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	cmd.opts.report(ctx, OpInsertRow, cmd.command, start, result.rowsAffected, err)
+	if err != nil {
+		return nil, cmd.opts.wrapError(OpInsertRow, cmd.command, args, err)
+	}
+	return result, nil
+}
+
+// returnedResult is the sql.Result for a statement
+// with a returning clause.
+type returnedResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r returnedResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r returnedResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
@@ -0,0 +1,77 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithUpsert(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		dialect sqlf.Dialect
+		format  string
+		columns []string
+		want    string
+	}{
+		{
+			dialect: sqlf.DialectSQLite,
+			format:  "insert into %s(%s) values(%s)",
+			columns: []string{"given_name"},
+			want:    "insert into `table1`(`given_name`,`family_name`,`Date_of_Birth`) values(?,?,?) on conflict(`given_name`) do update set `family_name`=excluded.`family_name`,`Date_of_Birth`=excluded.`Date_of_Birth`",
+		},
+		{
+			dialect: sqlf.DialectPG,
+			format:  "insert into %s(%s) values(%s) returning id",
+			columns: []string{"given_name"},
+			want:    `insert into "table1"("given_name","family_name","Date_of_Birth") values($1,$2,$3) on conflict("given_name") do update set "family_name"=excluded."family_name","Date_of_Birth"=excluded."Date_of_Birth" returning id`,
+		},
+		{
+			dialect: sqlf.DialectMySQL,
+			format:  "insert into %s(%s) values(%s)",
+			want:    "insert into `table1`(`given_name`,`family_name`,`Date_of_Birth`) values(?,?,?) on duplicate key update `given_name`=values(`given_name`),`family_name`=values(`family_name`),`Date_of_Birth`=values(`Date_of_Birth`),`id`=last_insert_id(`id`)",
+		},
+	}
+	for _, tt := range tests {
+		tbl := Row1Table.WithDialect(tt.dialect)
+		cmd := sqlf.InsertRowf(tt.format, tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithUpsert(tt.columns...))
+		assert.Equal(tt.want, cmd.Command())
+	}
+
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	cmd := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithUpsert("nickname"))
+	assert.EqualError(cmd.Exec(nil, &Row1{}), "WithUpsert: table table1 has no column nickname")
+}
+
+func TestWithUpsertExec(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table table1(id integer primary key autoincrement, given_name text unique, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+	tbl := Row1Table.WithDialect(sqlf.DialectSQLite)
+	upsert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithUpsert("given_name"))
+
+	john := Row1{GivenName: "John", FamilyName: "Smith"}
+	assert.NoError(upsert.Exec(db, &john))
+	assert.Equal(int64(1), john.Id)
+	jane := Row1{GivenName: "Jane", FamilyName: "Doe"}
+	assert.NoError(upsert.Exec(db, &jane))
+	assert.Equal(int64(2), jane.Id)
+
+	// the existing row is updated, and its id is returned
+	john2 := Row1{GivenName: "John", FamilyName: "Jones"}
+	assert.NoError(upsert.Exec(db, &john2))
+	assert.Equal(int64(1), john2.Id)
+
+	var names []string
+	assert.NoError(db.Select(&names, "select family_name from table1 order by id"))
+	assert.Equal([]string{"Jones", "Doe"}, names)
+}
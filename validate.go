@@ -0,0 +1,154 @@
+package sqlf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// ValidationError describes a column value that does not satisfy
+// one of the constraints declared in the field tags of the row struct.
+type ValidationError struct {
+	Column  string // Column name in the database table
+	Field   string // Field name in the Go struct
+	Message string // Description of the constraint violated
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Column, e.Message)
+}
+
+// ValidationErrors is the error returned by the Validate method
+// when one or more column values fail validation. There is one
+// entry for each constraint violated.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface.
+func (errs ValidationErrors) Error() string {
+	var buf bytes.Buffer
+	for i, err := range errs {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// constraints contains the constraints that can be declared for a column
+// in the field tag of the row struct.
+//
+//	NOT NULL        the value must not be the zero value for its type
+//	MAXLEN:n        the string (or []byte) value must not exceed n characters (bytes)
+//	ENUM:a,b,c      the value must format as one of the listed values
+type constraints struct {
+	notNull bool
+	maxLen  int
+	enum    []string
+}
+
+// parseConstraints builds the constraints for a field from its tag settings.
+// It panics if a constraint is badly formed, in the same way that Table
+// panics for an invalid row type.
+func parseConstraints(field reflect.StructField, tagSettings map[string]string) constraints {
+	var c constraints
+	if _, ok := tagSettings["NOT NULL"]; ok {
+		c.notNull = true
+	}
+	if value, ok := tagSettings["MAXLEN"]; ok {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			panic(fmt.Sprintf("sqlf.Table: invalid maxlen for field %s: %q", field.Name, value))
+		}
+		c.maxLen = n
+	}
+	if value, ok := tagSettings["ENUM"]; ok {
+		for _, v := range strings.Split(value, ",") {
+			c.enum = append(c.enum, strings.TrimSpace(v))
+		}
+	}
+	return c
+}
+
+// validate checks the value against the constraints, returning a
+// message describing the first constraint violated, or blank if the
+// value is valid.
+func (c constraints) validate(v reflect.Value) string {
+	zero := isZero(v)
+	if c.notNull && zero {
+		return "value is required"
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			// only a not null constraint applies to a nil pointer
+			return ""
+		}
+		v = v.Elem()
+	}
+	if c.maxLen > 0 {
+		var n int
+		switch {
+		case v.Kind() == reflect.String:
+			n = utf8.RuneCountInString(v.String())
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			n = v.Len()
+		}
+		if n > c.maxLen {
+			return fmt.Sprintf("length %d exceeds maximum of %d", n, c.maxLen)
+		}
+	}
+	if len(c.enum) > 0 && !zero {
+		s := fmt.Sprint(v.Interface())
+		found := false
+		for _, e := range c.enum {
+			if s == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("value %q is not one of %s", s, strings.Join(c.enum, ","))
+		}
+	}
+	return ""
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// Validate checks the contents of the row against the constraints
+// declared for each of the input columns of the command. If any column
+// value fails validation, the error returned is of type ValidationErrors.
+// Validation is optional: Exec does not call Validate.
+func (cmd execRowCommand) Validate(row interface{}) error {
+	if cmd.table == nil {
+		return errTableNotSpecified
+	}
+	rowVal, err := cmd.getRowValue(row)
+	if err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	for _, ci := range cmd.inputs {
+		v := reflectx.FieldByIndexesReadOnly(rowVal, ci.fields)
+		if msg := ci.constraints.validate(v); msg != "" {
+			errs = append(errs, &ValidationError{
+				Column:  ci.columnName,
+				Field:   ci.fieldName,
+				Message: msg,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	type Row struct {
+		ID     int64  `sql:"primary_key;auto_increment"`
+		Name   string `sql:"not null;maxlen:5"`
+		Status string `sql:"enum:active,inactive"`
+		Notes  *string
+	}
+	tbl := sqlf.Table("rows", Row{}).WithDialect(sqlf.DialectMySQL)
+	cmd := sqlf.InsertRowf("insert into %s(%s) values(%s)", tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values)
+
+	assert.NoError(cmd.Validate(Row{Name: "abc", Status: "active"}))
+	assert.NoError(cmd.Validate(&Row{Name: "abcde"}))
+
+	err := cmd.Validate(Row{Name: "", Status: "deleted"})
+	if assert.Error(err) {
+		errs, ok := err.(sqlf.ValidationErrors)
+		if assert.True(ok) && assert.Equal(2, len(errs)) {
+			assert.Equal("name", errs[0].Column)
+			assert.Equal("Name", errs[0].Field)
+			assert.Equal("value is required", errs[0].Message)
+			assert.Equal("status", errs[1].Column)
+		}
+		assert.Equal(`name: value is required; status: value "deleted" is not one of active,inactive`, err.Error())
+	}
+
+	err = cmd.Validate(Row{Name: "abcdef"})
+	assert.EqualError(err, "name: length 6 exceeds maximum of 5")
+
+	upd := sqlf.UpdateRowf("update %s set %s where %s", tbl.Update.TableName, tbl.Update.SetColumns, tbl.Update.WhereColumns)
+	assert.Error(upd.Validate(Row{ID: 1}))
+}
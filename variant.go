@@ -0,0 +1,352 @@
+package sqlf
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Variant creates a table for a variant of the row type of the table,
+// so that rows of more than one type can be stored in the one table
+// (sometimes called single-table inheritance). The row type of the table
+// has a discriminator column, tagged `sql:"discriminator"`, which holds
+// the type of each row. The variant row type embeds the row type of the
+// table and adds the fields for its own columns:
+//
+//	type Vehicle struct {
+//		ID   int64  `sql:"primary_key;auto_increment"`
+//		Kind string `sql:"discriminator"`
+//		Name string
+//	}
+//
+//	type Truck struct {
+//		Vehicle
+//		Payload int
+//	}
+//
+//	var vehicles = sqlf.Table("vehicles", Vehicle{})
+//	var trucks = vehicles.Variant("truck", Truck{})
+//
+// Commands built using the variant table set the discriminator column to
+// the value of the variant, regardless of the value of its field. Query
+// commands can select rows of any variant into a slice of interface{}
+// values (see WithVariantFactory):
+//
+//	listVehicles := sqlf.Queryf("select * from %s order by %s",
+//		vehicles.Select.TableName, vehicles.Select.OrderBy)
+//
+//	var rows []interface{} // *Vehicle, *Truck, ...
+//	err := listVehicles.Select(db, &rows)
+//
+// Variant panics if the table has no discriminator column, if the variant
+// row type does not have the same discriminator column, or if a variant
+// has already been created with the value, in the same way that Table
+// panics for an invalid row type.
+func (ti *TableInfo) Variant(value string, row interface{}) *TableInfo {
+	dc := ti.discriminatorColumn()
+	if dc == nil {
+		panic(fmt.Sprintf("sqlf.Variant: table %s has no discriminator column", ti.Name))
+	}
+	vt := ti.settings.Table(ti.Name, row)
+	if vdc := vt.discriminatorColumn(); vdc == nil || vdc.columnName != dc.columnName {
+		panic(fmt.Sprintf("sqlf.Variant: %s does not have discriminator column %s", vt.rowType, dc.columnName))
+	}
+	vt.variant = value
+	vt.variantOf = ti
+
+	variants.mutex.Lock()
+	defer variants.mutex.Unlock()
+	byValue := variants.m[ti.rowType]
+	if byValue == nil {
+		byValue = make(map[string]*TableInfo)
+		variants.m[ti.rowType] = byValue
+	}
+	if _, ok := byValue[value]; ok {
+		panic(fmt.Sprintf("sqlf.Variant: table %s has more than one variant %q", ti.Name, value))
+	}
+	byValue[value] = vt
+	return vt
+}
+
+// WithVariantFactory sets the function that creates the row for each
+// discriminator value, when a query command selects rows into a slice of
+// interface{} values. The function returns a pointer to a new struct,
+// which is scanned and appended to the slice. If it returns nil, the
+// variant created using TableInfo.Variant is used, or the row type of the
+// table if there is no variant for the value.
+func WithVariantFactory(factory func(discriminator string) interface{}) Option {
+	return func(o *options) {
+		o.variantFactory = factory
+	}
+}
+
+var variants = struct {
+	mutex sync.RWMutex
+	m     map[reflect.Type]map[string]*TableInfo // by row type and value
+}{m: make(map[reflect.Type]map[string]*TableInfo)}
+
+// lookupVariant returns the variant of the row type with the
+// discriminator value, or nil if there is none.
+func lookupVariant(rowType reflect.Type, value string) *TableInfo {
+	variants.mutex.RLock()
+	defer variants.mutex.RUnlock()
+	return variants.m[rowType][value]
+}
+
+// discriminatorColumn returns the discriminator column of the table,
+// or nil if it has none.
+func (ti *TableInfo) discriminatorColumn() *columnInfo {
+	for _, ci := range ti.columns {
+		if ci.discriminator {
+			return ci
+		}
+	}
+	return nil
+}
+
+// variantTable returns the table selected by the query command if it
+// has a discriminator column, or nil otherwise.
+func (cmd *queryCommand) variantTable() *TableInfo {
+	ti := firstTable(cmd.args)
+	if len(cmd.columns) > 0 {
+		ti = cmd.columns[0].table
+	}
+	if ti == nil || ti.discriminatorColumn() == nil {
+		return nil
+	}
+	return ti
+}
+
+// selectVariants scans each row into a new struct whose type depends
+// on the value of the discriminator column, and appends a pointer to
+// the struct to dest, which is a slice of interface{} values.
+func (cmd *queryCommand) selectVariants(ctx context.Context, rows Rows, columns []string, ti *TableInfo, dest reflect.Value) error {
+	if ti.variantOf != nil {
+		// a query of a variant table can return rows of any variant
+		ti = ti.variantOf
+	}
+	dc := ti.discriminatorColumn()
+	discriminator := -1
+	for i, column := range columns {
+		if column == dc.outputName() {
+			discriminator = i
+		}
+	}
+	if discriminator < 0 {
+		return fmt.Errorf("discriminator column %s is not selected", dc.columnName)
+	}
+	mapper, err := cmd.getMapper()
+	if err != nil {
+		return err
+	}
+
+	// names are mapped in the same way as the columns of the table
+	names := reflectx.NewMapperFunc("", ti.settings.columnName)
+
+	// the destination of each column, by row type
+	plans := make(map[reflect.Type][]traversal)
+	planFor := func(t reflect.Type, table *TableInfo) []traversal {
+		if plan, ok := plans[t]; ok {
+			return plan
+		}
+		// the columns of the table are scanned into the embedded
+		// struct of a row type returned by the variant factory
+		var prefix []int
+		embedded := true
+		if table == nil {
+			table, embedded = ti, false
+			for _, p := range structPaths(t) {
+				if p.typ == ti.rowType {
+					prefix, embedded = p.index, true
+					break
+				}
+			}
+		}
+		plan := make([]traversal, len(columns))
+		for i, column := range columns {
+			if ci := table.columnNamed(column); ci != nil && embedded {
+				index := append(append([]int{}, prefix...), ci.fields...)
+				plan[i] = traversal{index: index, column: ci}
+			} else if index := mapper.TraversalsByName(t, []string{column})[0]; len(index) > 0 {
+				plan[i] = traversal{index: index}
+			} else if index := names.TraversalsByName(t, []string{column})[0]; len(index) > 0 {
+				plan[i] = traversal{index: index}
+			}
+		}
+		plans[t] = plan
+		return plan
+	}
+
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		values[i] = &driverValue{}
+	}
+	for rows.Next() {
+		if err := rows.Scan(values...); err != nil {
+			return err
+		}
+		var value string
+		if err := assignValue(reflect.ValueOf(&value).Elem(), values[discriminator].(*driverValue).value); err != nil {
+			return fmt.Errorf("cannot scan %s: %v", dc.columnName, err)
+		}
+
+		var vp reflect.Value
+		table := ti
+		if vt := lookupVariant(ti.rowType, value); vt != nil {
+			table = vt
+		}
+		if cmd.opts.variantFactory != nil {
+			if row := cmd.opts.variantFactory(value); row != nil {
+				vp = reflect.ValueOf(row)
+				if vp.Kind() != reflect.Ptr || vp.Elem().Kind() != reflect.Struct {
+					return fmt.Errorf("variant factory returned %T, expected pointer to struct", row)
+				}
+			}
+		}
+		if !vp.IsValid() {
+			vp = reflect.New(table.rowType)
+		}
+		switch vp.Elem().Type() {
+		case table.rowType:
+		case ti.rowType:
+			table = ti
+		default:
+			table = nil
+		}
+
+		v := vp.Elem()
+		for i, t := range planFor(v.Type(), table) {
+			if t.index == nil {
+				// a column of another variant
+				continue
+			}
+			field := reflectx.FieldByIndexes(v, t.index)
+			src := values[i].(*driverValue).value
+			if err := scanField(ctx, field, t.column, src); err != nil {
+				return err
+			}
+		}
+		if err := afterScan(ctx, vp); err != nil {
+			return err
+		}
+		dest.Set(reflect.Append(dest, vp))
+	}
+	return rows.Err()
+}
+
+// driverValue implements sql.Scanner, and keeps the value returned by
+// the database driver so that it can be assigned to a field once the
+// type of the row is known.
+type driverValue struct {
+	value interface{}
+}
+
+func (dv *driverValue) Scan(src interface{}) error {
+	if b, ok := src.([]byte); ok {
+		// the driver can reuse the memory
+		src = append([]byte(nil), b...)
+	}
+	dv.value = src
+	return nil
+}
+
+// scanField sets the field to the value returned by the database driver
+// for the column, which is nil if the column is matched by name only.
+func scanField(ctx context.Context, field reflect.Value, ci *columnInfo, src interface{}) error {
+	var err error
+	switch {
+	case ci != nil && ci.codec != nil:
+		err = (&codecScanner{ctx: ctx, codec: ci.codec, column: ci, field: field}).Scan(src)
+	case ci != nil && ci.array:
+		err = (&arrayScanner{column: ci, field: field}).Scan(src)
+	default:
+		err = assignValue(field, src)
+	}
+	if err != nil {
+		return err
+	}
+	if ci != nil && ci.times != nil {
+		ci.times.normalizeField(field)
+	}
+	return nil
+}
+
+// assignValue sets the field to the value returned by the database
+// driver, converting the value in the same way as sql.Rows.Scan
+// for the common field types.
+func assignValue(field reflect.Value, src interface{}) error {
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	if src == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		v := reflect.New(field.Type().Elem())
+		if err := assignValue(v.Elem(), src); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(field.Type()) {
+		field.Set(sv)
+		return nil
+	}
+	var text string
+	switch s := src.(type) {
+	case []byte:
+		text = string(s)
+	case string:
+		text = s
+	case time.Time:
+		text = s.Format(time.RFC3339Nano)
+	default:
+		text = fmt.Sprint(src)
+	}
+	var err error
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("cannot assign %T to %s", src, field.Type())
+		}
+		field.SetBytes([]byte(text))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(text, 10, field.Type().Bits()); err == nil {
+			field.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(text, 10, field.Type().Bits()); err == nil {
+			field.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(text, field.Type().Bits()); err == nil {
+			field.SetFloat(f)
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(text); err == nil {
+			field.SetBool(b)
+		}
+	default:
+		return fmt.Errorf("cannot assign %T to %s", src, field.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot assign %T to %s: %v", src, field.Type(), err)
+	}
+	return nil
+}
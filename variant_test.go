@@ -0,0 +1,98 @@
+package sqlf_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type Vehicle struct {
+	ID   int64  `sql:"primary_key;auto_increment"`
+	Kind string `sql:"discriminator"`
+	Name string
+}
+
+type Truck struct {
+	Vehicle
+	Payload int
+}
+
+type Bus struct {
+	Vehicle
+	Seats *int
+}
+
+func TestVariant(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`create table vehicles(id integer primary key autoincrement, kind text, name text, payload integer, seats integer)`); err != nil {
+		t.Fatal(err)
+	}
+
+	vehicles := sqlf.Table("vehicles", Vehicle{}).WithDialect(sqlf.DialectSQLite)
+	trucks := vehicles.Variant("truck", Truck{})
+	buses := vehicles.Variant("bus", Bus{})
+	assert.Panics(func() { vehicles.Variant("truck", Truck{}) })
+	assert.Panics(func() { sqlf.Table("table1", Row1{}).Variant("x", Row1{}) })
+
+	insert := func(ti *sqlf.TableInfo, row interface{}) {
+		cmd := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+			ti.Insert.TableName, ti.Insert.Columns, ti.Insert.Values)
+		assert.NoError(cmd.Exec(db, row))
+	}
+	seats := 40
+	insert(vehicles, &Vehicle{Name: "Car"})
+	insert(trucks, &Truck{Vehicle: Vehicle{Kind: "ignored", Name: "Semi"}, Payload: 20})
+	insert(buses, &Bus{Vehicle: Vehicle{Name: "Coach"}, Seats: &seats})
+
+	var kinds []string
+	assert.NoError(sqlf.Queryf("select kind from vehicles order by id").Select(db, &kinds))
+	assert.Equal([]string{"", "truck", "bus"}, kinds)
+
+	list := sqlf.Queryf("select * from %s order by %s", vehicles.Select.TableName, vehicles.Select.OrderBy)
+	var rows []interface{}
+	assert.NoError(list.Select(db, &rows))
+	assert.Equal([]interface{}{
+		&Vehicle{ID: 1, Name: "Car"},
+		&Truck{Vehicle: Vehicle{ID: 2, Kind: "truck", Name: "Semi"}, Payload: 20},
+		&Bus{Vehicle: Vehicle{ID: 3, Kind: "bus", Name: "Coach"}, Seats: &seats},
+	}, rows)
+
+	// a query of a variant table returns rows of any variant
+	list = sqlf.Queryf("select %s, payload from %s where kind = ?",
+		vehicles.Select.Columns, trucks.Select.TableName)
+	rows = nil
+	assert.NoError(list.Select(db, &rows, "truck"))
+	assert.Equal([]interface{}{
+		&Truck{Vehicle: Vehicle{ID: 2, Kind: "truck", Name: "Semi"}, Payload: 20},
+	}, rows)
+
+	type Minibus struct {
+		Vehicle
+		Seats int
+	}
+	list = sqlf.Queryf("select * from %s order by %s", vehicles.Select.TableName, vehicles.Select.OrderBy,
+		sqlf.WithVariantFactory(func(kind string) interface{} {
+			if kind == "bus" {
+				return &Minibus{}
+			}
+			return nil
+		}))
+	rows = nil
+	assert.NoError(list.Select(db, &rows))
+	assert.Equal([]interface{}{
+		&Vehicle{ID: 1, Name: "Car"},
+		&Truck{Vehicle: Vehicle{ID: 2, Kind: "truck", Name: "Semi"}, Payload: 20},
+		&Minibus{Vehicle: Vehicle{ID: 3, Kind: "bus", Name: "Coach"}, Seats: 40},
+	}, rows)
+
+	// without a discriminator column, a slice of interface{} is not supported
+	rows = nil
+	assert.Error(sqlf.Queryf("select * from vehicles").Select(db, &rows))
+}
@@ -0,0 +1,198 @@
+package sqlf
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+)
+
+// formatErrorRE matches the text written by fmt.Sprintf when the
+// verbs of a format do not match its args, eg "%!s(MISSING)",
+// "%!(EXTRA string=x)" or "%!d(string=x)".
+var formatErrorRE = regexp.MustCompile(`%![a-zA-Z]?\(`)
+
+// verifySQL checks the SQL of a command built for dialect d, which has
+// n inputs. The inputs are numbered from 1 in order of appearance. If
+// exact is set, the command cannot have any other placeholders, because
+// the args are supplied by the command itself rather than the caller.
+//
+// The checks catch commands whose args would silently be misaligned,
+// including a format with more or fewer "%s" verbs than args, and
+// placeholders that are skipped or numbered more than once.
+func verifySQL(d Dialect, command string, n int, exact bool) error {
+	if m := formatErrorRE.FindStringIndex(command); m != nil {
+		end := strings.IndexByte(command[m[0]:], ')')
+		if end < 0 {
+			end = len(command) - m[0] - 1
+		}
+		return fmt.Errorf("format verbs do not match args: %s", command[m[0]:m[0]+end+1])
+	}
+
+	numbered := d.Placeholder(1) != d.Placeholder(2)
+	var count, max, unnumbered int
+	used := make(map[int]bool)
+	scanner := scan.NewScanner(strings.NewReader(command))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			break
+		}
+		if tok != scan.PLACEHOLDER {
+			continue
+		}
+		count++
+		if numbered {
+			if i, ok := placeholderNumber(lit); ok {
+				used[i] = true
+				if i > max {
+					max = i
+				}
+			} else {
+				unnumbered++
+			}
+		}
+	}
+	if unnumbered > 0 {
+		// "?" placeholders are rebound to the dialect when the
+		// command is executed, but cannot be mixed with numbers
+		if unnumbered < count {
+			return errors.New("numbered and unnumbered placeholders")
+		}
+		numbered = false
+	}
+
+	if numbered {
+		for i := 1; i <= max; i++ {
+			if !used[i] {
+				return fmt.Errorf("placeholder %s is missing", d.Placeholder(i))
+			}
+		}
+		if max < n || (exact && max != n) {
+			return fmt.Errorf("%d placeholders for %d inputs", max, n)
+		}
+		return nil
+	}
+	if count < n || (exact && count != n) {
+		return fmt.Errorf("%d placeholders for %d inputs", count, n)
+	}
+	return nil
+}
+
+// placeholderNumber returns the number of a numbered
+// placeholder, eg 3 for "$3" or "@p3".
+func placeholderNumber(lit string) (int, bool) {
+	digits := strings.TrimLeftFunc(lit, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	i, err := strconv.Atoi(digits)
+	return i, err == nil && i > 0
+}
+
+// verifyInsertLists checks that the column lists of an insert statement
+// name the same number of columns as its values lists, which is easily
+// broken by excluding a column from one list and not the other.
+func verifyInsertLists(args []interface{}) error {
+	var columns, values int
+	for _, arg := range args {
+		if cil, ok := arg.(ColumnList); ok {
+			switch cil.clause {
+			case clauseInsertColumns:
+				columns += len(cil.filtered())
+			case clauseInsertValues:
+				values += len(cil.filtered())
+			}
+		}
+	}
+	if values > 0 && columns != values {
+		return fmt.Errorf("%d insert columns for %d values", columns, values)
+	}
+	return nil
+}
+
+func (cmd execRowCommand) Verify() error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	if cmd.table == nil {
+		return errTableNotSpecified
+	}
+	if cmd.verifyErr != nil {
+		return fmt.Errorf("Verify: %v", cmd.verifyErr)
+	}
+	if err := verifySQL(cmd.table.Dialect(), cmd.command, len(cmd.inputs)+len(cmd.fixed), true); err != nil {
+		return fmt.Errorf("Verify: %v: %q", err, cmd.command)
+	}
+	return nil
+}
+
+// strictErr returns an error if a row command built using WithStrict has
+// no table or input columns, which is otherwise only reported when it is
+// executed. The op is the name of the function that built the command.
+func (cmd execRowCommand) strictErr(op string) error {
+	if cmd.table == nil {
+		return fmt.Errorf("%s: %v", op, errTableNotSpecified)
+	}
+	if len(cmd.inputs) == 0 {
+		return fmt.Errorf("%s: no input columns", op)
+	}
+	return nil
+}
+
+func (cmd execCommand) Verify() error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	if err := verifySQL(commandDialect(cmd.dialect), cmd.command, len(cmd.inputs), false); err != nil {
+		return fmt.Errorf("Verify: %v: %q", err, cmd.command)
+	}
+	return nil
+}
+
+func (cmd *queryCommand) Verify() error {
+	if ab := cmd.opts.binding; ab != nil && ab.err != nil {
+		return ab.err
+	}
+	if err := verifySQL(commandDialect(cmd.dialect), cmd.command, len(cmd.inputs), false); err != nil {
+		return fmt.Errorf("Verify: %v: %q", err, cmd.command)
+	}
+	return nil
+}
+
+// WithStrict causes a command to panic when it is built if it has any
+// problem that would otherwise be reported when it is first executed,
+// including a command built using InsertRowf or UpdateRowf that does not
+// specify a table or any input columns, and format verbs that do not match
+// the args. This ensures that a command declared as a package-level
+// variable fails when the program is initialized, rather than when it
+// handles its first request:
+//
+//	var getUser = sqlf.Queryf("select %s from %s where %s",
+//		users.Select.Columns, users.Select.TableName, users.Select.WhereColumns,
+//		sqlf.WithStrict())
+//
+// The checks are those of the command's Verify method. To check every
+// command, build the package with the "sqlfverify" build tag instead.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// mustVerify panics if the command fails verification. It is called when
+// a command without any other error is built, and checks the command if
+// the package is built with the "sqlfverify" build tag, see verifyOnBuild.
+// Other errors are reported when the command is executed, as usual. If the
+// command was built using WithStrict, it is called for every command, and
+// always checks the command.
+func mustVerify(cmd interface{ Verify() error }, strict bool) {
+	if !verifyOnBuild && !strict {
+		return
+	}
+	if err := cmd.Verify(); err != nil {
+		panic("sqlf." + err.Error())
+	}
+}
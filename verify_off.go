@@ -0,0 +1,6 @@
+//go:build !sqlfverify
+
+package sqlf
+
+// verifyOnBuild is not set, see verify_on.go.
+const verifyOnBuild = false
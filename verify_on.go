@@ -0,0 +1,12 @@
+//go:build sqlfverify
+
+package sqlf
+
+// verifyOnBuild is set when the package is built with the "sqlfverify"
+// build tag, which causes every command to be verified when it is built:
+//
+//	go test -tags sqlfverify ./...
+//
+// A command that fails verification panics, so that the problem is
+// found when the command is built, typically during initialization.
+const verifyOnBuild = true
@@ -0,0 +1,206 @@
+package sqlf_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyBuild returns the result of verifying the command that is built
+// by fn. When built with the "sqlfverify" tag, the command panics when it
+// is built instead, and the panic is returned as the error.
+func verifyBuild(fn func() interface{ Verify() error }) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return fn().Verify()
+}
+
+func TestVerifyProperties(t *testing.T) {
+	assert := assert.New(t)
+	type Row struct {
+		ID    int64 `sql:"primary_key"`
+		Name  string
+		Email string
+		Age   int
+		Score float64
+	}
+	names := []string{"Name", "Email", "Age", "Score"}
+	dialects := []sqlf.Dialect{sqlf.DialectPG, sqlf.DialectMySQL, sqlf.DialectMSSQL, sqlf.DialectSQLite}
+
+	// the same sequence of commands is built every time
+	rnd := rand.New(rand.NewSource(1))
+	subset := func() []string {
+		var excluded []string
+		for _, name := range names {
+			if rnd.Intn(2) == 0 {
+				excluded = append(excluded, name)
+			}
+		}
+		if len(excluded) == len(names) {
+			excluded = excluded[1:]
+		}
+		return excluded
+	}
+	placeholders := func(n int, p func() *sqlf.Placeholder) (string, []interface{}) {
+		var conds []string
+		var args []interface{}
+		for i := 0; i < n; i++ {
+			conds = append(conds, fmt.Sprintf("c%d = %%s", i))
+			args = append(args, p())
+		}
+		return strings.Join(conds, " and "), args
+	}
+
+	for i := 0; i < 200; i++ {
+		tbl := sqlf.Table("rows", Row{}).WithDialect(dialects[i%len(dialects)])
+		name := fmt.Sprintf("%d %s", i, sqlf.DialectName(tbl.Dialect()))
+
+		excluded := subset()
+		insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+			tbl.Insert.TableName, tbl.Insert.Columns.Exclude(excluded...), tbl.Insert.Values.Exclude(excluded...))
+		assert.NoError(insert.Verify(), name)
+
+		update := sqlf.UpdateRowf("update %s set %s where %s",
+			tbl.Update.TableName, tbl.Update.SetColumns.Exclude(subset()...), tbl.Update.WhereColumns)
+		assert.NoError(update.Verify(), name)
+
+		where, args := placeholders(rnd.Intn(5), tbl.Select.Placeholder)
+		if where == "" {
+			where = "1 = 1"
+		}
+		query := sqlf.Queryf("select %s from %s where "+where,
+			append([]interface{}{tbl.Select.Columns.Exclude(subset()...), tbl.Select.TableName}, args...)...)
+		assert.NoError(query.Verify(), name)
+
+		exec := sqlf.Execf("delete from %s where %s and "+where,
+			append([]interface{}{tbl.Delete.TableName, tbl.Delete.WhereColumns}, args...)...)
+		assert.NoError(exec.Verify(), name)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	assert := assert.New(t)
+	type Row struct {
+		ID    int64 `sql:"primary_key"`
+		Name  string
+		Email string
+	}
+	tbl := sqlf.Table("rows", Row{}).WithDialect(sqlf.DialectPG)
+
+	tests := []struct {
+		name  string
+		build func() interface{ Verify() error }
+		want  string
+	}{
+		{
+			name: "insert columns and values do not match",
+			build: func() interface{ Verify() error } {
+				return sqlf.InsertRowf("insert into %s(%s) values(%s)",
+					tbl.Insert.TableName, tbl.Insert.Columns.Exclude("Email"), tbl.Insert.Values)
+			},
+			want: "2 insert columns for 3 values",
+		},
+		{
+			name: "missing arg",
+			build: func() interface{ Verify() error } {
+				return sqlf.Queryf("select %s from %s where %s", tbl.Select.Columns, tbl.Select.TableName)
+			},
+			want: "format verbs do not match args: %!s(MISSING)",
+		},
+		{
+			name: "extra arg",
+			build: func() interface{ Verify() error } {
+				return sqlf.Execf("delete from %s", tbl.Delete.TableName, tbl.Delete.WhereColumns)
+			},
+			want: "format verbs do not match args: %!(EXTRA",
+		},
+		{
+			name: "skipped placeholder",
+			build: func() interface{ Verify() error } {
+				return sqlf.Execf("delete from rows where id = $1 or id = $3", sqlf.WithDialect(sqlf.DialectPG))
+			},
+			want: "placeholder $2 is missing",
+		},
+		{
+			name: "mixed placeholders",
+			build: func() interface{ Verify() error } {
+				return sqlf.Execf("delete from rows where id = $1 or id = ?", sqlf.WithDialect(sqlf.DialectPG))
+			},
+			want: "numbered and unnumbered placeholders",
+		},
+		{
+			name: "column list not in format",
+			build: func() interface{ Verify() error } {
+				return sqlf.UpdateRowf("update %s set name = 'x' where id = 1", tbl.Update.TableName, tbl.Update.WhereColumns)
+			},
+			want: "format verbs do not match args",
+		},
+	}
+	for _, tt := range tests {
+		err := verifyBuild(tt.build)
+		if assert.Error(err, tt.name) {
+			assert.Contains(err.Error(), tt.want, tt.name)
+		}
+	}
+
+	// "?" placeholders are rebound when the command is executed
+	query := sqlf.Queryf("select %s from %s where id = ? or name = ?",
+		tbl.Select.Columns, tbl.Select.TableName)
+	assert.NoError(query.Verify())
+	update := sqlf.UpdateRowf("update %s set %s where %s",
+		tbl.Update.TableName, sqlf.Set("name", "upper(?)"), tbl.Update.WhereColumns)
+	assert.NoError(update.Verify())
+}
+
+func TestWithStrict(t *testing.T) {
+	assert := assert.New(t)
+	type Row struct {
+		ID   int64 `sql:"primary_key"`
+		Name string
+	}
+	tbl := sqlf.Table("rows", Row{}).WithDialect(sqlf.DialectPG)
+
+	// problems are reported when the command is built
+	assert.PanicsWithValue("sqlf.InsertRowf: table not specified", func() {
+		sqlf.InsertRowf("insert into rows(name) values(?)", sqlf.WithStrict())
+	})
+	assert.PanicsWithValue("sqlf.UpdateRowf: no input columns", func() {
+		sqlf.UpdateRowf("update %s set name = 'x'", tbl.Update.TableName, sqlf.WithStrict())
+	})
+	assert.Panics(func() {
+		sqlf.Queryf("select %s from %s where %s", tbl.Select.Columns, tbl.Select.TableName, sqlf.WithStrict())
+	})
+	assert.Panics(func() {
+		sqlf.Execf("delete from %s", tbl.Delete.TableName, tbl.Delete.WhereColumns, sqlf.WithStrict())
+	})
+
+	// including errors that are otherwise reported when executed
+	assert.PanicsWithValue("sqlf.WithIgnoreDuplicates and WithUpsert cannot be used together", func() {
+		sqlf.InsertRowf("insert into %s(%s) values(%s)",
+			tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values,
+			sqlf.WithIgnoreDuplicates(), sqlf.WithUpsert(), sqlf.WithStrict())
+	})
+
+	// valid commands are unchanged
+	assert.NotPanics(func() {
+		insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+			tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithStrict())
+		assert.NoError(insert.Verify())
+		query := sqlf.Queryf("select %s from %s where id = ?",
+			tbl.Select.Columns, tbl.Select.TableName, sqlf.WithStrict())
+		assert.NoError(query.Verify())
+	})
+
+	// without the option, the problem is reported by Verify
+	// (unless built with the "sqlfverify" tag)
+	assert.Error(verifyBuild(func() interface{ Verify() error } {
+		return sqlf.InsertRowf("insert into rows(name) values(?)")
+	}))
+}
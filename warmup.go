@@ -0,0 +1,212 @@
+package sqlf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jjeffery/sqlf/scan"
+	"github.com/jmoiron/sqlx"
+)
+
+// WarmupError describes a command that could not be prepared by Warmup.
+type WarmupError struct {
+	Name    string // Name of the command, if any
+	Command string // SQL command
+	Err     error  // Error returned when preparing the command
+}
+
+// Error implements the error interface.
+func (e *WarmupError) Error() string {
+	name := e.Name
+	if name == "" {
+		name = fmt.Sprintf("%q", e.Command)
+	}
+	return fmt.Sprintf("%s: %v", name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *WarmupError) Unwrap() error {
+	return e.Err
+}
+
+// WarmupErrors is the error returned by Warmup when one or more commands
+// could not be prepared. There is one entry for each command.
+type WarmupErrors []*WarmupError
+
+// Error implements the error interface.
+func (errs WarmupErrors) Error() string {
+	var buf bytes.Buffer
+	for i, err := range errs {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// Warmup prepares commands using db, and returns a WarmupErrors describing
+// any commands that could not be prepared. If no commands are specified,
+// all commands added to the registry using Register are prepared. Calling
+// Warmup during program startup detects broken SQL and missing tables and
+// columns before the first request that runs the command fails:
+//
+//	if err := sqlf.Warmup(db); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Commands built using WithPrepared keep their prepared statement for
+// use when they are executed using db. The statements prepared for other
+// commands are closed. Commands whose SQL is checked when it is executed,
+// such as commands built using Scriptf, are not prepared. An error found
+// while building a command, which would be returned when the command is
+// executed, is also reported.
+//
+// Some drivers do not check the SQL until the statement is executed. See
+// WarmupExplain, which also executes an EXPLAIN statement for each command.
+func Warmup(db *sqlx.DB, commands ...Commander) error {
+	return warmup(db, false, commands)
+}
+
+// WarmupExplain prepares commands in the same way as Warmup, and then
+// executes an EXPLAIN statement for each command, with a null value for
+// each argument. The query plans are not returned. EXPLAIN is not
+// supported for SQL Server, so its commands are only prepared.
+func WarmupExplain(db *sqlx.DB, commands ...Commander) error {
+	return warmup(db, true, commands)
+}
+
+// warmable is implemented by the commands that can be prepared by Warmup.
+type warmable interface {
+	Commander
+	queryFor(db interface{}) string
+	warmupOptions() (options, error)
+}
+
+func (cmd execRowCommand) warmupOptions() (options, error) {
+	return cmd.opts, cmd.err
+}
+
+func (cmd execCommand) warmupOptions() (options, error) {
+	return cmd.opts, cmd.err
+}
+
+func (cmd *queryCommand) warmupOptions() (options, error) {
+	if cmd.opts.binding != nil {
+		return cmd.opts, cmd.opts.binding.err
+	}
+	return cmd.opts, nil
+}
+
+func warmup(db *sqlx.DB, explain bool, commands []Commander) error {
+	var named []RegisteredCommand
+	if len(commands) == 0 {
+		named = Commands()
+	} else {
+		for _, cmd := range commands {
+			named = append(named, RegisteredCommand{Cmd: cmd})
+		}
+	}
+
+	d := dialectForDriver(db.DriverName())
+	var errs WarmupErrors
+	for _, rc := range named {
+		cmd, ok := rc.Cmd.(warmable)
+		if !ok {
+			continue
+		}
+		opts, err := cmd.warmupOptions()
+		if rc.Name == "" {
+			rc.Name = opts.name
+		}
+		query := cmd.queryFor(db)
+		if err == nil {
+			err = prepare(db, opts.stmts, query)
+		}
+		if err == nil && explain && d != nil {
+			err = explainQuery(db, d, query)
+		}
+		if err != nil {
+			errs = append(errs, &WarmupError{Name: rc.Name, Command: cmd.Command(), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// prepare prepares the query. The statement is kept in stmts if
+// it is not nil, otherwise it is closed.
+func prepare(db *sqlx.DB, stmts *stmtCache, query string) error {
+	if stmts != nil {
+		_, err := stmts.get(db, query)
+		return err
+	}
+	stmt, err := db.Preparex(query)
+	if err != nil {
+		return err
+	}
+	return stmt.Close()
+}
+
+// explainQuery executes an EXPLAIN statement for the query.
+func explainQuery(db *sqlx.DB, d Dialect, query string) error {
+	var prefix string
+	switch DialectName(d) {
+	case "sqlite3":
+		prefix = "explain query plan "
+	case "postgres", "mysql":
+		prefix = "explain "
+	default:
+		return nil
+	}
+	args := make([]interface{}, numPlaceholders(query))
+	rows, err := db.Query(prefix+query, args...)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// numPlaceholders returns the number of args required by the placeholders
+// in the query, which are either positional ("?") or numbered (eg "$1").
+func numPlaceholders(query string) int {
+	var count int
+	scanner := scan.NewScanner(strings.NewReader(query))
+	for {
+		tok, lit := scanner.Scan()
+		if tok == scan.EOF {
+			return count
+		}
+		if tok != scan.PLACEHOLDER {
+			continue
+		}
+		if n, err := strconv.Atoi(lit[1:]); err == nil {
+			if n > count {
+				count = n
+			}
+		} else {
+			count++
+		}
+	}
+}
+
+// Ping checks that the database can be queried by executing a trivial
+// query ("select 1") using db. Unlike the Ping method of *sql.DB, the query
+// is executed in the same way as other commands, so it is reported to the
+// metrics sink as the command "sqlf.Ping", and it can be canceled using
+// ctx. Ping is suitable for health checks:
+//
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//		if err := sqlf.Ping(r.Context(), db); err != nil {
+//			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//		}
+//	})
+func Ping(ctx context.Context, db sqlx.Ext) error {
+	var one int
+	return Queryf("select 1", WithName("sqlf.Ping")).Get(ForContext(ctx, db), &one)
+}
@@ -0,0 +1,61 @@
+package sqlf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjeffery/sqlf"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmup(t *testing.T) {
+	assert := assert.New(t)
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`create table table1(id integer primary key, given_name text, family_name text, Date_of_Birth datetime)`); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl := Row1Table.WithDialect(sqlf.DialectPG)
+	good := sqlf.Queryf("select %s from %s where %s",
+		tbl.Select.Columns, tbl.Select.TableName, tbl.Update.WhereColumns.Include("FamilyName"))
+	insert := sqlf.InsertRowf("insert into %s(%s) values(%s)",
+		tbl.Insert.TableName, tbl.Insert.Columns, tbl.Insert.Values, sqlf.WithPrepared())
+	missing := sqlf.Execf("update %s set middle_name = ? where id = ?", tbl.Update.TableName, sqlf.WithName("SetMiddleName"))
+	broken := sqlf.Queryf("select * form table1")
+
+	assert.NoError(sqlf.Warmup(db, good, insert))
+	assert.NoError(sqlf.WarmupExplain(db, good, insert))
+
+	err = sqlf.Warmup(db, good, missing, broken)
+	var errs sqlf.WarmupErrors
+	if assert.True(errors.As(err, &errs)) && assert.Len(errs, 2) {
+		assert.Equal("SetMiddleName", errs[0].Name)
+		assert.Contains(errs[0].Error(), "SetMiddleName: ")
+		assert.Contains(errs[0].Err.Error(), "middle_name")
+		assert.Equal("", errs[1].Name)
+		assert.Equal("select * form table1", errs[1].Command)
+	}
+
+	// the prepared statement is kept by the command
+	assert.NoError(insert.Exec(db, &Row1{GivenName: "John"}))
+}
+
+func TestPing(t *testing.T) {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	assert.NoError(t, sqlf.Ping(context.Background(), db))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, sqlf.Ping(ctx, db))
+}